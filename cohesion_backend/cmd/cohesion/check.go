@@ -0,0 +1,327 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/diff"
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+var pathParamRegex = regexp.MustCompile(`\{[^}]+\}`)
+
+type checkOptions struct {
+	format   string
+	failOn   string
+	out      string
+	server   string
+	project  string
+	token    string
+	artifact string
+	waivers  string
+	policy   string
+	timeout  time.Duration
+}
+
+type endpointKey struct {
+	path   string
+	method string
+}
+
+func runCheck(args []string, stdout, stderr io.Writer) int {
+	var opts checkOptions
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&opts.format, "format", "table", "report format: table, json, sarif or junit")
+	fs.StringVar(&opts.failOn, "fail-on", string(diff.SeverityCritical), "lowest severity that fails the check: critical, warning, info or none")
+	fs.StringVar(&opts.out, "out", "", "write the report to this file instead of stdout")
+	fs.StringVar(&opts.server, "server", os.Getenv("COHESION_SERVER"), "Cohesion server URL; loads the project's stored schemas instead of local files")
+	fs.StringVar(&opts.project, "project", os.Getenv("COHESION_PROJECT"), "project ID to load from the server")
+	fs.StringVar(&opts.token, "token", os.Getenv("COHESION_TOKEN"), "bearer token for the server: a session token or a project API key with the read scope")
+	fs.StringVar(&opts.artifact, "sarif-artifact", "", "file URI attached to SARIF results (needed by code scanning uploads)")
+	fs.StringVar(&opts.waivers, "waivers", "", "JSON file with a list of waivers to apply")
+	fs.StringVar(&opts.policy, "policy", "", "JSON file with severity rules applied before the defaults")
+	fs.DurationVar(&opts.timeout, "timeout", 30*time.Second, "server request timeout")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: cohesion check [flags] [source=]<file-or-dir>...\n\n")
+		fmt.Fprintf(stderr, "Each file holds a SchemaIR object, an array of them, or an upload body\n")
+		fmt.Fprintf(stderr, "({\"schemas\": [...]}). A source= prefix overrides the source of every schema\n")
+		fmt.Fprintf(stderr, "in that file, e.g. backend-static=be.json frontend-static=fe.json.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitError
+	}
+
+	threshold := diff.Severity(opts.failOn)
+	if opts.failOn != "none" && !threshold.IsKnown() {
+		fmt.Fprintf(stderr, "invalid -fail-on %q\n", opts.failOn)
+		return exitError
+	}
+	switch opts.format {
+	case "table", "json", "sarif", "junit":
+	default:
+		fmt.Fprintf(stderr, "invalid -format %q\n", opts.format)
+		return exitError
+	}
+
+	var schemas []schemair.SchemaIR
+	var rules []diff.PolicyRule
+	var waivers []diff.Waiver
+	var err error
+	if opts.server != "" {
+		if fs.NArg() > 0 {
+			fmt.Fprintln(stderr, "-server cannot be combined with local files")
+			return exitError
+		}
+		schemas, err = fetchProjectSchemas(opts)
+		if err == nil {
+			rules, waivers, err = fetchProjectRules(opts)
+		}
+	} else {
+		if fs.NArg() == 0 {
+			fs.Usage()
+			return exitError
+		}
+		schemas, err = loadSchemaArgs(fs.Args(), stderr)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exitError
+	}
+
+	// Local rules come before the project's so they can override them;
+	// local waivers add to the project's.
+	if opts.policy != "" {
+		local, err := loadPolicy(opts.policy)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return exitError
+		}
+		rules = append(local, rules...)
+	}
+	if opts.waivers != "" {
+		local, err := loadWaivers(opts.waivers)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return exitError
+		}
+		waivers = append(waivers, local...)
+	}
+
+	engine := diff.NewEngine()
+	if len(rules) > 0 {
+		engine = engine.WithPolicy(diff.WithDefaults(rules))
+	}
+	if len(waivers) > 0 {
+		engine = engine.WithWaivers(waivers)
+	}
+
+	results := compareAll(engine, schemas, stderr)
+
+	w := stdout
+	if opts.out != "" {
+		f, err := os.Create(opts.out)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return exitError
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch opts.format {
+	case "json":
+		err = writeJSON(w, results)
+	case "sarif":
+		err = diff.WriteSARIF(w, results, diff.SARIFOptions{ArtifactURI: opts.artifact})
+	case "junit":
+		err = diff.WriteJUnit(w, "cohesion", results)
+	default:
+		err = writeTable(w, results)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "error: writing report: %v\n", err)
+		return exitError
+	}
+
+	if opts.failOn == "none" {
+		return exitOK
+	}
+	if failing := countAtLeast(results, threshold); failing > 0 {
+		fmt.Fprintf(stderr, "%d mismatch(es) at or above %s\n", failing, threshold)
+		return exitFailed
+	}
+	return exitOK
+}
+
+// compareAll groups schemas by endpoint and runs the diff engine on each.
+// When two inputs give the same source for an endpoint the later one wins.
+func compareAll(engine *diff.Engine, schemas []schemair.SchemaIR, stderr io.Writer) []*diff.Result {
+	grouped := make(map[endpointKey]map[schemair.SchemaSource]schemair.SchemaIR)
+	for _, s := range schemas {
+		key := endpointKey{normalizePath(s.Endpoint), strings.ToUpper(s.Method)}
+		if grouped[key] == nil {
+			grouped[key] = make(map[schemair.SchemaSource]schemair.SchemaIR)
+		}
+		if _, dup := grouped[key][s.Source]; dup {
+			fmt.Fprintf(stderr, "warning: %s %s has several %s schemas, using the last one\n", key.method, key.path, s.Source)
+		}
+		grouped[key][s.Source] = s
+	}
+
+	keys := make([]endpointKey, 0, len(grouped))
+	for k := range grouped {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].path != keys[j].path {
+			return keys[i].path < keys[j].path
+		}
+		return keys[i].method < keys[j].method
+	})
+
+	results := make([]*diff.Result, 0, len(keys))
+	for _, k := range keys {
+		sources := make([]schemair.SchemaSource, 0, len(grouped[k]))
+		for src := range grouped[k] {
+			sources = append(sources, src)
+		}
+		sort.Slice(sources, func(i, j int) bool { return sources[i] < sources[j] })
+
+		irs := make([]schemair.SchemaIR, 0, len(sources))
+		for _, src := range sources {
+			irs = append(irs, grouped[k][src])
+		}
+		results = append(results, engine.Compare(k.path, k.method, irs))
+	}
+	return results
+}
+
+func normalizePath(path string) string {
+	if len(path) > 0 && path[0] != '/' {
+		path = "/" + path
+	}
+	if len(path) > 1 && path[len(path)-1] == '/' {
+		path = path[:len(path)-1]
+	}
+	if path == "" {
+		path = "/"
+	}
+	return pathParamRegex.ReplaceAllString(path, "{}")
+}
+
+func countAtLeast(results []*diff.Result, threshold diff.Severity) int {
+	n := 0
+	for _, r := range results {
+		for _, m := range r.ActiveMismatches() {
+			if m.Severity.AtLeast(threshold) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+type jsonReport struct {
+	Summary reportSummary  `json:"summary"`
+	Results []*diff.Result `json:"results"`
+}
+
+type reportSummary struct {
+	Endpoints  int `json:"endpoints"`
+	Matched    int `json:"matched"`
+	Partial    int `json:"partial"`
+	Violations int `json:"violations"`
+	Critical   int `json:"critical"`
+	Warnings   int `json:"warnings"`
+	Info       int `json:"info"`
+}
+
+func summarize(results []*diff.Result) reportSummary {
+	s := reportSummary{Endpoints: len(results)}
+	for _, r := range results {
+		switch r.Status {
+		case schemair.StatusMatch:
+			s.Matched++
+		case schemair.StatusPartial:
+			s.Partial++
+		case schemair.StatusViolation:
+			s.Violations++
+		}
+		for _, m := range r.ActiveMismatches() {
+			switch m.Severity {
+			case diff.SeverityCritical:
+				s.Critical++
+			case diff.SeverityWarning:
+				s.Warnings++
+			case diff.SeverityInfo:
+				s.Info++
+			}
+		}
+	}
+	return s
+}
+
+func writeJSON(w io.Writer, results []*diff.Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(jsonReport{Summary: summarize(results), Results: results})
+}
+
+func writeTable(w io.Writer, results []*diff.Result) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tMETHOD\tENDPOINT\tSOURCES\tCRITICAL\tWARNING\tINFO")
+	for _, r := range results {
+		var crit, warn, info int
+		for _, m := range r.ActiveMismatches() {
+			switch m.Severity {
+			case diff.SeverityCritical:
+				crit++
+			case diff.SeverityWarning:
+				warn++
+			case diff.SeverityInfo:
+				info++
+			}
+		}
+		sources := make([]string, len(r.SourcesCompared))
+		for i, s := range r.SourcesCompared {
+			sources[i] = string(s)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%d\t%d\n",
+			r.Status, r.Method, r.Endpoint, strings.Join(sources, ","), crit, warn, info)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		if len(r.Mismatches) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s %s\n", r.Method, r.Endpoint)
+		for _, m := range r.Mismatches {
+			severity := string(m.Severity)
+			if m.Suppressed {
+				severity = "waived"
+			}
+			fmt.Fprintf(w, "  %-8s %-20s %s: %s\n", severity, m.Type, m.Path, m.Description)
+		}
+	}
+
+	s := summarize(results)
+	_, err := fmt.Fprintf(w, "\n%d endpoints: %d match, %d partial, %d violation (%d critical, %d warning, %d info)\n",
+		s.Endpoints, s.Matched, s.Partial, s.Violations, s.Critical, s.Warnings, s.Info)
+	return err
+}
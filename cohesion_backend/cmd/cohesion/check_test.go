@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunCheck(t *testing.T) {
+	dir := t.TempDir()
+	backend := filepath.Join(dir, "backend.json")
+	frontend := filepath.Join(dir, "frontend.json")
+
+	if err := os.WriteFile(backend, []byte(`{"schemas":[{"endpoint":"/api/users/{id}","method":"GET",
+		"response":{"200":{"type":"object","fields":{
+			"id":{"type":"string","required":true},
+			"name":{"type":"string","required":true}}}}}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(frontend, []byte(`[{"endpoint":"/api/users/{userId}","method":"GET","source":"frontend-static",
+		"response":{"200":{"type":"object","fields":{
+			"id":{"type":"string","required":true},
+			"name":{"type":"string","required":false}}}}}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) (int, string) {
+		var stdout, stderr bytes.Buffer
+		code := runCheck(args, &stdout, &stderr)
+		return code, stdout.String()
+	}
+
+	t.Run("Passes below the threshold", func(t *testing.T) {
+		code, out := run("backend-static="+backend, frontend)
+		if code != exitOK {
+			t.Errorf("Expected exit %d, got %d", exitOK, code)
+		}
+		if !strings.Contains(out, "/api/users/{}") {
+			t.Errorf("Expected both param spellings to be grouped, got:\n%s", out)
+		}
+	})
+
+	t.Run("Fails at a lower threshold", func(t *testing.T) {
+		code, _ := run("-fail-on", "warning", "backend-static="+backend, frontend)
+		if code != exitFailed {
+			t.Errorf("Expected exit %d, got %d", exitFailed, code)
+		}
+	})
+
+	t.Run("JUnit output", func(t *testing.T) {
+		_, out := run("-format", "junit", "backend-static="+backend, frontend)
+		if !strings.Contains(out, `<testcase name="GET /api/users/{}"`) || !strings.Contains(out, `type="optionality_mismatch"`) {
+			t.Errorf("Unexpected JUnit output:\n%s", out)
+		}
+	})
+
+	t.Run("Schemas without a source are rejected", func(t *testing.T) {
+		code, _ := run(backend)
+		if code != exitError {
+			t.Errorf("Expected exit %d, got %d", exitError, code)
+		}
+	})
+}
+
+func TestRunCheck_Server(t *testing.T) {
+	var policy, waivers string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer coh_test" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/endpoints":
+			w.Write([]byte(`[{"path":"/api/users/{id}","method":"GET","schemas":[
+				{"source":"backend-static","schema_data":{"response":{"200":{"type":"object","fields":{
+					"name":{"type":"string","required":true}}}}}},
+				{"source":"frontend-static","schema_data":{"response":{"200":{"type":"object","fields":{
+					"name":{"type":"string","required":false}}}}}}]}]`))
+		case "/api/projects/p1/severity-policy":
+			w.Write([]byte(policy))
+		case "/api/projects/p1/waivers":
+			w.Write([]byte(waivers))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	localPolicy := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(localPolicy, []byte(`[{"mismatch_type":"optionality_mismatch","severity":"info"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) (int, string) {
+		var stdout, stderr bytes.Buffer
+		args = append([]string{"-server", srv.URL, "-project", "p1", "-token", "coh_test"}, args...)
+		code := runCheck(args, &stdout, &stderr)
+		return code, stdout.String() + stderr.String()
+	}
+
+	t.Run("Applies the project's severity policy", func(t *testing.T) {
+		policy = `{"rules":[{"mismatch_type":"optionality_mismatch","severity":"critical"}],"defaults":[]}`
+		waivers = `[]`
+		if code, out := run(); code != exitFailed {
+			t.Errorf("Expected exit %d, got %d:\n%s", exitFailed, code, out)
+		}
+	})
+
+	t.Run("Local policy is checked before the project's", func(t *testing.T) {
+		if code, out := run("-policy", localPolicy, "-fail-on", "warning"); code != exitOK {
+			t.Errorf("Expected exit %d, got %d:\n%s", exitOK, code, out)
+		}
+	})
+
+	t.Run("Applies the project's waivers", func(t *testing.T) {
+		policy = `{"rules":[]}`
+		waivers = `[{"id":"w1","field_path":"response.200.name","reason":"migrating"}]`
+		if code, out := run("-fail-on", "info"); code != exitOK {
+			t.Errorf("Expected exit %d, got %d:\n%s", exitOK, code, out)
+		}
+	})
+
+	t.Run("Fails when the project's rules cannot be loaded", func(t *testing.T) {
+		policy = `not json`
+		if code, _ := run(); code != exitError {
+			t.Errorf("Expected exit %d, got %d", exitError, code)
+		}
+	})
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cohesion-api/cohesion_backend/internal/models"
+	"github.com/cohesion-api/cohesion_backend/pkg/diff"
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+// loadSchemaArgs reads every file argument, descending into directories for
+// *.json files. An argument may be prefixed with "source=" to set the source
+// of the schemas it contains.
+func loadSchemaArgs(args []string, stderr io.Writer) ([]schemair.SchemaIR, error) {
+	var all []schemair.SchemaIR
+	for _, arg := range args {
+		var source schemair.SchemaSource
+		path := arg
+		if prefix, rest, ok := strings.Cut(arg, "="); ok && schemair.SchemaSource(prefix).IsKnown() {
+			source = schemair.SchemaSource(prefix)
+			path = rest
+		}
+
+		files, err := expandPath(path)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			fmt.Fprintf(stderr, "warning: no .json files in %s\n", path)
+		}
+
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			schemas, err := parseSchemaFile(data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			for i := range schemas {
+				if source != "" {
+					schemas[i].Source = source
+				}
+				if !schemas[i].Source.IsKnown() {
+					return nil, fmt.Errorf("%s: %s %s has no known source; prefix the path with source=",
+						file, schemas[i].Method, schemas[i].Endpoint)
+				}
+			}
+			all = append(all, schemas...)
+		}
+	}
+	return all, nil
+}
+
+func expandPath(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(p), ".json") {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// parseSchemaFile accepts a single SchemaIR, an array of them, or the body
+// accepted by the /api/analyze upload endpoints.
+func parseSchemaFile(data []byte) ([]schemair.SchemaIR, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty file")
+	}
+
+	if data[0] == '[' {
+		var schemas []schemair.SchemaIR
+		if err := json.Unmarshal(data, &schemas); err != nil {
+			return nil, err
+		}
+		return validSchemas(schemas)
+	}
+
+	var wrapper struct {
+		Schemas []schemair.SchemaIR `json:"schemas"`
+	}
+	if err := json.Unmarshal(data, &wrapper); err != nil {
+		return nil, err
+	}
+	if wrapper.Schemas != nil {
+		return validSchemas(wrapper.Schemas)
+	}
+
+	var single schemair.SchemaIR
+	if err := json.Unmarshal(data, &single); err != nil {
+		return nil, err
+	}
+	return validSchemas([]schemair.SchemaIR{single})
+}
+
+func validSchemas(schemas []schemair.SchemaIR) ([]schemair.SchemaIR, error) {
+	for i, s := range schemas {
+		if s.Endpoint == "" || s.Method == "" {
+			return nil, fmt.Errorf("schema %d is missing endpoint or method", i)
+		}
+	}
+	return schemas, nil
+}
+
+// loadWaivers reads a JSON array of waivers in the same shape the engine
+// uses, e.g. [{"field_path": "response.200.legacy_id", "reason": "..."}].
+func loadWaivers(path string) ([]diff.Waiver, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var waivers []diff.Waiver
+	if err := json.Unmarshal(data, &waivers); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i, w := range waivers {
+		if w.Reason == "" {
+			return nil, fmt.Errorf("%s: waiver %d has no reason", path, i)
+		}
+		if w.Endpoint != "" {
+			waivers[i].Endpoint = normalizePath(w.Endpoint)
+		}
+	}
+	return waivers, nil
+}
+
+// loadPolicy reads severity rules, either as a bare array or in the
+// {"rules": [...]} body accepted by the severity policy API.
+func loadPolicy(path string) ([]diff.PolicyRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+
+	var rules []diff.PolicyRule
+	if len(data) > 0 && data[0] == '[' {
+		err = json.Unmarshal(data, &rules)
+	} else {
+		var p diff.Policy
+		err = json.Unmarshal(data, &p)
+		rules = p.Rules
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i := range rules {
+		if err := rules[i].Validate(); err != nil {
+			return nil, fmt.Errorf("%s: rule %d: %w", path, i, err)
+		}
+	}
+	return rules, nil
+}
+
+// fetchProjectSchemas loads the latest schema per source for every endpoint
+// of a project from a running Cohesion server.
+func fetchProjectSchemas(opts checkOptions) ([]schemair.SchemaIR, error) {
+	if opts.project == "" {
+		return nil, fmt.Errorf("-project is required with -server")
+	}
+
+	var endpoints []models.Endpoint
+	if err := getJSON(opts, "/api/endpoints?project_id="+url.QueryEscape(opts.project), &endpoints); err != nil {
+		return nil, fmt.Errorf("endpoints: %w", err)
+	}
+
+	var schemas []schemair.SchemaIR
+	for _, ep := range endpoints {
+		for _, s := range ep.Schemas {
+			data, err := json.Marshal(s.SchemaData)
+			if err != nil {
+				return nil, err
+			}
+			var ir schemair.SchemaIR
+			if err := json.Unmarshal(data, &ir); err != nil {
+				return nil, fmt.Errorf("%s %s (%s): %w", ep.Method, ep.Path, s.Source, err)
+			}
+			ir.Endpoint = ep.Path
+			ir.Method = ep.Method
+			ir.Source = schemair.SchemaSource(s.Source)
+			schemas = append(schemas, ir)
+		}
+	}
+	return schemas, nil
+}
+
+// fetchProjectRules loads the custom severity rules and the waivers stored
+// for a project, so a server check judges drift the way the project's own
+// diffs do.
+func fetchProjectRules(opts checkOptions) ([]diff.PolicyRule, []diff.Waiver, error) {
+	base := "/api/projects/" + url.PathEscape(opts.project)
+
+	var policy diff.Policy
+	if err := getJSON(opts, base+"/severity-policy", &policy); err != nil {
+		return nil, nil, fmt.Errorf("severity policy: %w", err)
+	}
+	var waivers []diff.Waiver
+	if err := getJSON(opts, base+"/waivers", &waivers); err != nil {
+		return nil, nil, fmt.Errorf("waivers: %w", err)
+	}
+	return policy.Rules, waivers, nil
+}
+
+// getJSON fetches path from the server and decodes the JSON response into v.
+func getJSON(opts checkOptions, path string, v interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(opts.server, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	if opts.token != "" {
+		req.Header.Set("Authorization", "Bearer "+opts.token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("server returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `cohesion - API contract checks without the web UI
+
+Usage:
+  cohesion check [flags] [source=]<file-or-dir>...
+  cohesion check [flags] -server <url> -project <id>
+
+Run "cohesion check -h" for the list of flags.
+`
+
+// Exit codes shared by all subcommands.
+const (
+	exitOK     = 0
+	exitFailed = 1
+	exitError  = 2
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(exitError)
+	}
+
+	switch os.Args[1] {
+	case "check":
+		os.Exit(runCheck(os.Args[2:], os.Stdout, os.Stderr))
+	case "help", "-h", "--help":
+		fmt.Fprint(os.Stdout, usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(exitError)
+	}
+}
@@ -13,15 +13,20 @@ import (
 	"github.com/cohesion-api/cohesion_backend/internal/config"
 	"github.com/cohesion-api/cohesion_backend/internal/controlplane"
 	"github.com/cohesion-api/cohesion_backend/internal/crypto"
+	"github.com/cohesion-api/cohesion_backend/internal/models"
 	"github.com/cohesion-api/cohesion_backend/internal/repository"
 	"github.com/cohesion-api/cohesion_backend/internal/services"
 	"github.com/cohesion-api/cohesion_backend/pkg/analyzer"
-	geminianalyzer "github.com/cohesion-api/cohesion_backend/pkg/analyzer/gemini"
+	"github.com/cohesion-api/cohesion_backend/pkg/analyzer/goast"
+	"github.com/cohesion-api/cohesion_backend/pkg/analyzer/llm"
 	ghpkg "github.com/cohesion-api/cohesion_backend/pkg/github"
 )
 
 func main() {
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 
 	if cfg.DatabaseURL == "" {
 		log.Fatal("DATABASE_URL is required")
@@ -48,17 +53,51 @@ func main() {
 	diffRepo := repository.NewDiffRepository(db)
 	userSettingsRepo := repository.NewUserSettingsRepository(db)
 	ghInstallRepo := repository.NewGitHubInstallationRepository(db)
+	waiverRepo := repository.NewWaiverRepository(db)
+	policyRepo := repository.NewSeverityPolicyRepository(db)
+	liveCaptureRepo := repository.NewLiveCaptureRepository(db)
+	redactionRepo := repository.NewRedactionPolicyRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	orgRepo := repository.NewOrganizationRepository(db)
+	scanJobRepo := repository.NewScanJobRepository(db)
+	analysisCacheRepo := repository.NewAnalysisCacheRepository(db)
 
 	projectService := services.NewProjectService(projectRepo, endpointRepo)
 	endpointService := services.NewEndpointService(endpointRepo, schemaRepo)
 	schemaService := services.NewSchemaService(db, schemaRepo, endpointRepo)
-	diffService := services.NewDiffService(diffRepo, schemaRepo, endpointRepo)
-	liveService := services.NewLiveService()
+	diffService := services.NewDiffService(diffRepo, schemaRepo, endpointRepo, waiverRepo, policyRepo)
+	liveService := services.NewLiveService(liveCaptureRepo, redactionRepo, models.CaptureRetention{
+		MaxAgeHours: &cfg.CaptureRetentionHours,
+		MaxCount:    &cfg.CaptureRetentionCount,
+	})
 	userSettingsService := services.NewUserSettingsService(userSettingsRepo)
 	ghInstallService := services.NewGitHubInstallationService(ghInstallRepo)
-	var codeAnalyzer analyzer.Analyzer
-	if cfg.GeminiAPIKey != "" {
-		codeAnalyzer = geminianalyzer.New(cfg.GeminiAPIKey, cfg.GeminiModel)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo)
+	orgService := services.NewOrganizationService(orgRepo, projectRepo)
+	var codeAnalyzer analyzer.FileAnalyzer
+	client, err := llm.NewClient(llm.Config{
+		Provider: cfg.LLMProvider,
+		APIKey:   cfg.LLMAPIKey,
+		Model:    cfg.LLMModel,
+		BaseURL:  cfg.LLMBaseURL,
+	})
+	if err == nil {
+		codeAnalyzer = llm.New(client)
+	} else if cfg.LLMAPIKey != "" || cfg.LLMBaseURL != "" {
+		log.Fatalf("Invalid LLM configuration: %v", err)
+	}
+
+	registry := analyzer.NewRegistry()
+	registry.Register(goast.New(), analyzer.ScanModeBackend)
+	registry.SetChunking(analyzer.ChunkOptions{
+		MaxTokens:   cfg.ScanChunkTokens,
+		Parallelism: cfg.ScanChunkParallelism,
+	})
+
+	analysisCacheService := services.NewAnalysisCacheService(analysisCacheRepo)
+	scanJobService := services.NewScanJobService(scanJobRepo, schemaService, registry, analysisCacheService)
+	if err := scanJobService.FailInterrupted(ctx); err != nil {
+		log.Fatalf("Failed to recover scan jobs: %v", err)
 	}
 
 	ghAppAuth := ghpkg.NewAppAuth(cfg.GitHubAppID, cfg.GitHubAppPrivateKey)
@@ -71,7 +110,12 @@ func main() {
 		LiveService:               liveService,
 		UserSettingsService:       userSettingsService,
 		GitHubInstallationService: ghInstallService,
+		APIKeyService:             apiKeyService,
+		OrganizationService:       orgService,
+		ScanJobService:            scanJobService,
+		AnalysisCacheService:      analysisCacheService,
 		Analyzer:                  codeAnalyzer,
+		AnalyzerRegistry:          registry,
 		GitHubAppAuth:             ghAppAuth,
 		GitHubAppSlug:             cfg.GitHubAppSlug,
 		FrontendURL:               cfg.FrontendURL,
@@ -87,6 +131,18 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	retentionCtx, stopRetention := context.WithCancel(context.Background())
+	defer stopRetention()
+	go liveService.RunRetention(retentionCtx, time.Hour)
+
+	scanCtx, stopScans := context.WithCancel(context.Background())
+	defer stopScans()
+	scansDone := make(chan struct{})
+	go func() {
+		scanJobService.Run(scanCtx, cfg.ScanWorkers)
+		close(scansDone)
+	}()
+
 	go func() {
 		log.Printf("Server starting on port %d", cfg.Port)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -99,6 +155,9 @@ func main() {
 	<-quit
 
 	log.Println("Shutting down server...")
+	stopRetention()
+	stopScans()
+	<-scansDone
 
 	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
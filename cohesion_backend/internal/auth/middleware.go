@@ -2,19 +2,39 @@ package auth
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"path"
 	"strings"
 	"sync"
 
 	"github.com/clerk/clerk-sdk-go/v2"
 	"github.com/clerk/clerk-sdk-go/v2/jwt"
+	"github.com/cohesion-api/cohesion_backend/internal/models"
 )
 
 type contextKey string
 
-const userIDKey contextKey = "clerk_user_id"
+const (
+	userIDKey contextKey = "clerk_user_id"
+	apiKeyKey contextKey = "api_key"
+)
+
+// KeyVerifier looks up project API keys. It returns nil for unknown or
+// revoked keys.
+type KeyVerifier interface {
+	VerifyAPIKey(ctx context.Context, token string) (*models.APIKey, error)
+}
+
+// KeyRoute lets API keys holding Scope call Method on paths matching
+// Pattern, in path.Match syntax. API keys are refused on every other route.
+type KeyRoute struct {
+	Method  string
+	Pattern string
+	Scope   string
+}
 
 var clerkInitOnce sync.Once
 
@@ -27,7 +47,9 @@ func initClerk() {
 	})
 }
 
-func Middleware() func(http.Handler) http.Handler {
+// Middleware authenticates requests with a Clerk session token or, on the
+// given routes, a project API key.
+func Middleware(keys KeyVerifier, routes []KeyRoute) func(http.Handler) http.Handler {
 	initClerk()
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -53,6 +75,11 @@ func Middleware() func(http.Handler) http.Handler {
 				return
 			}
 
+			if strings.HasPrefix(token, models.APIKeyPrefix) {
+				authenticateAPIKey(w, r, next, keys, routes, token)
+				return
+			}
+
 			claims, err := jwt.Verify(r.Context(), &jwt.VerifyParams{
 				Token: token,
 			})
@@ -70,6 +97,56 @@ func Middleware() func(http.Handler) http.Handler {
 	}
 }
 
+func authenticateAPIKey(w http.ResponseWriter, r *http.Request, next http.Handler, keys KeyVerifier, routes []KeyRoute, token string) {
+	var key *models.APIKey
+	if keys != nil {
+		var err error
+		key, err = keys.VerifyAPIKey(r.Context(), token)
+		if err != nil {
+			log.Printf("[auth] API key verification failed: %v", err)
+			http.Error(w, `{"error":"failed to verify API key"}`, http.StatusInternalServerError)
+			return
+		}
+	}
+	if key == nil {
+		http.Error(w, `{"error":"invalid token"}`, http.StatusUnauthorized)
+		return
+	}
+
+	scope := routeScope(routes, r)
+	if scope == "" {
+		http.Error(w, `{"error":"API keys cannot access this route"}`, http.StatusForbidden)
+		return
+	}
+	if !key.HasScope(scope) {
+		http.Error(w, fmt.Sprintf(`{"error":"API key lacks the %s scope"}`, scope), http.StatusForbidden)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), apiKeyKey, key)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+func routeScope(routes []KeyRoute, r *http.Request) string {
+	p := strings.TrimSuffix(r.URL.Path, "/")
+	for _, route := range routes {
+		if route.Method != r.Method {
+			continue
+		}
+		if ok, _ := path.Match(route.Pattern, p); ok {
+			return route.Scope
+		}
+	}
+	return ""
+}
+
+// APIKey returns the API key that authenticated the request, or nil for
+// requests authenticated as a user.
+func APIKey(ctx context.Context) *models.APIKey {
+	key, _ := ctx.Value(apiKeyKey).(*models.APIKey)
+	return key
+}
+
 func UserID(ctx context.Context) string {
 	if id, ok := ctx.Value(userIDKey).(string); ok {
 		return id
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
 
@@ -8,11 +9,32 @@ import (
 )
 
 type Config struct {
-	DatabaseURL  string
-	Port         int
-	Environment  string
-	GeminiAPIKey string
-	GeminiModel  string
+	DatabaseURL string
+	Port        int
+	Environment string
+
+	// LLMProvider, LLMAPIKey, LLMModel and LLMBaseURL configure the
+	// server-wide analyzer used by users without their own provider.
+	// GEMINI_API_KEY and GEMINI_MODEL are still read for the Gemini
+	// provider.
+	LLMProvider string
+	LLMAPIKey   string
+	LLMModel    string
+	LLMBaseURL  string
+
+	// CaptureRetentionHours and CaptureRetentionCount are the default
+	// limits on stored live captures per project. Zero or less means no
+	// limit.
+	CaptureRetentionHours int
+	CaptureRetentionCount int
+
+	// ScanWorkers is the number of repository scans run concurrently.
+	ScanWorkers int
+	// ScanChunkTokens and ScanChunkParallelism bound the chunks files are
+	// sent to the LLM in when they do not fit in one prompt, and how many
+	// chunks of a scan are analyzed at once. Zero selects the defaults.
+	ScanChunkTokens      int
+	ScanChunkParallelism int
 
 	GitHubAppID           int64
 	GitHubAppPrivateKey   []byte
@@ -22,11 +44,42 @@ type Config struct {
 	FrontendURL           string
 }
 
-func Load() *Config {
+// Load reads the configuration from the environment and .env. It fails
+// on numeric settings that do not parse rather than silently using zero.
+func Load() (*Config, error) {
 	godotenv.Load()
 
-	port, _ := strconv.Atoi(getEnv("PORT", "8080"))
-	appID, _ := strconv.ParseInt(getEnv("GITHUB_APP_ID", "0"), 10, 64)
+	port, err := getEnvInt("PORT", 8080)
+	if err != nil {
+		return nil, err
+	}
+	retentionHours, err := getEnvInt("CAPTURE_RETENTION_HOURS", 168)
+	if err != nil {
+		return nil, err
+	}
+	retentionCount, err := getEnvInt("CAPTURE_RETENTION_COUNT", 10000)
+	if err != nil {
+		return nil, err
+	}
+	scanWorkers, err := getEnvInt("SCAN_WORKERS", 4)
+	if err != nil {
+		return nil, err
+	}
+	chunkTokens, err := getEnvInt("SCAN_CHUNK_TOKENS", 0)
+	if err != nil {
+		return nil, err
+	}
+	chunkParallelism, err := getEnvInt("SCAN_CHUNK_PARALLELISM", 0)
+	if err != nil {
+		return nil, err
+	}
+	if scanWorkers < 1 {
+		scanWorkers = 1
+	}
+	appID, err := strconv.ParseInt(getEnv("GITHUB_APP_ID", "0"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GITHUB_APP_ID: %w", err)
+	}
 
 	var privateKey []byte
 	if key := getEnv("GITHUB_APP_PRIVATE_KEY", ""); key != "" {
@@ -35,12 +88,34 @@ func Load() *Config {
 		privateKey, _ = os.ReadFile(path)
 	}
 
+	llmProvider := getEnv("LLM_PROVIDER", "gemini")
+	llmAPIKey := getEnv("LLM_API_KEY", "")
+	llmModel := getEnv("LLM_MODEL", "")
+	if llmProvider == "gemini" {
+		if llmAPIKey == "" {
+			llmAPIKey = getEnv("GEMINI_API_KEY", "")
+		}
+		if llmModel == "" {
+			llmModel = getEnv("GEMINI_MODEL", "")
+		}
+	}
+
 	return &Config{
-		DatabaseURL:  getEnv("DATABASE_URL", ""),
-		Port:         port,
-		Environment:  getEnv("ENVIRONMENT", "development"),
-		GeminiAPIKey: getEnv("GEMINI_API_KEY", ""),
-		GeminiModel:  getEnv("GEMINI_MODEL", "gemini-2.0-flash"),
+		DatabaseURL: getEnv("DATABASE_URL", ""),
+		Port:        port,
+		Environment: getEnv("ENVIRONMENT", "development"),
+
+		LLMProvider: llmProvider,
+		LLMAPIKey:   llmAPIKey,
+		LLMModel:    llmModel,
+		LLMBaseURL:  getEnv("LLM_BASE_URL", ""),
+
+		CaptureRetentionHours: retentionHours,
+		CaptureRetentionCount: retentionCount,
+
+		ScanWorkers:          scanWorkers,
+		ScanChunkTokens:      chunkTokens,
+		ScanChunkParallelism: chunkParallelism,
 
 		GitHubAppID:           appID,
 		GitHubAppPrivateKey:   privateKey,
@@ -48,7 +123,7 @@ func Load() *Config {
 		GitHubAppClientSecret: getEnv("GITHUB_APP_CLIENT_SECRET", ""),
 		GitHubAppSlug:         getEnv("GITHUB_APP_SLUG", ""),
 		FrontendURL:           getEnv("FRONTEND_URL", "http://localhost:3000"),
-	}
+	}, nil
 }
 
 func getEnv(key, fallback string) string {
@@ -57,3 +132,15 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: must be an integer", key, value)
+	}
+	return n, nil
+}
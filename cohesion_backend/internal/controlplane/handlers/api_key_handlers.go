@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/cohesion-api/cohesion_backend/internal/auth"
+	"github.com/cohesion-api/cohesion_backend/internal/models"
+	"github.com/cohesion-api/cohesion_backend/internal/repository"
+	"github.com/cohesion-api/cohesion_backend/internal/services"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// CreateAPIKeyResponse carries the key's secret token, which is only ever
+// returned here.
+type CreateAPIKeyResponse struct {
+	models.APIKey
+	Token string `json:"token"`
+}
+
+func (h *Handlers) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	if h.requireProjectAccess(w, r, projectID, models.RoleOwner) == nil {
+		return
+	}
+
+	keys, err := h.apiKeyService.List(r.Context(), projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list API keys")
+		return
+	}
+
+	if keys == nil {
+		keys = []models.APIKey{}
+	}
+
+	respondJSON(w, http.StatusOK, keys)
+}
+
+func (h *Handlers) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if strings.TrimSpace(req.Name) == "" {
+		respondError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if err := services.ValidateScopes(req.Scopes); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if h.requireProjectAccess(w, r, projectID, models.RoleOwner) == nil {
+		return
+	}
+
+	key, token, err := h.apiKeyService.Create(r.Context(), projectID, strings.TrimSpace(req.Name), req.Scopes, auth.UserID(r.Context()))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create API key")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, CreateAPIKeyResponse{APIKey: *key, Token: token})
+}
+
+func (h *Handlers) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	keyID, err := uuid.Parse(chi.URLParam(r, "keyID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid key ID")
+		return
+	}
+
+	if h.requireProjectAccess(w, r, projectID, models.RoleOwner) == nil {
+		return
+	}
+
+	if err := h.apiKeyService.Revoke(r.Context(), projectID, keyID); err != nil {
+		if err == repository.ErrNotFound {
+			respondError(w, http.StatusNotFound, "API key not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to revoke API key")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,492 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cohesion-api/cohesion_backend/internal/auth"
+	"github.com/cohesion-api/cohesion_backend/internal/models"
+	"github.com/cohesion-api/cohesion_backend/internal/repository"
+	"github.com/cohesion-api/cohesion_backend/pkg/diff"
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	reportFormatJSON  = "json"
+	reportFormatSARIF = "sarif"
+	reportFormatJUnit = "junit"
+)
+
+// reportFormat picks the diff report format from the "format" query
+// parameter, falling back to the Accept header. JSON is the default.
+func reportFormat(r *http.Request) (string, bool) {
+	switch f := strings.ToLower(r.URL.Query().Get("format")); f {
+	case "":
+	case reportFormatJSON, reportFormatSARIF, reportFormatJUnit:
+		return f, true
+	default:
+		return "", false
+	}
+
+	accept := strings.ToLower(r.Header.Get("Accept"))
+	switch {
+	case strings.Contains(accept, "application/sarif+json"):
+		return reportFormatSARIF, true
+	case strings.Contains(accept, "application/junit+xml"),
+		strings.Contains(accept, "application/xml"),
+		strings.Contains(accept, "text/xml"):
+		return reportFormatJUnit, true
+	default:
+		return reportFormatJSON, true
+	}
+}
+
+// respondReport writes results as SARIF or JUnit. It is only used for the
+// non-JSON formats; JSON responses keep each handler's own shape.
+func respondReport(w http.ResponseWriter, format, name string, results []*diff.Result) {
+	var buf bytes.Buffer
+	var err error
+	contentType := "application/sarif+json"
+	if format == reportFormatJUnit {
+		contentType = "application/xml"
+		err = diff.WriteJUnit(&buf, name, results)
+	} else {
+		err = diff.WriteSARIF(&buf, results, diff.SARIFOptions{})
+	}
+	if err != nil {
+		log.Printf("ERROR: failed to render %s report: %v", format, err)
+		respondError(w, http.StatusInternalServerError, "Failed to render report")
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+func (h *Handlers) ComputeDiff(w http.ResponseWriter, r *http.Request) {
+	endpointID, err := uuid.Parse(chi.URLParam(r, "endpointID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid endpoint ID")
+		return
+	}
+
+	format, ok := reportFormat(r)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "format must be json, sarif or junit")
+		return
+	}
+
+	if h.requireEndpointAccess(w, r, endpointID, models.RoleViewer) == nil {
+		return
+	}
+
+	result, err := h.diffService.ComputeDiff(r.Context(), endpointID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to compute diff")
+		return
+	}
+
+	if format != reportFormatJSON {
+		respondReport(w, format, "cohesion", []*diff.Result{result})
+		return
+	}
+	respondJSON(w, http.StatusOK, result)
+}
+
+func (h *Handlers) ComputeProjectDiff(w http.ResponseWriter, r *http.Request) {
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	format, ok := reportFormat(r)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "format must be json, sarif or junit")
+		return
+	}
+
+	project := h.requireProjectAccess(w, r, projectID, models.RoleViewer)
+	if project == nil {
+		return
+	}
+
+	results, err := h.diffService.ComputeProjectDiff(r.Context(), projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to compute project diff")
+		return
+	}
+
+	if format != reportFormatJSON {
+		respondReport(w, format, project.Name, results)
+		return
+	}
+	respondJSON(w, http.StatusOK, results)
+}
+
+type CreateDiffRunRequest struct {
+	CommitSHA string `json:"commit_sha"`
+}
+
+func (h *Handlers) CreateDiffRun(w http.ResponseWriter, r *http.Request) {
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	format, ok := reportFormat(r)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "format must be json, sarif or junit")
+		return
+	}
+
+	var req CreateDiffRunRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+	if len(req.CommitSHA) > 64 {
+		respondError(w, http.StatusBadRequest, "commit_sha is too long")
+		return
+	}
+
+	project := h.requireProjectAccess(w, r, projectID, models.RoleEditor)
+	if project == nil {
+		return
+	}
+
+	run, results, err := h.diffService.RunProjectDiff(r.Context(), projectID, req.CommitSHA)
+	if err != nil {
+		log.Printf("ERROR: project diff run for %s: %v", projectID, err)
+		respondError(w, http.StatusInternalServerError, "Failed to run project diff")
+		return
+	}
+
+	if format != reportFormatJSON {
+		w.Header().Set("X-Cohesion-Run-ID", run.ID.String())
+		respondReport(w, format, project.Name, results)
+		return
+	}
+	respondJSON(w, http.StatusCreated, run)
+}
+
+func (h *Handlers) ListDiffRuns(w http.ResponseWriter, r *http.Request) {
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	if h.requireProjectAccess(w, r, projectID, models.RoleViewer) == nil {
+		return
+	}
+
+	runs, err := h.diffService.ListRuns(r.Context(), projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list diff runs")
+		return
+	}
+
+	if runs == nil {
+		runs = []models.DiffRun{}
+	}
+
+	respondJSON(w, http.StatusOK, runs)
+}
+
+// requireDiffRun loads a run and checks that it belongs to the project in
+// the URL, which the caller must already be allowed to access.
+func (h *Handlers) requireDiffRun(w http.ResponseWriter, r *http.Request, projectID uuid.UUID, param string) *models.DiffRun {
+	runID, err := uuid.Parse(param)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid run ID")
+		return nil
+	}
+
+	run, err := h.diffService.GetRun(r.Context(), runID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get diff run")
+		return nil
+	}
+	if run == nil || run.ProjectID != projectID {
+		respondError(w, http.StatusNotFound, "Diff run not found")
+		return nil
+	}
+	return run
+}
+
+func (h *Handlers) GetDiffRun(w http.ResponseWriter, r *http.Request) {
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	if h.requireProjectAccess(w, r, projectID, models.RoleViewer) == nil {
+		return
+	}
+
+	run := h.requireDiffRun(w, r, projectID, chi.URLParam(r, "runID"))
+	if run == nil {
+		return
+	}
+
+	respondJSON(w, http.StatusOK, run)
+}
+
+// CompareDiffRuns compares a run against ?base={runID}, or against the
+// previous completed run of the project when no base is given.
+func (h *Handlers) CompareDiffRuns(w http.ResponseWriter, r *http.Request) {
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	if h.requireProjectAccess(w, r, projectID, models.RoleViewer) == nil {
+		return
+	}
+
+	head := h.requireDiffRun(w, r, projectID, chi.URLParam(r, "runID"))
+	if head == nil {
+		return
+	}
+
+	var base *models.DiffRun
+	if baseParam := r.URL.Query().Get("base"); baseParam != "" {
+		if base = h.requireDiffRun(w, r, projectID, baseParam); base == nil {
+			return
+		}
+	} else {
+		base, err = h.diffService.GetPreviousRun(r.Context(), head)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to get previous diff run")
+			return
+		}
+		if base == nil {
+			respondError(w, http.StatusNotFound, "No earlier run to compare against")
+			return
+		}
+	}
+
+	cmp, err := h.diffService.CompareRuns(r.Context(), base, head)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to compare diff runs")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, cmp)
+}
+
+type CreateWaiverRequest struct {
+	EndpointPattern string     `json:"endpoint_pattern"`
+	Method          string     `json:"method"`
+	FieldPath       string     `json:"field_path"`
+	MismatchType    string     `json:"mismatch_type"`
+	Sources         []string   `json:"sources"`
+	Reason          string     `json:"reason"`
+	ExpiresAt       *time.Time `json:"expires_at"`
+}
+
+func (h *Handlers) ListWaivers(w http.ResponseWriter, r *http.Request) {
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	if h.requireProjectAccess(w, r, projectID, models.RoleViewer) == nil {
+		return
+	}
+
+	waivers, err := h.diffService.ListWaivers(r.Context(), projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list waivers")
+		return
+	}
+
+	if waivers == nil {
+		waivers = []models.Waiver{}
+	}
+
+	respondJSON(w, http.StatusOK, waivers)
+}
+
+func (h *Handlers) CreateWaiver(w http.ResponseWriter, r *http.Request) {
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	var req CreateWaiverRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if strings.TrimSpace(req.Reason) == "" {
+		respondError(w, http.StatusBadRequest, "reason is required")
+		return
+	}
+	if req.EndpointPattern == "" && req.FieldPath == "" && req.MismatchType == "" {
+		respondError(w, http.StatusBadRequest, "At least one of endpoint_pattern, field_path or mismatch_type is required")
+		return
+	}
+	if req.MismatchType != "" && !diff.MismatchType(req.MismatchType).IsKnown() {
+		respondError(w, http.StatusBadRequest, "Invalid mismatch_type")
+		return
+	}
+	if len(req.Sources) > 2 {
+		respondError(w, http.StatusBadRequest, "sources holds at most a pair")
+		return
+	}
+	for _, src := range req.Sources {
+		if !schemair.SchemaSource(src).IsKnown() {
+			respondError(w, http.StatusBadRequest, "Invalid source: "+src)
+			return
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		respondError(w, http.StatusBadRequest, "expires_at must be in the future")
+		return
+	}
+
+	if h.requireProjectAccess(w, r, projectID, models.RoleEditor) == nil {
+		return
+	}
+
+	waiver := &models.Waiver{
+		ProjectID:       projectID,
+		EndpointPattern: req.EndpointPattern,
+		Method:          req.Method,
+		FieldPath:       req.FieldPath,
+		MismatchType:    req.MismatchType,
+		Sources:         req.Sources,
+		Reason:          req.Reason,
+		ExpiresAt:       req.ExpiresAt,
+		CreatedBy:       auth.UserID(r.Context()),
+	}
+	if err := h.diffService.CreateWaiver(r.Context(), waiver); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create waiver")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, waiver)
+}
+
+func (h *Handlers) DeleteWaiver(w http.ResponseWriter, r *http.Request) {
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	waiverID, err := uuid.Parse(chi.URLParam(r, "waiverID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid waiver ID")
+		return
+	}
+
+	if h.requireProjectAccess(w, r, projectID, models.RoleEditor) == nil {
+		return
+	}
+
+	if err := h.diffService.DeleteWaiver(r.Context(), projectID, waiverID); err != nil {
+		if err == repository.ErrNotFound {
+			respondError(w, http.StatusNotFound, "Waiver not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to delete waiver")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type UpdateSeverityPolicyRequest struct {
+	Rules []diff.PolicyRule `json:"rules"`
+}
+
+func (h *Handlers) GetSeverityPolicy(w http.ResponseWriter, r *http.Request) {
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	if h.requireProjectAccess(w, r, projectID, models.RoleViewer) == nil {
+		return
+	}
+
+	policy, err := h.diffService.GetSeverityPolicy(r.Context(), projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get severity policy")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, policy)
+}
+
+func (h *Handlers) UpdateSeverityPolicy(w http.ResponseWriter, r *http.Request) {
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	var req UpdateSeverityPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	for i := range req.Rules {
+		if err := req.Rules[i].Validate(); err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("rule %d: %v", i, err))
+			return
+		}
+	}
+
+	if h.requireProjectAccess(w, r, projectID, models.RoleEditor) == nil {
+		return
+	}
+
+	policy, err := h.diffService.UpdateSeverityPolicy(r.Context(), projectID, req.Rules, auth.UserID(r.Context()))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update severity policy")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, policy)
+}
+
+func (h *Handlers) ResetSeverityPolicy(w http.ResponseWriter, r *http.Request) {
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	if h.requireProjectAccess(w, r, projectID, models.RoleEditor) == nil {
+		return
+	}
+
+	if err := h.diffService.ResetSeverityPolicy(r.Context(), projectID); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to reset severity policy")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,14 +2,17 @@ package handlers
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/clerk/clerk-sdk-go/v2/signintoken"
 	clerkuser "github.com/clerk/clerk-sdk-go/v2/user"
@@ -18,11 +21,10 @@ import (
 	"github.com/cohesion-api/cohesion_backend/internal/repository"
 	"github.com/cohesion-api/cohesion_backend/internal/services"
 	"github.com/cohesion-api/cohesion_backend/pkg/analyzer"
-	"github.com/cohesion-api/cohesion_backend/pkg/analyzer/gemini"
+	"github.com/cohesion-api/cohesion_backend/pkg/analyzer/llm"
 	ghpkg "github.com/cohesion-api/cohesion_backend/pkg/github"
 	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
 	"github.com/go-chi/chi/v5"
-	gh "github.com/google/go-github/v68/github"
 	"github.com/google/uuid"
 )
 
@@ -35,16 +37,21 @@ type ProxyTarget struct {
 }
 
 type Handlers struct {
-	projectService      *services.ProjectService
-	endpointService     *services.EndpointService
-	schemaService       *services.SchemaService
-	diffService         *services.DiffService
-	liveService         *services.LiveService
-	userSettingsService *services.UserSettingsService
-	ghInstallService    *services.GitHubInstallationService
-	analyzer            analyzer.Analyzer
-	githubAppAuth       *ghpkg.AppAuth
-	githubAppSlug       string
+	projectService       *services.ProjectService
+	endpointService      *services.EndpointService
+	schemaService        *services.SchemaService
+	diffService          *services.DiffService
+	liveService          *services.LiveService
+	userSettingsService  *services.UserSettingsService
+	ghInstallService     *services.GitHubInstallationService
+	apiKeyService        *services.APIKeyService
+	orgService           *services.OrganizationService
+	scanJobService       *services.ScanJobService
+	analysisCacheService *services.AnalysisCacheService
+	analyzer             analyzer.FileAnalyzer
+	registry             *analyzer.Registry
+	githubAppAuth        *ghpkg.AppAuth
+	githubAppSlug        string
 
 	proxyMu      sync.RWMutex
 	proxyTargets map[string]map[string]*ProxyTarget // projectID → label → target
@@ -58,22 +65,32 @@ func New(
 	liveService *services.LiveService,
 	userSettingsService *services.UserSettingsService,
 	ghInstallService *services.GitHubInstallationService,
-	a analyzer.Analyzer,
+	apiKeyService *services.APIKeyService,
+	orgService *services.OrganizationService,
+	scanJobService *services.ScanJobService,
+	analysisCacheService *services.AnalysisCacheService,
+	a analyzer.FileAnalyzer,
+	registry *analyzer.Registry,
 	githubAppAuth *ghpkg.AppAuth,
 	githubAppSlug string,
 ) *Handlers {
 	return &Handlers{
-		projectService:      projectService,
-		endpointService:     endpointService,
-		schemaService:       schemaService,
-		diffService:         diffService,
-		liveService:         liveService,
-		userSettingsService: userSettingsService,
-		ghInstallService:    ghInstallService,
-		analyzer:            a,
-		githubAppAuth:       githubAppAuth,
-		githubAppSlug:       githubAppSlug,
-		proxyTargets:        make(map[string]map[string]*ProxyTarget),
+		projectService:       projectService,
+		endpointService:      endpointService,
+		schemaService:        schemaService,
+		diffService:          diffService,
+		liveService:          liveService,
+		userSettingsService:  userSettingsService,
+		ghInstallService:     ghInstallService,
+		apiKeyService:        apiKeyService,
+		orgService:           orgService,
+		scanJobService:       scanJobService,
+		analysisCacheService: analysisCacheService,
+		analyzer:             a,
+		registry:             registry,
+		githubAppAuth:        githubAppAuth,
+		githubAppSlug:        githubAppSlug,
+		proxyTargets:         make(map[string]map[string]*ProxyTarget),
 	}
 }
 
@@ -135,6 +152,9 @@ func findDemoUser(ctx context.Context) (string, error) {
 type CreateProjectRequest struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	// OrganizationID optionally creates the project inside an organization
+	// the user is at least an editor of.
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
 }
 
 func (h *Handlers) CreateProject(w http.ResponseWriter, r *http.Request) {
@@ -150,7 +170,11 @@ func (h *Handlers) CreateProject(w http.ResponseWriter, r *http.Request) {
 	}
 
 	userID := auth.UserID(r.Context())
-	project, err := h.projectService.Create(r.Context(), userID, req.Name, req.Description)
+	if req.OrganizationID != nil && h.requireOrganizationRole(w, r, *req.OrganizationID, models.RoleEditor) == nil {
+		return
+	}
+
+	project, err := h.projectService.Create(r.Context(), userID, req.OrganizationID, req.Name, req.Description)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to create project")
 		return
@@ -166,15 +190,8 @@ func (h *Handlers) GetProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := auth.UserID(r.Context())
-	project, err := h.projectService.GetByID(r.Context(), projectID, userID)
-	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to get project")
-		return
-	}
-
+	project := h.requireProjectAccess(w, r, projectID, models.RoleViewer)
 	if project == nil {
-		respondError(w, http.StatusNotFound, "Project not found")
 		return
 	}
 
@@ -203,8 +220,11 @@ func (h *Handlers) DeleteProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := auth.UserID(r.Context())
-	if err := h.projectService.Delete(r.Context(), projectID, userID); err != nil {
+	if h.requireProjectAccess(w, r, projectID, models.RoleOwner) == nil {
+		return
+	}
+
+	if err := h.projectService.Delete(r.Context(), projectID); err != nil {
 		if err == repository.ErrNotFound {
 			respondError(w, http.StatusNotFound, "Project not found")
 			return
@@ -246,7 +266,7 @@ func (h *Handlers) uploadSchemas(w http.ResponseWriter, r *http.Request, source
 		return
 	}
 
-	if h.requireProjectAccess(w, r, projectID) == nil {
+	if h.requireProjectAccess(w, r, projectID, models.RoleEditor) == nil {
 		return
 	}
 
@@ -291,74 +311,50 @@ func (h *Handlers) ScanCodebase(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if h.requireProjectAccess(w, r, projectID) == nil {
+	if h.requireProjectAccess(w, r, projectID, models.RoleEditor) == nil {
 		return
 	}
 
-	var mode gemini.ScanMode
+	var mode analyzer.ScanMode
 	var source schemair.SchemaSource
 	switch req.ScanType {
 	case "frontend":
-		mode = gemini.ScanModeFrontend
+		mode = analyzer.ScanModeFrontend
 		source = schemair.SourceFrontendStatic
 	case "backend", "":
-		mode = gemini.ScanModeBackend
+		mode = analyzer.ScanModeBackend
 		source = schemair.SourceBackendStatic
 	default:
 		respondError(w, http.StatusBadRequest, "Invalid scan_type: must be 'backend' or 'frontend'")
 		return
 	}
 
-	var ga *gemini.GeminiAnalyzer
-	userID := auth.UserID(r.Context())
-	if userID != "" {
-		settings, err := h.userSettingsService.Get(r.Context(), userID)
-		if err == nil && settings.GeminiAPIKey != "" {
-			model := settings.GeminiModel
-			if model == "" {
-				model = "gemini-2.5-flash"
-			}
-			ga = gemini.New(settings.GeminiAPIKey, model)
-		}
-	}
-	if ga == nil {
-		var ok bool
-		ga, ok = h.analyzer.(*gemini.GeminiAnalyzer)
-		if !ok {
-			respondError(w, http.StatusBadRequest, "No Gemini API key configured. Add one in Settings.")
-			return
+	if len(req.Files) == 0 {
+		if req.DirPath != "" {
+			respondError(w, http.StatusBadRequest, "dir_path is not supported for security reasons. Upload files directly using the 'files' field instead.")
+		} else {
+			respondError(w, http.StatusBadRequest, "Either 'files' or 'dir_path' must be provided")
 		}
+		return
 	}
 
-	var schemas []*schemair.SchemaIR
-
-	if len(req.Files) > 0 {
-		sourceFiles := make([]gemini.SourceFile, len(req.Files))
-		for i, f := range req.Files {
-			sourceFiles[i] = gemini.SourceFile{Path: f.Path, Content: f.Content}
-		}
-
-		language := req.Language
-		if language == "" {
-			language = gemini.DetectLanguage(sourceFiles)
-		}
+	var settings *models.UserSettings
+	if userID := auth.UserID(r.Context()); userID != "" {
+		settings, _ = h.userSettingsService.Get(r.Context(), userID)
+	}
 
-		schemas, err = ga.AnalyzeFiles(r.Context(), sourceFiles, language, mode)
-	} else if req.DirPath != "" {
-		respondError(w, http.StatusBadRequest, "dir_path is not supported for security reasons. Upload files directly using the 'files' field instead.")
-		return
-	} else {
-		respondError(w, http.StatusBadRequest, "Either 'files' or 'dir_path' must be provided")
-		return
+	sourceFiles := make([]analyzer.SourceFile, len(req.Files))
+	for i, f := range req.Files {
+		sourceFiles[i] = analyzer.SourceFile{Path: f.Path, Content: f.Content}
 	}
 
-	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Analysis failed: "+err.Error())
+	result := h.runScan(w, r, projectID, sourceFiles, req.Language, mode, settings)
+	if result == nil {
 		return
 	}
 
-	valSchemas := make([]schemair.SchemaIR, len(schemas))
-	for i, s := range schemas {
+	valSchemas := make([]schemair.SchemaIR, len(result.Schemas))
+	for i, s := range result.Schemas {
 		s.Source = source
 		valSchemas[i] = *s
 	}
@@ -368,10 +364,7 @@ func (h *Handlers) ScanCodebase(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"message": "Analysis successful and schemas uploaded",
-		"count":   len(schemas),
-	})
+	respondJSON(w, http.StatusOK, services.ScanSummary(result, "Analysis successful and schemas uploaded"))
 }
 
 type ScanGitHubRequest struct {
@@ -382,6 +375,8 @@ type ScanGitHubRequest struct {
 	ScanType  string `json:"scan_type"`
 }
 
+// ScanGitHubRepo queues a scan of a GitHub repository and responds with the
+// job, whose progress can be followed on its stream.
 func (h *Handlers) ScanGitHubRepo(w http.ResponseWriter, r *http.Request) {
 	var req ScanGitHubRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -395,112 +390,43 @@ func (h *Handlers) ScanGitHubRepo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if h.requireProjectAccess(w, r, projectID) == nil {
-		return
-	}
-
-	owner, repo, err := ghpkg.ParseRepoURL(req.RepoURL)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
+	if h.requireProjectAccess(w, r, projectID, models.RoleEditor) == nil {
 		return
 	}
 
-	var mode gemini.ScanMode
-	var source schemair.SchemaSource
 	switch req.ScanType {
-	case "frontend":
-		mode = gemini.ScanModeFrontend
-		source = schemair.SourceFrontendStatic
-	case "backend", "":
-		mode = gemini.ScanModeBackend
-		source = schemair.SourceBackendStatic
+	case "":
+		req.ScanType = "backend"
+	case "backend", "frontend":
 	default:
 		respondError(w, http.StatusBadRequest, "Invalid scan_type: must be 'backend' or 'frontend'")
 		return
 	}
 
-	userID := auth.UserID(r.Context())
-	if userID == "" {
-		respondError(w, http.StatusUnauthorized, "Not authenticated")
-		return
+	job := &models.ScanJob{
+		ProjectID: projectID,
+		CreatedBy: auth.UserID(r.Context()),
+		RepoURL:   req.RepoURL,
+		Branch:    req.Branch,
+		Path:      req.Path,
+		ScanType:  req.ScanType,
 	}
-
-	settings, err := h.userSettingsService.Get(r.Context(), userID)
-	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to load user settings")
-		return
-	}
-
-	var ghClient *gh.Client
-	if h.githubAppAuth.IsConfigured() {
-		installations, err := h.ghInstallService.List(r.Context(), userID)
-		if err == nil {
-			for _, inst := range installations {
-				client, err := h.githubAppAuth.InstallationClient(inst.InstallationID)
-				if err != nil {
-					continue
-				}
-
-				_, _, err = client.Repositories.Get(r.Context(), owner, repo)
-				if err == nil {
-					ghClient = client
-					break
-				}
-			}
-		}
-	}
-	if ghClient == nil && settings.GitHubToken != "" {
-		ghClient = gh.NewClient(nil).WithAuthToken(settings.GitHubToken)
-	}
-	if ghClient == nil {
-		respondError(w, http.StatusBadRequest, "Connect a GitHub App or add a Personal Access Token in Settings")
+	spec := h.githubScanSpec(w, r, job)
+	if spec == nil {
 		return
 	}
 
-	files, language, err := ghpkg.FetchRepoFilesWithClient(r.Context(), ghClient, owner, repo, req.Branch, req.Path)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "GitHub fetch failed: "+err.Error())
+	err = h.scanJobService.Enqueue(r.Context(), job, *spec)
+	if err == services.ErrScanQueueFull {
+		respondError(w, http.StatusServiceUnavailable, err.Error())
 		return
 	}
-
-	var ga *gemini.GeminiAnalyzer
-	if settings.GeminiAPIKey != "" {
-		model := settings.GeminiModel
-		if model == "" {
-			model = "gemini-2.5-flash"
-		}
-		ga = gemini.New(settings.GeminiAPIKey, model)
-	}
-	if ga == nil {
-		var ok bool
-		ga, ok = h.analyzer.(*gemini.GeminiAnalyzer)
-		if !ok {
-			respondError(w, http.StatusBadRequest, "No Gemini API key configured. Add one in Settings.")
-			return
-		}
-	}
-
-	schemas, err := ga.AnalyzeFiles(r.Context(), files, language, mode)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Analysis failed: "+err.Error())
-		return
-	}
-
-	valSchemas := make([]schemair.SchemaIR, len(schemas))
-	for i, s := range schemas {
-		s.Source = source
-		valSchemas[i] = *s
-	}
-
-	if err := h.schemaService.UploadSchemas(r.Context(), projectID, valSchemas); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to upload analyzed schemas")
+		respondError(w, http.StatusInternalServerError, "Failed to queue scan")
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"message": fmt.Sprintf("Scanned %s/%s — %d endpoints found", owner, repo, len(schemas)),
-		"count":   len(schemas),
-	})
+	respondJSON(w, http.StatusAccepted, job)
 }
 
 func (h *Handlers) ListEndpoints(w http.ResponseWriter, r *http.Request) {
@@ -516,7 +442,7 @@ func (h *Handlers) ListEndpoints(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if h.requireProjectAccess(w, r, projectID) == nil {
+	if h.requireProjectAccess(w, r, projectID, models.RoleViewer) == nil {
 		return
 	}
 
@@ -540,7 +466,7 @@ func (h *Handlers) GetEndpoint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	endpoint := h.requireEndpointAccess(w, r, endpointID)
+	endpoint := h.requireEndpointAccess(w, r, endpointID, models.RoleViewer)
 	if endpoint == nil {
 		return
 	}
@@ -548,24 +474,110 @@ func (h *Handlers) GetEndpoint(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, endpoint)
 }
 
-func (h *Handlers) ComputeDiff(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) ListSchemaVersions(w http.ResponseWriter, r *http.Request) {
+	endpointID, err := uuid.Parse(chi.URLParam(r, "endpointID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid endpoint ID")
+		return
+	}
+
+	if h.requireEndpointAccess(w, r, endpointID, models.RoleViewer) == nil {
+		return
+	}
+
+	versions, err := h.schemaService.ListVersions(r.Context(), endpointID, r.URL.Query().Get("source"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list schema versions")
+		return
+	}
+
+	if versions == nil {
+		versions = []models.SchemaVersion{}
+	}
+
+	respondJSON(w, http.StatusOK, versions)
+}
+
+func (h *Handlers) GetSchemaVersion(w http.ResponseWriter, r *http.Request) {
 	endpointID, err := uuid.Parse(chi.URLParam(r, "endpointID"))
 	if err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid endpoint ID")
 		return
 	}
 
-	if h.requireEndpointAccess(w, r, endpointID) == nil {
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil || version < 1 {
+		respondError(w, http.StatusBadRequest, "Invalid version")
 		return
 	}
 
-	diff, err := h.diffService.ComputeDiff(r.Context(), endpointID)
+	if h.requireEndpointAccess(w, r, endpointID, models.RoleViewer) == nil {
+		return
+	}
+
+	schema, err := h.schemaService.GetVersion(r.Context(), endpointID, chi.URLParam(r, "source"), version)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to compute diff")
+		respondError(w, http.StatusInternalServerError, "Failed to get schema version")
+		return
+	}
+	if schema == nil {
+		respondError(w, http.StatusNotFound, "Schema version not found")
 		return
 	}
 
-	respondJSON(w, http.StatusOK, diff)
+	respondJSON(w, http.StatusOK, schema)
+}
+
+func (h *Handlers) GetSchemaChanges(w http.ResponseWriter, r *http.Request) {
+	endpointID, err := uuid.Parse(chi.URLParam(r, "endpointID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid endpoint ID")
+		return
+	}
+
+	query := r.URL.Query()
+	source := schemair.SchemaSource(query.Get("source"))
+	if source == "" {
+		source = schemair.SourceBackendStatic
+	}
+	if !source.IsKnown() {
+		respondError(w, http.StatusBadRequest, "Invalid source")
+		return
+	}
+
+	var from, to int
+	if v := query.Get("from"); v != "" {
+		if from, err = strconv.Atoi(v); err != nil || from < 1 {
+			respondError(w, http.StatusBadRequest, "Invalid from version")
+			return
+		}
+	}
+	if v := query.Get("to"); v != "" {
+		if to, err = strconv.Atoi(v); err != nil || to < 1 {
+			respondError(w, http.StatusBadRequest, "Invalid to version")
+			return
+		}
+	}
+	if from != 0 && to != 0 && from >= to {
+		respondError(w, http.StatusBadRequest, "from must be lower than to")
+		return
+	}
+
+	if h.requireEndpointAccess(w, r, endpointID, models.RoleViewer) == nil {
+		return
+	}
+
+	report, err := h.diffService.ComputeChanges(r.Context(), endpointID, string(source), from, to)
+	if err == repository.ErrNotFound {
+		respondError(w, http.StatusNotFound, "Schema versions not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to compute schema changes")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, report)
 }
 
 func (h *Handlers) GetStats(w http.ResponseWriter, r *http.Request) {
@@ -604,16 +616,22 @@ func (h *Handlers) GetUserSettings(w http.ResponseWriter, r *http.Request) {
 	}
 
 	respondJSON(w, http.StatusOK, map[string]string{
-		"gemini_api_key": maskSecret(settings.GeminiAPIKey),
-		"gemini_model":   settings.GeminiModel,
-		"github_token":   maskSecret(settings.GitHubToken),
+		"llm_provider": settings.LLMProvider,
+		"llm_api_key":  maskSecret(settings.LLMAPIKey),
+		"llm_model":    settings.LLMModel,
+		"llm_base_url": settings.LLMBaseURL,
+		"github_token": maskSecret(settings.GitHubToken),
 	})
 }
 
+// SaveUserSettingsRequest selects the user's LLM provider. An empty
+// provider uses the server's.
 type SaveUserSettingsRequest struct {
-	GeminiAPIKey string `json:"gemini_api_key"`
-	GeminiModel  string `json:"gemini_model"`
-	GitHubToken  string `json:"github_token"`
+	LLMProvider string `json:"llm_provider"`
+	LLMAPIKey   string `json:"llm_api_key"`
+	LLMModel    string `json:"llm_model"`
+	LLMBaseURL  string `json:"llm_base_url"`
+	GitHubToken string `json:"github_token"`
 }
 
 func (h *Handlers) SaveUserSettings(w http.ResponseWriter, r *http.Request) {
@@ -629,21 +647,43 @@ func (h *Handlers) SaveUserSettings(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.LLMProvider != "" && !llm.ValidProvider(req.LLMProvider) {
+		respondError(w, http.StatusBadRequest, "Invalid llm_provider: must be 'gemini', 'openai' or 'anthropic'")
+		return
+	}
+	if req.LLMProvider == "" && (req.LLMAPIKey != "" || req.LLMBaseURL != "") {
+		respondError(w, http.StatusBadRequest, "llm_provider is required with an API key or base URL")
+		return
+	}
+	if req.LLMBaseURL != "" {
+		if err := validateLLMBaseURL(req.LLMBaseURL); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid llm_base_url: "+err.Error())
+			return
+		}
+	}
+
 	existing, err := h.userSettingsService.Get(r.Context(), userID)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to retrieve existing settings")
 		return
 	}
 	if existing != nil {
-		if strings.HasPrefix(req.GeminiAPIKey, "••") && existing.GeminiAPIKey != "" {
-			req.GeminiAPIKey = existing.GeminiAPIKey
+		if strings.HasPrefix(req.LLMAPIKey, "••") && existing.LLMAPIKey != "" {
+			req.LLMAPIKey = existing.LLMAPIKey
 		}
 		if strings.HasPrefix(req.GitHubToken, "••") && existing.GitHubToken != "" {
 			req.GitHubToken = existing.GitHubToken
 		}
 	}
 
-	if err := h.userSettingsService.Save(r.Context(), userID, req.GeminiAPIKey, req.GeminiModel, req.GitHubToken); err != nil {
+	if err := h.userSettingsService.Save(r.Context(), &models.UserSettings{
+		ClerkUserID: userID,
+		LLMProvider: req.LLMProvider,
+		LLMAPIKey:   req.LLMAPIKey,
+		LLMModel:    req.LLMModel,
+		LLMBaseURL:  req.LLMBaseURL,
+		GitHubToken: req.GitHubToken,
+	}); err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to save settings")
 		return
 	}
@@ -651,9 +691,141 @@ func (h *Handlers) SaveUserSettings(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Settings saved"})
 }
 
-func (h *Handlers) requireProjectAccess(w http.ResponseWriter, r *http.Request, projectID uuid.UUID) *models.Project {
-	userID := auth.UserID(r.Context())
-	project, err := h.projectService.GetByID(r.Context(), projectID, userID)
+// runScan analyzes files with the registered analyzers, falling back to
+// the user's LLM provider for files they do not cover and reusing the
+// project's cached results for unchanged ones. On failure it writes the
+// error response and returns nil.
+func (h *Handlers) runScan(w http.ResponseWriter, r *http.Request, projectID uuid.UUID, files []analyzer.SourceFile, language string, mode analyzer.ScanMode, settings *models.UserSettings) *analyzer.Result {
+	var fallbackErr error
+	result, err := h.registry.AnalyzeFilesCached(r.Context(), files, language, mode, func() (analyzer.FileAnalyzer, error) {
+		a, err := h.scanAnalyzer(settings)
+		fallbackErr = err
+		return a, err
+	}, h.analysisCacheService.ForProject(projectID))
+	if err != nil {
+		if err == fallbackErr {
+			respondError(w, http.StatusBadRequest, err.Error())
+		} else {
+			respondError(w, http.StatusInternalServerError, "Analysis failed: "+err.Error())
+		}
+		return nil
+	}
+	return result
+}
+
+// scanAnalyzer returns the analyzer for a user's scans: their own LLM
+// provider when they configured one, otherwise the server's.
+func (h *Handlers) scanAnalyzer(settings *models.UserSettings) (analyzer.FileAnalyzer, error) {
+	if settings != nil && settings.LLMProvider != "" && (settings.LLMAPIKey != "" || settings.LLMBaseURL != "") {
+		// The base URL is checked again in case its host now resolves to
+		// a private address, and requests are pinned to the checked IP.
+		var httpClient *http.Client
+		if settings.LLMBaseURL != "" {
+			var err error
+			httpClient, err = llmHTTPClient(settings.LLMBaseURL)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid LLM base URL in Settings: %v", err)
+			}
+		}
+		client, err := llm.NewClient(llm.Config{
+			Provider:   settings.LLMProvider,
+			APIKey:     settings.LLMAPIKey,
+			Model:      settings.LLMModel,
+			BaseURL:    settings.LLMBaseURL,
+			HTTPClient: httpClient,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Invalid LLM settings: %v", err)
+		}
+		return llm.New(client), nil
+	}
+	if h.analyzer == nil {
+		return nil, fmt.Errorf("No LLM provider configured. Add one in Settings.")
+	}
+	return h.analyzer, nil
+}
+
+// validateLLMBaseURL keeps user-supplied LLM endpoints off the server's
+// network, like proxy targets. Local model servers are configured
+// server-wide with LLM_BASE_URL instead.
+func validateLLMBaseURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	_, err = validateProxyTarget(u)
+	return err
+}
+
+// llmRequestTimeout matches the default timeout of LLM clients.
+const llmRequestTimeout = 5 * time.Minute
+
+// llmHTTPClient validates a user-supplied LLM base URL and returns a client
+// that only connects to the IP address it was validated against, so a DNS
+// change between the check and the request cannot reach the server's
+// network. Redirects are refused for the same reason.
+func llmHTTPClient(raw string) (*http.Client, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	pinnedIP, err := validateProxyTarget(u)
+	if err != nil {
+		return nil, err
+	}
+	port := u.Port()
+	if port == "" {
+		if u.Scheme == "https" {
+			port = "443"
+		} else {
+			port = "80"
+		}
+	}
+	return &http.Client{
+		Timeout: llmRequestTimeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return (&net.Dialer{
+					Timeout: proxyDialTimeout,
+				}).DialContext(ctx, network, net.JoinHostPort(pinnedIP, port))
+			},
+			TLSHandshakeTimeout: proxyTLSHandshakeTimeout,
+			TLSClientConfig:     &tls.Config{ServerName: u.Hostname()},
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return fmt.Errorf("redirects are not followed for custom LLM base URLs")
+		},
+	}, nil
+}
+
+// requireProjectAccess loads a project the requesting user can access
+// with at least the given role or, for API key requests, the key's own
+// project. API keys are limited by their scopes instead of a role, so they
+// pass every check short of owner. A user without a role on the project is
+// denied.
+func (h *Handlers) requireProjectAccess(w http.ResponseWriter, r *http.Request, projectID uuid.UUID, role string) *models.Project {
+	if key := auth.APIKey(r.Context()); key != nil {
+		var project *models.Project
+		var err error
+		if key.ProjectID == projectID {
+			project, err = h.projectService.GetByIDUnscoped(r.Context(), projectID)
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to verify project access")
+			return nil
+		}
+		if project == nil {
+			respondError(w, http.StatusNotFound, "Project not found")
+			return nil
+		}
+		if role == models.RoleOwner {
+			respondError(w, http.StatusForbidden, "API keys cannot perform this action")
+			return nil
+		}
+		return project
+	}
+
+	project, err := h.projectService.GetByID(r.Context(), projectID, auth.UserID(r.Context()))
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to verify project access")
 		return nil
@@ -662,10 +834,14 @@ func (h *Handlers) requireProjectAccess(w http.ResponseWriter, r *http.Request,
 		respondError(w, http.StatusNotFound, "Project not found")
 		return nil
 	}
+	if !models.RoleAtLeast(project.Role, role) {
+		respondError(w, http.StatusForbidden, fmt.Sprintf("This action requires the %s role on the project", role))
+		return nil
+	}
 	return project
 }
 
-func (h *Handlers) requireEndpointAccess(w http.ResponseWriter, r *http.Request, endpointID uuid.UUID) *models.Endpoint {
+func (h *Handlers) requireEndpointAccess(w http.ResponseWriter, r *http.Request, endpointID uuid.UUID, role string) *models.Endpoint {
 	endpoint, err := h.endpointService.GetByID(r.Context(), endpointID)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to get endpoint")
@@ -675,7 +851,7 @@ func (h *Handlers) requireEndpointAccess(w http.ResponseWriter, r *http.Request,
 		respondError(w, http.StatusNotFound, "Endpoint not found")
 		return nil
 	}
-	if h.requireProjectAccess(w, r, endpoint.ProjectID) == nil {
+	if h.requireProjectAccess(w, r, endpoint.ProjectID, role) == nil {
 		return nil
 	}
 	return endpoint
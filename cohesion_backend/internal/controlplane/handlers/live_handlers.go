@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net"
 	"net/http"
 	"net/http/httputil"
@@ -17,14 +18,20 @@ import (
 	"crypto/tls"
 
 	"github.com/cohesion-api/cohesion_backend/internal/auth"
+	"github.com/cohesion-api/cohesion_backend/internal/models"
 	"github.com/cohesion-api/cohesion_backend/internal/services"
 	"github.com/cohesion-api/cohesion_backend/pkg/diff"
+	"github.com/cohesion-api/cohesion_backend/pkg/runtime"
 	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
 const (
+	// defaultCaptureLimit and maxCaptureLimit bound a page of stored captures.
+	defaultCaptureLimit = 200
+	maxCaptureLimit     = 1000
+
 	// maxProxyBodySize is the maximum request/response body size the proxy will buffer (10 MB).
 	maxProxyBodySize = 10 * 1024 * 1024
 	// proxyDialTimeout is the timeout for establishing a connection to the target.
@@ -53,7 +60,7 @@ func (h *Handlers) IngestRuntimeCaptures(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if h.requireProjectAccess(w, r, projectID) == nil {
+	if h.requireProjectAccess(w, r, projectID, models.RoleEditor) == nil {
 		return
 	}
 
@@ -62,7 +69,10 @@ func (h *Handlers) IngestRuntimeCaptures(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	h.liveService.IngestRequests(projectID, req.Requests)
+	if err := h.liveService.IngestRequests(r.Context(), projectID, req.Requests); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to store requests")
+		return
+	}
 
 	respondJSON(w, http.StatusCreated, map[string]string{
 		"message": "Requests ingested",
@@ -83,7 +93,7 @@ func (h *Handlers) LiveStream(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if h.requireProjectAccess(w, r, projectID) == nil {
+	if h.requireProjectAccess(w, r, projectID, models.RoleViewer) == nil {
 		return
 	}
 
@@ -134,14 +144,60 @@ func (h *Handlers) GetLiveRequests(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if h.requireProjectAccess(w, r, projectID) == nil {
+	if h.requireProjectAccess(w, r, projectID, models.RoleViewer) == nil {
 		return
 	}
 
-	requests := h.liveService.GetRecentRequests(projectID)
+	filter, err := parseCaptureFilter(r.URL.Query())
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	requests, err := h.liveService.GetRecentRequests(r.Context(), projectID, filter)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load requests")
+		return
+	}
+	if requests == nil {
+		requests = []services.LiveRequest{}
+	}
 	respondJSON(w, http.StatusOK, requests)
 }
 
+// parseCaptureFilter reads the limit, offset, since, until and source query
+// parameters of a capture listing.
+func parseCaptureFilter(q url.Values) (models.LiveCaptureFilter, error) {
+	filter := models.LiveCaptureFilter{
+		Source: q.Get("source"),
+		Limit:  defaultCaptureLimit,
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return filter, fmt.Errorf("limit must be a positive integer")
+		}
+		filter.Limit = min(limit, maxCaptureLimit)
+	}
+	if v := q.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return filter, fmt.Errorf("offset must be a non-negative integer")
+		}
+		filter.Offset = offset
+	}
+	for name, dst := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+			}
+			*dst = &t
+		}
+	}
+	return filter, nil
+}
+
 func (h *Handlers) InferFromLiveBuffer(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		ProjectID string `json:"project_id"`
@@ -157,11 +213,22 @@ func (h *Handlers) InferFromLiveBuffer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if h.requireProjectAccess(w, r, projectID) == nil {
+	if h.requireProjectAccess(w, r, projectID, models.RoleEditor) == nil {
+		return
+	}
+
+	known, err := h.endpointService.PathTemplates(r.Context(), projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load endpoint templates")
+		return
+	}
+
+	if err := h.liveService.Warm(r.Context(), projectID); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load captures")
 		return
 	}
 
-	schemas := h.liveService.InferFromBuffer(projectID)
+	schemas := h.liveService.InferFromBuffer(projectID, known)
 	if len(schemas) == 0 {
 		respondError(w, http.StatusBadRequest, "No buffered requests to infer from")
 		return
@@ -198,7 +265,7 @@ func (h *Handlers) StartCapture(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if h.requireProjectAccess(w, r, projectID) == nil {
+	if h.requireProjectAccess(w, r, projectID, models.RoleEditor) == nil {
 		return
 	}
 
@@ -234,11 +301,14 @@ func (h *Handlers) ClearLiveBuffer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if h.requireProjectAccess(w, r, projectID) == nil {
+	if h.requireProjectAccess(w, r, projectID, models.RoleEditor) == nil {
 		return
 	}
 
-	h.liveService.ClearBuffer(projectID)
+	if err := h.liveService.ClearBuffer(r.Context(), projectID); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to clear captures")
+		return
+	}
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Buffer cleared"})
 }
 
@@ -306,7 +376,7 @@ func (h *Handlers) ConfigureProxy(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if h.requireProjectAccess(w, r, projectID) == nil {
+	if h.requireProjectAccess(w, r, projectID, models.RoleEditor) == nil {
 		return
 	}
 
@@ -358,7 +428,7 @@ func (h *Handlers) ProxyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if h.requireProjectAccess(w, r, projectID) == nil {
+	if h.requireProjectAccess(w, r, projectID, models.RoleEditor) == nil {
 		return
 	}
 
@@ -378,7 +448,7 @@ func (h *Handlers) ProxyHandler(w http.ResponseWriter, r *http.Request) {
 	h.proxyMu.RUnlock()
 
 	// Read and buffer request body with size limit
-	var reqBody map[string]interface{}
+	var reqBody interface{}
 	var reqBodyBytes []byte
 	if r.Body != nil && r.ContentLength != 0 {
 		limited := io.LimitReader(r.Body, maxProxyBodySize+1)
@@ -388,7 +458,7 @@ func (h *Handlers) ProxyHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		r.Body = io.NopCloser(bytes.NewBuffer(reqBodyBytes))
-		json.Unmarshal(reqBodyBytes, &reqBody)
+		reqBody = runtime.DecodeBody(r.Header.Get("Content-Type"), reqBodyBytes)
 	}
 
 	// Extract the downstream path: everything after /api/live/proxy/{projectID}/{label}
@@ -442,9 +512,11 @@ func (h *Handlers) ProxyHandler(w http.ResponseWriter, r *http.Request) {
 	// Capture response
 	var respBodyBuf bytes.Buffer
 	var respStatusCode int
+	var respContentType string
 
 	proxy.ModifyResponse = func(resp *http.Response) error {
 		respStatusCode = resp.StatusCode
+		respContentType = resp.Header.Get("Content-Type")
 		limited := io.LimitReader(resp.Body, maxProxyBodySize)
 		respBytes, err := io.ReadAll(limited)
 		if err != nil {
@@ -463,9 +535,6 @@ func (h *Handlers) ProxyHandler(w http.ResponseWriter, r *http.Request) {
 
 	duration := time.Since(start)
 
-	var respBody map[string]interface{}
-	json.Unmarshal(respBodyBuf.Bytes(), &respBody)
-
 	capture := services.LiveRequest{
 		ID:           uuid.New().String(),
 		Timestamp:    start,
@@ -475,10 +544,17 @@ func (h *Handlers) ProxyHandler(w http.ResponseWriter, r *http.Request) {
 		StatusCode:   respStatusCode,
 		DurationMs:   float64(duration.Milliseconds()),
 		RequestBody:  reqBody,
-		ResponseBody: respBody,
+		ResponseBody: runtime.DecodeBody(respContentType, respBodyBuf.Bytes()),
+		QueryParams:  runtime.CaptureQuery(r.URL.Query()),
+		Headers:      runtime.CaptureHeaders(r.Header),
+
+		RequestContentType:  r.Header.Get("Content-Type"),
+		ResponseContentType: respContentType,
 	}
 
-	h.liveService.IngestRequests(projectID, []services.LiveRequest{capture})
+	if err := h.liveService.IngestRequests(r.Context(), projectID, []services.LiveRequest{capture}); err != nil {
+		log.Printf("[live] failed to store proxy capture for project %s: %v", projectID, err)
+	}
 }
 
 // LiveDiff computes a diff between two source labels in the live buffer.
@@ -499,7 +575,7 @@ func (h *Handlers) LiveDiff(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if h.requireProjectAccess(w, r, projectID) == nil {
+	if h.requireProjectAccess(w, r, projectID, models.RoleViewer) == nil {
 		return
 	}
 
@@ -508,8 +584,19 @@ func (h *Handlers) LiveDiff(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	schemasA := h.liveService.InferFromBufferBySource(projectID, req.SourceA)
-	schemasB := h.liveService.InferFromBufferBySource(projectID, req.SourceB)
+	known, err := h.endpointService.PathTemplates(r.Context(), projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load endpoint templates")
+		return
+	}
+
+	if err := h.liveService.Warm(r.Context(), projectID); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load captures")
+		return
+	}
+
+	schemasA := h.liveService.InferFromBufferBySource(projectID, req.SourceA, known)
+	schemasB := h.liveService.InferFromBufferBySource(projectID, req.SourceB, known)
 
 	if len(schemasA) == 0 && len(schemasB) == 0 {
 		respondError(w, http.StatusBadRequest, "No buffered requests for either source")
@@ -540,7 +627,11 @@ func (h *Handlers) LiveDiff(w http.ResponseWriter, r *http.Request) {
 		schemaMap[k] = append(schemaMap[k], *s)
 	}
 
-	engine := diff.NewEngine()
+	engine, err := h.diffService.ProjectEngine(r.Context(), projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load diff policy")
+		return
+	}
 	var results []diff.Result
 
 	for k, schemas := range schemaMap {
@@ -571,7 +662,7 @@ func (h *Handlers) GetLiveSchemas(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if h.requireProjectAccess(w, r, projectID) == nil {
+	if h.requireProjectAccess(w, r, projectID, models.RoleViewer) == nil {
 		return
 	}
 
@@ -581,7 +672,18 @@ func (h *Handlers) GetLiveSchemas(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	schemas := h.liveService.InferFromBufferBySource(projectID, source)
+	known, err := h.endpointService.PathTemplates(r.Context(), projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load endpoint templates")
+		return
+	}
+
+	if err := h.liveService.Warm(r.Context(), projectID); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load captures")
+		return
+	}
+
+	schemas := h.liveService.InferFromBufferBySource(projectID, source, known)
 	if schemas == nil {
 		schemas = []*schemair.SchemaIR{}
 	}
@@ -603,7 +705,12 @@ func (h *Handlers) GetLiveSources(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if h.requireProjectAccess(w, r, projectID) == nil {
+	if h.requireProjectAccess(w, r, projectID, models.RoleViewer) == nil {
+		return
+	}
+
+	if err := h.liveService.Warm(r.Context(), projectID); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load captures")
 		return
 	}
 
@@ -613,3 +720,147 @@ func (h *Handlers) GetLiveSources(w http.ResponseWriter, r *http.Request) {
 	}
 	respondJSON(w, http.StatusOK, sources)
 }
+
+// GetCaptureRetention returns how long the project's captures are stored.
+func (h *Handlers) GetCaptureRetention(w http.ResponseWriter, r *http.Request) {
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	if h.requireProjectAccess(w, r, projectID, models.RoleViewer) == nil {
+		return
+	}
+
+	rt, err := h.liveService.GetRetention(r.Context(), projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load capture retention")
+		return
+	}
+	respondJSON(w, http.StatusOK, rt)
+}
+
+// UpdateCaptureRetention sets the project's capture age and count limits.
+// A null or omitted limit selects the server default and zero disables it.
+func (h *Handlers) UpdateCaptureRetention(w http.ResponseWriter, r *http.Request) {
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	if h.requireProjectAccess(w, r, projectID, models.RoleEditor) == nil {
+		return
+	}
+
+	var req struct {
+		MaxAgeHours *int `json:"max_age_hours"`
+		MaxCount    *int `json:"max_count"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if (req.MaxAgeHours != nil && *req.MaxAgeHours < 0) || (req.MaxCount != nil && *req.MaxCount < 0) {
+		respondError(w, http.StatusBadRequest, "max_age_hours and max_count must not be negative")
+		return
+	}
+
+	rt := &models.CaptureRetention{
+		ProjectID:   projectID,
+		MaxAgeHours: req.MaxAgeHours,
+		MaxCount:    req.MaxCount,
+	}
+	if err := h.liveService.UpdateRetention(r.Context(), rt); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update capture retention")
+		return
+	}
+
+	rt, err = h.liveService.GetRetention(r.Context(), projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load capture retention")
+		return
+	}
+	respondJSON(w, http.StatusOK, rt)
+}
+
+type UpdateRedactionPolicyRequest struct {
+	Rules []runtime.RedactionRule `json:"rules"`
+}
+
+// GetRedactionPolicy returns the project's custom redaction rules and the
+// built-in ones.
+func (h *Handlers) GetRedactionPolicy(w http.ResponseWriter, r *http.Request) {
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	if h.requireProjectAccess(w, r, projectID, models.RoleViewer) == nil {
+		return
+	}
+
+	policy, err := h.liveService.GetRedactionPolicy(r.Context(), projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get redaction policy")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, policy)
+}
+
+// UpdateRedactionPolicy replaces the project's custom redaction rules. They
+// apply to captures ingested from then on.
+func (h *Handlers) UpdateRedactionPolicy(w http.ResponseWriter, r *http.Request) {
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	var req UpdateRedactionPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	for i := range req.Rules {
+		if err := req.Rules[i].Validate(); err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("rule %d: %v", i, err))
+			return
+		}
+	}
+
+	if h.requireProjectAccess(w, r, projectID, models.RoleEditor) == nil {
+		return
+	}
+
+	policy, err := h.liveService.UpdateRedactionPolicy(r.Context(), projectID, req.Rules, auth.UserID(r.Context()))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update redaction policy")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, policy)
+}
+
+func (h *Handlers) ResetRedactionPolicy(w http.ResponseWriter, r *http.Request) {
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	if h.requireProjectAccess(w, r, projectID, models.RoleEditor) == nil {
+		return
+	}
+
+	if err := h.liveService.ResetRedactionPolicy(r.Context(), projectID); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to reset redaction policy")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/cohesion-api/cohesion_backend/internal/models"
+	"github.com/cohesion-api/cohesion_backend/pkg/openapi"
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+type ImportOpenAPIRequest struct {
+	ProjectID string `json:"project_id"`
+	Spec      string `json:"spec"`
+}
+
+func (h *Handlers) ImportOpenAPISpec(w http.ResponseWriter, r *http.Request) {
+	var req ImportOpenAPIRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	projectID, err := uuid.Parse(req.ProjectID)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	if h.requireProjectAccess(w, r, projectID, models.RoleEditor) == nil {
+		return
+	}
+
+	if strings.TrimSpace(req.Spec) == "" {
+		respondError(w, http.StatusBadRequest, "spec is required")
+		return
+	}
+
+	schemas, err := openapi.Import([]byte(req.Spec))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid OpenAPI spec: "+err.Error())
+		return
+	}
+
+	if err := h.schemaService.UploadSchemas(r.Context(), projectID, schemas); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to upload OpenAPI schemas")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"message": "OpenAPI spec imported",
+		"count":   len(schemas),
+	})
+}
+
+func (h *Handlers) ExportOpenAPISpec(w http.ResponseWriter, r *http.Request) {
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	project := h.requireProjectAccess(w, r, projectID, models.RoleViewer)
+	if project == nil {
+		return
+	}
+
+	authority := schemair.SchemaSource(r.URL.Query().Get("authority"))
+	if authority == "" {
+		authority = schemair.SourceBackendStatic
+	}
+	if !authority.IsKnown() {
+		respondError(w, http.StatusBadRequest, "Invalid authority: must be a schema source such as 'backend-static'")
+		return
+	}
+	strict := r.URL.Query().Get("strict") == "true"
+
+	doc, err := h.schemaService.ExportOpenAPI(r.Context(), project, authority, strict)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to export OpenAPI spec")
+		return
+	}
+
+	switch r.URL.Query().Get("format") {
+	case "yaml":
+		out, err := yaml.Marshal(doc)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to encode OpenAPI spec")
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		w.WriteHeader(http.StatusOK)
+		w.Write(out)
+	case "", "json":
+		respondJSON(w, http.StatusOK, doc)
+	default:
+		respondError(w, http.StatusBadRequest, "Invalid format: must be 'json' or 'yaml'")
+	}
+}
@@ -0,0 +1,349 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cohesion-api/cohesion_backend/internal/auth"
+	"github.com/cohesion-api/cohesion_backend/internal/models"
+	"github.com/cohesion-api/cohesion_backend/internal/repository"
+	"github.com/cohesion-api/cohesion_backend/internal/services"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name"`
+}
+
+type OrganizationResponse struct {
+	models.Organization
+	Members []models.OrganizationMember `json:"members"`
+}
+
+type UpdateMemberRequest struct {
+	Role string `json:"role"`
+}
+
+type CreateInvitationRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// CreateInvitationResponse carries the invitation's secret token, which is
+// only ever returned here. The inviter shares it with the invitee.
+type CreateInvitationResponse struct {
+	models.Invitation
+	Token string `json:"token"`
+}
+
+type AcceptInvitationRequest struct {
+	Token string `json:"token"`
+}
+
+// MoveProjectRequest moves a project into an organization, or out of one
+// when OrganizationID is null.
+type MoveProjectRequest struct {
+	OrganizationID *uuid.UUID `json:"organization_id"`
+}
+
+func (h *Handlers) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	var req CreateOrganizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if strings.TrimSpace(req.Name) == "" {
+		respondError(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+
+	org, err := h.orgService.Create(r.Context(), strings.TrimSpace(req.Name), auth.UserID(r.Context()))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create organization")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, org)
+}
+
+func (h *Handlers) ListOrganizations(w http.ResponseWriter, r *http.Request) {
+	orgs, err := h.orgService.List(r.Context(), auth.UserID(r.Context()))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list organizations")
+		return
+	}
+
+	if orgs == nil {
+		orgs = []models.Organization{}
+	}
+
+	respondJSON(w, http.StatusOK, orgs)
+}
+
+func (h *Handlers) GetOrganization(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid organization ID")
+		return
+	}
+
+	org := h.requireOrganizationRole(w, r, orgID, models.RoleViewer)
+	if org == nil {
+		return
+	}
+
+	members, err := h.orgService.ListMembers(r.Context(), orgID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list members")
+		return
+	}
+
+	if members == nil {
+		members = []models.OrganizationMember{}
+	}
+
+	respondJSON(w, http.StatusOK, OrganizationResponse{Organization: *org, Members: members})
+}
+
+func (h *Handlers) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid organization ID")
+		return
+	}
+	memberID := chi.URLParam(r, "userID")
+
+	var req UpdateMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := services.ValidateRole(req.Role); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if h.requireOrganizationRole(w, r, orgID, models.RoleOwner) == nil {
+		return
+	}
+
+	if err := h.orgService.UpdateMemberRole(r.Context(), orgID, memberID, req.Role); err != nil {
+		respondMemberError(w, err, "Failed to update member")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Member updated"})
+}
+
+// RemoveMember removes a member from an organization. Owners may remove
+// anyone; other members may only leave.
+func (h *Handlers) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid organization ID")
+		return
+	}
+	memberID := chi.URLParam(r, "userID")
+
+	role := models.RoleOwner
+	if memberID == auth.UserID(r.Context()) {
+		role = models.RoleViewer
+	}
+	if h.requireOrganizationRole(w, r, orgID, role) == nil {
+		return
+	}
+
+	if err := h.orgService.RemoveMember(r.Context(), orgID, memberID); err != nil {
+		respondMemberError(w, err, "Failed to remove member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func respondMemberError(w http.ResponseWriter, err error, message string) {
+	switch err {
+	case repository.ErrNotFound:
+		respondError(w, http.StatusNotFound, "Member not found")
+	case services.ErrLastOwner:
+		respondError(w, http.StatusConflict, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, message)
+	}
+}
+
+func (h *Handlers) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid organization ID")
+		return
+	}
+
+	if h.requireOrganizationRole(w, r, orgID, models.RoleOwner) == nil {
+		return
+	}
+
+	invs, err := h.orgService.ListInvitations(r.Context(), orgID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list invitations")
+		return
+	}
+
+	if invs == nil {
+		invs = []models.Invitation{}
+	}
+
+	respondJSON(w, http.StatusOK, invs)
+}
+
+func (h *Handlers) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid organization ID")
+		return
+	}
+
+	var req CreateInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = models.RoleViewer
+	}
+	if err := services.ValidateRole(req.Role); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if h.requireOrganizationRole(w, r, orgID, models.RoleOwner) == nil {
+		return
+	}
+
+	inv, token, err := h.orgService.Invite(r.Context(), orgID, strings.TrimSpace(req.Email), req.Role, auth.UserID(r.Context()))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create invitation")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, CreateInvitationResponse{Invitation: *inv, Token: token})
+}
+
+func (h *Handlers) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid organization ID")
+		return
+	}
+
+	invitationID, err := uuid.Parse(chi.URLParam(r, "invitationID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid invitation ID")
+		return
+	}
+
+	if h.requireOrganizationRole(w, r, orgID, models.RoleOwner) == nil {
+		return
+	}
+
+	if err := h.orgService.RevokeInvitation(r.Context(), orgID, invitationID); err != nil {
+		if err == repository.ErrNotFound {
+			respondError(w, http.StatusNotFound, "Invitation not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to revoke invitation")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handlers) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var req AcceptInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Token == "" {
+		respondError(w, http.StatusBadRequest, "token is required")
+		return
+	}
+
+	userID := auth.UserID(r.Context())
+	inv, err := h.orgService.AcceptInvitation(r.Context(), req.Token, userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to accept invitation")
+		return
+	}
+	if inv == nil {
+		respondError(w, http.StatusNotFound, "Invitation not found or expired")
+		return
+	}
+
+	org, err := h.orgService.Get(r.Context(), inv.OrganizationID, userID)
+	if err != nil || org == nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load organization")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, org)
+}
+
+// MoveProject moves a project into or out of an organization. The user
+// must own the project and be at least an editor of the target
+// organization.
+func (h *Handlers) MoveProject(w http.ResponseWriter, r *http.Request) {
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	var req MoveProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	project := h.requireProjectAccess(w, r, projectID, models.RoleOwner)
+	if project == nil {
+		return
+	}
+	if req.OrganizationID != nil && h.requireOrganizationRole(w, r, *req.OrganizationID, models.RoleEditor) == nil {
+		return
+	}
+
+	if err := h.orgService.MoveProject(r.Context(), projectID, req.OrganizationID); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to move project")
+		return
+	}
+
+	project.OrganizationID = req.OrganizationID
+	respondJSON(w, http.StatusOK, project)
+}
+
+// requireOrganizationRole loads an organization the requesting user is a
+// member of with at least the given role.
+func (h *Handlers) requireOrganizationRole(w http.ResponseWriter, r *http.Request, orgID uuid.UUID, role string) *models.Organization {
+	org, err := h.orgService.Get(r.Context(), orgID, auth.UserID(r.Context()))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to verify organization access")
+		return nil
+	}
+	if org == nil {
+		respondError(w, http.StatusNotFound, "Organization not found")
+		return nil
+	}
+	if !models.RoleAtLeast(org.Role, role) {
+		respondError(w, http.StatusForbidden, fmt.Sprintf("This action requires the %s role in the organization", role))
+		return nil
+	}
+	return org
+}
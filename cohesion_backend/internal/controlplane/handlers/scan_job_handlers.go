@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cohesion-api/cohesion_backend/internal/auth"
+	"github.com/cohesion-api/cohesion_backend/internal/models"
+	"github.com/cohesion-api/cohesion_backend/internal/services"
+	"github.com/cohesion-api/cohesion_backend/pkg/analyzer"
+	ghpkg "github.com/cohesion-api/cohesion_backend/pkg/github"
+	"github.com/go-chi/chi/v5"
+	gh "github.com/google/go-github/v68/github"
+	"github.com/google/uuid"
+)
+
+// githubScanSpec resolves the requesting user's GitHub credentials and LLM
+// settings for a scan of the job's repository. On failure it writes the
+// error response and returns nil.
+func (h *Handlers) githubScanSpec(w http.ResponseWriter, r *http.Request, job *models.ScanJob) *services.ScanSpec {
+	owner, repo, err := ghpkg.ParseRepoURL(job.RepoURL)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return nil
+	}
+
+	userID := auth.UserID(r.Context())
+	if userID == "" {
+		respondError(w, http.StatusUnauthorized, "Not authenticated")
+		return nil
+	}
+
+	settings, err := h.userSettingsService.Get(r.Context(), userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load user settings")
+		return nil
+	}
+
+	ghClient := h.githubClient(r.Context(), userID, settings, owner, repo)
+	if ghClient == nil {
+		respondError(w, http.StatusBadRequest, "Connect a GitHub App or add a Personal Access Token in Settings")
+		return nil
+	}
+
+	return &services.ScanSpec{
+		Fetch: func(ctx context.Context) (*ghpkg.RepoFiles, error) {
+			fetched, err := ghpkg.FetchRepoWithClient(ctx, ghClient, owner, repo, job.Branch, job.Path)
+			if err != nil {
+				return nil, fmt.Errorf("GitHub fetch failed: %v", err)
+			}
+			return fetched, nil
+		},
+		Fallback: func() (analyzer.FileAnalyzer, error) {
+			return h.scanAnalyzer(settings)
+		},
+	}
+}
+
+// githubClient returns a client that can read the repository: one of the
+// user's GitHub App installations when it has access, otherwise their
+// personal access token. It returns nil when the user has neither.
+func (h *Handlers) githubClient(ctx context.Context, userID string, settings *models.UserSettings, owner, repo string) *gh.Client {
+	if h.githubAppAuth.IsConfigured() {
+		installations, err := h.ghInstallService.List(ctx, userID)
+		if err == nil {
+			for _, inst := range installations {
+				client, err := h.githubAppAuth.InstallationClient(inst.InstallationID)
+				if err != nil {
+					continue
+				}
+
+				_, _, err = client.Repositories.Get(ctx, owner, repo)
+				if err == nil {
+					return client
+				}
+			}
+		}
+	}
+	if settings.GitHubToken != "" {
+		return gh.NewClient(nil).WithAuthToken(settings.GitHubToken)
+	}
+	return nil
+}
+
+func (h *Handlers) ListScanJobs(w http.ResponseWriter, r *http.Request) {
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	if h.requireProjectAccess(w, r, projectID, models.RoleViewer) == nil {
+		return
+	}
+
+	jobs, err := h.scanJobService.ListByProject(r.Context(), projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list scan jobs")
+		return
+	}
+
+	if jobs == nil {
+		jobs = []models.ScanJob{}
+	}
+
+	respondJSON(w, http.StatusOK, jobs)
+}
+
+// requireScanJob loads a job after checking the caller's role on the
+// project in the URL, and checks that the job belongs to that project. On
+// failure it writes the error response and returns nil.
+func (h *Handlers) requireScanJob(w http.ResponseWriter, r *http.Request, role string) *models.ScanJob {
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return nil
+	}
+
+	jobID, err := uuid.Parse(chi.URLParam(r, "jobID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid job ID")
+		return nil
+	}
+
+	if h.requireProjectAccess(w, r, projectID, role) == nil {
+		return nil
+	}
+
+	job, err := h.scanJobService.Get(r.Context(), jobID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get scan job")
+		return nil
+	}
+	if job == nil || job.ProjectID != projectID {
+		respondError(w, http.StatusNotFound, "Scan job not found")
+		return nil
+	}
+	return job
+}
+
+func (h *Handlers) GetScanJob(w http.ResponseWriter, r *http.Request) {
+	job := h.requireScanJob(w, r, models.RoleViewer)
+	if job == nil {
+		return
+	}
+
+	respondJSON(w, http.StatusOK, job)
+}
+
+func (h *Handlers) CancelScanJob(w http.ResponseWriter, r *http.Request) {
+	job := h.requireScanJob(w, r, models.RoleEditor)
+	if job == nil {
+		return
+	}
+
+	err := h.scanJobService.Cancel(r.Context(), job.ID)
+	if err == services.ErrScanJobFinished {
+		respondError(w, http.StatusConflict, "Scan job already finished")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to cancel scan job")
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, map[string]string{"message": "Scan job cancellation requested"})
+}
+
+func (h *Handlers) RetryScanJob(w http.ResponseWriter, r *http.Request) {
+	job := h.requireScanJob(w, r, models.RoleEditor)
+	if job == nil {
+		return
+	}
+
+	if job.Status != models.ScanJobFailed && job.Status != models.ScanJobCancelled {
+		respondError(w, http.StatusConflict, services.ErrScanJobNotRetryable.Error())
+		return
+	}
+
+	spec := h.githubScanSpec(w, r, job)
+	if spec == nil {
+		return
+	}
+
+	err := h.scanJobService.Retry(r.Context(), job, *spec)
+	switch err {
+	case nil:
+	case services.ErrScanJobNotRetryable:
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	case services.ErrScanQueueFull:
+		respondError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	default:
+		respondError(w, http.StatusInternalServerError, "Failed to retry scan job")
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, job)
+}
+
+// StreamScanJob streams a job's progress as server-sent events: its current
+// state first, then every phase and status change until it finishes.
+func (h *Handlers) StreamScanJob(w http.ResponseWriter, r *http.Request) {
+	job := h.requireScanJob(w, r, models.RoleViewer)
+	if job == nil {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondError(w, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
+	// Subscribe before reading the job again so no change is missed
+	// between the two.
+	ch := h.scanJobService.Subscribe(job.ID)
+	defer h.scanJobService.Unsubscribe(job.ID, ch)
+
+	job, err := h.scanJobService.Get(r.Context(), job.ID)
+	if err != nil || job == nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get scan job")
+		return
+	}
+
+	// Scans outlast the server's write timeout.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	writeEvent := func(event services.ScanJobEvent) {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		flusher.Flush()
+	}
+
+	writeEvent(services.ScanJobEvent{Type: "status", Job: *job})
+	if job.Finished() {
+		return
+	}
+
+	ctx := r.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-ch:
+			if !ok {
+				return
+			}
+			writeEvent(event)
+			if event.Job.Finished() {
+				return
+			}
+		}
+	}
+}
+
+func (h *Handlers) GetAnalysisCache(w http.ResponseWriter, r *http.Request) {
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	if h.requireProjectAccess(w, r, projectID, models.RoleViewer) == nil {
+		return
+	}
+
+	stats, err := h.analysisCacheService.Stats(r.Context(), projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get analysis cache")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, stats)
+}
+
+// InvalidateAnalysisCache drops the project's cached analysis results, or
+// only those of the analyzer named by the fingerprint query parameter, so
+// the next scan analyzes every file again.
+func (h *Handlers) InvalidateAnalysisCache(w http.ResponseWriter, r *http.Request) {
+	projectID, err := uuid.Parse(chi.URLParam(r, "projectID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	if h.requireProjectAccess(w, r, projectID, models.RoleEditor) == nil {
+		return
+	}
+
+	deleted, err := h.analysisCacheService.Invalidate(r.Context(), projectID, r.URL.Query().Get("fingerprint"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to invalidate analysis cache")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Analysis cache invalidated",
+		"deleted": deleted,
+	})
+}
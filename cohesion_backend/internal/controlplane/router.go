@@ -5,6 +5,7 @@ import (
 
 	"github.com/cohesion-api/cohesion_backend/internal/auth"
 	"github.com/cohesion-api/cohesion_backend/internal/controlplane/handlers"
+	"github.com/cohesion-api/cohesion_backend/internal/models"
 	"github.com/cohesion-api/cohesion_backend/internal/services"
 	"github.com/cohesion-api/cohesion_backend/pkg/analyzer"
 	ghpkg "github.com/cohesion-api/cohesion_backend/pkg/github"
@@ -21,12 +22,56 @@ type Services struct {
 	LiveService               *services.LiveService
 	UserSettingsService       *services.UserSettingsService
 	GitHubInstallationService *services.GitHubInstallationService
-	Analyzer                  analyzer.Analyzer
+	APIKeyService             *services.APIKeyService
+	OrganizationService       *services.OrganizationService
+	ScanJobService            *services.ScanJobService
+	AnalysisCacheService      *services.AnalysisCacheService
+	Analyzer                  analyzer.FileAnalyzer
+	AnalyzerRegistry          *analyzer.Registry
 	GitHubAppAuth             *ghpkg.AppAuth
 	GitHubAppSlug             string
 	FrontendURL               string
 }
 
+// apiKeyRoutes are the routes project API keys may call, by scope: ingest
+// for capture agents, read for dashboards and scripts, scan for CI jobs
+// uploading analysis results and gating on diffs. Keys are refused on every
+// other route, including key management itself.
+var apiKeyRoutes = []auth.KeyRoute{
+	{Method: http.MethodPost, Pattern: "/api/live/ingest", Scope: models.ScopeIngest},
+
+	{Method: http.MethodGet, Pattern: "/api/projects/*", Scope: models.ScopeRead},
+	{Method: http.MethodGet, Pattern: "/api/projects/*/openapi", Scope: models.ScopeRead},
+	{Method: http.MethodGet, Pattern: "/api/projects/*/diff", Scope: models.ScopeRead},
+	{Method: http.MethodGet, Pattern: "/api/projects/*/diff/runs", Scope: models.ScopeRead},
+	{Method: http.MethodGet, Pattern: "/api/projects/*/diff/runs/*", Scope: models.ScopeRead},
+	{Method: http.MethodGet, Pattern: "/api/projects/*/diff/runs/*/compare", Scope: models.ScopeRead},
+	{Method: http.MethodGet, Pattern: "/api/projects/*/scan-jobs", Scope: models.ScopeRead},
+	{Method: http.MethodGet, Pattern: "/api/projects/*/scan-jobs/*", Scope: models.ScopeRead},
+	{Method: http.MethodGet, Pattern: "/api/projects/*/scan-jobs/*/stream", Scope: models.ScopeRead},
+	{Method: http.MethodGet, Pattern: "/api/projects/*/analysis-cache", Scope: models.ScopeRead},
+	{Method: http.MethodGet, Pattern: "/api/projects/*/waivers", Scope: models.ScopeRead},
+	{Method: http.MethodGet, Pattern: "/api/projects/*/severity-policy", Scope: models.ScopeRead},
+	{Method: http.MethodGet, Pattern: "/api/endpoints", Scope: models.ScopeRead},
+	{Method: http.MethodGet, Pattern: "/api/endpoints/*", Scope: models.ScopeRead},
+	{Method: http.MethodGet, Pattern: "/api/endpoints/*/versions", Scope: models.ScopeRead},
+	{Method: http.MethodGet, Pattern: "/api/endpoints/*/versions/*/*", Scope: models.ScopeRead},
+	{Method: http.MethodGet, Pattern: "/api/endpoints/*/changes", Scope: models.ScopeRead},
+	{Method: http.MethodGet, Pattern: "/api/live/requests", Scope: models.ScopeRead},
+	{Method: http.MethodGet, Pattern: "/api/live/stream", Scope: models.ScopeRead},
+	{Method: http.MethodGet, Pattern: "/api/live/schemas", Scope: models.ScopeRead},
+	{Method: http.MethodGet, Pattern: "/api/live/sources", Scope: models.ScopeRead},
+
+	{Method: http.MethodPost, Pattern: "/api/analyze/backend", Scope: models.ScopeScan},
+	{Method: http.MethodPost, Pattern: "/api/analyze/frontend", Scope: models.ScopeScan},
+	{Method: http.MethodPost, Pattern: "/api/analyze/runtime", Scope: models.ScopeScan},
+	{Method: http.MethodPost, Pattern: "/api/analyze/openapi", Scope: models.ScopeScan},
+	{Method: http.MethodPost, Pattern: "/api/analyze/scan", Scope: models.ScopeScan},
+	{Method: http.MethodDelete, Pattern: "/api/projects/*/analysis-cache", Scope: models.ScopeScan},
+	{Method: http.MethodPost, Pattern: "/api/projects/*/diff", Scope: models.ScopeScan},
+	{Method: http.MethodPost, Pattern: "/api/diff/*", Scope: models.ScopeScan},
+}
+
 func NewRouter(svc *Services) http.Handler {
 	r := chi.NewRouter()
 
@@ -45,7 +90,8 @@ func NewRouter(svc *Services) http.Handler {
 	h := handlers.New(
 		svc.ProjectService, svc.EndpointService, svc.SchemaService,
 		svc.DiffService, svc.LiveService, svc.UserSettingsService,
-		svc.GitHubInstallationService, svc.Analyzer,
+		svc.GitHubInstallationService, svc.APIKeyService, svc.OrganizationService,
+		svc.ScanJobService, svc.AnalysisCacheService, svc.Analyzer, svc.AnalyzerRegistry,
 		svc.GitHubAppAuth, svc.GitHubAppSlug,
 	)
 
@@ -53,7 +99,7 @@ func NewRouter(svc *Services) http.Handler {
 		r.Get("/health", h.Health)
 		r.Get("/demo/token", h.DemoToken)
 		r.Group(func(r chi.Router) {
-			r.Use(auth.Middleware())
+			r.Use(auth.Middleware(svc.APIKeyService, apiKeyRoutes))
 			r.Use(svc.LiveService.SelfCaptureMiddleware(func(r *http.Request) string {
 				return auth.UserID(r.Context())
 			}))
@@ -63,12 +109,53 @@ func NewRouter(svc *Services) http.Handler {
 				r.Get("/", h.ListProjects)
 				r.Get("/{projectID}", h.GetProject)
 				r.Delete("/{projectID}", h.DeleteProject)
+				r.Get("/{projectID}/openapi", h.ExportOpenAPISpec)
+				r.Get("/{projectID}/diff", h.ComputeProjectDiff)
+				r.Post("/{projectID}/diff", h.CreateDiffRun)
+				r.Get("/{projectID}/diff/runs", h.ListDiffRuns)
+				r.Get("/{projectID}/diff/runs/{runID}", h.GetDiffRun)
+				r.Get("/{projectID}/diff/runs/{runID}/compare", h.CompareDiffRuns)
+				r.Get("/{projectID}/scan-jobs", h.ListScanJobs)
+				r.Get("/{projectID}/scan-jobs/{jobID}", h.GetScanJob)
+				r.Get("/{projectID}/scan-jobs/{jobID}/stream", h.StreamScanJob)
+				r.Post("/{projectID}/scan-jobs/{jobID}/cancel", h.CancelScanJob)
+				r.Post("/{projectID}/scan-jobs/{jobID}/retry", h.RetryScanJob)
+				r.Get("/{projectID}/analysis-cache", h.GetAnalysisCache)
+				r.Delete("/{projectID}/analysis-cache", h.InvalidateAnalysisCache)
+				r.Get("/{projectID}/waivers", h.ListWaivers)
+				r.Post("/{projectID}/waivers", h.CreateWaiver)
+				r.Delete("/{projectID}/waivers/{waiverID}", h.DeleteWaiver)
+				r.Get("/{projectID}/severity-policy", h.GetSeverityPolicy)
+				r.Put("/{projectID}/severity-policy", h.UpdateSeverityPolicy)
+				r.Delete("/{projectID}/severity-policy", h.ResetSeverityPolicy)
+				r.Get("/{projectID}/capture-retention", h.GetCaptureRetention)
+				r.Put("/{projectID}/capture-retention", h.UpdateCaptureRetention)
+				r.Get("/{projectID}/redaction-policy", h.GetRedactionPolicy)
+				r.Put("/{projectID}/redaction-policy", h.UpdateRedactionPolicy)
+				r.Delete("/{projectID}/redaction-policy", h.ResetRedactionPolicy)
+				r.Get("/{projectID}/api-keys", h.ListAPIKeys)
+				r.Post("/{projectID}/api-keys", h.CreateAPIKey)
+				r.Delete("/{projectID}/api-keys/{keyID}", h.RevokeAPIKey)
+				r.Put("/{projectID}/organization", h.MoveProject)
+			})
+
+			r.Route("/organizations", func(r chi.Router) {
+				r.Post("/", h.CreateOrganization)
+				r.Get("/", h.ListOrganizations)
+				r.Get("/{orgID}", h.GetOrganization)
+				r.Put("/{orgID}/members/{userID}", h.UpdateMember)
+				r.Delete("/{orgID}/members/{userID}", h.RemoveMember)
+				r.Get("/{orgID}/invitations", h.ListInvitations)
+				r.Post("/{orgID}/invitations", h.CreateInvitation)
+				r.Delete("/{orgID}/invitations/{invitationID}", h.RevokeInvitation)
 			})
+			r.Post("/invitations/accept", h.AcceptInvitation)
 
 			r.Route("/analyze", func(r chi.Router) {
 				r.Post("/backend", h.UploadBackendSchemas)
 				r.Post("/frontend", h.UploadFrontendSchemas)
 				r.Post("/runtime", h.UploadRuntimeSchemas)
+				r.Post("/openapi", h.ImportOpenAPISpec)
 				r.Post("/scan", h.ScanCodebase)
 				r.Post("/github", h.ScanGitHubRepo)
 			})
@@ -76,6 +163,9 @@ func NewRouter(svc *Services) http.Handler {
 			r.Route("/endpoints", func(r chi.Router) {
 				r.Get("/", h.ListEndpoints)
 				r.Get("/{endpointID}", h.GetEndpoint)
+				r.Get("/{endpointID}/versions", h.ListSchemaVersions)
+				r.Get("/{endpointID}/versions/{source}/{version}", h.GetSchemaVersion)
+				r.Get("/{endpointID}/changes", h.GetSchemaChanges)
 			})
 
 			r.Post("/diff/{endpointID}", h.ComputeDiff)
@@ -7,12 +7,16 @@ import (
 )
 
 type Project struct {
-	ID          uuid.UUID `json:"id"`
-	OwnerID     string    `json:"owner_id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID             uuid.UUID  `json:"id"`
+	OwnerID        string     `json:"owner_id"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
+	Name           string     `json:"name"`
+	Description    string     `json:"description,omitempty"`
+	// Role is the requesting user's role on the project: owner for the
+	// project's owner, otherwise their role in the project's organization.
+	Role      string    `json:"role,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type Endpoint struct {
@@ -26,31 +30,275 @@ type Endpoint struct {
 }
 
 type Schema struct {
-	ID         uuid.UUID              `json:"id"`
-	EndpointID uuid.UUID              `json:"endpoint_id"`
-	Source     string                 `json:"source"`
-	SchemaData map[string]interface{} `json:"schema_data"`
-	Version    int                    `json:"version"`
-	CreatedAt  time.Time              `json:"created_at"`
-	UpdatedAt  time.Time              `json:"updated_at"`
+	ID          uuid.UUID              `json:"id"`
+	EndpointID  uuid.UUID              `json:"endpoint_id"`
+	Source      string                 `json:"source"`
+	SchemaData  map[string]interface{} `json:"schema_data"`
+	Version     int                    `json:"version"`
+	ContentHash string                 `json:"content_hash,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
+
+type SchemaVersion struct {
+	ID          uuid.UUID `json:"id"`
+	EndpointID  uuid.UUID `json:"endpoint_id"`
+	Source      string    `json:"source"`
+	Version     int       `json:"version"`
+	ContentHash string    `json:"content_hash"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 type Diff struct {
 	ID              uuid.UUID              `json:"id"`
 	EndpointID      uuid.UUID              `json:"endpoint_id"`
+	RunID           *uuid.UUID             `json:"run_id,omitempty"`
 	DiffData        map[string]interface{} `json:"diff_data"`
 	SourcesCompared string                 `json:"sources_compared"`
 	CreatedAt       time.Time              `json:"created_at"`
 }
 
+const (
+	DiffRunRunning   = "running"
+	DiffRunCompleted = "completed"
+	DiffRunFailed    = "failed"
+)
+
+// DiffRun is one project-wide diff. Each compared endpoint's result is a
+// Diff row pointing back at the run.
+type DiffRun struct {
+	ID             uuid.UUID       `json:"id"`
+	ProjectID      uuid.UUID       `json:"project_id"`
+	CommitSHA      string          `json:"commit_sha,omitempty"`
+	Status         string          `json:"status"`
+	Error          string          `json:"error,omitempty"`
+	EndpointCount  int             `json:"endpoint_count"`
+	SkippedCount   int             `json:"skipped_count"`
+	MatchedCount   int             `json:"matched_count"`
+	PartialCount   int             `json:"partial_count"`
+	ViolationCount int             `json:"violation_count"`
+	CriticalCount  int             `json:"critical_count"`
+	WarningCount   int             `json:"warning_count"`
+	InfoCount      int             `json:"info_count"`
+	StartedAt      time.Time       `json:"started_at"`
+	FinishedAt     *time.Time      `json:"finished_at,omitempty"`
+	Results        []DiffRunResult `json:"results,omitempty"`
+}
+
+type DiffRunResult struct {
+	DiffID        uuid.UUID `json:"diff_id"`
+	EndpointID    uuid.UUID `json:"endpoint_id"`
+	Path          string    `json:"path"`
+	Method        string    `json:"method"`
+	Status        string    `json:"status"`
+	CriticalCount int       `json:"critical_count"`
+	WarningCount  int       `json:"warning_count"`
+	InfoCount     int       `json:"info_count"`
+}
+
+const (
+	ScanJobQueued    = "queued"
+	ScanJobRunning   = "running"
+	ScanJobCompleted = "completed"
+	ScanJobFailed    = "failed"
+	ScanJobCancelled = "cancelled"
+)
+
+// Scan job phases, in the order a job runs them.
+const (
+	ScanPhaseFetch    = "fetch"
+	ScanPhaseDiscover = "discover"
+	ScanPhaseAnalyze  = "analyze"
+	ScanPhaseUpload   = "upload"
+)
+
+// ScanJob is a repository scan run in the background. Summary holds the
+// same fields a synchronous scan responds with once the job completes.
+type ScanJob struct {
+	ID            uuid.UUID              `json:"id"`
+	ProjectID     uuid.UUID              `json:"project_id"`
+	CreatedBy     string                 `json:"created_by"`
+	RepoURL       string                 `json:"repo_url"`
+	Branch        string                 `json:"branch,omitempty"`
+	Path          string                 `json:"path,omitempty"`
+	ScanType      string                 `json:"scan_type"`
+	Status        string                 `json:"status"`
+	Phase         string                 `json:"phase,omitempty"`
+	Error         string                 `json:"error,omitempty"`
+	FileCount     int                    `json:"file_count"`
+	EndpointCount int                    `json:"endpoint_count"`
+	Summary       map[string]interface{} `json:"summary,omitempty"`
+	Attempts      int                    `json:"attempts"`
+	CreatedAt     time.Time              `json:"created_at"`
+	StartedAt     *time.Time             `json:"started_at,omitempty"`
+	FinishedAt    *time.Time             `json:"finished_at,omitempty"`
+}
+
+// Finished reports whether the job reached a final status.
+func (j *ScanJob) Finished() bool {
+	return j.Status == ScanJobCompleted || j.Status == ScanJobFailed || j.Status == ScanJobCancelled
+}
+
+// AnalysisCacheStats describes a project's cached analysis results.
+type AnalysisCacheStats struct {
+	Entries   int                  `json:"entries"`
+	SizeBytes int64                `json:"size_bytes"`
+	Analyzers []AnalysisCacheUsage `json:"analyzers"`
+}
+
+// AnalysisCacheUsage counts the cached results made by one analyzer, as
+// identified by its fingerprint: provider, model and prompt version.
+type AnalysisCacheUsage struct {
+	Fingerprint string    `json:"fingerprint"`
+	Entries     int       `json:"entries"`
+	SizeBytes   int64     `json:"size_bytes"`
+	LastUsedAt  time.Time `json:"last_used_at"`
+}
+
+type Waiver struct {
+	ID              uuid.UUID  `json:"id"`
+	ProjectID       uuid.UUID  `json:"project_id"`
+	EndpointPattern string     `json:"endpoint_pattern"`
+	Method          string     `json:"method"`
+	FieldPath       string     `json:"field_path"`
+	MismatchType    string     `json:"mismatch_type"`
+	Sources         []string   `json:"sources"`
+	Reason          string     `json:"reason"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+	CreatedBy       string     `json:"created_by"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// SeverityRule overrides the severity of mismatches matching all of its
+// non-empty criteria.
+type SeverityRule struct {
+	Section      string   `json:"section,omitempty"`
+	MismatchType string   `json:"mismatch_type,omitempty"`
+	PresentIn    []string `json:"present_in,omitempty"`
+	MissingFrom  []string `json:"missing_from,omitempty"`
+	Severity     string   `json:"severity"`
+}
+
+type SeverityPolicy struct {
+	ProjectID uuid.UUID      `json:"project_id"`
+	Rules     []SeverityRule `json:"rules"`
+	UpdatedBy string         `json:"updated_by"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// RedactionRule masks captured values by field name, value pattern or both.
+type RedactionRule struct {
+	Field   string `json:"field,omitempty"`
+	Pattern string `json:"pattern,omitempty"`
+}
+
+type RedactionPolicy struct {
+	ProjectID uuid.UUID       `json:"project_id"`
+	Rules     []RedactionRule `json:"rules"`
+	UpdatedBy string          `json:"updated_by"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// APIKeyPrefix starts every API key, telling them apart from session tokens.
+const APIKeyPrefix = "coh_"
+
+// API key scopes. A key may only call the routes its scopes allow, and only
+// for its own project.
+const (
+	ScopeIngest = "ingest"
+	ScopeRead   = "read"
+	ScopeScan   = "scan"
+)
+
+// APIKey authenticates a machine client for one project. Only a hash of the
+// key is stored; the key itself is shown once, when it is created.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	ProjectID  uuid.UUID  `json:"project_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// HasScope reports whether the key grants scope.
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Roles in an organization, from most to least privileged. Owners manage
+// members, invitations and API keys and may delete projects; editors scan,
+// upload and configure; viewers only read.
+const (
+	RoleOwner  = "owner"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+var roleRanks = map[string]int{RoleViewer: 1, RoleEditor: 2, RoleOwner: 3}
+
+// ValidRole reports whether role is one of the known roles.
+func ValidRole(role string) bool {
+	return roleRanks[role] > 0
+}
+
+// RoleAtLeast reports whether role grants everything min does.
+func RoleAtLeast(role, min string) bool {
+	return roleRanks[role] > 0 && roleRanks[role] >= roleRanks[min]
+}
+
+type Organization struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	// Role is the requesting user's role in the organization.
+	Role string `json:"role,omitempty"`
+}
+
+type OrganizationMember struct {
+	OrganizationID uuid.UUID `json:"organization_id"`
+	UserID         string    `json:"user_id"`
+	Role           string    `json:"role"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Invitation lets whoever holds its token join an organization with the
+// given role. Only a hash of the token is stored.
+type Invitation struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID uuid.UUID  `json:"organization_id"`
+	Email          string     `json:"email,omitempty"`
+	Role           string     `json:"role"`
+	TokenHash      string     `json:"-"`
+	InvitedBy      string     `json:"invited_by"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	AcceptedBy     *string    `json:"accepted_by,omitempty"`
+	AcceptedAt     *time.Time `json:"accepted_at,omitempty"`
+}
+
+// UserSettings holds a user's own credentials. The LLM fields select the
+// provider used for their scans; an empty key falls back to the server's.
 type UserSettings struct {
-	ID           uuid.UUID `json:"id"`
-	ClerkUserID  string    `json:"clerk_user_id"`
-	GeminiAPIKey string    `json:"gemini_api_key"`
-	GeminiModel  string    `json:"gemini_model"`
-	GitHubToken  string    `json:"github_token"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID          uuid.UUID `json:"id"`
+	ClerkUserID string    `json:"clerk_user_id"`
+	LLMProvider string    `json:"llm_provider"`
+	LLMAPIKey   string    `json:"llm_api_key"`
+	LLMModel    string    `json:"llm_model"`
+	LLMBaseURL  string    `json:"llm_base_url"`
+	GitHubToken string    `json:"github_token"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 type GitHubInstallation struct {
@@ -62,3 +310,41 @@ type GitHubInstallation struct {
 	CreatedAt          time.Time `json:"created_at"`
 	UpdatedAt          time.Time `json:"updated_at"`
 }
+
+// LiveCapture is one request/response pair observed by self-capture, the
+// proxy or the ingest endpoint. Bodies hold any decoded JSON value.
+type LiveCapture struct {
+	ID           string              `json:"id"`
+	Timestamp    time.Time           `json:"timestamp"`
+	Path         string              `json:"path"`
+	Method       string              `json:"method"`
+	StatusCode   int                 `json:"status_code"`
+	DurationMs   float64             `json:"duration_ms"`
+	RequestBody  interface{}         `json:"request_body,omitempty"`
+	ResponseBody interface{}         `json:"response_body,omitempty"`
+	Source       string              `json:"source,omitempty"`
+	QueryParams  map[string][]string `json:"query_params,omitempty"`
+	Headers      map[string]string   `json:"headers,omitempty"`
+
+	RequestContentType  string `json:"request_content_type,omitempty"`
+	ResponseContentType string `json:"response_content_type,omitempty"`
+}
+
+// LiveCaptureFilter selects stored captures, newest first. Zero values
+// leave a criterion unset.
+type LiveCaptureFilter struct {
+	Source string
+	Since  *time.Time
+	Until  *time.Time
+	Limit  int
+	Offset int
+}
+
+// CaptureRetention bounds how long and how many captures are kept for a
+// project. A nil limit falls back to the server default; zero means no limit.
+type CaptureRetention struct {
+	ProjectID   uuid.UUID `json:"project_id"`
+	MaxAgeHours *int      `json:"max_age_hours"`
+	MaxCount    *int      `json:"max_count"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
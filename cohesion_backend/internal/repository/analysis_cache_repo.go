@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/cohesion-api/cohesion_backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type AnalysisCacheRepository struct {
+	db *DB
+}
+
+func NewAnalysisCacheRepository(db *DB) *AnalysisCacheRepository {
+	return &AnalysisCacheRepository{db: db}
+}
+
+// maxAnalysisCacheEntries bounds a project's cache; the least recently used
+// entries beyond it are pruned.
+const maxAnalysisCacheEntries = 2000
+
+// Get returns the schemas JSON cached under key and marks the entry used.
+// The boolean is false when there is no such entry.
+func (r *AnalysisCacheRepository) Get(ctx context.Context, projectID uuid.UUID, key string) ([]byte, bool, error) {
+	var schemas []byte
+	err := r.db.Pool.QueryRow(ctx, `
+		UPDATE analysis_cache SET last_used_at = NOW()
+		WHERE project_id = $1 AND cache_key = $2
+		RETURNING schemas
+	`, projectID, key).Scan(&schemas)
+	if err == pgx.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return schemas, true, nil
+}
+
+// Put stores schemas JSON under key, replacing any entry there, and prunes
+// the project's least recently used entries beyond maxAnalysisCacheEntries.
+func (r *AnalysisCacheRepository) Put(ctx context.Context, projectID uuid.UUID, key, fingerprint string, schemas []byte) error {
+	_, err := r.db.Pool.Exec(ctx, `
+		INSERT INTO analysis_cache (project_id, cache_key, fingerprint, schemas)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (project_id, cache_key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint, schemas = EXCLUDED.schemas, last_used_at = NOW()
+	`, projectID, key, fingerprint, schemas)
+	if err != nil {
+		return err
+	}
+	_, _ = r.db.Pool.Exec(ctx, `
+		DELETE FROM analysis_cache WHERE project_id = $1 AND cache_key IN (
+			SELECT cache_key FROM analysis_cache WHERE project_id = $1
+			ORDER BY last_used_at DESC
+			OFFSET $2
+		)
+	`, projectID, maxAnalysisCacheEntries)
+
+	return nil
+}
+
+// UsageByProject counts a project's entries per fingerprint, most recently
+// used first.
+func (r *AnalysisCacheRepository) UsageByProject(ctx context.Context, projectID uuid.UUID) ([]models.AnalysisCacheUsage, error) {
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT fingerprint, COUNT(*), COALESCE(SUM(pg_column_size(schemas)), 0), MAX(last_used_at)
+		FROM analysis_cache WHERE project_id = $1
+		GROUP BY fingerprint
+		ORDER BY MAX(last_used_at) DESC
+	`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usage []models.AnalysisCacheUsage
+	for rows.Next() {
+		var u models.AnalysisCacheUsage
+		if err := rows.Scan(&u.Fingerprint, &u.Entries, &u.SizeBytes, &u.LastUsedAt); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+// DeleteByProject removes a project's entries, only those made under
+// fingerprint when it is not empty, and returns how many were removed.
+func (r *AnalysisCacheRepository) DeleteByProject(ctx context.Context, projectID uuid.UUID, fingerprint string) (int64, error) {
+	tag, err := r.db.Pool.Exec(ctx, `
+		DELETE FROM analysis_cache WHERE project_id = $1 AND ($2 = '' OR fingerprint = $2)
+	`, projectID, fingerprint)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/cohesion-api/cohesion_backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type APIKeyRepository struct {
+	db *DB
+}
+
+func NewAPIKeyRepository(db *DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	key.ID = uuid.New()
+	key.CreatedAt = time.Now()
+	if key.Scopes == nil {
+		key.Scopes = []string{}
+	}
+
+	_, err := r.db.Pool.Exec(ctx, `
+		INSERT INTO api_keys (id, project_id, name, key_prefix, key_hash, scopes, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, key.ID, key.ProjectID, key.Name, key.Prefix, key.KeyHash, key.Scopes, key.CreatedBy, key.CreatedAt)
+	return err
+}
+
+func (r *APIKeyRepository) ListByProject(ctx context.Context, projectID uuid.UUID) ([]models.APIKey, error) {
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT id, project_id, name, key_prefix, key_hash, scopes, created_by, created_at, last_used_at, revoked_at
+		FROM api_keys WHERE project_id = $1 ORDER BY created_at DESC
+	`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		var k models.APIKey
+		if err := rows.Scan(&k.ID, &k.ProjectID, &k.Name, &k.Prefix, &k.KeyHash, &k.Scopes,
+			&k.CreatedBy, &k.CreatedAt, &k.LastUsedAt, &k.RevokedAt); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (r *APIKeyRepository) GetByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	var k models.APIKey
+	err := r.db.Pool.QueryRow(ctx, `
+		SELECT id, project_id, name, key_prefix, key_hash, scopes, created_by, created_at, last_used_at, revoked_at
+		FROM api_keys WHERE key_hash = $1
+	`, hash).Scan(&k.ID, &k.ProjectID, &k.Name, &k.Prefix, &k.KeyHash, &k.Scopes,
+		&k.CreatedBy, &k.CreatedAt, &k.LastUsedAt, &k.RevokedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// Revoke marks a project's key as revoked. Revoking a revoked key keeps the
+// original revocation time.
+func (r *APIKeyRepository) Revoke(ctx context.Context, projectID, id uuid.UUID) error {
+	tag, err := r.db.Pool.Exec(ctx, `
+		UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW())
+		WHERE id = $1 AND project_id = $2
+	`, id, projectID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	_, err := r.db.Pool.Exec(ctx, `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`, id, at)
+	return err
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"log"
 	"time"
 
 	"github.com/cohesion-api/cohesion_backend/internal/models"
@@ -17,22 +18,29 @@ func NewDiffRepository(db *DB) *DiffRepository {
 	return &DiffRepository{db: db}
 }
 
-const maxDiffsPerEndpoint = 10
+const (
+	maxDiffsPerEndpoint = 10
+	maxRunsPerProject   = 50
+)
 
 func (r *DiffRepository) Create(ctx context.Context, diff *models.Diff) error {
 	diff.ID = uuid.New()
 	diff.CreatedAt = time.Now()
 
 	_, err := r.db.Pool.Exec(ctx, `
-		INSERT INTO diffs (id, endpoint_id, diff_data, sources_compared, created_at)
-		VALUES ($1, $2, $3, $4, $5)
-	`, diff.ID, diff.EndpointID, diff.DiffData, diff.SourcesCompared, diff.CreatedAt)
+		INSERT INTO diffs (id, endpoint_id, run_id, diff_data, sources_compared, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, diff.ID, diff.EndpointID, diff.RunID, diff.DiffData, diff.SourcesCompared, diff.CreatedAt)
 	if err != nil {
 		return err
 	}
+	if diff.RunID != nil {
+		// Run results live as long as their run.
+		return nil
+	}
 	_, _ = r.db.Pool.Exec(ctx, `
 		DELETE FROM diffs WHERE id IN (
-			SELECT id FROM diffs WHERE endpoint_id = $1
+			SELECT id FROM diffs WHERE endpoint_id = $1 AND run_id IS NULL
 			ORDER BY created_at DESC
 			OFFSET $2
 		)
@@ -43,31 +51,21 @@ func (r *DiffRepository) Create(ctx context.Context, diff *models.Diff) error {
 
 func (r *DiffRepository) GetByEndpointID(ctx context.Context, endpointID uuid.UUID) ([]models.Diff, error) {
 	rows, err := r.db.Pool.Query(ctx, `
-		SELECT id, endpoint_id, diff_data, sources_compared, created_at
+		SELECT id, endpoint_id, run_id, diff_data, sources_compared, created_at
 		FROM diffs WHERE endpoint_id = $1 ORDER BY created_at DESC
 	`, endpointID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var diffs []models.Diff
-	for rows.Next() {
-		var d models.Diff
-		if err := rows.Scan(&d.ID, &d.EndpointID, &d.DiffData, &d.SourcesCompared, &d.CreatedAt); err != nil {
-			return nil, err
-		}
-		diffs = append(diffs, d)
-	}
-	return diffs, rows.Err()
+	return scanDiffs(rows)
 }
 
 func (r *DiffRepository) GetLatestByEndpoint(ctx context.Context, endpointID uuid.UUID) (*models.Diff, error) {
 	var diff models.Diff
 	err := r.db.Pool.QueryRow(ctx, `
-		SELECT id, endpoint_id, diff_data, sources_compared, created_at
+		SELECT id, endpoint_id, run_id, diff_data, sources_compared, created_at
 		FROM diffs WHERE endpoint_id = $1 ORDER BY created_at DESC LIMIT 1
-	`, endpointID).Scan(&diff.ID, &diff.EndpointID, &diff.DiffData, &diff.SourcesCompared, &diff.CreatedAt)
+	`, endpointID).Scan(&diff.ID, &diff.EndpointID, &diff.RunID, &diff.DiffData, &diff.SourcesCompared, &diff.CreatedAt)
 
 	if err == pgx.ErrNoRows {
 		return nil, nil
@@ -81,7 +79,7 @@ func (r *DiffRepository) GetLatestByEndpointIDs(ctx context.Context, endpointIDs
 	}
 
 	rows, err := r.db.Pool.Query(ctx, `
-		SELECT DISTINCT ON (endpoint_id) id, endpoint_id, diff_data, sources_compared, created_at
+		SELECT DISTINCT ON (endpoint_id) id, endpoint_id, run_id, diff_data, sources_compared, created_at
 		FROM diffs WHERE endpoint_id = ANY($1) ORDER BY endpoint_id, created_at DESC
 	`, endpointIDs)
 	if err != nil {
@@ -92,10 +90,147 @@ func (r *DiffRepository) GetLatestByEndpointIDs(ctx context.Context, endpointIDs
 	result := make(map[uuid.UUID]*models.Diff)
 	for rows.Next() {
 		var d models.Diff
-		if err := rows.Scan(&d.ID, &d.EndpointID, &d.DiffData, &d.SourcesCompared, &d.CreatedAt); err != nil {
+		if err := rows.Scan(&d.ID, &d.EndpointID, &d.RunID, &d.DiffData, &d.SourcesCompared, &d.CreatedAt); err != nil {
 			return nil, err
 		}
 		result[d.EndpointID] = &d
 	}
 	return result, rows.Err()
 }
+
+func scanDiffs(rows pgx.Rows) ([]models.Diff, error) {
+	defer rows.Close()
+
+	var diffs []models.Diff
+	for rows.Next() {
+		var d models.Diff
+		if err := rows.Scan(&d.ID, &d.EndpointID, &d.RunID, &d.DiffData, &d.SourcesCompared, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, d)
+	}
+	return diffs, rows.Err()
+}
+
+func (r *DiffRepository) GetByRunID(ctx context.Context, runID uuid.UUID) ([]models.Diff, error) {
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT id, endpoint_id, run_id, diff_data, sources_compared, created_at
+		FROM diffs WHERE run_id = $1 ORDER BY created_at
+	`, runID)
+	if err != nil {
+		return nil, err
+	}
+	return scanDiffs(rows)
+}
+
+// CreateRun inserts a run in the running state and prunes the project's
+// oldest finished runs beyond maxRunsPerProject, along with their results.
+// Runs still in progress are never pruned, and a failed prune is logged
+// rather than failing the new run.
+func (r *DiffRepository) CreateRun(ctx context.Context, run *models.DiffRun) error {
+	run.ID = uuid.New()
+	run.Status = models.DiffRunRunning
+	run.StartedAt = time.Now()
+
+	_, err := r.db.Pool.Exec(ctx, `
+		INSERT INTO diff_runs (id, project_id, commit_sha, status, started_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, run.ID, run.ProjectID, run.CommitSHA, run.Status, run.StartedAt)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Pool.Exec(ctx, `
+		DELETE FROM diff_runs WHERE id IN (
+			SELECT id FROM diff_runs WHERE project_id = $1 AND status <> $3
+			ORDER BY started_at DESC
+			OFFSET $2
+		)
+	`, run.ProjectID, maxRunsPerProject, models.DiffRunRunning)
+	if err != nil {
+		log.Printf("WARNING: failed to prune diff runs of project %s: %v", run.ProjectID, err)
+	}
+
+	return nil
+}
+
+// FinishRun records the outcome of a run. It returns ErrNotFound if the run
+// no longer exists.
+func (r *DiffRepository) FinishRun(ctx context.Context, run *models.DiffRun) error {
+	now := time.Now()
+	run.FinishedAt = &now
+
+	tag, err := r.db.Pool.Exec(ctx, `
+		UPDATE diff_runs SET status = $2, error = $3, endpoint_count = $4, skipped_count = $5,
+			matched_count = $6, partial_count = $7, violation_count = $8,
+			critical_count = $9, warning_count = $10, info_count = $11, finished_at = $12
+		WHERE id = $1
+	`, run.ID, run.Status, run.Error, run.EndpointCount, run.SkippedCount,
+		run.MatchedCount, run.PartialCount, run.ViolationCount,
+		run.CriticalCount, run.WarningCount, run.InfoCount, run.FinishedAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+const diffRunColumns = `id, project_id, commit_sha, status, error, endpoint_count, skipped_count,
+	matched_count, partial_count, violation_count, critical_count, warning_count, info_count,
+	started_at, finished_at`
+
+func scanDiffRun(row pgx.Row, run *models.DiffRun) error {
+	return row.Scan(&run.ID, &run.ProjectID, &run.CommitSHA, &run.Status, &run.Error,
+		&run.EndpointCount, &run.SkippedCount, &run.MatchedCount, &run.PartialCount, &run.ViolationCount,
+		&run.CriticalCount, &run.WarningCount, &run.InfoCount, &run.StartedAt, &run.FinishedAt)
+}
+
+func (r *DiffRepository) GetRun(ctx context.Context, runID uuid.UUID) (*models.DiffRun, error) {
+	var run models.DiffRun
+	err := scanDiffRun(r.db.Pool.QueryRow(ctx, `
+		SELECT `+diffRunColumns+` FROM diff_runs WHERE id = $1
+	`, runID), &run)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return &run, err
+}
+
+// GetPreviousRun returns the latest completed run of the project that
+// started before the given time.
+func (r *DiffRepository) GetPreviousRun(ctx context.Context, projectID uuid.UUID, before time.Time) (*models.DiffRun, error) {
+	var run models.DiffRun
+	err := scanDiffRun(r.db.Pool.QueryRow(ctx, `
+		SELECT `+diffRunColumns+` FROM diff_runs
+		WHERE project_id = $1 AND status = $2 AND started_at < $3
+		ORDER BY started_at DESC LIMIT 1
+	`, projectID, models.DiffRunCompleted, before), &run)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return &run, err
+}
+
+func (r *DiffRepository) ListRuns(ctx context.Context, projectID uuid.UUID, limit int) ([]models.DiffRun, error) {
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT `+diffRunColumns+` FROM diff_runs
+		WHERE project_id = $1 ORDER BY started_at DESC LIMIT $2
+	`, projectID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []models.DiffRun
+	for rows.Next() {
+		var run models.DiffRun
+		if err := scanDiffRun(rows, &run); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
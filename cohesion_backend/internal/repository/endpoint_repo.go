@@ -78,7 +78,11 @@ func (r *EndpointRepository) GetByProjectWithSchemas(ctx context.Context, projec
 		SELECT e.id, e.project_id, e.path, e.method, e.created_at, e.updated_at,
 		       s.id, s.source, s.schema_data, s.version, s.created_at, s.updated_at
 		FROM endpoints e
-		LEFT JOIN schemas s ON s.endpoint_id = e.id
+		LEFT JOIN LATERAL (
+			SELECT DISTINCT ON (source) id, source, schema_data, version, created_at, updated_at
+			FROM schemas WHERE endpoint_id = e.id
+			ORDER BY source, version DESC
+		) s ON true
 		WHERE e.project_id = $1
 		ORDER BY e.path, e.method, s.source
 	`, projectID)
@@ -145,7 +149,11 @@ func (r *EndpointRepository) GetByProjectIDsWithSchemas(ctx context.Context, pro
 		SELECT e.id, e.project_id, e.path, e.method, e.created_at, e.updated_at,
 		       s.id, s.source, s.schema_data, s.version, s.created_at, s.updated_at
 		FROM endpoints e
-		LEFT JOIN schemas s ON s.endpoint_id = e.id
+		LEFT JOIN LATERAL (
+			SELECT DISTINCT ON (source) id, source, schema_data, version, created_at, updated_at
+			FROM schemas WHERE endpoint_id = e.id
+			ORDER BY source, version DESC
+		) s ON true
 		WHERE e.project_id = ANY($1)
 		ORDER BY e.path, e.method, s.source
 	`, projectIDs)
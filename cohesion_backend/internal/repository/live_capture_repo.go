@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cohesion-api/cohesion_backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type LiveCaptureRepository struct {
+	db *DB
+}
+
+func NewLiveCaptureRepository(db *DB) *LiveCaptureRepository {
+	return &LiveCaptureRepository{db: db}
+}
+
+// Append stores captures, ignoring any whose ID the project already has so
+// ingest clients can safely retry a batch.
+func (r *LiveCaptureRepository) Append(ctx context.Context, projectID uuid.UUID, captures []models.LiveCapture) error {
+	batch := &pgx.Batch{}
+	for _, c := range captures {
+		reqBody, err := jsonColumn(c.RequestBody)
+		if err != nil {
+			return err
+		}
+		respBody, err := jsonColumn(c.ResponseBody)
+		if err != nil {
+			return err
+		}
+		query, err := jsonColumn(c.QueryParams)
+		if err != nil {
+			return err
+		}
+		headers, err := jsonColumn(c.Headers)
+		if err != nil {
+			return err
+		}
+		batch.Queue(`
+			INSERT INTO live_captures (project_id, id, source, method, path, status_code, duration_ms,
+				request_body, response_body, query_params, headers,
+				request_content_type, response_content_type, captured_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+			ON CONFLICT (project_id, id) DO NOTHING
+		`, projectID, c.ID, c.Source, c.Method, c.Path, c.StatusCode, c.DurationMs,
+			reqBody, respBody, query, headers,
+			c.RequestContentType, c.ResponseContentType, c.Timestamp)
+	}
+	return r.db.Pool.SendBatch(ctx, batch).Close()
+}
+
+func (r *LiveCaptureRepository) List(ctx context.Context, projectID uuid.UUID, filter models.LiveCaptureFilter) ([]models.LiveCapture, error) {
+	conds := []string{"project_id = $1"}
+	args := []interface{}{projectID}
+	if filter.Source != "" {
+		args = append(args, filter.Source)
+		conds = append(conds, fmt.Sprintf("source = $%d", len(args)))
+	}
+	if filter.Since != nil {
+		args = append(args, *filter.Since)
+		conds = append(conds, fmt.Sprintf("captured_at >= $%d", len(args)))
+	}
+	if filter.Until != nil {
+		args = append(args, *filter.Until)
+		conds = append(conds, fmt.Sprintf("captured_at < $%d", len(args)))
+	}
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT id, source, method, path, status_code, duration_ms,
+			request_body, response_body, query_params, headers,
+			request_content_type, response_content_type, captured_at
+		FROM live_captures WHERE `+strings.Join(conds, " AND ")+`
+		ORDER BY captured_at DESC, id DESC
+		LIMIT $`+fmt.Sprint(len(args)-1)+` OFFSET $`+fmt.Sprint(len(args)),
+		args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var captures []models.LiveCapture
+	for rows.Next() {
+		var c models.LiveCapture
+		var reqBody, respBody, query, headers []byte
+		if err := rows.Scan(&c.ID, &c.Source, &c.Method, &c.Path, &c.StatusCode, &c.DurationMs,
+			&reqBody, &respBody, &query, &headers,
+			&c.RequestContentType, &c.ResponseContentType, &c.Timestamp); err != nil {
+			return nil, err
+		}
+		if err := scanJSONColumns(
+			reqBody, &c.RequestBody,
+			respBody, &c.ResponseBody,
+			query, &c.QueryParams,
+			headers, &c.Headers,
+		); err != nil {
+			return nil, err
+		}
+		captures = append(captures, c)
+	}
+	return captures, rows.Err()
+}
+
+func (r *LiveCaptureRepository) DeleteByProject(ctx context.Context, projectID uuid.UUID) error {
+	_, err := r.db.Pool.Exec(ctx, `DELETE FROM live_captures WHERE project_id = $1`, projectID)
+	return err
+}
+
+// Prune deletes captures older than their project's maximum age and beyond
+// its maximum count. Projects without retention settings, or with NULL
+// limits, use the given defaults. A limit of zero or less means no limit.
+// It returns the number of deleted rows.
+func (r *LiveCaptureRepository) Prune(ctx context.Context, defaultMaxAge time.Duration, defaultMaxCount int) (int64, error) {
+	aged, err := r.db.Pool.Exec(ctx, `
+		DELETE FROM live_captures c
+		USING (
+			SELECT p.id AS project_id, COALESCE(rt.max_age_hours, $1) AS max_age_hours
+			FROM projects p LEFT JOIN live_capture_retention rt ON rt.project_id = p.id
+		) lim
+		WHERE c.project_id = lim.project_id
+			AND lim.max_age_hours > 0
+			AND c.captured_at < NOW() - make_interval(hours => lim.max_age_hours)
+	`, int(defaultMaxAge/time.Hour))
+	if err != nil {
+		return 0, err
+	}
+
+	counted, err := r.db.Pool.Exec(ctx, `
+		DELETE FROM live_captures c
+		USING (
+			SELECT ranked.project_id, ranked.id
+			FROM (
+				SELECT project_id, id,
+					ROW_NUMBER() OVER (PARTITION BY project_id ORDER BY captured_at DESC, id DESC) AS rn
+				FROM live_captures
+			) ranked
+			LEFT JOIN live_capture_retention rt ON rt.project_id = ranked.project_id
+			WHERE COALESCE(rt.max_count, $1) > 0
+				AND ranked.rn > COALESCE(rt.max_count, $1)
+		) excess
+		WHERE c.project_id = excess.project_id AND c.id = excess.id
+	`, defaultMaxCount)
+	if err != nil {
+		return aged.RowsAffected(), err
+	}
+	return aged.RowsAffected() + counted.RowsAffected(), nil
+}
+
+func (r *LiveCaptureRepository) GetRetention(ctx context.Context, projectID uuid.UUID) (*models.CaptureRetention, error) {
+	var rt models.CaptureRetention
+	err := r.db.Pool.QueryRow(ctx, `
+		SELECT project_id, max_age_hours, max_count, updated_at
+		FROM live_capture_retention WHERE project_id = $1
+	`, projectID).Scan(&rt.ProjectID, &rt.MaxAgeHours, &rt.MaxCount, &rt.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rt, nil
+}
+
+func (r *LiveCaptureRepository) UpsertRetention(ctx context.Context, rt *models.CaptureRetention) error {
+	rt.UpdatedAt = time.Now()
+	_, err := r.db.Pool.Exec(ctx, `
+		INSERT INTO live_capture_retention (project_id, max_age_hours, max_count, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (project_id)
+		DO UPDATE SET max_age_hours = EXCLUDED.max_age_hours, max_count = EXCLUDED.max_count, updated_at = EXCLUDED.updated_at
+	`, rt.ProjectID, rt.MaxAgeHours, rt.MaxCount, rt.UpdatedAt)
+	return err
+}
+
+// jsonColumn encodes a value for a JSONB column. Values are marshaled here
+// rather than by the driver, which would pass strings through as raw JSON.
+func jsonColumn(v interface{}) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil || string(raw) == "null" {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// scanJSONColumns decodes pairs of raw JSONB columns and their targets.
+// NULL columns leave the target at its zero value.
+func scanJSONColumns(pairs ...interface{}) error {
+	for i := 0; i+1 < len(pairs); i += 2 {
+		raw, _ := pairs[i].([]byte)
+		if len(raw) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(raw, pairs[i+1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cohesion-api/cohesion_backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type OrganizationRepository struct {
+	db *DB
+}
+
+func NewOrganizationRepository(db *DB) *OrganizationRepository {
+	return &OrganizationRepository{db: db}
+}
+
+// Create inserts an organization with its creator as the first owner.
+func (r *OrganizationRepository) Create(ctx context.Context, org *models.Organization) error {
+	org.ID = uuid.New()
+	org.CreatedAt = time.Now()
+	org.Role = models.RoleOwner
+
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO organizations (id, name, created_by, created_at) VALUES ($1, $2, $3, $4)
+	`, org.ID, org.Name, org.CreatedBy, org.CreatedAt); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO organization_members (organization_id, user_id, role, created_at) VALUES ($1, $2, $3, $4)
+	`, org.ID, org.CreatedBy, models.RoleOwner, org.CreatedAt); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// GetForMember returns an organization with the user's role, or nil if the
+// user is not a member.
+func (r *OrganizationRepository) GetForMember(ctx context.Context, id uuid.UUID, userID string) (*models.Organization, error) {
+	var org models.Organization
+	err := r.db.Pool.QueryRow(ctx, `
+		SELECT o.id, o.name, o.created_by, o.created_at, m.role
+		FROM organizations o
+		JOIN organization_members m ON m.organization_id = o.id AND m.user_id = $2
+		WHERE o.id = $1
+	`, id, userID).Scan(&org.ID, &org.Name, &org.CreatedBy, &org.CreatedAt, &org.Role)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (r *OrganizationRepository) ListForMember(ctx context.Context, userID string) ([]models.Organization, error) {
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT o.id, o.name, o.created_by, o.created_at, m.role
+		FROM organizations o
+		JOIN organization_members m ON m.organization_id = o.id
+		WHERE m.user_id = $1 ORDER BY o.created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orgs []models.Organization
+	for rows.Next() {
+		var o models.Organization
+		if err := rows.Scan(&o.ID, &o.Name, &o.CreatedBy, &o.CreatedAt, &o.Role); err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, o)
+	}
+	return orgs, rows.Err()
+}
+
+func (r *OrganizationRepository) ListMembers(ctx context.Context, orgID uuid.UUID) ([]models.OrganizationMember, error) {
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT organization_id, user_id, role, created_at
+		FROM organization_members WHERE organization_id = $1 ORDER BY created_at
+	`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []models.OrganizationMember
+	for rows.Next() {
+		var m models.OrganizationMember
+		if err := rows.Scan(&m.OrganizationID, &m.UserID, &m.Role, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// ErrLastOwner is returned when a change would leave an organization
+// without an owner.
+var ErrLastOwner = fmt.Errorf("an organization must keep at least one owner")
+
+// UpdateMemberRole changes a member's role. Demoting the last owner fails
+// with ErrLastOwner.
+func (r *OrganizationRepository) UpdateMemberRole(ctx context.Context, orgID uuid.UUID, userID, role string) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if role != models.RoleOwner {
+		if err := checkNotLastOwnerTx(ctx, tx, orgID, userID); err != nil {
+			return err
+		}
+	}
+	tag, err := tx.Exec(ctx, `
+		UPDATE organization_members SET role = $3 WHERE organization_id = $1 AND user_id = $2
+	`, orgID, userID, role)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return tx.Commit(ctx)
+}
+
+// RemoveMember removes a member. Removing the last owner fails with
+// ErrLastOwner.
+func (r *OrganizationRepository) RemoveMember(ctx context.Context, orgID uuid.UUID, userID string) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkNotLastOwnerTx(ctx, tx, orgID, userID); err != nil {
+		return err
+	}
+	tag, err := tx.Exec(ctx, `
+		DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2
+	`, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return tx.Commit(ctx)
+}
+
+// checkNotLastOwnerTx fails with ErrLastOwner if userID is the only owner
+// of the organization. The owners' rows stay locked until tx ends, so two
+// owners demoting or removing each other cannot both succeed.
+func checkNotLastOwnerTx(ctx context.Context, tx pgx.Tx, orgID uuid.UUID, userID string) error {
+	rows, err := tx.Query(ctx, `
+		SELECT user_id FROM organization_members
+		WHERE organization_id = $1 AND role = $2
+		FOR UPDATE
+	`, orgID, models.RoleOwner)
+	if err != nil {
+		return err
+	}
+	owners := 0
+	isOwner := false
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		owners++
+		isOwner = isOwner || id == userID
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if isOwner && owners <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+func (r *OrganizationRepository) CreateInvitation(ctx context.Context, inv *models.Invitation) error {
+	inv.ID = uuid.New()
+	inv.CreatedAt = time.Now()
+
+	_, err := r.db.Pool.Exec(ctx, `
+		INSERT INTO organization_invitations (id, organization_id, email, role, token_hash, invited_by, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, inv.ID, inv.OrganizationID, inv.Email, inv.Role, inv.TokenHash, inv.InvitedBy, inv.CreatedAt, inv.ExpiresAt)
+	return err
+}
+
+// ListInvitations returns an organization's pending invitations, including
+// expired ones.
+func (r *OrganizationRepository) ListInvitations(ctx context.Context, orgID uuid.UUID) ([]models.Invitation, error) {
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT id, organization_id, email, role, token_hash, invited_by, created_at, expires_at, accepted_by, accepted_at
+		FROM organization_invitations
+		WHERE organization_id = $1 AND accepted_at IS NULL
+		ORDER BY created_at DESC
+	`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invs []models.Invitation
+	for rows.Next() {
+		var inv models.Invitation
+		if err := rows.Scan(&inv.ID, &inv.OrganizationID, &inv.Email, &inv.Role, &inv.TokenHash,
+			&inv.InvitedBy, &inv.CreatedAt, &inv.ExpiresAt, &inv.AcceptedBy, &inv.AcceptedAt); err != nil {
+			return nil, err
+		}
+		invs = append(invs, inv)
+	}
+	return invs, rows.Err()
+}
+
+func (r *OrganizationRepository) DeleteInvitation(ctx context.Context, orgID, id uuid.UUID) error {
+	tag, err := r.db.Pool.Exec(ctx, `
+		DELETE FROM organization_invitations WHERE id = $1 AND organization_id = $2 AND accepted_at IS NULL
+	`, id, orgID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AcceptInvitation marks the pending, unexpired invitation with the given
+// token hash as accepted by userID and adds them to its organization. A user
+// who is already a member keeps the higher of their role and the invited
+// one. It returns nil if no such invitation exists.
+func (r *OrganizationRepository) AcceptInvitation(ctx context.Context, tokenHash, userID string) (*models.Invitation, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var inv models.Invitation
+	err = tx.QueryRow(ctx, `
+		UPDATE organization_invitations SET accepted_by = $2, accepted_at = NOW()
+		WHERE token_hash = $1 AND accepted_at IS NULL AND expires_at > NOW()
+		RETURNING id, organization_id, email, role, token_hash, invited_by, created_at, expires_at, accepted_by, accepted_at
+	`, tokenHash, userID).Scan(&inv.ID, &inv.OrganizationID, &inv.Email, &inv.Role, &inv.TokenHash,
+		&inv.InvitedBy, &inv.CreatedAt, &inv.ExpiresAt, &inv.AcceptedBy, &inv.AcceptedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var current string
+	err = tx.QueryRow(ctx, `
+		SELECT role FROM organization_members WHERE organization_id = $1 AND user_id = $2 FOR UPDATE
+	`, inv.OrganizationID, userID).Scan(&current)
+	switch {
+	case err == pgx.ErrNoRows:
+		_, err = tx.Exec(ctx, `
+			INSERT INTO organization_members (organization_id, user_id, role) VALUES ($1, $2, $3)
+		`, inv.OrganizationID, userID, inv.Role)
+	case err == nil && !models.RoleAtLeast(current, inv.Role):
+		_, err = tx.Exec(ctx, `
+			UPDATE organization_members SET role = $3 WHERE organization_id = $1 AND user_id = $2
+		`, inv.OrganizationID, userID, inv.Role)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &inv, tx.Commit(ctx)
+}
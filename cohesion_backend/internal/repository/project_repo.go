@@ -18,25 +18,51 @@ func NewProjectRepository(db *DB) *ProjectRepository {
 	return &ProjectRepository{db: db}
 }
 
+func scanProject(row pgx.Row, p *models.Project) error {
+	return row.Scan(&p.ID, &p.OwnerID, &p.OrganizationID, &p.Name, &p.Description, &p.Role, &p.CreatedAt, &p.UpdatedAt)
+}
+
 func (r *ProjectRepository) Create(ctx context.Context, project *models.Project) error {
 	project.ID = uuid.New()
 	project.CreatedAt = time.Now()
 	project.UpdatedAt = time.Now()
 
 	_, err := r.db.Pool.Exec(ctx, `
-		INSERT INTO projects (id, owner_id, name, description, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`, project.ID, project.OwnerID, project.Name, project.Description, project.CreatedAt, project.UpdatedAt)
+		INSERT INTO projects (id, owner_id, organization_id, name, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, project.ID, project.OwnerID, project.OrganizationID, project.Name, project.Description, project.CreatedAt, project.UpdatedAt)
 
 	return err
 }
 
-func (r *ProjectRepository) GetByID(ctx context.Context, id uuid.UUID, ownerID string) (*models.Project, error) {
+// GetByID returns a project the user owns or can access through its
+// organization, with the user's role filled in: owner for the project's
+// owner, otherwise their role in the organization.
+func (r *ProjectRepository) GetByID(ctx context.Context, id uuid.UUID, userID string) (*models.Project, error) {
+	var project models.Project
+	err := scanProject(r.db.Pool.QueryRow(ctx, `
+		SELECT p.id, p.owner_id, p.organization_id, p.name, p.description,
+			CASE WHEN p.owner_id = $2 THEN 'owner' ELSE COALESCE(m.role, '') END,
+			p.created_at, p.updated_at
+		FROM projects p
+		LEFT JOIN organization_members m ON m.organization_id = p.organization_id AND m.user_id = $2
+		WHERE p.id = $1 AND (p.owner_id = $2 OR m.user_id IS NOT NULL)
+	`, id, userID), &project)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return &project, err
+}
+
+// GetByIDUnscoped returns a project regardless of its owner. Callers must
+// have authorized the request some other way, e.g. with a project API key.
+func (r *ProjectRepository) GetByIDUnscoped(ctx context.Context, id uuid.UUID) (*models.Project, error) {
 	var project models.Project
 	err := r.db.Pool.QueryRow(ctx, `
-		SELECT id, owner_id, name, description, created_at, updated_at
-		FROM projects WHERE id = $1 AND owner_id = $2
-	`, id, ownerID).Scan(&project.ID, &project.OwnerID, &project.Name, &project.Description, &project.CreatedAt, &project.UpdatedAt)
+		SELECT id, owner_id, organization_id, name, description, created_at, updated_at
+		FROM projects WHERE id = $1
+	`, id).Scan(&project.ID, &project.OwnerID, &project.OrganizationID, &project.Name, &project.Description, &project.CreatedAt, &project.UpdatedAt)
 
 	if err == pgx.ErrNoRows {
 		return nil, nil
@@ -44,11 +70,18 @@ func (r *ProjectRepository) GetByID(ctx context.Context, id uuid.UUID, ownerID s
 	return &project, err
 }
 
-func (r *ProjectRepository) List(ctx context.Context, ownerID string) ([]models.Project, error) {
+// List returns the projects the user owns or can access through an
+// organization.
+func (r *ProjectRepository) List(ctx context.Context, userID string) ([]models.Project, error) {
 	rows, err := r.db.Pool.Query(ctx, `
-		SELECT id, owner_id, name, description, created_at, updated_at
-		FROM projects WHERE owner_id = $1 ORDER BY created_at DESC
-	`, ownerID)
+		SELECT p.id, p.owner_id, p.organization_id, p.name, p.description,
+			CASE WHEN p.owner_id = $1 THEN 'owner' ELSE COALESCE(m.role, '') END,
+			p.created_at, p.updated_at
+		FROM projects p
+		LEFT JOIN organization_members m ON m.organization_id = p.organization_id AND m.user_id = $1
+		WHERE p.owner_id = $1 OR m.user_id IS NOT NULL
+		ORDER BY p.created_at DESC
+	`, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -57,7 +90,7 @@ func (r *ProjectRepository) List(ctx context.Context, ownerID string) ([]models.
 	var projects []models.Project
 	for rows.Next() {
 		var p models.Project
-		if err := rows.Scan(&p.ID, &p.OwnerID, &p.Name, &p.Description, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		if err := scanProject(rows, &p); err != nil {
 			return nil, err
 		}
 		projects = append(projects, p)
@@ -65,10 +98,27 @@ func (r *ProjectRepository) List(ctx context.Context, ownerID string) ([]models.
 	return projects, rows.Err()
 }
 
+// SetOrganization moves a project into an organization, or out of one when
+// orgID is nil.
+func (r *ProjectRepository) SetOrganization(ctx context.Context, id uuid.UUID, orgID *uuid.UUID) error {
+	result, err := r.db.Pool.Exec(ctx, `
+		UPDATE projects SET organization_id = $2, updated_at = NOW() WHERE id = $1
+	`, id, orgID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 var ErrNotFound = fmt.Errorf("not found")
 
-func (r *ProjectRepository) Delete(ctx context.Context, id uuid.UUID, ownerID string) error {
-	result, err := r.db.Pool.Exec(ctx, `DELETE FROM projects WHERE id = $1 AND owner_id = $2`, id, ownerID)
+// Delete removes a project. Callers must have checked the user is allowed
+// to.
+func (r *ProjectRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.Pool.Exec(ctx, `DELETE FROM projects WHERE id = $1`, id)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/cohesion-api/cohesion_backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type RedactionPolicyRepository struct {
+	db *DB
+}
+
+func NewRedactionPolicyRepository(db *DB) *RedactionPolicyRepository {
+	return &RedactionPolicyRepository{db: db}
+}
+
+func (r *RedactionPolicyRepository) GetByProject(ctx context.Context, projectID uuid.UUID) (*models.RedactionPolicy, error) {
+	var p models.RedactionPolicy
+	err := r.db.Pool.QueryRow(ctx, `
+		SELECT project_id, rules, updated_by, updated_at
+		FROM redaction_policies WHERE project_id = $1
+	`, projectID).Scan(&p.ProjectID, &p.Rules, &p.UpdatedBy, &p.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *RedactionPolicyRepository) Upsert(ctx context.Context, policy *models.RedactionPolicy) error {
+	policy.UpdatedAt = time.Now()
+	if policy.Rules == nil {
+		policy.Rules = []models.RedactionRule{}
+	}
+
+	_, err := r.db.Pool.Exec(ctx, `
+		INSERT INTO redaction_policies (project_id, rules, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (project_id)
+		DO UPDATE SET rules = EXCLUDED.rules, updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at
+	`, policy.ProjectID, policy.Rules, policy.UpdatedBy, policy.UpdatedAt)
+	return err
+}
+
+func (r *RedactionPolicyRepository) Delete(ctx context.Context, projectID uuid.UUID) error {
+	_, err := r.db.Pool.Exec(ctx, `DELETE FROM redaction_policies WHERE project_id = $1`, projectID)
+	return err
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/cohesion-api/cohesion_backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type ScanJobRepository struct {
+	db *DB
+}
+
+func NewScanJobRepository(db *DB) *ScanJobRepository {
+	return &ScanJobRepository{db: db}
+}
+
+const maxScanJobsPerProject = 50
+
+// Create inserts a job in the queued state and prunes the project's oldest
+// finished jobs beyond maxScanJobsPerProject.
+func (r *ScanJobRepository) Create(ctx context.Context, job *models.ScanJob) error {
+	job.ID = uuid.New()
+	job.Status = models.ScanJobQueued
+	job.CreatedAt = time.Now()
+
+	_, err := r.db.Pool.Exec(ctx, `
+		INSERT INTO scan_jobs (id, project_id, created_by, repo_url, branch, path, scan_type, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, job.ID, job.ProjectID, job.CreatedBy, job.RepoURL, job.Branch, job.Path, job.ScanType, job.Status, job.CreatedAt)
+	if err != nil {
+		return err
+	}
+	_, _ = r.db.Pool.Exec(ctx, `
+		DELETE FROM scan_jobs WHERE id IN (
+			SELECT id FROM scan_jobs WHERE project_id = $1 AND status NOT IN ($2, $3)
+			ORDER BY created_at DESC
+			OFFSET $4
+		)
+	`, job.ProjectID, models.ScanJobQueued, models.ScanJobRunning, maxScanJobsPerProject)
+
+	return nil
+}
+
+// Requeue resets a failed or cancelled job to the queued state. It returns
+// ErrNotFound when the job is not in one of those states.
+func (r *ScanJobRepository) Requeue(ctx context.Context, job *models.ScanJob) error {
+	tag, err := r.db.Pool.Exec(ctx, `
+		UPDATE scan_jobs SET status = $2, phase = '', error = '', file_count = 0, endpoint_count = 0,
+			summary = NULL, started_at = NULL, finished_at = NULL
+		WHERE id = $1 AND status IN ($3, $4)
+	`, job.ID, models.ScanJobQueued, models.ScanJobFailed, models.ScanJobCancelled)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	job.Status = models.ScanJobQueued
+	job.Phase = ""
+	job.Error = ""
+	job.FileCount = 0
+	job.EndpointCount = 0
+	job.Summary = nil
+	job.StartedAt = nil
+	job.FinishedAt = nil
+	return nil
+}
+
+// Start moves a queued job to the running state and counts the attempt.
+func (r *ScanJobRepository) Start(ctx context.Context, job *models.ScanJob) error {
+	now := time.Now()
+	job.Status = models.ScanJobRunning
+	job.StartedAt = &now
+	job.Attempts++
+
+	_, err := r.db.Pool.Exec(ctx, `
+		UPDATE scan_jobs SET status = $2, started_at = $3, attempts = $4 WHERE id = $1
+	`, job.ID, job.Status, job.StartedAt, job.Attempts)
+	return err
+}
+
+// UpdateProgress records the phase a running job reached.
+func (r *ScanJobRepository) UpdateProgress(ctx context.Context, job *models.ScanJob) error {
+	_, err := r.db.Pool.Exec(ctx, `
+		UPDATE scan_jobs SET phase = $2, file_count = $3, endpoint_count = $4 WHERE id = $1
+	`, job.ID, job.Phase, job.FileCount, job.EndpointCount)
+	return err
+}
+
+func (r *ScanJobRepository) Finish(ctx context.Context, job *models.ScanJob) error {
+	now := time.Now()
+	job.FinishedAt = &now
+
+	summary, err := jsonColumn(job.Summary)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Pool.Exec(ctx, `
+		UPDATE scan_jobs SET status = $2, phase = $3, error = $4, file_count = $5, endpoint_count = $6,
+			summary = $7, finished_at = $8
+		WHERE id = $1
+	`, job.ID, job.Status, job.Phase, job.Error, job.FileCount, job.EndpointCount, summary, job.FinishedAt)
+	return err
+}
+
+// FailActive marks every queued or running job failed. Jobs only run in the
+// process that queued them, so any left active at startup were interrupted.
+func (r *ScanJobRepository) FailActive(ctx context.Context, reason string) (int64, error) {
+	tag, err := r.db.Pool.Exec(ctx, `
+		UPDATE scan_jobs SET status = $1, error = $2, finished_at = NOW()
+		WHERE status IN ($3, $4)
+	`, models.ScanJobFailed, reason, models.ScanJobQueued, models.ScanJobRunning)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+const scanJobColumns = `id, project_id, created_by, repo_url, branch, path, scan_type, status, phase, error,
+	file_count, endpoint_count, summary, attempts, created_at, started_at, finished_at`
+
+func scanScanJob(row pgx.Row, job *models.ScanJob) error {
+	var summary []byte
+	if err := row.Scan(&job.ID, &job.ProjectID, &job.CreatedBy, &job.RepoURL, &job.Branch, &job.Path,
+		&job.ScanType, &job.Status, &job.Phase, &job.Error, &job.FileCount, &job.EndpointCount,
+		&summary, &job.Attempts, &job.CreatedAt, &job.StartedAt, &job.FinishedAt); err != nil {
+		return err
+	}
+	return scanJSONColumns(summary, &job.Summary)
+}
+
+func (r *ScanJobRepository) Get(ctx context.Context, jobID uuid.UUID) (*models.ScanJob, error) {
+	var job models.ScanJob
+	err := scanScanJob(r.db.Pool.QueryRow(ctx, `
+		SELECT `+scanJobColumns+` FROM scan_jobs WHERE id = $1
+	`, jobID), &job)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return &job, err
+}
+
+func (r *ScanJobRepository) ListByProject(ctx context.Context, projectID uuid.UUID, limit int) ([]models.ScanJob, error) {
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT `+scanJobColumns+` FROM scan_jobs
+		WHERE project_id = $1 ORDER BY created_at DESC LIMIT $2
+	`, projectID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []models.ScanJob
+	for rows.Next() {
+		var job models.ScanJob
+		if err := scanScanJob(rows, &job); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
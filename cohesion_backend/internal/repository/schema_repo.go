@@ -2,6 +2,9 @@ package repository
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"time"
 
 	"github.com/cohesion-api/cohesion_backend/internal/models"
@@ -17,95 +20,148 @@ func NewSchemaRepository(db *DB) *SchemaRepository {
 	return &SchemaRepository{db: db}
 }
 
-func (r *SchemaRepository) Upsert(ctx context.Context, schema *models.Schema) error {
-	if schema.ID == uuid.Nil {
-		schema.ID = uuid.New()
+// SchemaContentHash hashes a canonical JSON encoding of schema data, so the
+// same contract hashes identically whether it came from structs or JSONB.
+func SchemaContentHash(data map[string]interface{}) (string, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	var canonical interface{}
+	if err := json.Unmarshal(raw, &canonical); err != nil {
+		return "", err
 	}
-	schema.CreatedAt = time.Now()
-	schema.UpdatedAt = time.Now()
-	if schema.Version == 0 {
-		schema.Version = 1
+	raw, err = json.Marshal(canonical)
+	if err != nil {
+		return "", err
 	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
 
-	_, err := r.db.Pool.Exec(ctx, `
-		INSERT INTO schemas (id, endpoint_id, source, schema_data, version, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		ON CONFLICT (endpoint_id, source, version) 
-		DO UPDATE SET schema_data = EXCLUDED.schema_data, updated_at = EXCLUDED.updated_at
-	`, schema.ID, schema.EndpointID, schema.Source, schema.SchemaData, schema.Version, schema.CreatedAt, schema.UpdatedAt)
+type schemaHeadKey struct {
+	endpointID uuid.UUID
+	source     string
+}
 
-	return err
+type schemaHead struct {
+	id          uuid.UUID
+	version     int
+	contentHash string
 }
 
-func (r *SchemaRepository) UpsertBatch(ctx context.Context, schemas []models.Schema) error {
-	batch := &pgx.Batch{}
+func (r *SchemaRepository) latestHeadsTx(ctx context.Context, tx pgx.Tx, endpointIDs []uuid.UUID) (map[schemaHeadKey]schemaHead, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT DISTINCT ON (endpoint_id, source) id, endpoint_id, source, version, content_hash,
+		       CASE WHEN content_hash = '' THEN schema_data END
+		FROM schemas WHERE endpoint_id = ANY($1)
+		ORDER BY endpoint_id, source, version DESC
+	`, endpointIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	for i := range schemas {
-		if schemas[i].ID == uuid.Nil {
-			schemas[i].ID = uuid.New()
+	heads := make(map[schemaHeadKey]schemaHead)
+	for rows.Next() {
+		var head schemaHead
+		var key schemaHeadKey
+		var legacyData map[string]interface{}
+		if err := rows.Scan(&head.id, &key.endpointID, &key.source, &head.version, &head.contentHash, &legacyData); err != nil {
+			return nil, err
 		}
-		schemas[i].CreatedAt = time.Now()
-		schemas[i].UpdatedAt = time.Now()
-		if schemas[i].Version == 0 {
-			schemas[i].Version = 1
+		if head.contentHash == "" && legacyData != nil {
+			if head.contentHash, err = SchemaContentHash(legacyData); err != nil {
+				return nil, err
+			}
 		}
-
-		batch.Queue(`
-			INSERT INTO schemas (id, endpoint_id, source, schema_data, version, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7)
-			ON CONFLICT (endpoint_id, source, version) 
-			DO UPDATE SET schema_data = EXCLUDED.schema_data, updated_at = EXCLUDED.updated_at
-		`, schemas[i].ID, schemas[i].EndpointID, schemas[i].Source, schemas[i].SchemaData, schemas[i].Version, schemas[i].CreatedAt, schemas[i].UpdatedAt)
+		heads[key] = head
 	}
+	return heads, rows.Err()
+}
 
-	results := r.db.Pool.SendBatch(ctx, batch)
-	defer results.Close()
+// lockEndpointsTx takes a transaction-scoped advisory lock per endpoint, in
+// a fixed order so concurrent uploads of overlapping endpoints cannot
+// deadlock. Uploads for the same endpoint then read its heads one at a time.
+func (r *SchemaRepository) lockEndpointsTx(ctx context.Context, tx pgx.Tx, endpointIDs []uuid.UUID) error {
+	_, err := tx.Exec(ctx, `
+		SELECT pg_advisory_xact_lock(hashtextextended('schemas:' || id::text, 0))
+		FROM (SELECT DISTINCT id FROM unnest($1::uuid[]) AS id ORDER BY id) ids
+	`, endpointIDs)
+	return err
+}
 
-	for range schemas {
-		if _, err := results.Exec(); err != nil {
-			return err
-		}
+// AppendVersionsTx stores each schema as the next version for its
+// (endpoint, source) pair. Schemas whose content matches the latest stored
+// version are skipped and report that version instead. It locks the
+// endpoints until tx ends, so concurrent appends number their versions one
+// after the other. It returns the number of versions written.
+func (r *SchemaRepository) AppendVersionsTx(ctx context.Context, tx pgx.Tx, schemas []models.Schema) (int, error) {
+	if len(schemas) == 0 {
+		return 0, nil
 	}
 
-	return nil
-}
+	endpointIDs := make([]uuid.UUID, 0, len(schemas))
+	for _, s := range schemas {
+		endpointIDs = append(endpointIDs, s.EndpointID)
+	}
+	if err := r.lockEndpointsTx(ctx, tx, endpointIDs); err != nil {
+		return 0, err
+	}
+	heads, err := r.latestHeadsTx(ctx, tx, endpointIDs)
+	if err != nil {
+		return 0, err
+	}
 
-func (r *SchemaRepository) UpsertBatchTx(ctx context.Context, tx pgx.Tx, schemas []models.Schema) error {
+	now := time.Now()
 	batch := &pgx.Batch{}
-
 	for i := range schemas {
-		if schemas[i].ID == uuid.Nil {
-			schemas[i].ID = uuid.New()
+		if schemas[i].ContentHash == "" {
+			if schemas[i].ContentHash, err = SchemaContentHash(schemas[i].SchemaData); err != nil {
+				return 0, err
+			}
 		}
-		schemas[i].CreatedAt = time.Now()
-		schemas[i].UpdatedAt = time.Now()
-		if schemas[i].Version == 0 {
-			schemas[i].Version = 1
+
+		key := schemaHeadKey{schemas[i].EndpointID, schemas[i].Source}
+		head, exists := heads[key]
+		if exists && head.contentHash == schemas[i].ContentHash {
+			schemas[i].ID = head.id
+			schemas[i].Version = head.version
+			continue
 		}
 
+		schemas[i].ID = uuid.New()
+		schemas[i].Version = head.version + 1
+		schemas[i].CreatedAt = now
+		schemas[i].UpdatedAt = now
+		heads[key] = schemaHead{id: schemas[i].ID, version: schemas[i].Version, contentHash: schemas[i].ContentHash}
+
 		batch.Queue(`
-			INSERT INTO schemas (id, endpoint_id, source, schema_data, version, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7)
-			ON CONFLICT (endpoint_id, source, version)
-			DO UPDATE SET schema_data = EXCLUDED.schema_data, updated_at = EXCLUDED.updated_at
-		`, schemas[i].ID, schemas[i].EndpointID, schemas[i].Source, schemas[i].SchemaData, schemas[i].Version, schemas[i].CreatedAt, schemas[i].UpdatedAt)
+			INSERT INTO schemas (id, endpoint_id, source, schema_data, version, content_hash, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, schemas[i].ID, schemas[i].EndpointID, schemas[i].Source, schemas[i].SchemaData, schemas[i].Version, schemas[i].ContentHash, schemas[i].CreatedAt, schemas[i].UpdatedAt)
+	}
+
+	if batch.Len() == 0 {
+		return 0, nil
 	}
 
 	results := tx.SendBatch(ctx, batch)
 	defer results.Close()
 
-	for range schemas {
+	for i := 0; i < batch.Len(); i++ {
 		if _, err := results.Exec(); err != nil {
-			return err
+			return 0, err
 		}
 	}
 
-	return nil
+	return batch.Len(), nil
 }
 
+// GetByEndpointID returns the latest version of each source's schema.
 func (r *SchemaRepository) GetByEndpointID(ctx context.Context, endpointID uuid.UUID) ([]models.Schema, error) {
 	rows, err := r.db.Pool.Query(ctx, `
-		SELECT id, endpoint_id, source, schema_data, version, created_at, updated_at
+		SELECT DISTINCT ON (source) id, endpoint_id, source, schema_data, version, content_hash, created_at, updated_at
 		FROM schemas WHERE endpoint_id = $1 ORDER BY source, version DESC
 	`, endpointID)
 	if err != nil {
@@ -116,7 +172,7 @@ func (r *SchemaRepository) GetByEndpointID(ctx context.Context, endpointID uuid.
 	var schemas []models.Schema
 	for rows.Next() {
 		var s models.Schema
-		if err := rows.Scan(&s.ID, &s.EndpointID, &s.Source, &s.SchemaData, &s.Version, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		if err := rows.Scan(&s.ID, &s.EndpointID, &s.Source, &s.SchemaData, &s.Version, &s.ContentHash, &s.CreatedAt, &s.UpdatedAt); err != nil {
 			return nil, err
 		}
 		schemas = append(schemas, s)
@@ -130,7 +186,7 @@ func (r *SchemaRepository) GetByEndpointIDs(ctx context.Context, endpointIDs []u
 	}
 
 	rows, err := r.db.Pool.Query(ctx, `
-		SELECT id, endpoint_id, source, schema_data, version, created_at, updated_at
+		SELECT DISTINCT ON (endpoint_id, source) id, endpoint_id, source, schema_data, version, content_hash, created_at, updated_at
 		FROM schemas WHERE endpoint_id = ANY($1) ORDER BY endpoint_id, source, version DESC
 	`, endpointIDs)
 	if err != nil {
@@ -141,7 +197,7 @@ func (r *SchemaRepository) GetByEndpointIDs(ctx context.Context, endpointIDs []u
 	result := make(map[uuid.UUID][]models.Schema)
 	for rows.Next() {
 		var s models.Schema
-		if err := rows.Scan(&s.ID, &s.EndpointID, &s.Source, &s.SchemaData, &s.Version, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		if err := rows.Scan(&s.ID, &s.EndpointID, &s.Source, &s.SchemaData, &s.Version, &s.ContentHash, &s.CreatedAt, &s.UpdatedAt); err != nil {
 			return nil, err
 		}
 		result[s.EndpointID] = append(result[s.EndpointID], s)
@@ -152,9 +208,46 @@ func (r *SchemaRepository) GetByEndpointIDs(ctx context.Context, endpointIDs []u
 func (r *SchemaRepository) GetByEndpointAndSource(ctx context.Context, endpointID uuid.UUID, source string) (*models.Schema, error) {
 	var schema models.Schema
 	err := r.db.Pool.QueryRow(ctx, `
-		SELECT id, endpoint_id, source, schema_data, version, created_at, updated_at
+		SELECT id, endpoint_id, source, schema_data, version, content_hash, created_at, updated_at
 		FROM schemas WHERE endpoint_id = $1 AND source = $2 ORDER BY version DESC LIMIT 1
-	`, endpointID, source).Scan(&schema.ID, &schema.EndpointID, &schema.Source, &schema.SchemaData, &schema.Version, &schema.CreatedAt, &schema.UpdatedAt)
+	`, endpointID, source).Scan(&schema.ID, &schema.EndpointID, &schema.Source, &schema.SchemaData, &schema.Version, &schema.ContentHash, &schema.CreatedAt, &schema.UpdatedAt)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return &schema, err
+}
+
+// ListVersions returns version metadata for an endpoint, newest first. An
+// empty source lists every source's timeline.
+func (r *SchemaRepository) ListVersions(ctx context.Context, endpointID uuid.UUID, source string) ([]models.SchemaVersion, error) {
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT id, endpoint_id, source, version, content_hash, created_at
+		FROM schemas WHERE endpoint_id = $1 AND ($2 = '' OR source = $2)
+		ORDER BY created_at DESC, source, version DESC
+	`, endpointID, source)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []models.SchemaVersion
+	for rows.Next() {
+		var v models.SchemaVersion
+		if err := rows.Scan(&v.ID, &v.EndpointID, &v.Source, &v.Version, &v.ContentHash, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+func (r *SchemaRepository) GetVersion(ctx context.Context, endpointID uuid.UUID, source string, version int) (*models.Schema, error) {
+	var schema models.Schema
+	err := r.db.Pool.QueryRow(ctx, `
+		SELECT id, endpoint_id, source, schema_data, version, content_hash, created_at, updated_at
+		FROM schemas WHERE endpoint_id = $1 AND source = $2 AND version = $3
+	`, endpointID, source, version).Scan(&schema.ID, &schema.EndpointID, &schema.Source, &schema.SchemaData, &schema.Version, &schema.ContentHash, &schema.CreatedAt, &schema.UpdatedAt)
 
 	if err == pgx.ErrNoRows {
 		return nil, nil
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/cohesion-api/cohesion_backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type SeverityPolicyRepository struct {
+	db *DB
+}
+
+func NewSeverityPolicyRepository(db *DB) *SeverityPolicyRepository {
+	return &SeverityPolicyRepository{db: db}
+}
+
+func (r *SeverityPolicyRepository) GetByProject(ctx context.Context, projectID uuid.UUID) (*models.SeverityPolicy, error) {
+	var p models.SeverityPolicy
+	err := r.db.Pool.QueryRow(ctx, `
+		SELECT project_id, rules, updated_by, updated_at
+		FROM severity_policies WHERE project_id = $1
+	`, projectID).Scan(&p.ProjectID, &p.Rules, &p.UpdatedBy, &p.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetByProjectIDs returns the custom rules of several projects keyed by
+// project. Projects without a policy are absent from the map.
+func (r *SeverityPolicyRepository) GetByProjectIDs(ctx context.Context, projectIDs []uuid.UUID) (map[uuid.UUID][]models.SeverityRule, error) {
+	result := make(map[uuid.UUID][]models.SeverityRule)
+	if len(projectIDs) == 0 {
+		return result, nil
+	}
+
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT project_id, rules FROM severity_policies WHERE project_id = ANY($1)
+	`, projectIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var projectID uuid.UUID
+		var rules []models.SeverityRule
+		if err := rows.Scan(&projectID, &rules); err != nil {
+			return nil, err
+		}
+		result[projectID] = rules
+	}
+	return result, rows.Err()
+}
+
+func (r *SeverityPolicyRepository) Upsert(ctx context.Context, policy *models.SeverityPolicy) error {
+	policy.UpdatedAt = time.Now()
+	if policy.Rules == nil {
+		policy.Rules = []models.SeverityRule{}
+	}
+
+	_, err := r.db.Pool.Exec(ctx, `
+		INSERT INTO severity_policies (project_id, rules, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (project_id)
+		DO UPDATE SET rules = EXCLUDED.rules, updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at
+	`, policy.ProjectID, policy.Rules, policy.UpdatedBy, policy.UpdatedAt)
+	return err
+}
+
+func (r *SeverityPolicyRepository) Delete(ctx context.Context, projectID uuid.UUID) error {
+	_, err := r.db.Pool.Exec(ctx, `DELETE FROM severity_policies WHERE project_id = $1`, projectID)
+	return err
+}
@@ -21,9 +21,9 @@ func NewUserSettingsRepository(db *DB) *UserSettingsRepository {
 func (r *UserSettingsRepository) GetByClerkUserID(ctx context.Context, clerkUserID string) (*models.UserSettings, error) {
 	var s models.UserSettings
 	err := r.db.Pool.QueryRow(ctx, `
-		SELECT id, clerk_user_id, gemini_api_key, gemini_model, github_token, created_at, updated_at
+		SELECT id, clerk_user_id, llm_provider, llm_api_key, llm_model, llm_base_url, github_token, created_at, updated_at
 		FROM user_settings WHERE clerk_user_id = $1
-	`, clerkUserID).Scan(&s.ID, &s.ClerkUserID, &s.GeminiAPIKey, &s.GeminiModel, &s.GitHubToken, &s.CreatedAt, &s.UpdatedAt)
+	`, clerkUserID).Scan(&s.ID, &s.ClerkUserID, &s.LLMProvider, &s.LLMAPIKey, &s.LLMModel, &s.LLMBaseURL, &s.GitHubToken, &s.CreatedAt, &s.UpdatedAt)
 
 	if err == pgx.ErrNoRows {
 		return nil, nil
@@ -32,8 +32,8 @@ func (r *UserSettingsRepository) GetByClerkUserID(ctx context.Context, clerkUser
 		return nil, err
 	}
 
-	if decrypted, err := crypto.Decrypt(s.GeminiAPIKey); err == nil {
-		s.GeminiAPIKey = decrypted
+	if decrypted, err := crypto.Decrypt(s.LLMAPIKey); err == nil {
+		s.LLMAPIKey = decrypted
 	}
 	if decrypted, err := crypto.Decrypt(s.GitHubToken); err == nil {
 		s.GitHubToken = decrypted
@@ -51,7 +51,7 @@ func (r *UserSettingsRepository) Upsert(ctx context.Context, settings *models.Us
 		settings.CreatedAt = now
 	}
 
-	encGemini, err := crypto.Encrypt(settings.GeminiAPIKey)
+	encLLM, err := crypto.Encrypt(settings.LLMAPIKey)
 	if err != nil {
 		return err
 	}
@@ -61,14 +61,16 @@ func (r *UserSettingsRepository) Upsert(ctx context.Context, settings *models.Us
 	}
 
 	_, err = r.db.Pool.Exec(ctx, `
-		INSERT INTO user_settings (id, clerk_user_id, gemini_api_key, gemini_model, github_token, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO user_settings (id, clerk_user_id, llm_provider, llm_api_key, llm_model, llm_base_url, github_token, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		ON CONFLICT (clerk_user_id) DO UPDATE SET
-			gemini_api_key = EXCLUDED.gemini_api_key,
-			gemini_model = EXCLUDED.gemini_model,
+			llm_provider = EXCLUDED.llm_provider,
+			llm_api_key = EXCLUDED.llm_api_key,
+			llm_model = EXCLUDED.llm_model,
+			llm_base_url = EXCLUDED.llm_base_url,
 			github_token = EXCLUDED.github_token,
 			updated_at = EXCLUDED.updated_at
-	`, settings.ID, settings.ClerkUserID, encGemini, settings.GeminiModel, encGitHub, settings.CreatedAt, settings.UpdatedAt)
+	`, settings.ID, settings.ClerkUserID, settings.LLMProvider, encLLM, settings.LLMModel, settings.LLMBaseURL, encGitHub, settings.CreatedAt, settings.UpdatedAt)
 
 	return err
 }
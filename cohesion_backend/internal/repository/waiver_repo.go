@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/cohesion-api/cohesion_backend/internal/models"
+	"github.com/google/uuid"
+)
+
+type WaiverRepository struct {
+	db *DB
+}
+
+func NewWaiverRepository(db *DB) *WaiverRepository {
+	return &WaiverRepository{db: db}
+}
+
+func (r *WaiverRepository) Create(ctx context.Context, waiver *models.Waiver) error {
+	waiver.ID = uuid.New()
+	waiver.CreatedAt = time.Now()
+	if waiver.Sources == nil {
+		waiver.Sources = []string{}
+	}
+
+	_, err := r.db.Pool.Exec(ctx, `
+		INSERT INTO diff_waivers (id, project_id, endpoint_pattern, method, field_path, mismatch_type,
+			sources, reason, expires_at, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, waiver.ID, waiver.ProjectID, waiver.EndpointPattern, waiver.Method, waiver.FieldPath, waiver.MismatchType,
+		waiver.Sources, waiver.Reason, waiver.ExpiresAt, waiver.CreatedBy, waiver.CreatedAt)
+	return err
+}
+
+func (r *WaiverRepository) ListByProject(ctx context.Context, projectID uuid.UUID) ([]models.Waiver, error) {
+	return r.list(ctx, `WHERE project_id = $1`, projectID)
+}
+
+// ListByProjectIDs returns the waivers of several projects keyed by project.
+func (r *WaiverRepository) ListByProjectIDs(ctx context.Context, projectIDs []uuid.UUID) (map[uuid.UUID][]models.Waiver, error) {
+	result := make(map[uuid.UUID][]models.Waiver)
+	if len(projectIDs) == 0 {
+		return result, nil
+	}
+
+	waivers, err := r.list(ctx, `WHERE project_id = ANY($1)`, projectIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range waivers {
+		result[w.ProjectID] = append(result[w.ProjectID], w)
+	}
+	return result, nil
+}
+
+func (r *WaiverRepository) list(ctx context.Context, where string, arg interface{}) ([]models.Waiver, error) {
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT id, project_id, endpoint_pattern, method, field_path, mismatch_type,
+			sources, reason, expires_at, created_by, created_at
+		FROM diff_waivers `+where+` ORDER BY created_at DESC
+	`, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var waivers []models.Waiver
+	for rows.Next() {
+		var w models.Waiver
+		if err := rows.Scan(&w.ID, &w.ProjectID, &w.EndpointPattern, &w.Method, &w.FieldPath, &w.MismatchType,
+			&w.Sources, &w.Reason, &w.ExpiresAt, &w.CreatedBy, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		waivers = append(waivers, w)
+	}
+	return waivers, rows.Err()
+}
+
+func (r *WaiverRepository) Delete(ctx context.Context, projectID, id uuid.UUID) error {
+	tag, err := r.db.Pool.Exec(ctx, `DELETE FROM diff_waivers WHERE id = $1 AND project_id = $2`, id, projectID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
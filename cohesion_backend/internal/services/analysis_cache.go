@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/cohesion-api/cohesion_backend/internal/models"
+	"github.com/cohesion-api/cohesion_backend/internal/repository"
+	"github.com/cohesion-api/cohesion_backend/pkg/analyzer"
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+	"github.com/google/uuid"
+)
+
+// AnalysisCacheService keeps the LLM analysis results of each project's
+// scans so rescans only analyze what changed. Entries are keyed by the
+// content of every file a chunk's analysis read, including its shared
+// context, so they never go stale; invalidating only forces a fresh
+// analysis.
+type AnalysisCacheService struct {
+	repo *repository.AnalysisCacheRepository
+}
+
+func NewAnalysisCacheService(repo *repository.AnalysisCacheRepository) *AnalysisCacheService {
+	return &AnalysisCacheService{repo: repo}
+}
+
+// ForProject returns the cache a project's scans read and fill. Projects
+// do not share entries.
+func (s *AnalysisCacheService) ForProject(projectID uuid.UUID) analyzer.Cache {
+	return &projectAnalysisCache{repo: s.repo, projectID: projectID}
+}
+
+func (s *AnalysisCacheService) Stats(ctx context.Context, projectID uuid.UUID) (*models.AnalysisCacheStats, error) {
+	usage, err := s.repo.UsageByProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.AnalysisCacheStats{Analyzers: usage}
+	if stats.Analyzers == nil {
+		stats.Analyzers = []models.AnalysisCacheUsage{}
+	}
+	for _, u := range usage {
+		stats.Entries += u.Entries
+		stats.SizeBytes += u.SizeBytes
+	}
+	return stats, nil
+}
+
+// Invalidate drops a project's cached results, only those of the analyzer
+// with the given fingerprint when it is not empty, so the next scan
+// analyzes every file again.
+func (s *AnalysisCacheService) Invalidate(ctx context.Context, projectID uuid.UUID, fingerprint string) (int64, error) {
+	return s.repo.DeleteByProject(ctx, projectID, fingerprint)
+}
+
+type projectAnalysisCache struct {
+	repo      *repository.AnalysisCacheRepository
+	projectID uuid.UUID
+}
+
+func (c *projectAnalysisCache) Get(ctx context.Context, key string) ([]*schemair.SchemaIR, bool, error) {
+	raw, ok, err := c.repo.Get(ctx, c.projectID, key)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	var schemas []*schemair.SchemaIR
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &schemas); err != nil {
+			return nil, false, err
+		}
+	}
+	return schemas, true, nil
+}
+
+func (c *projectAnalysisCache) Put(ctx context.Context, key, fingerprint string, schemas []*schemair.SchemaIR) error {
+	raw, err := json.Marshal(schemas)
+	if err != nil {
+		return err
+	}
+	return c.repo.Put(ctx, c.projectID, key, fingerprint, raw)
+}
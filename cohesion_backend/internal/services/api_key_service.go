@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cohesion-api/cohesion_backend/internal/models"
+	"github.com/cohesion-api/cohesion_backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// lastUsedGranularity limits how often a key's last use is written, so a
+// busy capture agent does not update its row on every request.
+const lastUsedGranularity = time.Minute
+
+var knownScopes = map[string]bool{
+	models.ScopeIngest: true,
+	models.ScopeRead:   true,
+	models.ScopeScan:   true,
+}
+
+type APIKeyService struct {
+	apiKeyRepo *repository.APIKeyRepository
+}
+
+func NewAPIKeyService(apiKeyRepo *repository.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{apiKeyRepo: apiKeyRepo}
+}
+
+// ValidateScopes checks that scopes is a non-empty list of known scopes.
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !knownScopes[scope] {
+			return fmt.Errorf("invalid scope %q", scope)
+		}
+	}
+	return nil
+}
+
+// Create issues a key for the project and returns it alongside the secret
+// token, which is not stored and cannot be retrieved again. Scopes must
+// have been validated by the caller.
+func (s *APIKeyService) Create(ctx context.Context, projectID uuid.UUID, name string, scopes []string, createdBy string) (*models.APIKey, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	token := models.APIKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	key := &models.APIKey{
+		ProjectID: projectID,
+		Name:      name,
+		Prefix:    token[:len(models.APIKeyPrefix)+8],
+		KeyHash:   hashAPIKey(token),
+		Scopes:    scopes,
+		CreatedBy: createdBy,
+	}
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+	return key, token, nil
+}
+
+func (s *APIKeyService) List(ctx context.Context, projectID uuid.UUID) ([]models.APIKey, error) {
+	return s.apiKeyRepo.ListByProject(ctx, projectID)
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, projectID, keyID uuid.UUID) error {
+	return s.apiKeyRepo.Revoke(ctx, projectID, keyID)
+}
+
+// VerifyAPIKey returns the active key matching token, or nil if there is
+// none, and records its use.
+func (s *APIKeyService) VerifyAPIKey(ctx context.Context, token string) (*models.APIKey, error) {
+	if !strings.HasPrefix(token, models.APIKeyPrefix) {
+		return nil, nil
+	}
+	key, err := s.apiKeyRepo.GetByHash(ctx, hashAPIKey(token))
+	if err != nil || key == nil || key.RevokedAt != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedGranularity {
+		if err := s.apiKeyRepo.TouchLastUsed(ctx, key.ID, now); err != nil {
+			return nil, err
+		}
+		key.LastUsedAt = &now
+	}
+	return key, nil
+}
+
+// hashAPIKey hashes a key for storage. Keys carry 256 bits of randomness,
+// so a fast unsalted hash is enough and allows lookup by hash.
+func hashAPIKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
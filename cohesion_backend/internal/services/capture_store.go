@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cohesion-api/cohesion_backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// maxCaptureIDLength is the longest capture ID the store accepts. Longer
+// client-supplied IDs are replaced.
+const maxCaptureIDLength = 64
+
+// Column sizes of the other bounded capture fields. Longer values are
+// truncated on ingest so one oversized capture cannot fail its batch.
+const (
+	maxCaptureMethodLength      = 10
+	maxCaptureSourceLength      = 255
+	maxCaptureContentTypeLength = 255
+)
+
+// truncate shortens s to at most n bytes without splitting a character.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// CaptureStore persists live captures beyond the in-memory buffer.
+// *repository.LiveCaptureRepository is the Postgres implementation.
+type CaptureStore interface {
+	Append(ctx context.Context, projectID uuid.UUID, captures []models.LiveCapture) error
+	List(ctx context.Context, projectID uuid.UUID, filter models.LiveCaptureFilter) ([]models.LiveCapture, error)
+	DeleteByProject(ctx context.Context, projectID uuid.UUID) error
+	Prune(ctx context.Context, defaultMaxAge time.Duration, defaultMaxCount int) (int64, error)
+	GetRetention(ctx context.Context, projectID uuid.UUID) (*models.CaptureRetention, error)
+	UpsertRetention(ctx context.Context, rt *models.CaptureRetention) error
+}
+
+// GetRetention returns the project's capture retention. Limits the project
+// leaves unset are reported as the server defaults.
+func (s *LiveService) GetRetention(ctx context.Context, projectID uuid.UUID) (*models.CaptureRetention, error) {
+	rt := &models.CaptureRetention{ProjectID: projectID}
+	if s.store != nil {
+		stored, err := s.store.GetRetention(ctx, projectID)
+		if err != nil {
+			return nil, err
+		}
+		if stored != nil {
+			rt = stored
+		}
+	}
+	if rt.MaxAgeHours == nil {
+		rt.MaxAgeHours = s.retention.MaxAgeHours
+	}
+	if rt.MaxCount == nil {
+		rt.MaxCount = s.retention.MaxCount
+	}
+	return rt, nil
+}
+
+// UpdateRetention stores the project's capture retention. Nil limits select
+// the server defaults and zero disables a limit.
+func (s *LiveService) UpdateRetention(ctx context.Context, rt *models.CaptureRetention) error {
+	if (rt.MaxAgeHours != nil && *rt.MaxAgeHours < 0) || (rt.MaxCount != nil && *rt.MaxCount < 0) {
+		return fmt.Errorf("retention limits must not be negative")
+	}
+	if s.store == nil {
+		return fmt.Errorf("capture storage is not configured")
+	}
+	return s.store.UpsertRetention(ctx, rt)
+}
+
+// RunRetention prunes stored captures every interval until ctx is done.
+func (s *LiveService) RunRetention(ctx context.Context, interval time.Duration) {
+	if s.store == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.prune(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *LiveService) prune(ctx context.Context) {
+	var maxAge time.Duration
+	if s.retention.MaxAgeHours != nil {
+		maxAge = time.Duration(*s.retention.MaxAgeHours) * time.Hour
+	}
+	var maxCount int
+	if s.retention.MaxCount != nil {
+		maxCount = *s.retention.MaxCount
+	}
+	deleted, err := s.store.Prune(ctx, maxAge, maxCount)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("[live] capture retention failed: %v", err)
+		}
+		return
+	}
+	if deleted > 0 {
+		log.Printf("[live] pruned %d stored captures", deleted)
+	}
+}
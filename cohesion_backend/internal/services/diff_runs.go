@@ -0,0 +1,281 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	"github.com/cohesion-api/cohesion_backend/internal/models"
+	"github.com/cohesion-api/cohesion_backend/pkg/diff"
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+	"github.com/google/uuid"
+)
+
+const (
+	diffRunWorkers = 8
+	diffRunsListed = 50
+)
+
+// RunProjectDiff diffs every endpoint of a project concurrently and records
+// the outcome as a run. The per-endpoint results are returned alongside the
+// run so callers can render them without reading them back.
+func (s *DiffService) RunProjectDiff(ctx context.Context, projectID uuid.UUID, commitSHA string) (*models.DiffRun, []*diff.Result, error) {
+	endpoints, err := s.endpointRepo.GetByProjectWithSchemas(ctx, projectID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	engine, err := s.ProjectEngine(ctx, projectID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	run := &models.DiffRun{ProjectID: projectID, CommitSHA: commitSHA}
+	if err := s.diffRepo.CreateRun(ctx, run); err != nil {
+		return nil, nil, err
+	}
+
+	results := make([]*diff.Result, len(endpoints))
+	diffIDs := make([]uuid.UUID, len(endpoints))
+	errs := make([]error, len(endpoints))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < diffRunWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				endpoint := endpoints[i]
+				schemaIRs, _ := schemasToIR(endpoint.Schemas)
+				results[i] = engine.Compare(endpoint.Path, endpoint.Method, schemaIRs)
+				if len(schemaIRs) < 2 {
+					continue
+				}
+
+				d := resultToDiff(endpoint.ID, results[i])
+				d.RunID = &run.ID
+				if err := s.diffRepo.Create(ctx, d); err != nil {
+					errs[i] = fmt.Errorf("%s %s: %w", endpoint.Method, endpoint.Path, err)
+					continue
+				}
+				diffIDs[i] = d.ID
+			}
+		}()
+	}
+	for i := range endpoints {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	run.Status = models.DiffRunCompleted
+	for i, endpoint := range endpoints {
+		if errs[i] != nil {
+			run.Status = models.DiffRunFailed
+			if run.Error == "" {
+				run.Error = errs[i].Error()
+			}
+			continue
+		}
+		if diffIDs[i] == uuid.Nil {
+			run.SkippedCount++
+			continue
+		}
+		run.Results = append(run.Results, runResult(diffIDs[i], endpoint.ID, results[i]))
+	}
+	tallyRun(run)
+
+	// Record the outcome even if the caller has gone away.
+	if err := s.diffRepo.FinishRun(context.WithoutCancel(ctx), run); err != nil {
+		return nil, nil, err
+	}
+	if run.Status == models.DiffRunFailed {
+		return run, results, fmt.Errorf("diff run %s failed: %s", run.ID, run.Error)
+	}
+	return run, results, nil
+}
+
+func runResult(diffID, endpointID uuid.UUID, result *diff.Result) models.DiffRunResult {
+	r := models.DiffRunResult{
+		DiffID:     diffID,
+		EndpointID: endpointID,
+		Path:       result.Endpoint,
+		Method:     result.Method,
+		Status:     string(result.Status),
+	}
+	for _, m := range result.ActiveMismatches() {
+		switch m.Severity {
+		case diff.SeverityCritical:
+			r.CriticalCount++
+		case diff.SeverityWarning:
+			r.WarningCount++
+		case diff.SeverityInfo:
+			r.InfoCount++
+		}
+	}
+	return r
+}
+
+func tallyRun(run *models.DiffRun) {
+	run.EndpointCount = len(run.Results)
+	for _, r := range run.Results {
+		switch schemair.MatchStatus(r.Status) {
+		case schemair.StatusMatch:
+			run.MatchedCount++
+		case schemair.StatusPartial:
+			run.PartialCount++
+		case schemair.StatusViolation:
+			run.ViolationCount++
+		}
+		run.CriticalCount += r.CriticalCount
+		run.WarningCount += r.WarningCount
+		run.InfoCount += r.InfoCount
+	}
+}
+
+func (s *DiffService) ListRuns(ctx context.Context, projectID uuid.UUID) ([]models.DiffRun, error) {
+	return s.diffRepo.ListRuns(ctx, projectID, diffRunsListed)
+}
+
+// GetRun loads a run with its per-endpoint results.
+func (s *DiffService) GetRun(ctx context.Context, runID uuid.UUID) (*models.DiffRun, error) {
+	run, err := s.diffRepo.GetRun(ctx, runID)
+	if err != nil || run == nil {
+		return run, err
+	}
+
+	_, results, err := s.loadRunResults(ctx, run.ID)
+	if err != nil {
+		return nil, err
+	}
+	run.Results = results
+	return run, nil
+}
+
+func (s *DiffService) loadRunResults(ctx context.Context, runID uuid.UUID) (map[uuid.UUID]*diff.Result, []models.DiffRunResult, error) {
+	diffs, err := s.diffRepo.GetByRunID(ctx, runID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byEndpoint := make(map[uuid.UUID]*diff.Result, len(diffs))
+	summaries := make([]models.DiffRunResult, 0, len(diffs))
+	for _, d := range diffs {
+		result, err := diffToResult(d)
+		if err != nil {
+			log.Printf("WARNING: failed to decode diff %s of run %s: %v", d.ID, runID, err)
+			continue
+		}
+		byEndpoint[d.EndpointID] = result
+		summaries = append(summaries, runResult(d.ID, d.EndpointID, result))
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Path != summaries[j].Path {
+			return summaries[i].Path < summaries[j].Path
+		}
+		return summaries[i].Method < summaries[j].Method
+	})
+	return byEndpoint, summaries, nil
+}
+
+type RunComparison struct {
+	BaseRunID  uuid.UUID          `json:"base_run_id"`
+	HeadRunID  uuid.UUID          `json:"head_run_id"`
+	Introduced int                `json:"introduced"`
+	Resolved   int                `json:"resolved"`
+	Endpoints  []EndpointRunDelta `json:"endpoints"`
+}
+
+// EndpointRunDelta describes how one endpoint changed between two runs. An
+// empty status means the endpoint was not compared in that run.
+type EndpointRunDelta struct {
+	EndpointID uuid.UUID       `json:"endpoint_id"`
+	Path       string          `json:"path"`
+	Method     string          `json:"method"`
+	BaseStatus string          `json:"base_status"`
+	HeadStatus string          `json:"head_status"`
+	Introduced []diff.Mismatch `json:"introduced"`
+	Resolved   []diff.Mismatch `json:"resolved"`
+}
+
+// CompareRuns reports mismatches introduced and resolved between two runs.
+// Mismatches are matched by type and field path; a newly waived mismatch
+// counts as resolved. Endpoints that did not change are omitted.
+func (s *DiffService) CompareRuns(ctx context.Context, base, head *models.DiffRun) (*RunComparison, error) {
+	baseResults, _, err := s.loadRunResults(ctx, base.ID)
+	if err != nil {
+		return nil, err
+	}
+	headResults, _, err := s.loadRunResults(ctx, head.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	cmp := &RunComparison{
+		BaseRunID: base.ID,
+		HeadRunID: head.ID,
+		Endpoints: []EndpointRunDelta{},
+	}
+
+	endpointIDs := make(map[uuid.UUID]bool)
+	for id := range baseResults {
+		endpointIDs[id] = true
+	}
+	for id := range headResults {
+		endpointIDs[id] = true
+	}
+
+	for id := range endpointIDs {
+		before, after := baseResults[id], headResults[id]
+		delta := EndpointRunDelta{EndpointID: id}
+		var beforeMismatches, afterMismatches []diff.Mismatch
+		if before != nil {
+			delta.Path, delta.Method, delta.BaseStatus = before.Endpoint, before.Method, string(before.Status)
+			beforeMismatches = before.ActiveMismatches()
+		}
+		if after != nil {
+			delta.Path, delta.Method, delta.HeadStatus = after.Endpoint, after.Method, string(after.Status)
+			afterMismatches = after.ActiveMismatches()
+		}
+
+		delta.Introduced = mismatchesNotIn(afterMismatches, beforeMismatches)
+		delta.Resolved = mismatchesNotIn(beforeMismatches, afterMismatches)
+		if len(delta.Introduced) == 0 && len(delta.Resolved) == 0 && delta.BaseStatus == delta.HeadStatus {
+			continue
+		}
+
+		cmp.Introduced += len(delta.Introduced)
+		cmp.Resolved += len(delta.Resolved)
+		cmp.Endpoints = append(cmp.Endpoints, delta)
+	}
+
+	sort.Slice(cmp.Endpoints, func(i, j int) bool {
+		if cmp.Endpoints[i].Path != cmp.Endpoints[j].Path {
+			return cmp.Endpoints[i].Path < cmp.Endpoints[j].Path
+		}
+		return cmp.Endpoints[i].Method < cmp.Endpoints[j].Method
+	})
+	return cmp, nil
+}
+
+// GetPreviousRun returns the completed run before the given one, if any.
+func (s *DiffService) GetPreviousRun(ctx context.Context, run *models.DiffRun) (*models.DiffRun, error) {
+	return s.diffRepo.GetPreviousRun(ctx, run.ProjectID, run.StartedAt)
+}
+
+func mismatchesNotIn(a, b []diff.Mismatch) []diff.Mismatch {
+	seen := make(map[string]bool, len(b))
+	for _, m := range b {
+		seen[string(m.Type)+"|"+m.Path] = true
+	}
+	out := []diff.Mismatch{}
+	for _, m := range a {
+		if !seen[string(m.Type)+"|"+m.Path] {
+			out = append(out, m)
+		}
+	}
+	return out
+}
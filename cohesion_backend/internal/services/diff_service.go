@@ -17,14 +17,18 @@ type DiffService struct {
 	diffRepo     *repository.DiffRepository
 	schemaRepo   *repository.SchemaRepository
 	endpointRepo *repository.EndpointRepository
+	waiverRepo   *repository.WaiverRepository
+	policyRepo   *repository.SeverityPolicyRepository
 	diffEngine   *diff.Engine
 }
 
-func NewDiffService(diffRepo *repository.DiffRepository, schemaRepo *repository.SchemaRepository, endpointRepo *repository.EndpointRepository) *DiffService {
+func NewDiffService(diffRepo *repository.DiffRepository, schemaRepo *repository.SchemaRepository, endpointRepo *repository.EndpointRepository, waiverRepo *repository.WaiverRepository, policyRepo *repository.SeverityPolicyRepository) *DiffService {
 	return &DiffService{
 		diffRepo:     diffRepo,
 		schemaRepo:   schemaRepo,
 		endpointRepo: endpointRepo,
+		waiverRepo:   waiverRepo,
+		policyRepo:   policyRepo,
 		diffEngine:   diff.NewEngine(),
 	}
 }
@@ -74,31 +78,49 @@ func (s *DiffService) ComputeDiff(ctx context.Context, endpointID uuid.UUID) (*d
 		return nil, err
 	}
 
+	engine, err := s.ProjectEngine(ctx, endpoint.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Always go through the engine so we get a proper Confidence object
 	schemaIRs, _ := schemasToIR(schemas)
-	result := s.diffEngine.Compare(endpoint.Path, endpoint.Method, schemaIRs)
+	result := engine.Compare(endpoint.Path, endpoint.Method, schemaIRs)
 
 	if len(schemaIRs) >= 2 {
-		diffData := map[string]interface{}{
+		if err := s.diffRepo.Create(ctx, resultToDiff(endpointID, result)); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func resultToDiff(endpointID uuid.UUID, result *diff.Result) *models.Diff {
+	return &models.Diff{
+		EndpointID: endpointID,
+		DiffData: map[string]interface{}{
 			"endpoint":         result.Endpoint,
 			"method":           result.Method,
 			"status":           result.Status,
 			"mismatches":       result.Mismatches,
+			"suppressed_count": result.SuppressedCount,
 			"sources_compared": result.SourcesCompared,
-		}
-
-		diffModel := &models.Diff{
-			EndpointID:      endpointID,
-			DiffData:        diffData,
-			SourcesCompared: formatSources(result.SourcesCompared),
-		}
-
-		if err := s.diffRepo.Create(ctx, diffModel); err != nil {
-			return nil, err
-		}
+		},
+		SourcesCompared: formatSources(result.SourcesCompared),
 	}
+}
 
-	return result, nil
+func diffToResult(d models.Diff) (*diff.Result, error) {
+	data, err := json.Marshal(d.DiffData)
+	if err != nil {
+		return nil, err
+	}
+	var result diff.Result
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
 }
 
 type DiffStats struct {
@@ -119,6 +141,16 @@ func (s *DiffService) ComputeStats(ctx context.Context, projectIDs []uuid.UUID)
 		return nil, err
 	}
 
+	waivers, err := s.waiverRepo.ListByProjectIDs(ctx, projectIDs)
+	if err != nil {
+		return nil, err
+	}
+	policies, err := s.policyRepo.GetByProjectIDs(ctx, projectIDs)
+	if err != nil {
+		return nil, err
+	}
+	engines := make(map[uuid.UUID]*diff.Engine)
+
 	for _, endpoint := range endpoints {
 		sources := make(map[string]bool)
 		for _, schema := range endpoint.Schemas {
@@ -133,7 +165,15 @@ func (s *DiffService) ComputeStats(ctx context.Context, projectIDs []uuid.UUID)
 			continue
 		}
 
-		result := s.diffEngine.Compare(endpoint.Path, endpoint.Method, schemaIRs)
+		engine, ok := engines[endpoint.ProjectID]
+		if !ok {
+			engine = s.diffEngine.
+				WithPolicy(diff.WithDefaults(toPolicyRules(policies[endpoint.ProjectID]))).
+				WithWaivers(toDiffWaivers(waivers[endpoint.ProjectID]))
+			engines[endpoint.ProjectID] = engine
+		}
+
+		result := engine.Compare(endpoint.Path, endpoint.Method, schemaIRs)
 		switch result.Status {
 		case schemair.StatusMatch:
 			stats.Matched++
@@ -146,6 +186,49 @@ func (s *DiffService) ComputeStats(ctx context.Context, projectIDs []uuid.UUID)
 	return stats, nil
 }
 
+// ComputeProjectDiff compares every endpoint of a project without persisting
+// anything. Endpoints with fewer than two sources are included so reports
+// list them as unchecked.
+func (s *DiffService) ComputeProjectDiff(ctx context.Context, projectID uuid.UUID) ([]*diff.Result, error) {
+	endpoints, err := s.endpointRepo.GetByProjectWithSchemas(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	engine, err := s.ProjectEngine(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*diff.Result, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		schemaIRs, _ := schemasToIR(endpoint.Schemas)
+		results = append(results, engine.Compare(endpoint.Path, endpoint.Method, schemaIRs))
+	}
+	return results, nil
+}
+
+// ProjectEngine returns the diff engine configured with the project's
+// severity policy and waivers. Schemas that are not stored, such as those
+// inferred from live captures, are compared with it directly.
+func (s *DiffService) ProjectEngine(ctx context.Context, projectID uuid.UUID) (*diff.Engine, error) {
+	waivers, err := s.waiverRepo.ListByProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := s.policyRepo.GetByProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	var rules []models.SeverityRule
+	if policy != nil {
+		rules = policy.Rules
+	}
+	return s.diffEngine.
+		WithPolicy(diff.WithDefaults(toPolicyRules(rules))).
+		WithWaivers(toDiffWaivers(waivers)), nil
+}
+
 func (s *DiffService) GetLatestDiff(ctx context.Context, endpointID uuid.UUID) (*models.Diff, error) {
 	return s.diffRepo.GetLatestByEndpoint(ctx, endpointID)
 }
@@ -160,3 +243,47 @@ func formatSources(sources []schemair.SchemaSource) string {
 	}
 	return result
 }
+
+// ComputeChanges compares two stored versions of one source's schema. A zero
+// to selects the latest version and a zero from selects the one before to.
+func (s *DiffService) ComputeChanges(ctx context.Context, endpointID uuid.UUID, source string, from, to int) (*diff.ChangeReport, error) {
+	endpoint, err := s.endpointRepo.GetByID(ctx, endpointID)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint == nil {
+		return nil, repository.ErrNotFound
+	}
+
+	var after *models.Schema
+	if to == 0 {
+		after, err = s.schemaRepo.GetByEndpointAndSource(ctx, endpointID, source)
+	} else {
+		after, err = s.schemaRepo.GetVersion(ctx, endpointID, source, to)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if after == nil {
+		return nil, repository.ErrNotFound
+	}
+
+	if from == 0 {
+		from = after.Version - 1
+	}
+	before, err := s.schemaRepo.GetVersion(ctx, endpointID, source, from)
+	if err != nil {
+		return nil, err
+	}
+	if before == nil {
+		return nil, repository.ErrNotFound
+	}
+
+	beforeIR, _ := schemasToIR([]models.Schema{*before})
+	afterIR, _ := schemasToIR([]models.Schema{*after})
+	if len(beforeIR) == 0 || len(afterIR) == 0 {
+		return nil, fmt.Errorf("schema version for %s could not be parsed", source)
+	}
+
+	return s.diffEngine.CompareVersions(endpoint.Path, endpoint.Method, beforeIR[0], afterIR[0], before.Version, after.Version), nil
+}
@@ -50,3 +50,21 @@ func (s *EndpointService) GetOrCreate(ctx context.Context, projectID uuid.UUID,
 	}
 	return endpoint, nil
 }
+
+// PathTemplates returns the distinct endpoint paths of a project, used to
+// map observed request paths onto known routes.
+func (s *EndpointService) PathTemplates(ctx context.Context, projectID uuid.UUID) ([]string, error) {
+	endpoints, err := s.endpointRepo.GetByProjectID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(endpoints))
+	paths := make([]string, 0, len(endpoints))
+	for _, ep := range endpoints {
+		if !seen[ep.Path] {
+			seen[ep.Path] = true
+			paths = append(paths, ep.Path)
+		}
+	}
+	return paths, nil
+}
@@ -2,29 +2,23 @@ package services
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
 	"io"
+	"log"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/cohesion-api/cohesion_backend/internal/models"
+	"github.com/cohesion-api/cohesion_backend/internal/repository"
 	"github.com/cohesion-api/cohesion_backend/pkg/runtime"
 	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
 	"github.com/google/uuid"
 )
 
-type LiveRequest struct {
-	ID           string                 `json:"id"`
-	Timestamp    time.Time              `json:"timestamp"`
-	Path         string                 `json:"path"`
-	Method       string                 `json:"method"`
-	StatusCode   int                    `json:"status_code"`
-	DurationMs   float64                `json:"duration_ms"`
-	RequestBody  map[string]interface{} `json:"request_body,omitempty"`
-	ResponseBody map[string]interface{} `json:"response_body,omitempty"`
-	Source       string                 `json:"source,omitempty"`
-}
+// LiveRequest is a captured request as buffered, streamed and stored.
+type LiveRequest = models.LiveCapture
 
 type LiveEvent struct {
 	Type    string      `json:"type"` // "request" or "clear"
@@ -32,22 +26,41 @@ type LiveEvent struct {
 	Source  string      `json:"source,omitempty"`
 }
 
+// projectBuffer holds a project's most recent captures. IDs are tracked so
+// a batch retried after a lost response is not buffered or streamed twice.
 type projectBuffer struct {
 	data    []LiveRequest
+	ids     map[string]bool
 	maxSize int
 	head    int
 	count   int
 }
 
-func (b *projectBuffer) add(req LiveRequest) {
+func newProjectBuffer(maxSize int) *projectBuffer {
+	return &projectBuffer{
+		data:    make([]LiveRequest, 0, maxSize),
+		ids:     make(map[string]bool, maxSize),
+		maxSize: maxSize,
+	}
+}
+
+// add buffers req unless a capture with its ID is already buffered, and
+// reports whether it did.
+func (b *projectBuffer) add(req LiveRequest) bool {
+	if b.ids[req.ID] {
+		return false
+	}
+	b.ids[req.ID] = true
 	if b.count < b.maxSize {
 		b.data = append(b.data, req)
 		b.count++
 		b.head = b.count % b.maxSize
 	} else {
+		delete(b.ids, b.data[b.head].ID)
 		b.data[b.head] = req
 		b.head = (b.head + 1) % b.maxSize
 	}
+	return true
 }
 
 func (b *projectBuffer) all() []LiveRequest {
@@ -62,30 +75,43 @@ func (b *projectBuffer) all() []LiveRequest {
 	return result
 }
 
-func (b *projectBuffer) clear() {
-	b.data = b.data[:0]
-	b.head = 0
-	b.count = 0
-}
-
 type captureEntry struct {
 	ownerID string
 }
 
+// LiveService buffers the most recent captures of every project in memory
+// for the SSE stream and inference, and persists all of them to the capture
+// store when one is configured.
 type LiveService struct {
 	mu          sync.RWMutex
 	buffers     map[uuid.UUID]*projectBuffer
 	subscribers map[uuid.UUID]map[chan LiveEvent]struct{}
 	maxPerProj  int
 	captures    map[uuid.UUID]captureEntry
+
+	store     CaptureStore
+	retention models.CaptureRetention
+
+	redactionRepo *repository.RedactionPolicyRepository
+	redactors     map[uuid.UUID]*runtime.Redactor
 }
 
-func NewLiveService() *LiveService {
+// NewLiveService returns a live service persisting to store, which may be
+// nil to keep captures in memory only. Captures are redacted with each
+// project's rules from redactionRepo, or only the defaults if it is nil.
+// retention holds the defaults for projects without their own retention
+// settings.
+func NewLiveService(store CaptureStore, redactionRepo *repository.RedactionPolicyRepository, retention models.CaptureRetention) *LiveService {
 	return &LiveService{
 		buffers:     make(map[uuid.UUID]*projectBuffer),
 		subscribers: make(map[uuid.UUID]map[chan LiveEvent]struct{}),
 		captures:    make(map[uuid.UUID]captureEntry),
 		maxPerProj:  200,
+		store:       store,
+		retention:   retention,
+
+		redactionRepo: redactionRepo,
+		redactors:     make(map[uuid.UUID]*runtime.Redactor),
 	}
 }
 
@@ -153,16 +179,17 @@ func (s *LiveService) SelfCaptureMiddleware(userIDFunc func(r *http.Request) str
 				return
 			}
 
-			if strings.HasPrefix(r.URL.Path, "/api/live/") {
+			// Event streams never finish, so there is no response to capture.
+			if strings.HasPrefix(r.URL.Path, "/api/live/") || strings.HasSuffix(r.URL.Path, "/stream") {
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			var reqBody map[string]interface{}
+			var reqBody interface{}
 			if r.Body != nil && r.ContentLength != 0 {
 				bodyBytes, _ := io.ReadAll(r.Body)
 				r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-				json.Unmarshal(bodyBytes, &reqBody)
+				reqBody = runtime.DecodeBody(r.Header.Get("Content-Type"), bodyBytes)
 			}
 
 			start := time.Now()
@@ -177,9 +204,6 @@ func (s *LiveService) SelfCaptureMiddleware(userIDFunc func(r *http.Request) str
 
 			duration := time.Since(start)
 
-			var respBody map[string]interface{}
-			json.Unmarshal(crw.body.Bytes(), &respBody)
-
 			capture := LiveRequest{
 				ID:           uuid.New().String(),
 				Timestamp:    start,
@@ -188,56 +212,152 @@ func (s *LiveService) SelfCaptureMiddleware(userIDFunc func(r *http.Request) str
 				StatusCode:   crw.statusCode,
 				DurationMs:   float64(duration.Milliseconds()),
 				RequestBody:  reqBody,
-				ResponseBody: respBody,
+				ResponseBody: runtime.DecodeBody(crw.Header().Get("Content-Type"), crw.body.Bytes()),
 				Source:       "self",
+				QueryParams:  runtime.CaptureQuery(r.URL.Query()),
+				Headers:      runtime.CaptureHeaders(r.Header),
+
+				RequestContentType:  r.Header.Get("Content-Type"),
+				ResponseContentType: crw.Header().Get("Content-Type"),
 			}
 
-			s.IngestRequests(projectID, []LiveRequest{capture})
+			if err := s.IngestRequests(r.Context(), projectID, []LiveRequest{capture}); err != nil {
+				log.Printf("[live] failed to store self-capture for project %s: %v", projectID, err)
+			}
 		})
 	}
 }
 
-func (s *LiveService) IngestRequests(projectID uuid.UUID, requests []LiveRequest) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	buf, ok := s.buffers[projectID]
-	if !ok {
-		buf = &projectBuffer{
-			data:    make([]LiveRequest, 0, s.maxPerProj),
-			maxSize: s.maxPerProj,
-		}
-		s.buffers[projectID] = buf
-	}
+// IngestRequests redacts captures, adds them to the project's buffer,
+// streams them to subscribers and persists them. Captures whose ID is
+// already buffered, such as a retried batch, are only passed to the store,
+// which ignores IDs it has.
+func (s *LiveService) IngestRequests(ctx context.Context, projectID uuid.UUID, requests []LiveRequest) error {
+	s.warm(ctx, projectID)
+	redactor := s.redactor(ctx, projectID)
 
 	for i := range requests {
 		req := &requests[i]
-		if req.ID == "" {
+		if req.ID == "" || len(req.ID) > maxCaptureIDLength {
 			req.ID = uuid.New().String()
 		}
 		if req.Timestamp.IsZero() {
 			req.Timestamp = time.Now()
 		}
-		buf.add(*req)
+		req.Method = truncate(req.Method, maxCaptureMethodLength)
+		req.Source = truncate(req.Source, maxCaptureSourceLength)
+		req.RequestContentType = truncate(req.RequestContentType, maxCaptureContentTypeLength)
+		req.ResponseContentType = truncate(req.ResponseContentType, maxCaptureContentTypeLength)
+		if req.Headers != nil {
+			req.Headers = runtime.SanitizeHeaders(req.Headers)
+		}
+		req.RequestBody = redactor.Redact(runtime.NormalizeBody(req.RequestContentType, req.RequestBody))
+		req.ResponseBody = redactor.Redact(runtime.NormalizeBody(req.ResponseContentType, req.ResponseBody))
+		req.QueryParams = redactor.RedactQuery(req.QueryParams)
+		req.Headers = redactor.RedactHeaders(req.Headers)
+	}
+
+	s.mu.Lock()
+	buf := s.bufferLocked(projectID)
+	for _, req := range requests {
+		if !buf.add(req) {
+			continue
+		}
 
 		s.broadcast(projectID, LiveEvent{
 			Type:    "request",
-			Payload: *req,
+			Payload: req,
 			Source:  req.Source,
 		})
 	}
+	s.mu.Unlock()
+
+	if s.store == nil {
+		return nil
+	}
+	return s.store.Append(ctx, projectID, requests)
 }
 
-func (s *LiveService) GetRecentRequests(projectID uuid.UUID) []LiveRequest {
+// GetRecentRequests returns a page of the project's captures, newest first,
+// from the store or, without one, from the buffer.
+func (s *LiveService) GetRecentRequests(ctx context.Context, projectID uuid.UUID, filter models.LiveCaptureFilter) ([]LiveRequest, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = s.maxPerProj
+	}
+	if s.store != nil {
+		return s.store.List(ctx, projectID, filter)
+	}
+
 	s.mu.RLock()
-	defer s.mu.RUnlock()
+	buf, ok := s.buffers[projectID]
+	var all []LiveRequest
+	if ok {
+		all = buf.all()
+	}
+	s.mu.RUnlock()
+
+	result := []LiveRequest{}
+	skipped := 0
+	for i := len(all) - 1; i >= 0 && len(result) < filter.Limit; i-- {
+		req := all[i]
+		if (filter.Source != "" && req.Source != filter.Source) ||
+			(filter.Since != nil && req.Timestamp.Before(*filter.Since)) ||
+			(filter.Until != nil && !req.Timestamp.Before(*filter.Until)) {
+			continue
+		}
+		if skipped < filter.Offset {
+			skipped++
+			continue
+		}
+		result = append(result, req)
+	}
+	return result, nil
+}
 
+func (s *LiveService) bufferLocked(projectID uuid.UUID) *projectBuffer {
 	buf, ok := s.buffers[projectID]
 	if !ok {
-		return []LiveRequest{}
+		buf = newProjectBuffer(s.maxPerProj)
+		s.buffers[projectID] = buf
+	}
+	return buf
+}
+
+// Warm loads a project's most recent stored captures into its buffer if it
+// has none yet, e.g. after a restart, so inference and the dual-source
+// views see earlier traffic.
+func (s *LiveService) Warm(ctx context.Context, projectID uuid.UUID) error {
+	if s.store == nil {
+		return nil
+	}
+	s.mu.RLock()
+	_, ok := s.buffers[projectID]
+	s.mu.RUnlock()
+	if ok {
+		return nil
+	}
+
+	recent, err := s.store.List(ctx, projectID, models.LiveCaptureFilter{Limit: s.maxPerProj})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.buffers[projectID]; ok {
+		return nil
 	}
+	buf := s.bufferLocked(projectID)
+	for i := len(recent) - 1; i >= 0; i-- {
+		buf.add(recent[i])
+	}
+	return nil
+}
 
-	return buf.all()
+func (s *LiveService) warm(ctx context.Context, projectID uuid.UUID) {
+	if err := s.Warm(ctx, projectID); err != nil {
+		log.Printf("[live] failed to load stored captures for project %s: %v", projectID, err)
+	}
 }
 
 func (s *LiveService) GetBufferedAsCaptured(projectID uuid.UUID) []runtime.CapturedRequest {
@@ -259,17 +379,25 @@ func (s *LiveService) GetBufferedAsCaptured(projectID uuid.UUID) []runtime.Captu
 			StatusCode:       req.StatusCode,
 			Response:         req.ResponseBody,
 			ObservationCount: 1,
+			QueryParams:      req.QueryParams,
+			Headers:          req.Headers,
+
+			RequestContentType:  req.RequestContentType,
+			ResponseContentType: req.ResponseContentType,
 		}
 	}
 	return result
 }
 
-func (s *LiveService) InferFromBuffer(projectID uuid.UUID) []*schemair.SchemaIR {
+// InferFromBuffer infers schemas from the project's buffered traffic.
+// Concrete paths are first mapped onto the known endpoint templates or onto
+// templates learned from the buffer.
+func (s *LiveService) InferFromBuffer(projectID uuid.UUID, known []string) []*schemair.SchemaIR {
 	captured := s.GetBufferedAsCaptured(projectID)
 	if len(captured) == 0 {
 		return nil
 	}
-	return runtime.InferSchema(captured)
+	return runtime.InferSchema(runtime.NewPathTemplater(known).Apply(captured))
 }
 
 func (s *LiveService) GetBufferedBySource(projectID uuid.UUID, source string) []LiveRequest {
@@ -309,18 +437,33 @@ func (s *LiveService) GetBufferedAsCapturedBySource(projectID uuid.UUID, source
 				StatusCode:       req.StatusCode,
 				Response:         req.ResponseBody,
 				ObservationCount: 1,
+				QueryParams:      req.QueryParams,
+				Headers:          req.Headers,
+
+				RequestContentType:  req.RequestContentType,
+				ResponseContentType: req.ResponseContentType,
 			})
 		}
 	}
 	return result
 }
 
-func (s *LiveService) InferFromBufferBySource(projectID uuid.UUID, source string) []*schemair.SchemaIR {
+// InferFromBufferBySource infers schemas from one source's buffered
+// traffic. Templates are learned from the whole buffer so every source of a
+// project maps a path onto the same endpoint.
+func (s *LiveService) InferFromBufferBySource(projectID uuid.UUID, source string, known []string) []*schemair.SchemaIR {
 	captured := s.GetBufferedAsCapturedBySource(projectID, source)
 	if len(captured) == 0 {
 		return nil
 	}
-	return runtime.InferSchema(captured)
+
+	all := s.GetBufferedAsCaptured(projectID)
+	paths := make([]string, len(all))
+	for i, req := range all {
+		paths[i] = req.Path
+	}
+	templates := runtime.NewPathTemplater(known).Learn(paths)
+	return runtime.InferSchema(runtime.ApplyTemplates(captured, templates))
 }
 
 func (s *LiveService) GetDistinctSources(projectID uuid.UUID) []string {
@@ -346,13 +489,22 @@ func (s *LiveService) GetDistinctSources(projectID uuid.UUID) []string {
 	return sources
 }
 
-func (s *LiveService) ClearBuffer(projectID uuid.UUID) {
+// ClearBuffer drops a project's buffered and stored captures.
+func (s *LiveService) ClearBuffer(ctx context.Context, projectID uuid.UUID) error {
+	if s.store != nil {
+		if err := s.store.DeleteByProject(ctx, projectID); err != nil {
+			return err
+		}
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	delete(s.buffers, projectID)
+	// An empty buffer rather than none, so it is not warmed from the store.
+	s.buffers[projectID] = newProjectBuffer(s.maxPerProj)
 
 	s.broadcast(projectID, LiveEvent{Type: "clear"})
+	return nil
 }
 
 func (s *LiveService) Subscribe(projectID uuid.UUID) chan LiveEvent {
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/cohesion-api/cohesion_backend/internal/models"
+	"github.com/cohesion-api/cohesion_backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// InvitationTTL is how long an invitation can be accepted.
+const InvitationTTL = 7 * 24 * time.Hour
+
+// ErrLastOwner is returned when a change would leave an organization
+// without an owner.
+var ErrLastOwner = repository.ErrLastOwner
+
+type OrganizationService struct {
+	orgRepo     *repository.OrganizationRepository
+	projectRepo *repository.ProjectRepository
+}
+
+func NewOrganizationService(orgRepo *repository.OrganizationRepository, projectRepo *repository.ProjectRepository) *OrganizationService {
+	return &OrganizationService{orgRepo: orgRepo, projectRepo: projectRepo}
+}
+
+// ValidateRole checks that role is one of owner, editor and viewer.
+func ValidateRole(role string) error {
+	if !models.ValidRole(role) {
+		return fmt.Errorf("invalid role %q: must be %s, %s or %s", role, models.RoleOwner, models.RoleEditor, models.RoleViewer)
+	}
+	return nil
+}
+
+// Create makes an organization owned by its creator.
+func (s *OrganizationService) Create(ctx context.Context, name, createdBy string) (*models.Organization, error) {
+	org := &models.Organization{Name: name, CreatedBy: createdBy}
+	if err := s.orgRepo.Create(ctx, org); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// Get returns the organization with the user's role, or nil if the user is
+// not a member.
+func (s *OrganizationService) Get(ctx context.Context, orgID uuid.UUID, userID string) (*models.Organization, error) {
+	return s.orgRepo.GetForMember(ctx, orgID, userID)
+}
+
+func (s *OrganizationService) List(ctx context.Context, userID string) ([]models.Organization, error) {
+	return s.orgRepo.ListForMember(ctx, userID)
+}
+
+func (s *OrganizationService) ListMembers(ctx context.Context, orgID uuid.UUID) ([]models.OrganizationMember, error) {
+	return s.orgRepo.ListMembers(ctx, orgID)
+}
+
+// UpdateMemberRole changes a member's role. Demoting the last owner fails
+// with ErrLastOwner. The role must have been validated by the caller.
+func (s *OrganizationService) UpdateMemberRole(ctx context.Context, orgID uuid.UUID, userID, role string) error {
+	return s.orgRepo.UpdateMemberRole(ctx, orgID, userID, role)
+}
+
+// RemoveMember removes a member. Removing the last owner fails with
+// ErrLastOwner.
+func (s *OrganizationService) RemoveMember(ctx context.Context, orgID uuid.UUID, userID string) error {
+	return s.orgRepo.RemoveMember(ctx, orgID, userID)
+}
+
+// Invite creates an invitation and returns it alongside its secret token,
+// which is not stored and cannot be retrieved again. The role must have
+// been validated by the caller.
+func (s *OrganizationService) Invite(ctx context.Context, orgID uuid.UUID, email, role, invitedBy string) (*models.Invitation, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	inv := &models.Invitation{
+		OrganizationID: orgID,
+		Email:          email,
+		Role:           role,
+		TokenHash:      hashInvitationToken(token),
+		InvitedBy:      invitedBy,
+		ExpiresAt:      time.Now().Add(InvitationTTL),
+	}
+	if err := s.orgRepo.CreateInvitation(ctx, inv); err != nil {
+		return nil, "", err
+	}
+	return inv, token, nil
+}
+
+func (s *OrganizationService) ListInvitations(ctx context.Context, orgID uuid.UUID) ([]models.Invitation, error) {
+	return s.orgRepo.ListInvitations(ctx, orgID)
+}
+
+func (s *OrganizationService) RevokeInvitation(ctx context.Context, orgID, invitationID uuid.UUID) error {
+	return s.orgRepo.DeleteInvitation(ctx, orgID, invitationID)
+}
+
+// AcceptInvitation adds the user to the invitation's organization. It
+// returns nil if the token does not match a pending, unexpired invitation.
+func (s *OrganizationService) AcceptInvitation(ctx context.Context, token, userID string) (*models.Invitation, error) {
+	return s.orgRepo.AcceptInvitation(ctx, hashInvitationToken(token), userID)
+}
+
+// MoveProject moves a project into an organization, or out of one when
+// orgID is nil.
+func (s *OrganizationService) MoveProject(ctx context.Context, projectID uuid.UUID, orgID *uuid.UUID) error {
+	return s.projectRepo.SetOrganization(ctx, projectID, orgID)
+}
+
+func hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
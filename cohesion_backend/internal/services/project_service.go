@@ -20,11 +20,14 @@ func NewProjectService(projectRepo *repository.ProjectRepository, endpointRepo *
 	}
 }
 
-func (s *ProjectService) Create(ctx context.Context, ownerID, name, description string) (*models.Project, error) {
+// Create makes a project owned by ownerID, inside orgID when it is not nil.
+func (s *ProjectService) Create(ctx context.Context, ownerID string, orgID *uuid.UUID, name, description string) (*models.Project, error) {
 	project := &models.Project{
-		OwnerID:     ownerID,
-		Name:        name,
-		Description: description,
+		OwnerID:        ownerID,
+		OrganizationID: orgID,
+		Name:           name,
+		Description:    description,
+		Role:           models.RoleOwner,
 	}
 
 	if err := s.projectRepo.Create(ctx, project); err != nil {
@@ -34,14 +37,20 @@ func (s *ProjectService) Create(ctx context.Context, ownerID, name, description
 	return project, nil
 }
 
-func (s *ProjectService) GetByID(ctx context.Context, id uuid.UUID, ownerID string) (*models.Project, error) {
-	return s.projectRepo.GetByID(ctx, id, ownerID)
+// GetByID returns a project the user can access, with their role on it.
+func (s *ProjectService) GetByID(ctx context.Context, id uuid.UUID, userID string) (*models.Project, error) {
+	return s.projectRepo.GetByID(ctx, id, userID)
 }
 
-func (s *ProjectService) List(ctx context.Context, ownerID string) ([]models.Project, error) {
-	return s.projectRepo.List(ctx, ownerID)
+// GetByIDUnscoped returns a project without checking its owner.
+func (s *ProjectService) GetByIDUnscoped(ctx context.Context, id uuid.UUID) (*models.Project, error) {
+	return s.projectRepo.GetByIDUnscoped(ctx, id)
 }
 
-func (s *ProjectService) Delete(ctx context.Context, id uuid.UUID, ownerID string) error {
-	return s.projectRepo.Delete(ctx, id, ownerID)
+func (s *ProjectService) List(ctx context.Context, userID string) ([]models.Project, error) {
+	return s.projectRepo.List(ctx, userID)
+}
+
+func (s *ProjectService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.projectRepo.Delete(ctx, id)
 }
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"github.com/cohesion-api/cohesion_backend/internal/models"
+	"github.com/cohesion-api/cohesion_backend/pkg/runtime"
+	"github.com/google/uuid"
+)
+
+// EffectiveRedaction is a project's custom redaction rules alongside the
+// built-in field names and value detectors that always apply.
+type EffectiveRedaction struct {
+	models.RedactionPolicy
+	DefaultFields []string `json:"default_fields"`
+	Detectors     []string `json:"detectors"`
+}
+
+func (s *LiveService) GetRedactionPolicy(ctx context.Context, projectID uuid.UUID) (*EffectiveRedaction, error) {
+	var policy *models.RedactionPolicy
+	if s.redactionRepo != nil {
+		var err error
+		if policy, err = s.redactionRepo.GetByProject(ctx, projectID); err != nil {
+			return nil, err
+		}
+	}
+	if policy == nil {
+		policy = &models.RedactionPolicy{ProjectID: projectID, Rules: []models.RedactionRule{}}
+	}
+	return effectiveRedaction(policy), nil
+}
+
+// UpdateRedactionPolicy replaces the project's custom rules. Rules must have
+// been validated by the caller.
+func (s *LiveService) UpdateRedactionPolicy(ctx context.Context, projectID uuid.UUID, rules []runtime.RedactionRule, updatedBy string) (*EffectiveRedaction, error) {
+	policy := &models.RedactionPolicy{
+		ProjectID: projectID,
+		Rules:     fromRedactionRules(rules),
+		UpdatedBy: updatedBy,
+	}
+	if err := s.redactionRepo.Upsert(ctx, policy); err != nil {
+		return nil, err
+	}
+	s.forgetRedactor(projectID)
+	return effectiveRedaction(policy), nil
+}
+
+// ResetRedactionPolicy drops the project's custom rules so only the
+// defaults apply.
+func (s *LiveService) ResetRedactionPolicy(ctx context.Context, projectID uuid.UUID) error {
+	if err := s.redactionRepo.Delete(ctx, projectID); err != nil {
+		return err
+	}
+	s.forgetRedactor(projectID)
+	return nil
+}
+
+// redactor returns the project's redactor, building it from the stored
+// rules on first use. If the rules cannot be loaded the defaults still
+// apply, so captures are never stored unredacted.
+func (s *LiveService) redactor(ctx context.Context, projectID uuid.UUID) *runtime.Redactor {
+	s.mu.RLock()
+	r, ok := s.redactors[projectID]
+	s.mu.RUnlock()
+	if ok {
+		return r
+	}
+
+	policy, err := s.GetRedactionPolicy(ctx, projectID)
+	if err == nil {
+		r, err = runtime.NewRedactor(toRedactionRules(policy.Rules))
+	}
+	if err != nil {
+		log.Printf("[live] failed to load redaction rules for project %s, applying defaults: %v", projectID, err)
+		r, _ = runtime.NewRedactor(nil)
+		return r
+	}
+
+	s.mu.Lock()
+	s.redactors[projectID] = r
+	s.mu.Unlock()
+	return r
+}
+
+func (s *LiveService) forgetRedactor(projectID uuid.UUID) {
+	s.mu.Lock()
+	delete(s.redactors, projectID)
+	s.mu.Unlock()
+}
+
+func effectiveRedaction(policy *models.RedactionPolicy) *EffectiveRedaction {
+	return &EffectiveRedaction{
+		RedactionPolicy: *policy,
+		DefaultFields:   runtime.DefaultRedactedFields,
+		Detectors:       runtime.Detectors,
+	}
+}
+
+func toRedactionRules(rules []models.RedactionRule) []runtime.RedactionRule {
+	out := make([]runtime.RedactionRule, 0, len(rules))
+	for _, r := range rules {
+		out = append(out, runtime.RedactionRule{Field: r.Field, Pattern: r.Pattern})
+	}
+	return out
+}
+
+func fromRedactionRules(rules []runtime.RedactionRule) []models.RedactionRule {
+	out := make([]models.RedactionRule, 0, len(rules))
+	for _, r := range rules {
+		out = append(out, models.RedactionRule{Field: r.Field, Pattern: r.Pattern})
+	}
+	return out
+}
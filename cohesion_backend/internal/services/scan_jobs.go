@@ -0,0 +1,413 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/cohesion-api/cohesion_backend/internal/models"
+	"github.com/cohesion-api/cohesion_backend/internal/repository"
+	"github.com/cohesion-api/cohesion_backend/pkg/analyzer"
+	ghpkg "github.com/cohesion-api/cohesion_backend/pkg/github"
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+	"github.com/google/uuid"
+)
+
+const (
+	scanJobQueueSize = 64
+	scanJobsListed   = 50
+	// skippedListed caps the skipped files and types a scan summary lists;
+	// the file count covers them all.
+	skippedListed = 200
+)
+
+var (
+	ErrScanQueueFull       = fmt.Errorf("too many scans are queued, try again later")
+	ErrScanJobFinished     = fmt.Errorf("scan job already finished")
+	ErrScanJobNotRetryable = fmt.Errorf("only failed or cancelled scan jobs can be retried")
+)
+
+// ScanSpec supplies what a job needs from whoever queued it: how to fetch
+// the files, usually with the requesting user's GitHub credentials, and
+// the fallback analyzer for files no registered analyzer covers.
+type ScanSpec struct {
+	Fetch    func(ctx context.Context) (*ghpkg.RepoFiles, error)
+	Fallback func() (analyzer.FileAnalyzer, error)
+}
+
+// ScanJobEvent is sent to a job's subscribers whenever it changes phase or
+// status, carrying the job as it is after the change.
+type ScanJobEvent struct {
+	Type string         `json:"type"` // "phase" or "status"
+	Job  models.ScanJob `json:"job"`
+}
+
+type scanTask struct {
+	job       *models.ScanJob
+	spec      ScanSpec
+	ctx       context.Context
+	cancel    context.CancelFunc
+	started   bool
+	cancelled bool
+}
+
+// ScanJobService runs repository scans in the background so they are not
+// bound by the request that queued them. Jobs only run in the process that
+// queued them; their state is persisted so it outlives the process.
+type ScanJobService struct {
+	repo          *repository.ScanJobRepository
+	schemaService *SchemaService
+	registry      *analyzer.Registry
+	analysisCache *AnalysisCacheService
+
+	queue       chan *scanTask
+	mu          sync.Mutex
+	tasks       map[uuid.UUID]*scanTask
+	subscribers map[uuid.UUID]map[chan ScanJobEvent]struct{}
+}
+
+func NewScanJobService(repo *repository.ScanJobRepository, schemaService *SchemaService, registry *analyzer.Registry, analysisCache *AnalysisCacheService) *ScanJobService {
+	return &ScanJobService{
+		repo:          repo,
+		schemaService: schemaService,
+		registry:      registry,
+		analysisCache: analysisCache,
+		queue:         make(chan *scanTask, scanJobQueueSize),
+		tasks:         make(map[uuid.UUID]*scanTask),
+		subscribers:   make(map[uuid.UUID]map[chan ScanJobEvent]struct{}),
+	}
+}
+
+// FailInterrupted marks jobs left queued or running by a previous process
+// as failed so they can be retried. Call it before serving requests.
+func (s *ScanJobService) FailInterrupted(ctx context.Context) error {
+	n, err := s.repo.FailActive(ctx, "Interrupted by a server restart")
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("[scan] marked %d interrupted scan jobs failed", n)
+	}
+	return nil
+}
+
+// Run executes queued jobs with the given number of workers until ctx is
+// cancelled, then interrupts the running jobs and waits for them to record
+// their outcome.
+func (s *ScanJobService) Run(ctx context.Context, workers int) {
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case t := <-s.queue:
+					s.execute(t)
+				}
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	s.mu.Lock()
+	for _, t := range s.tasks {
+		t.cancel()
+	}
+	s.mu.Unlock()
+	wg.Wait()
+}
+
+// Enqueue persists a new job and queues it.
+func (s *ScanJobService) Enqueue(ctx context.Context, job *models.ScanJob, spec ScanSpec) error {
+	if len(s.queue) == cap(s.queue) {
+		return ErrScanQueueFull
+	}
+	if err := s.repo.Create(ctx, job); err != nil {
+		return err
+	}
+	return s.submit(ctx, job, spec)
+}
+
+// Retry queues a failed or cancelled job again under the same ID.
+func (s *ScanJobService) Retry(ctx context.Context, job *models.ScanJob, spec ScanSpec) error {
+	if len(s.queue) == cap(s.queue) {
+		return ErrScanQueueFull
+	}
+	if err := s.repo.Requeue(ctx, job); err != nil {
+		if err == repository.ErrNotFound {
+			return ErrScanJobNotRetryable
+		}
+		return err
+	}
+	s.publish("status", job)
+	return s.submit(ctx, job, spec)
+}
+
+func (s *ScanJobService) submit(ctx context.Context, job *models.ScanJob, spec ScanSpec) error {
+	taskCtx, cancel := context.WithCancel(context.Background())
+	t := &scanTask{job: job, spec: spec, ctx: taskCtx, cancel: cancel}
+
+	s.mu.Lock()
+	s.tasks[job.ID] = t
+	s.mu.Unlock()
+
+	select {
+	case s.queue <- t:
+		return nil
+	default:
+	}
+
+	s.release(t)
+	job.Status = models.ScanJobFailed
+	job.Error = ErrScanQueueFull.Error()
+	if err := s.repo.Finish(context.WithoutCancel(ctx), job); err != nil {
+		return err
+	}
+	return ErrScanQueueFull
+}
+
+// Cancel stops a queued or running job. Queued jobs are cancelled at once;
+// running jobs stop at their next cancellation point and then record the
+// cancelled status.
+func (s *ScanJobService) Cancel(ctx context.Context, jobID uuid.UUID) error {
+	s.mu.Lock()
+	t, ok := s.tasks[jobID]
+	if ok {
+		t.cancelled = true
+		t.cancel()
+		if !t.started {
+			delete(s.tasks, jobID)
+		}
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return ErrScanJobFinished
+	}
+	if t.started {
+		return nil
+	}
+	t.job.Status = models.ScanJobCancelled
+	if err := s.repo.Finish(ctx, t.job); err != nil {
+		return err
+	}
+	s.publish("status", t.job)
+	return nil
+}
+
+func (s *ScanJobService) Get(ctx context.Context, jobID uuid.UUID) (*models.ScanJob, error) {
+	return s.repo.Get(ctx, jobID)
+}
+
+func (s *ScanJobService) ListByProject(ctx context.Context, projectID uuid.UUID) ([]models.ScanJob, error) {
+	return s.repo.ListByProject(ctx, projectID, scanJobsListed)
+}
+
+func (s *ScanJobService) Subscribe(jobID uuid.UUID) chan ScanJobEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch := make(chan ScanJobEvent, 16)
+	if s.subscribers[jobID] == nil {
+		s.subscribers[jobID] = make(map[chan ScanJobEvent]struct{})
+	}
+	s.subscribers[jobID][ch] = struct{}{}
+	return ch
+}
+
+func (s *ScanJobService) Unsubscribe(jobID uuid.UUID, ch chan ScanJobEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if subs, ok := s.subscribers[jobID]; ok {
+		delete(subs, ch)
+		close(ch)
+		if len(subs) == 0 {
+			delete(s.subscribers, jobID)
+		}
+	}
+}
+
+func (s *ScanJobService) publish(eventType string, job *models.ScanJob) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event := ScanJobEvent{Type: eventType, Job: *job}
+	for ch := range s.subscribers[job.ID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+func (s *ScanJobService) release(t *scanTask) {
+	s.mu.Lock()
+	if s.tasks[t.job.ID] == t {
+		delete(s.tasks, t.job.ID)
+	}
+	s.mu.Unlock()
+	t.cancel()
+}
+
+func (s *ScanJobService) execute(t *scanTask) {
+	defer s.release(t)
+
+	s.mu.Lock()
+	skip := t.cancelled
+	t.started = true
+	s.mu.Unlock()
+	if skip {
+		return
+	}
+
+	job := t.job
+	// Record progress and the outcome even once the job is cancelled.
+	record := context.WithoutCancel(t.ctx)
+	err := s.repo.Start(record, job)
+	if err == nil {
+		s.publish("status", job)
+		err = s.scan(t.ctx, record, t)
+	}
+
+	s.mu.Lock()
+	cancelled := t.cancelled
+	s.mu.Unlock()
+	switch {
+	case err == nil:
+		job.Status = models.ScanJobCompleted
+	case cancelled:
+		job.Status = models.ScanJobCancelled
+	case t.ctx.Err() != nil:
+		job.Status = models.ScanJobFailed
+		job.Error = "Interrupted by server shutdown"
+	default:
+		job.Status = models.ScanJobFailed
+		job.Error = err.Error()
+	}
+	if err := s.repo.Finish(record, job); err != nil {
+		log.Printf("[scan] failed to record job %s: %v", job.ID, err)
+	}
+	s.publish("status", job)
+}
+
+// scan runs the job's phases, reporting each one as it starts.
+func (s *ScanJobService) scan(ctx, record context.Context, t *scanTask) error {
+	job := t.job
+	phase := func(name string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		job.Phase = name
+		if err := s.repo.UpdateProgress(record, job); err != nil {
+			return err
+		}
+		s.publish("phase", job)
+		return nil
+	}
+
+	if err := phase(models.ScanPhaseFetch); err != nil {
+		return err
+	}
+	fetched, err := t.spec.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+	files := fetched.Files
+	job.FileCount = len(files)
+
+	if err := phase(models.ScanPhaseDiscover); err != nil {
+		return err
+	}
+	detection := analyzer.Detect(files)
+	job.Summary = map[string]interface{}{"detection": detection}
+
+	if err := phase(models.ScanPhaseAnalyze); err != nil {
+		return err
+	}
+	mode, source := scanMode(job.ScanType)
+	var fallbackErr error
+	result, err := s.registry.AnalyzeFilesCached(ctx, files, fetched.Language, mode, func() (analyzer.FileAnalyzer, error) {
+		a, err := t.spec.Fallback()
+		fallbackErr = err
+		return a, err
+	}, s.analysisCache.ForProject(job.ProjectID))
+	if err != nil {
+		if err == fallbackErr || ctx.Err() != nil {
+			return err
+		}
+		return fmt.Errorf("Analysis failed: %v", err)
+	}
+	job.EndpointCount = len(result.Schemas)
+	result.Skipped = append(fetched.Skipped, result.Skipped...)
+	result.SkippedFiles = len(result.Skipped)
+
+	if err := phase(models.ScanPhaseUpload); err != nil {
+		return err
+	}
+	schemas := make([]schemair.SchemaIR, len(result.Schemas))
+	for i, sch := range result.Schemas {
+		sch.Source = source
+		schemas[i] = *sch
+	}
+	if err := s.schemaService.UploadSchemas(ctx, job.ProjectID, schemas); err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		return fmt.Errorf("Failed to upload analyzed schemas")
+	}
+
+	name := job.RepoURL
+	if owner, repo, err := ghpkg.ParseRepoURL(job.RepoURL); err == nil {
+		name = owner + "/" + repo
+	}
+	job.Summary = ScanSummary(result, fmt.Sprintf("Scanned %s — %d endpoints found", name, len(result.Schemas)))
+	return nil
+}
+
+// scanMode maps a scan type to the analyzers' mode and the source the
+// resulting schemas are stored under.
+func scanMode(scanType string) (analyzer.ScanMode, schemair.SchemaSource) {
+	if scanType == "frontend" {
+		return analyzer.ScanModeFrontend, schemair.SourceFrontendStatic
+	}
+	return analyzer.ScanModeBackend, schemair.SourceBackendStatic
+}
+
+// ScanSummary describes a registry scan the way scan responses report it.
+func ScanSummary(result *analyzer.Result, message string) map[string]interface{} {
+	analyzers := result.Analyzers
+	if analyzers == nil {
+		analyzers = []string{}
+	}
+	skipped := result.Skipped
+	if len(skipped) > skippedListed {
+		skipped = skipped[:skippedListed]
+	}
+	if skipped == nil {
+		skipped = []analyzer.SkippedFile{}
+	}
+	skippedTypes := result.SkippedTypes
+	if len(skippedTypes) > skippedListed {
+		skippedTypes = skippedTypes[:skippedListed]
+	}
+	if skippedTypes == nil {
+		skippedTypes = []analyzer.SkippedType{}
+	}
+	return map[string]interface{}{
+		"message":        message,
+		"count":          len(result.Schemas),
+		"detection":      result.Detection,
+		"analyzers":      analyzers,
+		"fallback_files": result.FallbackFiles,
+		"chunks":         result.Chunks,
+		"cache":          result.Cache,
+		"skipped_files":  result.SkippedFiles,
+		"skipped":        skipped,
+		"skipped_types":  skippedTypes,
+	}
+}
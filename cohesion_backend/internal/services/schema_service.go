@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"strings"
 
 	"github.com/cohesion-api/cohesion_backend/internal/models"
 	"github.com/cohesion-api/cohesion_backend/internal/repository"
+	"github.com/cohesion-api/cohesion_backend/pkg/openapi"
+	"github.com/cohesion-api/cohesion_backend/pkg/runtime"
 	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
 	"github.com/google/uuid"
 )
@@ -37,6 +40,11 @@ func (s *SchemaService) UploadSchemas(ctx context.Context, projectID uuid.UUID,
 		return nil
 	}
 
+	schemas, err := s.templateRuntimePaths(ctx, projectID, schemas)
+	if err != nil {
+		return err
+	}
+
 	type epKey struct{ path, method string }
 	epMap := make(map[epKey]*models.Endpoint)
 	var uniqueEndpoints []*models.Endpoint
@@ -65,6 +73,13 @@ func (s *SchemaService) UploadSchemas(ctx context.Context, projectID uuid.UUID,
 		return err
 	}
 
+	// Later schemas for the same endpoint and source win, so an upload
+	// produces at most one new version per pair.
+	type schemaKey struct {
+		endpointID uuid.UUID
+		source     schemair.SchemaSource
+	}
+	schemaIndex := make(map[schemaKey]int)
 	dbSchemas := make([]models.Schema, 0, len(schemas))
 	for _, schema := range schemas {
 		normalizedPath := s.normalizePath(schema.Endpoint)
@@ -78,21 +93,79 @@ func (s *SchemaService) UploadSchemas(ctx context.Context, projectID uuid.UUID,
 			"request":  schema.Request,
 			"response": schema.Response,
 		}
+		// Parameter sections are only stored when present so schemas
+		// without them keep hashing as before.
+		if len(schema.PathParams) > 0 {
+			schemaData["path_params"] = schema.PathParams
+		}
+		if len(schema.QueryParams) > 0 {
+			schemaData["query_params"] = schema.QueryParams
+		}
+		if len(schema.Headers) > 0 {
+			schemaData["headers"] = schema.Headers
+		}
 
-		dbSchemas = append(dbSchemas, models.Schema{
+		dbSchema := models.Schema{
 			EndpointID: endpoint.ID,
 			Source:     string(schema.Source),
 			SchemaData: schemaData,
-		})
+		}
+		sk := schemaKey{endpoint.ID, schema.Source}
+		if i, exists := schemaIndex[sk]; exists {
+			dbSchemas[i] = dbSchema
+			continue
+		}
+		schemaIndex[sk] = len(dbSchemas)
+		dbSchemas = append(dbSchemas, dbSchema)
 	}
 
-	if err := s.schemaRepo.UpsertBatchTx(ctx, tx, dbSchemas); err != nil {
+	if _, err := s.schemaRepo.AppendVersionsTx(ctx, tx, dbSchemas); err != nil {
 		return err
 	}
 
 	return tx.Commit(ctx)
 }
 
+// templateRuntimePaths maps the concrete paths of runtime-observed schemas
+// onto the project's known endpoints, or onto templates learned from the
+// upload itself, and merges schemas that end up on the same endpoint.
+func (s *SchemaService) templateRuntimePaths(ctx context.Context, projectID uuid.UUID, schemas []schemair.SchemaIR) ([]schemair.SchemaIR, error) {
+	var observed, declared []schemair.SchemaIR
+	for _, schema := range schemas {
+		if schema.Source == schemair.SourceRuntime {
+			observed = append(observed, schema)
+		} else {
+			declared = append(declared, schema)
+		}
+	}
+	if len(observed) == 0 {
+		return schemas, nil
+	}
+
+	endpoints, err := s.endpointRepo.GetByProjectID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	known := make([]string, 0, len(endpoints)+len(declared))
+	for _, ep := range endpoints {
+		known = append(known, ep.Path)
+	}
+	for _, schema := range declared {
+		known = append(known, s.normalizePath(schema.Endpoint))
+	}
+
+	paths := make([]string, len(observed))
+	for i := range observed {
+		paths[i] = s.normalizePath(observed[i].Endpoint)
+	}
+	templates := runtime.NewPathTemplater(known).Learn(paths)
+	for i := range observed {
+		observed[i].Endpoint = templates[paths[i]]
+	}
+
+	return append(declared, runtime.MergeSchemas(observed)...), nil
+}
+
 func (s *SchemaService) normalizePath(path string) string {
 	if len(path) > 0 && path[0] != '/' {
 		path = "/" + path
@@ -110,3 +183,44 @@ func (s *SchemaService) normalizePath(path string) string {
 func (s *SchemaService) GetByEndpoint(ctx context.Context, endpointID uuid.UUID) ([]models.Schema, error) {
 	return s.schemaRepo.GetByEndpointID(ctx, endpointID)
 }
+
+// ExportOpenAPI renders the project's endpoints as an OpenAPI document.
+// Endpoints whose stored schemas cannot be read are left out and listed
+// in the document's warnings.
+func (s *SchemaService) ExportOpenAPI(ctx context.Context, project *models.Project, authority schemair.SchemaSource, strict bool) (*openapi.Document, error) {
+	endpoints, err := s.endpointRepo.GetByProjectWithSchemas(ctx, project.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []schemair.SchemaIR
+	var skipped []string
+	for _, endpoint := range endpoints {
+		schemaIRs, warnings := schemasToIR(endpoint.Schemas)
+		if len(warnings) > 0 {
+			skipped = append(skipped, fmt.Sprintf("%s %s skipped: %s", endpoint.Method, endpoint.Path, strings.Join(warnings, "; ")))
+			continue
+		}
+		for _, ir := range schemaIRs {
+			ir.Endpoint = endpoint.Path
+			ir.Method = endpoint.Method
+			all = append(all, ir)
+		}
+	}
+
+	doc := openapi.Export(all, openapi.ExportOptions{
+		Title:     project.Name,
+		Authority: authority,
+		Strict:    strict,
+	})
+	doc.Warnings = skipped
+	return doc, nil
+}
+
+func (s *SchemaService) ListVersions(ctx context.Context, endpointID uuid.UUID, source string) ([]models.SchemaVersion, error) {
+	return s.schemaRepo.ListVersions(ctx, endpointID, source)
+}
+
+func (s *SchemaService) GetVersion(ctx context.Context, endpointID uuid.UUID, source string, version int) (*models.Schema, error) {
+	return s.schemaRepo.GetVersion(ctx, endpointID, source, version)
+}
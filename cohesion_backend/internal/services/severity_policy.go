@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+
+	"github.com/cohesion-api/cohesion_backend/internal/models"
+	"github.com/cohesion-api/cohesion_backend/pkg/diff"
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+	"github.com/google/uuid"
+)
+
+// EffectivePolicy is a project's custom severity rules alongside the
+// built-in rules they take precedence over.
+type EffectivePolicy struct {
+	models.SeverityPolicy
+	Defaults []diff.PolicyRule `json:"defaults"`
+}
+
+func (s *DiffService) GetSeverityPolicy(ctx context.Context, projectID uuid.UUID) (*EffectivePolicy, error) {
+	policy, err := s.policyRepo.GetByProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		policy = &models.SeverityPolicy{ProjectID: projectID, Rules: []models.SeverityRule{}}
+	}
+	return &EffectivePolicy{SeverityPolicy: *policy, Defaults: diff.DefaultPolicy().Rules}, nil
+}
+
+// UpdateSeverityPolicy replaces the project's custom rules. Rules must have
+// been validated by the caller.
+func (s *DiffService) UpdateSeverityPolicy(ctx context.Context, projectID uuid.UUID, rules []diff.PolicyRule, updatedBy string) (*EffectivePolicy, error) {
+	policy := &models.SeverityPolicy{
+		ProjectID: projectID,
+		Rules:     fromPolicyRules(rules),
+		UpdatedBy: updatedBy,
+	}
+	if err := s.policyRepo.Upsert(ctx, policy); err != nil {
+		return nil, err
+	}
+	return &EffectivePolicy{SeverityPolicy: *policy, Defaults: diff.DefaultPolicy().Rules}, nil
+}
+
+// ResetSeverityPolicy drops the project's custom rules so only the defaults
+// apply.
+func (s *DiffService) ResetSeverityPolicy(ctx context.Context, projectID uuid.UUID) error {
+	return s.policyRepo.Delete(ctx, projectID)
+}
+
+func toPolicyRules(rules []models.SeverityRule) []diff.PolicyRule {
+	out := make([]diff.PolicyRule, 0, len(rules))
+	for _, r := range rules {
+		out = append(out, diff.PolicyRule{
+			Section:     r.Section,
+			Type:        diff.MismatchType(r.MismatchType),
+			PresentIn:   toSchemaSources(r.PresentIn),
+			MissingFrom: toSchemaSources(r.MissingFrom),
+			Severity:    diff.Severity(r.Severity),
+		})
+	}
+	return out
+}
+
+func fromPolicyRules(rules []diff.PolicyRule) []models.SeverityRule {
+	out := make([]models.SeverityRule, 0, len(rules))
+	for _, r := range rules {
+		out = append(out, models.SeverityRule{
+			Section:      r.Section,
+			MismatchType: string(r.Type),
+			PresentIn:    fromSchemaSources(r.PresentIn),
+			MissingFrom:  fromSchemaSources(r.MissingFrom),
+			Severity:     string(r.Severity),
+		})
+	}
+	return out
+}
+
+func toSchemaSources(sources []string) []schemair.SchemaSource {
+	if len(sources) == 0 {
+		return nil
+	}
+	out := make([]schemair.SchemaSource, len(sources))
+	for i, src := range sources {
+		out[i] = schemair.SchemaSource(src)
+	}
+	return out
+}
+
+func fromSchemaSources(sources []schemair.SchemaSource) []string {
+	if len(sources) == 0 {
+		return nil
+	}
+	out := make([]string, len(sources))
+	for i, src := range sources {
+		out[i] = string(src)
+	}
+	return out
+}
@@ -28,18 +28,14 @@ func (s *UserSettingsService) Get(ctx context.Context, clerkUserID string) (*mod
 	return settings, nil
 }
 
-func (s *UserSettingsService) Save(ctx context.Context, clerkUserID, geminiAPIKey, geminiModel, githubToken string) error {
-	existing, err := s.repo.GetByClerkUserID(ctx, clerkUserID)
+// Save stores a user's settings. The LLM provider must have been validated
+// by the caller.
+func (s *UserSettingsService) Save(ctx context.Context, settings *models.UserSettings) error {
+	existing, err := s.repo.GetByClerkUserID(ctx, settings.ClerkUserID)
 	if err != nil {
 		return err
 	}
 
-	settings := &models.UserSettings{
-		ClerkUserID:  clerkUserID,
-		GeminiAPIKey: geminiAPIKey,
-		GeminiModel:  geminiModel,
-		GitHubToken:  githubToken,
-	}
 	if existing != nil {
 		settings.ID = existing.ID
 		settings.CreatedAt = existing.CreatedAt
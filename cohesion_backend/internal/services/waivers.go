@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cohesion-api/cohesion_backend/internal/models"
+	"github.com/cohesion-api/cohesion_backend/pkg/diff"
+	"github.com/google/uuid"
+)
+
+func (s *DiffService) ListWaivers(ctx context.Context, projectID uuid.UUID) ([]models.Waiver, error) {
+	return s.waiverRepo.ListByProject(ctx, projectID)
+}
+
+// CreateWaiver stores a waiver. Path parameters in the endpoint pattern are
+// normalized the same way uploaded endpoints are, so "/users/{id}" matches
+// the stored "/users/{}".
+func (s *DiffService) CreateWaiver(ctx context.Context, waiver *models.Waiver) error {
+	if waiver.EndpointPattern != "" {
+		waiver.EndpointPattern = pathParamRegex.ReplaceAllString(waiver.EndpointPattern, "{}")
+	}
+	waiver.Method = strings.ToUpper(waiver.Method)
+	return s.waiverRepo.Create(ctx, waiver)
+}
+
+func (s *DiffService) DeleteWaiver(ctx context.Context, projectID, waiverID uuid.UUID) error {
+	return s.waiverRepo.Delete(ctx, projectID, waiverID)
+}
+
+func toDiffWaivers(waivers []models.Waiver) []diff.Waiver {
+	out := make([]diff.Waiver, 0, len(waivers))
+	for _, w := range waivers {
+		out = append(out, diff.Waiver{
+			ID:        w.ID.String(),
+			Endpoint:  w.EndpointPattern,
+			Method:    w.Method,
+			FieldPath: w.FieldPath,
+			Type:      diff.MismatchType(w.MismatchType),
+			Sources:   toSchemaSources(w.Sources),
+			ExpiresAt: w.ExpiresAt,
+			Reason:    w.Reason,
+		})
+	}
+	return out
+}
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_schemas_endpoint_source_version;
+ALTER TABLE schemas DROP COLUMN IF EXISTS content_hash;
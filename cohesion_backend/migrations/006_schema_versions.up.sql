@@ -0,0 +1,2 @@
+ALTER TABLE schemas ADD COLUMN content_hash VARCHAR(64) NOT NULL DEFAULT '';
+CREATE INDEX idx_schemas_endpoint_source_version ON schemas(endpoint_id, source, version DESC);
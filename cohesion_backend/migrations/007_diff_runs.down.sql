@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_diffs_run_id;
+ALTER TABLE diffs DROP COLUMN IF EXISTS run_id;
+DROP TABLE IF EXISTS diff_runs;
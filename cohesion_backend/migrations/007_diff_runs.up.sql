@@ -0,0 +1,21 @@
+CREATE TABLE diff_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    commit_sha VARCHAR(64) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'running',
+    error TEXT NOT NULL DEFAULT '',
+    endpoint_count INT NOT NULL DEFAULT 0,
+    skipped_count INT NOT NULL DEFAULT 0,
+    matched_count INT NOT NULL DEFAULT 0,
+    partial_count INT NOT NULL DEFAULT 0,
+    violation_count INT NOT NULL DEFAULT 0,
+    critical_count INT NOT NULL DEFAULT 0,
+    warning_count INT NOT NULL DEFAULT 0,
+    info_count INT NOT NULL DEFAULT 0,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ
+);
+CREATE INDEX idx_diff_runs_project_started ON diff_runs(project_id, started_at DESC);
+
+ALTER TABLE diffs ADD COLUMN run_id UUID REFERENCES diff_runs(id) ON DELETE CASCADE;
+CREATE INDEX idx_diffs_run_id ON diffs(run_id);
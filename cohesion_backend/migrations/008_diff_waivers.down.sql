@@ -0,0 +1 @@
+DROP TABLE IF EXISTS diff_waivers;
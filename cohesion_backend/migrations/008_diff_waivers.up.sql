@@ -0,0 +1,14 @@
+CREATE TABLE diff_waivers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    endpoint_pattern VARCHAR(500) NOT NULL DEFAULT '',
+    method VARCHAR(10) NOT NULL DEFAULT '',
+    field_path VARCHAR(500) NOT NULL DEFAULT '',
+    mismatch_type VARCHAR(50) NOT NULL DEFAULT '',
+    sources TEXT[] NOT NULL DEFAULT '{}',
+    reason TEXT NOT NULL,
+    expires_at TIMESTAMPTZ,
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+CREATE INDEX idx_diff_waivers_project_id ON diff_waivers(project_id);
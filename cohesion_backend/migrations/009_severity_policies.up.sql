@@ -0,0 +1,6 @@
+CREATE TABLE severity_policies (
+    project_id UUID PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    rules JSONB NOT NULL DEFAULT '[]',
+    updated_by VARCHAR(255) NOT NULL DEFAULT '',
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
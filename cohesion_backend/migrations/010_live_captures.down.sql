@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS live_capture_retention;
+DROP TABLE IF EXISTS live_captures;
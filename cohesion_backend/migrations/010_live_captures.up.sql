@@ -0,0 +1,25 @@
+CREATE TABLE live_captures (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    id VARCHAR(64) NOT NULL,
+    source VARCHAR(255) NOT NULL DEFAULT '',
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    status_code INT NOT NULL DEFAULT 0,
+    duration_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+    request_body JSONB,
+    response_body JSONB,
+    query_params JSONB,
+    headers JSONB,
+    request_content_type VARCHAR(255) NOT NULL DEFAULT '',
+    response_content_type VARCHAR(255) NOT NULL DEFAULT '',
+    captured_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (project_id, id)
+);
+CREATE INDEX idx_live_captures_project_captured ON live_captures(project_id, captured_at DESC);
+
+CREATE TABLE live_capture_retention (
+    project_id UUID PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    max_age_hours INT NOT NULL DEFAULT 0,
+    max_count INT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
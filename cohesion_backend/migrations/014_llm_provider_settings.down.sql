@@ -0,0 +1,4 @@
+ALTER TABLE user_settings DROP COLUMN IF EXISTS llm_base_url;
+ALTER TABLE user_settings DROP COLUMN IF EXISTS llm_provider;
+ALTER TABLE user_settings RENAME COLUMN llm_model TO gemini_model;
+ALTER TABLE user_settings RENAME COLUMN llm_api_key TO gemini_api_key;
@@ -0,0 +1,4 @@
+ALTER TABLE user_settings RENAME COLUMN gemini_api_key TO llm_api_key;
+ALTER TABLE user_settings RENAME COLUMN gemini_model TO llm_model;
+ALTER TABLE user_settings ADD COLUMN llm_provider VARCHAR(20) NOT NULL DEFAULT 'gemini';
+ALTER TABLE user_settings ADD COLUMN llm_base_url TEXT NOT NULL DEFAULT '';
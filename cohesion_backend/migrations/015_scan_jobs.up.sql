@@ -0,0 +1,21 @@
+CREATE TABLE scan_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    created_by VARCHAR(255) NOT NULL,
+    repo_url TEXT NOT NULL,
+    branch VARCHAR(255) NOT NULL DEFAULT '',
+    path TEXT NOT NULL DEFAULT '',
+    scan_type VARCHAR(20) NOT NULL DEFAULT 'backend',
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',
+    phase VARCHAR(20) NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    file_count INT NOT NULL DEFAULT 0,
+    endpoint_count INT NOT NULL DEFAULT 0,
+    summary JSONB,
+    attempts INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ
+);
+CREATE INDEX idx_scan_jobs_project_created ON scan_jobs(project_id, created_at DESC);
+CREATE INDEX idx_scan_jobs_status ON scan_jobs(status);
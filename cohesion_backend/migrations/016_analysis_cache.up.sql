@@ -0,0 +1,10 @@
+CREATE TABLE analysis_cache (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    cache_key VARCHAR(64) NOT NULL,
+    fingerprint VARCHAR(255) NOT NULL,
+    schemas JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (project_id, cache_key)
+);
+CREATE INDEX idx_analysis_cache_project_used ON analysis_cache(project_id, last_used_at DESC);
@@ -0,0 +1,6 @@
+UPDATE live_capture_retention SET max_age_hours = 0 WHERE max_age_hours IS NULL;
+UPDATE live_capture_retention SET max_count = 0 WHERE max_count IS NULL;
+ALTER TABLE live_capture_retention ALTER COLUMN max_age_hours SET DEFAULT 0;
+ALTER TABLE live_capture_retention ALTER COLUMN max_age_hours SET NOT NULL;
+ALTER TABLE live_capture_retention ALTER COLUMN max_count SET DEFAULT 0;
+ALTER TABLE live_capture_retention ALTER COLUMN max_count SET NOT NULL;
@@ -0,0 +1,6 @@
+ALTER TABLE live_capture_retention ALTER COLUMN max_age_hours DROP NOT NULL;
+ALTER TABLE live_capture_retention ALTER COLUMN max_age_hours DROP DEFAULT;
+ALTER TABLE live_capture_retention ALTER COLUMN max_count DROP NOT NULL;
+ALTER TABLE live_capture_retention ALTER COLUMN max_count DROP DEFAULT;
+UPDATE live_capture_retention SET max_age_hours = NULL WHERE max_age_hours = 0;
+UPDATE live_capture_retention SET max_count = NULL WHERE max_count = 0;
@@ -0,0 +1,58 @@
+package analyzer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+// Cache stores the schemas found in a chunk under a key derived from the
+// chunk's files, so a rescan only analyzes the directories that changed.
+type Cache interface {
+	// Get returns the schemas stored under key and whether there were any.
+	Get(ctx context.Context, key string) ([]*schemair.SchemaIR, bool, error)
+	Put(ctx context.Context, key, fingerprint string, schemas []*schemair.SchemaIR) error
+}
+
+// Fingerprinter is implemented by analyzers whose results can be cached.
+// Fingerprint identifies what a result depends on besides the files, such
+// as the model and prompt version; results are only reused under the same
+// fingerprint.
+type Fingerprinter interface {
+	Fingerprint() string
+}
+
+// CacheStats counts the chunks of a scan served from the cache.
+type CacheStats struct {
+	Hits   int `json:"hits"`
+	Misses int `json:"misses"`
+	// CachedFiles counts the files in the chunks served from the cache.
+	CachedFiles int `json:"cached_files"`
+}
+
+// ChunkKey derives the cache key of a chunk from the analyzer fingerprint,
+// the scan mode and language hint, and the path and content hash of every
+// file in the chunk and its context. The model reads the context to resolve
+// handler types, so changing a shared route or type file yields a new key
+// for every chunk it is shared with.
+func ChunkKey(fingerprint string, c Chunk, language string, mode ScanMode) string {
+	h := sha256.New()
+	h.Write([]byte(fingerprint + "\n" + string(mode) + "\n" + language + "\n"))
+	writeFiles := func(kind string, files []SourceFile) {
+		lines := make([]string, len(files))
+		for i, f := range files {
+			sum := sha256.Sum256([]byte(f.Content))
+			lines[i] = kind + " " + f.Path + " " + hex.EncodeToString(sum[:]) + "\n"
+		}
+		sort.Strings(lines)
+		for _, l := range lines {
+			h.Write([]byte(l))
+		}
+	}
+	writeFiles("file", c.Files)
+	writeFiles("context", c.Context)
+	return hex.EncodeToString(h.Sum(nil))
+}
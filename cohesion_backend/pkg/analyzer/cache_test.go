@@ -0,0 +1,169 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+// memCache stores schemas encoded, like a database would, so merging
+// cached results cannot modify them.
+type memCache struct {
+	mu      sync.Mutex
+	entries map[string][]byte
+}
+
+func (m *memCache) Get(ctx context.Context, key string) ([]*schemair.SchemaIR, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	raw, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	var schemas []*schemair.SchemaIR
+	return schemas, true, json.Unmarshal(raw, &schemas)
+}
+
+func (m *memCache) Put(ctx context.Context, key, fingerprint string, schemas []*schemair.SchemaIR) error {
+	raw, err := json.Marshal(schemas)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.entries == nil {
+		m.entries = make(map[string][]byte)
+	}
+	m.entries[key] = raw
+	return nil
+}
+
+type fingerprinted struct {
+	*chunkAnalyzer
+	fingerprint string
+}
+
+func (f fingerprinted) Fingerprint() string { return f.fingerprint }
+
+func TestAnalyzeChunkedCache(t *testing.T) {
+	a := &chunkAnalyzer{byFile: map[string][]*schemair.SchemaIR{
+		"api/handlers.go":    {{Endpoint: "/invoices", Method: "POST"}},
+		"billing/invoice.go": {{Endpoint: "/invoices/{id}", Method: "GET"}},
+		"users/users.go":     {{Endpoint: "/users", Method: "GET"}},
+	}}
+	cache := &memCache{}
+	opts := ChunkOptions{MaxTokens: 400, Cache: cache}
+	tree := append([]SourceFile(nil), largeTree...)
+
+	scan := func(fingerprint string) (*ChunkedResult, int) {
+		t.Helper()
+		before := a.calls
+		res, err := AnalyzeChunked(context.Background(), fingerprinted{a, fingerprint}, tree, "Go", ScanModeBackend, opts)
+		if err != nil {
+			t.Fatalf("AnalyzeChunked: %v", err)
+		}
+		if len(res.Schemas) != 3 {
+			t.Errorf("got %d schemas, want 3", len(res.Schemas))
+		}
+		return res, a.calls - before
+	}
+
+	if res, calls := scan("model-a"); calls != 3 || res.Cache != (CacheStats{Misses: 3}) {
+		t.Errorf("first scan: %d calls, cache %+v", calls, res.Cache)
+	}
+	if res, calls := scan("model-a"); calls != 0 || res.Cache != (CacheStats{Hits: 3, CachedFiles: 5}) {
+		t.Errorf("unchanged rescan: %d calls, cache %+v", calls, res.Cache)
+	}
+
+	tree[0] = sized("users/users.go", 199)
+	if res, calls := scan("model-a"); calls != 1 || res.Cache != (CacheStats{Hits: 2, Misses: 1, CachedFiles: 4}) {
+		t.Errorf("rescan after a change: %d calls, cache %+v", calls, res.Cache)
+	}
+
+	// Route and type files are shared with other directories, so changing
+	// one misses every chunk that reads it.
+	tree[4] = sized("billing/models.go", 39)
+	if res, calls := scan("model-a"); calls != 3 || res.Cache.Hits != 0 {
+		t.Errorf("rescan after a context change: %d calls, cache %+v", calls, res.Cache)
+	}
+
+	if res, calls := scan("model-b"); calls != 3 || res.Cache.Hits != 0 {
+		t.Errorf("rescan with another model: %d calls, cache %+v", calls, res.Cache)
+	}
+
+	// Analyzers without a fingerprint are never cached.
+	before := a.calls
+	res, err := AnalyzeChunked(context.Background(), a, tree, "Go", ScanModeBackend, opts)
+	if err != nil || a.calls-before != 3 || res.Cache != (CacheStats{}) {
+		t.Errorf("unfingerprinted scan: err %v, %d calls, cache %+v", err, a.calls-before, res.Cache)
+	}
+}
+
+func TestAnalyzeChunkedCacheSmallTree(t *testing.T) {
+	a := &chunkAnalyzer{byFile: map[string][]*schemair.SchemaIR{
+		"api/routes.go":  {{Endpoint: "/invoices", Method: "GET"}},
+		"users/users.go": {{Endpoint: "/users", Method: "GET"}},
+	}}
+	opts := ChunkOptions{Cache: &memCache{}}
+	tree := []SourceFile{sized("api/routes.go", 60), sized("billing/invoice.go", 150), sized("users/users.go", 200)}
+
+	scan := func() (*ChunkedResult, int) {
+		t.Helper()
+		before := a.calls
+		res, err := AnalyzeChunked(context.Background(), fingerprinted{a, "model-a"}, tree, "Go", ScanModeBackend, opts)
+		if err != nil {
+			t.Fatalf("AnalyzeChunked: %v", err)
+		}
+		return res, a.calls - before
+	}
+
+	// The tree fits in one prompt but is cached per directory, so an edit
+	// only misses its own.
+	if res, calls := scan(); calls != 3 || len(res.Schemas) != 2 {
+		t.Errorf("first scan: %d calls, %d schemas", calls, len(res.Schemas))
+	}
+	tree[1] = sized("billing/invoice.go", 151)
+	if res, calls := scan(); calls != 1 || res.Cache != (CacheStats{Hits: 2, Misses: 1, CachedFiles: 2}) {
+		t.Errorf("rescan after a change: %d calls, cache %+v", calls, res.Cache)
+	}
+
+	// MaxChunks only counts the chunks analyzed.
+	tree[1], tree[2] = sized("billing/invoice.go", 152), sized("users/users.go", 201)
+	opts.MaxChunks = 1
+	res, calls := scan()
+	if calls != 1 || res.Cache.Hits != 1 || len(res.Skipped) != 1 || res.Skipped[0].Path != "users/users.go" {
+		t.Errorf("limited rescan: %d calls, cache %+v, skipped %v", calls, res.Cache, res.Skipped)
+	}
+}
+
+func TestChunkKey(t *testing.T) {
+	c := Chunk{Files: []SourceFile{{Path: "a.py", Content: "a"}, {Path: "b.py", Content: "b"}}}
+	key := ChunkKey("m", c, "Python", ScanModeBackend)
+
+	reordered := Chunk{Files: []SourceFile{c.Files[1], c.Files[0]}}
+	if ChunkKey("m", reordered, "Python", ScanModeBackend) != key {
+		t.Error("key depends on file order")
+	}
+	withContext := Chunk{Files: c.Files, Context: []SourceFile{{Path: "models.py", Content: "class User: ..."}}}
+	contextKey := ChunkKey("m", withContext, "Python", ScanModeBackend)
+	if contextKey == key {
+		t.Error("key ignores the shared context")
+	}
+	withContext.Context = []SourceFile{{Path: "models.py", Content: "class User: name: str"}}
+	if ChunkKey("m", withContext, "Python", ScanModeBackend) == contextKey {
+		t.Error("key ignores a change to a context file")
+	}
+	for name, other := range map[string]string{
+		"content":     ChunkKey("m", Chunk{Files: []SourceFile{{Path: "a.py", Content: "A"}, c.Files[1]}}, "Python", ScanModeBackend),
+		"files":       ChunkKey("m", Chunk{Files: c.Files[:1]}, "Python", ScanModeBackend),
+		"fingerprint": ChunkKey("n", c, "Python", ScanModeBackend),
+		"mode":        ChunkKey("m", c, "Python", ScanModeFrontend),
+	} {
+		if other == key {
+			t.Errorf("key ignores the %s", name)
+		}
+	}
+}
@@ -0,0 +1,449 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"sync"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+	"github.com/cohesion-api/cohesion_backend/pkg/sourcefile"
+)
+
+const bytesPerToken = 4
+
+// ChunkOptions bound how a tree too large for one prompt is split up.
+type ChunkOptions struct {
+	// MaxTokens is the estimated size of the source sent in one chunk,
+	// including the context files shared with the other chunks.
+	MaxTokens int
+	// Parallelism is the number of chunks analyzed at once.
+	Parallelism int
+	// MaxChunks caps the chunks of one scan; files that would need more
+	// are skipped. Chunks served from the cache do not count.
+	MaxChunks int
+	// Cache, when set, holds chunk results from earlier scans. It is only
+	// used with analyzers that implement Fingerprinter.
+	Cache Cache
+}
+
+// DefaultChunkOptions leave room for the prompt and the answer in a
+// 128k-token context window.
+var DefaultChunkOptions = ChunkOptions{MaxTokens: 60_000, Parallelism: 4, MaxChunks: 64}
+
+func (o ChunkOptions) withDefaults() ChunkOptions {
+	if o.MaxTokens <= 0 {
+		o.MaxTokens = DefaultChunkOptions.MaxTokens
+	}
+	if o.Parallelism <= 0 {
+		o.Parallelism = DefaultChunkOptions.Parallelism
+	}
+	if o.MaxChunks <= 0 {
+		o.MaxChunks = DefaultChunkOptions.MaxChunks
+	}
+	return o
+}
+
+// SkippedFile is a file a scan left unanalyzed.
+type SkippedFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// Chunk is a set of files analyzed together. Context holds route tables
+// and type definitions from elsewhere in the tree, given for reference so
+// handlers can be tied to their paths and types; each context file is
+// also analyzed in its own chunk.
+type Chunk struct {
+	Files   []SourceFile
+	Context []SourceFile
+}
+
+// ContextAnalyzer is a FileAnalyzer that can also take reference files it
+// should read but not report endpoints for.
+type ContextAnalyzer interface {
+	FileAnalyzer
+	AnalyzeFilesWithContext(ctx context.Context, files, shared []SourceFile, language string, mode ScanMode) ([]*schemair.SchemaIR, error)
+}
+
+// EstimateTokens approximates the prompt tokens a file's content takes.
+func EstimateTokens(content string) int {
+	return len(content)/bytesPerToken + 1
+}
+
+// Partition splits files into chunks of at most opts.MaxTokens. Files of
+// the same directory, usually one package, are kept together where they
+// fit, and directories holding route and handler files come first so they
+// are the last to be skipped. When more than one chunk is needed, route and
+// type files are shared as context, up to a quarter of each chunk.
+func Partition(files []SourceFile, opts ChunkOptions) ([]Chunk, []SkippedFile) {
+	opts = opts.withDefaults()
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	total := 0
+	for _, f := range files {
+		total += EstimateTokens(f.Content)
+	}
+	if total <= opts.MaxTokens {
+		return []Chunk{{Files: files}}, nil
+	}
+
+	byPriority := sortByPriority(files)
+	shared, sharedTokens := sharedContext(byPriority, opts)
+
+	capacity := opts.MaxTokens - sharedTokens
+	var chunks []Chunk
+	var skipped []SkippedFile
+	var current []SourceFile
+	size := 0
+	flush := func() {
+		if len(current) > 0 {
+			chunks = append(chunks, Chunk{Files: current})
+		}
+		current, size = nil, 0
+	}
+	for _, g := range directoryGroups(byPriority) {
+		groupTokens := 0
+		for _, f := range g.files {
+			groupTokens += EstimateTokens(f.Content)
+		}
+		// Start a directory in a fresh chunk rather than split it, unless
+		// it would not fit in one anyway.
+		if size+groupTokens > capacity && groupTokens <= capacity {
+			flush()
+		}
+		for _, f := range g.files {
+			t := EstimateTokens(f.Content)
+			if t > capacity {
+				skipped = append(skipped, SkippedFile{Path: f.Path, Reason: "larger than one analysis chunk"})
+				continue
+			}
+			if size+t > capacity {
+				flush()
+			}
+			current = append(current, f)
+			size += t
+		}
+	}
+	flush()
+
+	if len(chunks) > opts.MaxChunks {
+		for _, c := range chunks[opts.MaxChunks:] {
+			skipped = append(skipped, overLimit(c, opts)...)
+		}
+		chunks = chunks[:opts.MaxChunks]
+	}
+
+	attachContext(chunks, shared)
+	return chunks, skipped
+}
+
+// PartitionDirectories splits files into one chunk per directory, with
+// directories larger than a chunk split in path order, so a chunk's files
+// depend only on the paths in its directory and not on the size of the
+// rest of the tree. It is how cached scans are split: editing a file only
+// changes the chunk of its directory. Route and type files are shared as
+// context as in Partition. The MaxChunks limit is left to the caller.
+func PartitionDirectories(files []SourceFile, opts ChunkOptions) ([]Chunk, []SkippedFile) {
+	opts = opts.withDefaults()
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	byPriority := sortByPriority(files)
+	groups := directoryGroups(byPriority)
+	var shared []SourceFile
+	sharedTokens := 0
+	if len(groups) > 1 {
+		shared, sharedTokens = sharedContext(byPriority, opts)
+	}
+
+	capacity := opts.MaxTokens - sharedTokens
+	var chunks []Chunk
+	var skipped []SkippedFile
+	for _, g := range groups {
+		var current []SourceFile
+		size := 0
+		for _, f := range g.files {
+			t := EstimateTokens(f.Content)
+			if t > capacity {
+				skipped = append(skipped, SkippedFile{Path: f.Path, Reason: "larger than one analysis chunk"})
+				continue
+			}
+			if size+t > capacity {
+				chunks = append(chunks, Chunk{Files: current})
+				current, size = nil, 0
+			}
+			current = append(current, f)
+			size += t
+		}
+		if len(current) > 0 {
+			chunks = append(chunks, Chunk{Files: current})
+		}
+	}
+
+	attachContext(chunks, shared)
+	return chunks, skipped
+}
+
+// sortByPriority orders files by sourcefile.Priority, then by path.
+func sortByPriority(files []SourceFile) []SourceFile {
+	sorted := make([]SourceFile, len(files))
+	copy(sorted, files)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		pi, pj := sourcefile.Priority(sorted[i].Path), sourcefile.Priority(sorted[j].Path)
+		if pi != pj {
+			return pi < pj
+		}
+		return sorted[i].Path < sorted[j].Path
+	})
+	return sorted
+}
+
+// sharedContext picks the route and type files shared with every chunk,
+// up to a quarter of one, and returns them with their estimated tokens.
+func sharedContext(byPriority []SourceFile, opts ChunkOptions) ([]SourceFile, int) {
+	var shared []SourceFile
+	tokens := 0
+	for _, f := range byPriority {
+		if p := sourcefile.Priority(f.Path); p != 1 && p != 3 {
+			continue
+		}
+		if t := EstimateTokens(f.Content); tokens+t <= opts.MaxTokens/4 {
+			shared = append(shared, f)
+			tokens += t
+		}
+	}
+	return shared, tokens
+}
+
+type directoryGroup struct {
+	dir      string
+	priority int
+	files    []SourceFile
+}
+
+// directoryGroups groups files by directory, ordered by the priority of
+// their most important file, then by path.
+func directoryGroups(byPriority []SourceFile) []*directoryGroup {
+	groups := make(map[string]*directoryGroup)
+	var order []*directoryGroup
+	for _, f := range byPriority {
+		dir := path.Dir(f.Path)
+		g, ok := groups[dir]
+		if !ok {
+			g = &directoryGroup{dir: dir, priority: sourcefile.Priority(f.Path)}
+			groups[dir] = g
+			order = append(order, g)
+		}
+		g.files = append(g.files, f)
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		if order[i].priority != order[j].priority {
+			return order[i].priority < order[j].priority
+		}
+		return order[i].dir < order[j].dir
+	})
+	return order
+}
+
+// attachContext gives every chunk the shared files it does not analyze
+// itself.
+func attachContext(chunks []Chunk, shared []SourceFile) {
+	for i := range chunks {
+		own := make(map[string]bool, len(chunks[i].Files))
+		for _, f := range chunks[i].Files {
+			own[f.Path] = true
+		}
+		for _, f := range shared {
+			if !own[f.Path] {
+				chunks[i].Context = append(chunks[i].Context, f)
+			}
+		}
+	}
+}
+
+func overLimit(c Chunk, opts ChunkOptions) []SkippedFile {
+	skipped := make([]SkippedFile, len(c.Files))
+	for i, f := range c.Files {
+		skipped[i] = SkippedFile{Path: f.Path, Reason: fmt.Sprintf("over the limit of %d analysis chunks", opts.MaxChunks)}
+	}
+	return skipped
+}
+
+// ChunkedResult is the merged outcome of analyzing chunks.
+type ChunkedResult struct {
+	Schemas []*schemair.SchemaIR
+	Chunks  int
+	// Skipped lists the files left out by partitioning or by chunks whose
+	// analysis failed.
+	Skipped []SkippedFile
+	Cache   CacheStats
+}
+
+// AnalyzeChunked partitions files and analyzes the chunks concurrently with
+// a, then merges the schemas per endpoint. With opts.Cache and an analyzer
+// that implements Fingerprinter, files are split with PartitionDirectories,
+// chunks found in the cache are not analyzed again, the others are stored
+// there once analyzed, and MaxChunks only limits the chunks analyzed. A
+// chunk that fails has its files reported as skipped; the scan only fails
+// when every chunk does.
+func AnalyzeChunked(ctx context.Context, a FileAnalyzer, files []SourceFile, language string, mode ScanMode, opts ChunkOptions) (*ChunkedResult, error) {
+	opts = opts.withDefaults()
+	fp, cacheable := a.(Fingerprinter)
+	cacheable = cacheable && opts.Cache != nil
+
+	var chunks []Chunk
+	var skipped []SkippedFile
+	var fingerprint string
+	var keys []string
+	var results [][]*schemair.SchemaIR
+	var cached []bool
+	if cacheable {
+		fingerprint = fp.Fingerprint()
+		all, partitionSkipped := PartitionDirectories(files, opts)
+		skipped = partitionSkipped
+		misses := 0
+		for _, c := range all {
+			key := ChunkKey(fingerprint, c, language, mode)
+			schemas, ok, err := opts.Cache.Get(ctx, key)
+			if err != nil {
+				log.Printf("[analyzer] cache lookup failed: %v", err)
+			}
+			if !ok {
+				if misses == opts.MaxChunks {
+					skipped = append(skipped, overLimit(c, opts)...)
+					continue
+				}
+				misses++
+			}
+			chunks = append(chunks, c)
+			keys = append(keys, key)
+			results = append(results, schemas)
+			cached = append(cached, ok)
+		}
+	} else {
+		chunks, skipped = Partition(files, opts)
+		keys = make([]string, len(chunks))
+		results = make([][]*schemair.SchemaIR, len(chunks))
+		cached = make([]bool, len(chunks))
+	}
+	res := &ChunkedResult{Chunks: len(chunks), Skipped: skipped}
+	if len(chunks) == 0 {
+		return res, nil
+	}
+
+	errs := make([]error, len(chunks))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < opts.Parallelism && w < len(chunks); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], errs[i] = analyzeChunk(ctx, a, chunks[i], language, mode)
+				if errs[i] == nil && keys[i] != "" {
+					if err := opts.Cache.Put(ctx, keys[i], fingerprint, results[i]); err != nil {
+						log.Printf("[analyzer] failed to cache chunk: %v", err)
+					}
+				}
+			}
+		}()
+	}
+	for i := range chunks {
+		if !cached[i] {
+			jobs <- i
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var firstErr error
+	var all []*schemair.SchemaIR
+	succeeded := 0
+	for i, c := range chunks {
+		if errs[i] != nil {
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+			for _, f := range c.Files {
+				res.Skipped = append(res.Skipped, SkippedFile{Path: f.Path, Reason: "analysis failed: " + errs[i].Error()})
+			}
+			continue
+		}
+		succeeded++
+		all = append(all, results[i]...)
+		if cached[i] {
+			res.Cache.Hits++
+			res.Cache.CachedFiles += len(c.Files)
+		} else if keys[i] != "" {
+			res.Cache.Misses++
+		}
+	}
+	if succeeded == 0 {
+		return nil, firstErr
+	}
+
+	res.Schemas = MergeSchemas(all)
+	return res, nil
+}
+
+func analyzeChunk(ctx context.Context, a FileAnalyzer, c Chunk, language string, mode ScanMode) ([]*schemair.SchemaIR, error) {
+	if ca, ok := a.(ContextAnalyzer); ok && len(c.Context) > 0 {
+		return ca.AnalyzeFilesWithContext(ctx, c.Files, c.Context, language, mode)
+	}
+	return a.AnalyzeFiles(ctx, c.Files, language, mode)
+}
+
+// MergeSchemas de-duplicates schemas describing the same endpoint, matched
+// by method and path regardless of path parameter names. When chunks
+// disagree, the schema describing the most fields wins and the others only
+// fill in what it lacks.
+func MergeSchemas(schemas []*schemair.SchemaIR) []*schemair.SchemaIR {
+	ranked := make([]*schemair.SchemaIR, len(schemas))
+	copy(ranked, schemas)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return schemaWeight(ranked[i]) > schemaWeight(ranked[j])
+	})
+
+	merged := newSchemaSet()
+	for _, s := range ranked {
+		merged.add(s)
+	}
+	sort.SliceStable(merged.list, func(i, j int) bool {
+		if merged.list[i].Endpoint != merged.list[j].Endpoint {
+			return merged.list[i].Endpoint < merged.list[j].Endpoint
+		}
+		return merged.list[i].Method < merged.list[j].Method
+	})
+	return merged.list
+}
+
+// schemaWeight counts the fields, parameters and responses a schema
+// describes.
+func schemaWeight(s *schemair.SchemaIR) int {
+	w := objectWeight(s.Request) + len(s.PathParams) + len(s.QueryParams) + len(s.Headers)
+	for _, body := range s.Response {
+		w += 1 + objectWeight(body)
+	}
+	return w
+}
+
+func objectWeight(o *schemair.ObjectSchema) int {
+	if o == nil {
+		return 0
+	}
+	w := objectWeight(o.Items)
+	for _, f := range o.Fields {
+		w += 1 + objectWeight(f.Nested)
+	}
+	return w
+}
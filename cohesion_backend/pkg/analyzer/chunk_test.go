@@ -0,0 +1,212 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+// sized returns a file whose content is estimated at the given tokens.
+func sized(path string, tokens int) SourceFile {
+	return SourceFile{Path: path, Content: strings.Repeat("x", (tokens-1)*bytesPerToken)}
+}
+
+var largeTree = []SourceFile{
+	sized("users/users.go", 200),
+	sized("billing/invoice.go", 150),
+	sized("api/routes.go", 60),
+	sized("huge/big.go", 500),
+	sized("billing/models.go", 40),
+	sized("api/handlers.go", 100),
+}
+
+func chunkPaths(files []SourceFile) []string {
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	return paths
+}
+
+func TestPartitionSingleChunk(t *testing.T) {
+	chunks, skipped := Partition(mixedTree, ChunkOptions{})
+	if len(chunks) != 1 || len(chunks[0].Files) != len(mixedTree) || chunks[0].Context != nil || skipped != nil {
+		t.Errorf("chunks = %+v, skipped = %v", chunks, skipped)
+	}
+}
+
+func TestPartition(t *testing.T) {
+	chunks, skipped := Partition(largeTree, ChunkOptions{MaxTokens: 400})
+
+	want := []struct{ files, context []string }{
+		{files: []string{"api/routes.go", "api/handlers.go"}, context: []string{"billing/models.go"}},
+		{files: []string{"billing/models.go", "billing/invoice.go"}, context: []string{"api/routes.go"}},
+		{files: []string{"users/users.go"}, context: []string{"api/routes.go", "billing/models.go"}},
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("got %d chunks, want %d", len(chunks), len(want))
+	}
+	for i, w := range want {
+		if got := chunkPaths(chunks[i].Files); !reflect.DeepEqual(got, w.files) {
+			t.Errorf("chunk %d files = %v, want %v", i, got, w.files)
+		}
+		if got := chunkPaths(chunks[i].Context); !reflect.DeepEqual(got, w.context) {
+			t.Errorf("chunk %d context = %v, want %v", i, got, w.context)
+		}
+	}
+	if want := []SkippedFile{{Path: "huge/big.go", Reason: "larger than one analysis chunk"}}; !reflect.DeepEqual(skipped, want) {
+		t.Errorf("skipped = %v, want %v", skipped, want)
+	}
+
+	chunks, skipped = Partition(largeTree, ChunkOptions{MaxTokens: 400, MaxChunks: 2})
+	if len(chunks) != 2 || len(skipped) != 2 {
+		t.Fatalf("got %d chunks, skipped %v", len(chunks), skipped)
+	}
+	if skipped[1].Path != "users/users.go" || !strings.Contains(skipped[1].Reason, "limit of 2") {
+		t.Errorf("skipped = %v", skipped)
+	}
+}
+
+func TestPartitionDirectories(t *testing.T) {
+	chunks, skipped := PartitionDirectories(largeTree, ChunkOptions{MaxTokens: 400})
+
+	want := []struct{ files, context []string }{
+		{files: []string{"api/routes.go", "api/handlers.go"}, context: []string{"billing/models.go"}},
+		{files: []string{"billing/models.go", "billing/invoice.go"}, context: []string{"api/routes.go"}},
+		{files: []string{"users/users.go"}, context: []string{"api/routes.go", "billing/models.go"}},
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("got %d chunks, want %d", len(chunks), len(want))
+	}
+	for i, w := range want {
+		if got := chunkPaths(chunks[i].Files); !reflect.DeepEqual(got, w.files) {
+			t.Errorf("chunk %d files = %v, want %v", i, got, w.files)
+		}
+		if got := chunkPaths(chunks[i].Context); !reflect.DeepEqual(got, w.context) {
+			t.Errorf("chunk %d context = %v, want %v", i, got, w.context)
+		}
+	}
+	if len(skipped) != 1 || skipped[0].Path != "huge/big.go" {
+		t.Errorf("skipped = %v", skipped)
+	}
+
+	// Small trees are split too, and a single directory has no context.
+	chunks, _ = PartitionDirectories(largeTree[:3], ChunkOptions{})
+	if len(chunks) != 3 {
+		t.Errorf("got %d chunks, want one per directory", len(chunks))
+	}
+	chunks, _ = PartitionDirectories([]SourceFile{sized("a/x.go", 10), sized("a/y.go", 10)}, ChunkOptions{MaxTokens: 15})
+	if len(chunks) != 2 || chunks[0].Context != nil {
+		t.Errorf("chunks = %+v", chunks)
+	}
+}
+
+// chunkAnalyzer returns the schemas listed for each file it is given and
+// fails chunks containing the fail path.
+type chunkAnalyzer struct {
+	fakeAnalyzer
+	byFile map[string][]*schemair.SchemaIR
+	fail   string
+
+	mu       sync.Mutex
+	contexts map[string][]string
+	calls    int
+}
+
+func (c *chunkAnalyzer) AnalyzeFiles(ctx context.Context, files []SourceFile, language string, mode ScanMode) ([]*schemair.SchemaIR, error) {
+	return c.AnalyzeFilesWithContext(ctx, files, nil, language, mode)
+}
+
+func (c *chunkAnalyzer) AnalyzeFilesWithContext(ctx context.Context, files, shared []SourceFile, language string, mode ScanMode) ([]*schemair.SchemaIR, error) {
+	var out []*schemair.SchemaIR
+	for _, f := range files {
+		if f.Path == c.fail {
+			return nil, errors.New("model timed out")
+		}
+		out = append(out, c.byFile[f.Path]...)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	if c.contexts == nil {
+		c.contexts = make(map[string][]string)
+	}
+	c.contexts[files[0].Path] = chunkPaths(shared)
+	return out, nil
+}
+
+func TestAnalyzeChunked(t *testing.T) {
+	a := &chunkAnalyzer{
+		byFile: map[string][]*schemair.SchemaIR{
+			"api/routes.go": {
+				{Endpoint: "/invoices/{id}", Method: "GET", Response: map[int]*schemair.ObjectSchema{200: {Type: "object"}}},
+			},
+			"api/handlers.go": {
+				{Endpoint: "/invoices", Method: "POST"},
+			},
+			"billing/invoice.go": {
+				{Endpoint: "/invoices/{invoiceID}/", Method: "GET", Response: map[int]*schemair.ObjectSchema{
+					200: {Type: "object", Fields: map[string]*schemair.Field{"id": {Type: "string"}, "total": {Type: "number"}}},
+				}},
+			},
+		},
+		fail: "users/users.go",
+	}
+
+	res, err := AnalyzeChunked(context.Background(), a, largeTree, "Go", ScanModeBackend, ChunkOptions{MaxTokens: 400, Parallelism: 2})
+	if err != nil {
+		t.Fatalf("AnalyzeChunked: %v", err)
+	}
+	if res.Chunks != 3 {
+		t.Errorf("chunks = %d, want 3", res.Chunks)
+	}
+	if got := a.contexts["billing/models.go"]; !reflect.DeepEqual(got, []string{"api/routes.go"}) {
+		t.Errorf("billing chunk context = %v", got)
+	}
+
+	if len(res.Schemas) != 2 {
+		t.Fatalf("expected 2 merged schemas, got %d", len(res.Schemas))
+	}
+	get := res.Schemas[1]
+	if get.Method != "GET" || len(get.Response[200].Fields) != 2 {
+		t.Errorf("merged GET = %+v", get)
+	}
+
+	if len(res.Skipped) != 2 || res.Skipped[0].Path != "huge/big.go" {
+		t.Fatalf("skipped = %v", res.Skipped)
+	}
+	if s := res.Skipped[1]; s.Path != "users/users.go" || !strings.HasPrefix(s.Reason, "analysis failed: ") {
+		t.Errorf("skipped = %v", s)
+	}
+}
+
+func TestAnalyzeChunkedAllFail(t *testing.T) {
+	a := &chunkAnalyzer{fail: "api/routes.go"}
+	_, err := AnalyzeChunked(context.Background(), a, largeTree[2:3], "Go", ScanModeBackend, ChunkOptions{})
+	if err == nil {
+		t.Error("expected an error when no chunk succeeded")
+	}
+}
+
+func TestMergeSchemas(t *testing.T) {
+	thin := &schemair.SchemaIR{Endpoint: "/users/{id}", Method: "GET", Response: map[int]*schemair.ObjectSchema{404: {Type: "object"}}}
+	rich := &schemair.SchemaIR{Endpoint: "/users/{userId}", Method: "GET", Response: map[int]*schemair.ObjectSchema{
+		200: {Type: "object", Fields: map[string]*schemair.Field{"name": {Type: "string"}}},
+	}}
+	other := &schemair.SchemaIR{Endpoint: "/users/{id}", Method: "DELETE"}
+
+	merged := MergeSchemas([]*schemair.SchemaIR{thin, other, rich})
+	if len(merged) != 2 {
+		t.Fatalf("expected 2 schemas, got %d", len(merged))
+	}
+	got := merged[1]
+	if got.Endpoint != "/users/{userId}" || got.Response[200] == nil || got.Response[404] == nil {
+		t.Errorf("merged = %+v", got)
+	}
+}
@@ -0,0 +1,136 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/sourcefile"
+)
+
+// Detection describes the languages and frameworks found in a scanned
+// tree.
+type Detection struct {
+	// Language is the most common language by file count.
+	Language  string         `json:"language"`
+	Languages map[string]int `json:"languages"`
+	// Frameworks lists the frameworks each language's manifests depend on.
+	// A language without manifests has no entry.
+	Frameworks map[string][]string `json:"frameworks,omitempty"`
+}
+
+// goModules maps module paths in go.mod to framework names. A Go module
+// always counts as net/http too.
+var goModules = map[string]string{
+	"github.com/go-chi/chi":    "chi",
+	"github.com/gin-gonic/gin": "gin",
+	"github.com/labstack/echo": "echo",
+	"github.com/gorilla/mux":   "gorilla/mux",
+	"github.com/gofiber/fiber": "fiber",
+}
+
+var npmPackages = map[string]string{
+	"express":       "express",
+	"fastify":       "fastify",
+	"koa":           "koa",
+	"hono":          "hono",
+	"@nestjs/core":  "nestjs",
+	"next":          "next",
+	"react":         "react",
+	"vue":           "vue",
+	"@angular/core": "angular",
+	"axios":         "axios",
+}
+
+var pythonPackages = regexp.MustCompile(`(?im)^[\s"']*(fastapi|flask|django|starlette|aiohttp)\b`)
+
+// Detect counts files per language using sourcefile.LanguageHints and reads
+// go.mod, package.json, pyproject.toml and requirements.txt for
+// frameworks.
+func Detect(files []SourceFile) Detection {
+	d := Detection{Languages: make(map[string]int)}
+	frameworks := make(map[string]map[string]bool)
+	add := func(language, framework string) {
+		if frameworks[language] == nil {
+			frameworks[language] = make(map[string]bool)
+		}
+		if framework != "" {
+			frameworks[language][framework] = true
+		}
+	}
+
+	for _, f := range files {
+		switch filepath.Base(f.Path) {
+		case "go.mod":
+			add("Go", "net/http")
+			for _, line := range strings.Split(f.Content, "\n") {
+				fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(line), "require"))
+				if len(fields) == 0 {
+					continue
+				}
+				for module, name := range goModules {
+					if fields[0] == module || strings.HasPrefix(fields[0], module+"/") {
+						add("Go", name)
+					}
+				}
+			}
+		case "package.json":
+			var pkg struct {
+				Dependencies    map[string]string `json:"dependencies"`
+				DevDependencies map[string]string `json:"devDependencies"`
+			}
+			if err := json.Unmarshal([]byte(f.Content), &pkg); err != nil {
+				continue
+			}
+			language := "JavaScript"
+			if _, ok := pkg.DevDependencies["typescript"]; ok {
+				language = "TypeScript"
+			}
+			add(language, "")
+			for _, deps := range []map[string]string{pkg.Dependencies, pkg.DevDependencies} {
+				for dep := range deps {
+					if name, ok := npmPackages[dep]; ok {
+						add(language, name)
+					}
+				}
+			}
+		case "pyproject.toml", "requirements.txt":
+			add("Python", "")
+			for _, m := range pythonPackages.FindAllStringSubmatch(f.Content, -1) {
+				add("Python", strings.ToLower(m[1]))
+			}
+		default:
+			if language := sourcefile.LanguageHints[strings.ToLower(filepath.Ext(f.Path))]; language != "" {
+				d.Languages[language]++
+			}
+		}
+	}
+
+	max := 0
+	for language, count := range d.Languages {
+		if count > max || (count == max && language < d.Language) {
+			max = count
+			d.Language = language
+		}
+	}
+	if len(frameworks) > 0 {
+		d.Frameworks = make(map[string][]string, len(frameworks))
+		for language, names := range frameworks {
+			list := make([]string, 0, len(names))
+			for name := range names {
+				list = append(list, name)
+			}
+			sort.Strings(list)
+			d.Frameworks[language] = list
+		}
+	}
+	return d
+}
+
+// fileLanguage returns the language of a source file, or "" for manifests
+// and files without a language hint.
+func fileLanguage(path string) string {
+	return sourcefile.LanguageHints[strings.ToLower(filepath.Ext(path))]
+}
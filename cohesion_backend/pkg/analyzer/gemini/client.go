@@ -1,60 +0,0 @@
-package gemini
-
-import (
-	"context"
-	"fmt"
-
-	"github.com/google/generative-ai-go/genai"
-	"google.golang.org/api/option"
-)
-
-type Client struct {
-	client *genai.Client
-	model  *genai.GenerativeModel
-}
-
-func NewClient(ctx context.Context, apiKey, modelName string) (*Client, error) {
-	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
-	}
-
-	model := client.GenerativeModel(modelName)
-	model.SetTemperature(0)
-	model.ResponseMIMEType = "application/json"
-
-	return &Client{
-		client: client,
-		model:  model,
-	}, nil
-}
-
-func (c *Client) Generate(ctx context.Context, prompt string) (string, error) {
-	resp, err := c.model.GenerateContent(ctx, genai.Text(prompt))
-	if err != nil {
-		return "", fmt.Errorf("gemini generation failed: %w", err)
-	}
-
-	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
-		return "", fmt.Errorf("gemini returned no candidates")
-	}
-
-	var result string
-	for _, part := range resp.Candidates[0].Content.Parts {
-		if text, ok := part.(genai.Text); ok {
-			result += string(text)
-		}
-	}
-
-	if result == "" {
-		return "", fmt.Errorf("gemini returned empty response")
-	}
-
-	return result, nil
-}
-
-func (c *Client) Close() {
-	if c.client != nil {
-		c.client.Close()
-	}
-}
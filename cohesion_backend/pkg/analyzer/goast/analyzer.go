@@ -0,0 +1,464 @@
+// Package goast extracts endpoint schemas from Go backends by reading their
+// syntax trees. It finds route registrations for net/http, chi, gin and
+// echo, resolves the structs handlers decode and encode, and emits Schema IR
+// with confidence 1.0 without calling out to a model or the network.
+//
+// Only the parsed files are consulted, without type checking: types from
+// packages outside the scan are mapped when they are well known (time.Time,
+// uuid.UUID, sql.Null*) and become "any" otherwise, which
+// AnalyzeFilesCoverage reports as skipped.
+package goast
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/analyzer"
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+	"github.com/cohesion-api/cohesion_backend/pkg/sourcefile"
+)
+
+// Frameworks lists the routers whose registrations are recognised.
+var Frameworks = []string{"net/http", "chi", "gin", "echo"}
+
+type Analyzer struct{}
+
+func New() *Analyzer {
+	return &Analyzer{}
+}
+
+func (a *Analyzer) Language() string  { return "go" }
+func (a *Analyzer) Framework() string { return strings.Join(Frameworks, ", ") }
+
+// Analyze reads the Go files under sourcePath, skipping tests and vendored
+// or generated directories.
+func (a *Analyzer) Analyze(ctx context.Context, sourcePath string) ([]*schemair.SchemaIR, error) {
+	var files []analyzer.SourceFile
+	err := filepath.Walk(sourcePath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if p != sourcePath && sourcefile.SkipDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, _ := filepath.Rel(sourcePath, p)
+		rel = filepath.ToSlash(rel)
+		if filepath.Ext(p) != ".go" || sourcefile.IsTestFile(rel) {
+			return nil
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		files = append(files, analyzer.SourceFile{Path: rel, Content: string(content)})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no Go files found in %s", sourcePath)
+	}
+	return a.AnalyzeFiles(ctx, files, "Go", analyzer.ScanModeBackend)
+}
+
+// AnalyzeFiles extracts the endpoints served by the Go files among files.
+// Other files are ignored. Only backend scans are supported.
+func (a *Analyzer) AnalyzeFiles(ctx context.Context, files []analyzer.SourceFile, language string, mode analyzer.ScanMode) ([]*schemair.SchemaIR, error) {
+	schemas, _, err := a.AnalyzeFilesCoverage(ctx, files, language, mode)
+	return schemas, err
+}
+
+// AnalyzeFilesCoverage is AnalyzeFiles also reporting the files the
+// schemas were read from, those registering routes, declaring their
+// handlers or declaring the types they use, and the types it could not
+// resolve. Files with routes for an unrecognised router are not covered.
+func (a *Analyzer) AnalyzeFilesCoverage(ctx context.Context, files []analyzer.SourceFile, language string, mode analyzer.ScanMode) ([]*schemair.SchemaIR, *analyzer.Coverage, error) {
+	if mode == analyzer.ScanModeFrontend {
+		return nil, nil, fmt.Errorf("the Go analyzer only extracts backend endpoints")
+	}
+
+	ix, err := buildIndex(ctx, files)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byKey := make(map[string]*schemair.SchemaIR)
+	for _, rt := range newWalker(ix).discover() {
+		for _, schema := range ix.endpointSchemas(rt) {
+			key := schema.Method + " " + schema.Endpoint
+			if _, ok := byKey[key]; !ok {
+				byKey[key] = schema
+			}
+		}
+	}
+
+	schemas := make([]*schemair.SchemaIR, 0, len(byKey))
+	for _, schema := range byKey {
+		finalize(schema)
+		schemas = append(schemas, schema)
+	}
+	sort.Slice(schemas, func(i, j int) bool {
+		if schemas[i].Endpoint != schemas[j].Endpoint {
+			return schemas[i].Endpoint < schemas[j].Endpoint
+		}
+		return schemas[i].Method < schemas[j].Method
+	})
+
+	coverage := &analyzer.Coverage{Files: make([]string, 0, len(ix.covered))}
+	for p := range ix.covered {
+		coverage.Files = append(coverage.Files, p)
+	}
+	sort.Strings(coverage.Files)
+	for typ, p := range ix.unresolved {
+		coverage.SkippedTypes = append(coverage.SkippedTypes, analyzer.SkippedType{Type: typ, Path: p})
+	}
+	sort.Slice(coverage.SkippedTypes, func(i, j int) bool {
+		return coverage.SkippedTypes[i].Type < coverage.SkippedTypes[j].Type
+	})
+	return schemas, coverage, nil
+}
+
+type sourceFile struct {
+	path string
+	dir  string
+	ast  *ast.File
+	// imports maps each import's local name to its path.
+	imports map[string]string
+}
+
+type typeDecl struct {
+	spec *ast.TypeSpec
+	file *sourceFile
+}
+
+type funcDecl struct {
+	decl *ast.FuncDecl
+	file *sourceFile
+}
+
+// index holds the declarations of every parsed file, by name. Lookups
+// follow Go's scoping: unqualified names resolve within the file's
+// directory and qualified ones within the imported package.
+type index struct {
+	files   []*sourceFile
+	types   map[string][]*typeDecl
+	funcs   map[string][]*funcDecl
+	methods map[string][]*funcDecl
+	// enums holds the constant values declared for a named type, keyed by
+	// directory and type name.
+	enums map[string][]interface{}
+	// marshalers are the types with their own JSON encoding, whose shape
+	// cannot be read from their fields.
+	marshalers map[string]bool
+	// covered holds the paths of the files endpoints were read from.
+	covered map[string]bool
+	// unresolved maps the types that became "any" because they are not
+	// declared in the parsed files to the first file using them.
+	unresolved map[string]string
+}
+
+func buildIndex(ctx context.Context, files []analyzer.SourceFile) (*index, error) {
+	ix := &index{
+		types:      make(map[string][]*typeDecl),
+		funcs:      make(map[string][]*funcDecl),
+		methods:    make(map[string][]*funcDecl),
+		enums:      make(map[string][]interface{}),
+		marshalers: make(map[string]bool),
+		covered:    make(map[string]bool),
+		unresolved: make(map[string]string),
+	}
+
+	sorted := make([]analyzer.SourceFile, 0, len(files))
+	for _, f := range files {
+		p := filepath.ToSlash(f.Path)
+		if strings.HasSuffix(p, ".go") && !sourcefile.IsTestFile(p) && !sourcefile.InSkippedDir(p) {
+			sorted = append(sorted, f)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+
+	fset := token.NewFileSet()
+	for _, f := range sorted {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// Files that do not parse are skipped; the rest of the package is
+		// still useful.
+		parsed, err := parser.ParseFile(fset, f.Path, f.Content, parser.SkipObjectResolution)
+		if err != nil && parsed == nil {
+			continue
+		}
+		sf := &sourceFile{
+			path:    filepath.ToSlash(f.Path),
+			dir:     path.Dir(filepath.ToSlash(f.Path)),
+			ast:     parsed,
+			imports: make(map[string]string),
+		}
+		for _, imp := range parsed.Imports {
+			p, _ := strconv.Unquote(imp.Path.Value)
+			name := packageName(p)
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			sf.imports[name] = p
+		}
+		ix.files = append(ix.files, sf)
+		ix.addDecls(sf)
+	}
+	if len(ix.files) == 0 {
+		return nil, fmt.Errorf("no Go source files could be parsed")
+	}
+	return ix, nil
+}
+
+func (ix *index) addDecls(f *sourceFile) {
+	for _, decl := range f.ast.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			fd := &funcDecl{decl: d, file: f}
+			if d.Recv == nil || len(d.Recv.List) == 0 {
+				ix.funcs[d.Name.Name] = append(ix.funcs[d.Name.Name], fd)
+				continue
+			}
+			ix.methods[d.Name.Name] = append(ix.methods[d.Name.Name], fd)
+			if d.Name.Name == "MarshalJSON" || d.Name.Name == "MarshalText" {
+				if recv := baseTypeName(d.Recv.List[0].Type); recv != "" {
+					ix.marshalers[f.dir+"."+recv] = true
+				}
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					ix.types[s.Name.Name] = append(ix.types[s.Name.Name], &typeDecl{spec: s, file: f})
+				case *ast.ValueSpec:
+					if d.Tok == token.CONST {
+						ix.addEnumValues(f, s)
+					}
+				}
+			}
+		}
+	}
+}
+
+// addEnumValues records constants declared with an explicit named type and
+// a literal value, e.g. StatusActive Status = "active".
+func (ix *index) addEnumValues(f *sourceFile, s *ast.ValueSpec) {
+	typ, ok := s.Type.(*ast.Ident)
+	if !ok || len(s.Values) != len(s.Names) {
+		return
+	}
+	key := f.dir + "." + typ.Name
+	for _, v := range s.Values {
+		if val, ok := literalValue(v); ok {
+			ix.enums[key] = append(ix.enums[key], val)
+		}
+	}
+}
+
+func (ix *index) lookupType(name, qualifier string, from *sourceFile) *typeDecl {
+	for _, td := range ix.types[name] {
+		if inPackage(td.file, qualifier, from) {
+			return td
+		}
+	}
+	return nil
+}
+
+func (ix *index) lookupFunc(name, qualifier string, from *sourceFile) *funcDecl {
+	for _, fd := range ix.funcs[name] {
+		if inPackage(fd.file, qualifier, from) {
+			return fd
+		}
+	}
+	return nil
+}
+
+// lookupMethod finds a method by name alone, for receivers whose type
+// cannot be inferred. A method in the caller's directory is preferred;
+// otherwise the name must be unambiguous.
+func (ix *index) lookupMethod(name string, from *sourceFile) *funcDecl {
+	cands := ix.methods[name]
+	for _, fd := range cands {
+		if fd.file.dir == from.dir {
+			return fd
+		}
+	}
+	if len(cands) == 1 {
+		return cands[0]
+	}
+	return nil
+}
+
+// methodOf finds the method name declared on the named type td.
+func (ix *index) methodOf(td *typeDecl, name string) *funcDecl {
+	for _, fd := range ix.methods[name] {
+		if fd.file.dir == td.file.dir && baseTypeName(fd.decl.Recv.List[0].Type) == td.spec.Name.Name {
+			return fd
+		}
+	}
+	return nil
+}
+
+// lookupCallee resolves the function a call expression calls, using the
+// types of the variables in scope to pick among methods of the same name.
+func (ix *index) lookupCallee(fun ast.Expr, scope *funcScope) *funcDecl {
+	switch f := fun.(type) {
+	case *ast.Ident:
+		return ix.lookupFunc(f.Name, "", scope.file)
+	case *ast.SelectorExpr:
+		if x, ok := f.X.(*ast.Ident); ok {
+			if _, isPkg := scope.file.imports[x.Name]; isPkg {
+				return ix.lookupFunc(f.Sel.Name, x.Name, scope.file)
+			}
+		}
+		if ref := ix.exprType(f.X, scope, 0); ref != nil {
+			if td := ix.lookupNamed(ref.expr, ref.file); td != nil {
+				if fd := ix.methodOf(td, f.Sel.Name); fd != nil {
+					return fd
+				}
+			}
+		}
+		return ix.lookupMethod(f.Sel.Name, scope.file)
+	case *ast.ParenExpr:
+		return ix.lookupCallee(f.X, scope)
+	}
+	return nil
+}
+
+// inPackage reports whether decl's file belongs to the package that
+// qualifier names in from, or to from's own package when it is empty.
+func inPackage(decl *sourceFile, qualifier string, from *sourceFile) bool {
+	if qualifier == "" {
+		return decl.dir == from.dir
+	}
+	imp, ok := from.imports[qualifier]
+	if !ok {
+		return false
+	}
+	return strings.HasSuffix(imp, "/"+decl.dir) || imp == decl.dir ||
+		(decl.ast.Name.Name == packageName(imp) && path.Base(decl.dir) == path.Base(imp))
+}
+
+// packageName guesses a package's name from its import path, skipping
+// major version suffixes: github.com/go-chi/chi/v5 is chi and
+// gopkg.in/yaml.v3 is yaml.
+func packageName(importPath string) string {
+	parts := strings.Split(importPath, "/")
+	name := parts[len(parts)-1]
+	if len(parts) > 1 && isMajorVersion(name) {
+		name = parts[len(parts)-2]
+	}
+	if i := strings.Index(name, ".v"); i > 0 {
+		name = name[:i]
+	}
+	return strings.ReplaceAll(name, "-", "")
+}
+
+func isMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(s[1:])
+	return err == nil
+}
+
+func baseTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return baseTypeName(t.X)
+	case *ast.IndexExpr:
+		return baseTypeName(t.X)
+	case *ast.IndexListExpr:
+		return baseTypeName(t.X)
+	}
+	return ""
+}
+
+// literalValue returns the value of a string, number or boolean literal,
+// with numbers as float64 like decoded JSON.
+func literalValue(expr ast.Expr) (interface{}, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		switch e.Kind {
+		case token.STRING:
+			s, err := strconv.Unquote(e.Value)
+			return s, err == nil
+		case token.INT, token.FLOAT:
+			f, err := strconv.ParseFloat(e.Value, 64)
+			return f, err == nil
+		}
+	case *ast.Ident:
+		if e.Name == "true" || e.Name == "false" {
+			return e.Name == "true", true
+		}
+	case *ast.ParenExpr:
+		return literalValue(e.X)
+	}
+	return nil, false
+}
+
+func stringLit(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
+
+// finalize marks every field as statically known.
+func finalize(schema *schemair.SchemaIR) {
+	if schema.Request != nil {
+		finalizeObject(schema.Request)
+	}
+	for _, obj := range schema.Response {
+		if obj != nil {
+			finalizeObject(obj)
+		}
+	}
+	for i := range schema.PathParams {
+		finalizeField(&schema.PathParams[i].Field)
+	}
+	for _, f := range schema.QueryParams {
+		finalizeField(f)
+	}
+	for _, f := range schema.Headers {
+		finalizeField(f)
+	}
+}
+
+func finalizeObject(obj *schemair.ObjectSchema) {
+	for _, f := range obj.Fields {
+		finalizeField(f)
+	}
+	if obj.Items != nil {
+		finalizeObject(obj.Items)
+	}
+}
+
+func finalizeField(f *schemair.Field) {
+	f.Confidence = 1.0
+	f.SourceTag = schemair.SourceBackendStatic
+	if f.Nested != nil {
+		finalizeObject(f.Nested)
+	}
+}
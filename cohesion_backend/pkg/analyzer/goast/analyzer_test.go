@@ -0,0 +1,543 @@
+package goast
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/analyzer"
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+const modelsFile = `package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Role string
+
+const (
+	RoleAdmin  Role = "admin"
+	RoleMember Role = "member"
+)
+
+type Base struct {
+	ID        uuid.UUID ` + "`json:\"id\"`" + `
+	CreatedAt time.Time ` + "`json:\"created_at\"`" + `
+}
+
+type User struct {
+	Base
+	Name     string   ` + "`json:\"name\"`" + `
+	Email    *string  ` + "`json:\"email\"`" + `
+	Nickname string   ` + "`json:\"nickname,omitempty\"`" + `
+	Role     Role     ` + "`json:\"role\"`" + `
+	Password string   ` + "`json:\"-\"`" + `
+	Manager  *User    ` + "`json:\"manager,omitempty\"`" + `
+	Tags     []string ` + "`json:\"tags\"`" + `
+	internal string
+}
+
+type CreateUserRequest struct {
+	Name  string ` + "`json:\"name\" validate:\"required,min=2,max=64\"`" + `
+	Email string ` + "`json:\"email,omitempty\" validate:\"required,email\"`" + `
+	Role  Role   ` + "`json:\"role\" validate:\"oneof=admin member\"`" + `
+}
+`
+
+const chiServer = `package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"example.com/app/internal/models"
+)
+
+type Handler struct{}
+
+func (h *Handler) listUsers() []models.User { return nil }
+
+func NewRouter(h *Handler) http.Handler {
+	r := chi.NewRouter()
+	r.Route("/api", func(r chi.Router) {
+		r.Get("/users", h.ListUsers)
+		r.Post("/users", h.CreateUser)
+		r.Route("/users/{userID}", func(r chi.Router) {
+			r.Get("/", h.GetUser)
+		})
+	})
+	r.Mount("/admin", adminRoutes(h))
+	return r
+}
+
+func adminRoutes(h *Handler) http.Handler {
+	r := chi.NewRouter()
+	r.Delete("/users/{id:[0-9]+}", h.DeleteUser)
+	return r
+}
+
+func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	_ = page
+	users := h.listUsers()
+	respondJSON(w, http.StatusOK, users)
+}
+
+func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	user := models.User{Name: req.Name}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(user)
+}
+
+func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		return
+	}
+	_ = id
+	if r.Header.Get("X-Request-ID") == "" {
+		return
+	}
+	respondJSON(w, http.StatusOK, &models.User{})
+}
+
+func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	if _, err := strconv.Atoi(idStr); err != nil {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func respondJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func respondError(w http.ResponseWriter, status int, msg string) {
+	respondJSON(w, status, map[string]string{"error": msg})
+}
+`
+
+const ginServer = `package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Order struct {
+	SKU      string  ` + "`json:\"sku\" binding:\"required\"`" + `
+	Quantity int     ` + "`json:\"quantity\" binding:\"required,min=1\"`" + `
+	Note     *string ` + "`json:\"note\"`" + `
+}
+
+type Filter struct {
+	Status string ` + "`form:\"status\"`" + `
+	Limit  int    ` + "`form:\"limit\" binding:\"required\"`" + `
+}
+
+func main() {
+	r := gin.Default()
+	v1 := r.Group("/v1")
+	{
+		v1.GET("/items/:id", auth(), getItem)
+		registerOrders(v1.Group("/orders"))
+	}
+	r.Run()
+}
+
+func registerOrders(rg *gin.RouterGroup) {
+	rg.POST("", createOrder)
+	rg.GET("/*path", listOrders)
+}
+
+func auth() gin.HandlerFunc { return func(c *gin.Context) {} }
+
+func getItem(c *gin.Context) {
+	if c.Param("id") == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": c.Param("id"), "count": 1, "ok": true})
+}
+
+func createOrder(c *gin.Context) {
+	var o Order
+	if err := c.ShouldBindJSON(&o); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, o)
+}
+
+func listOrders(c *gin.Context) {
+	var f Filter
+	c.ShouldBindQuery(&f)
+	c.JSON(http.StatusOK, []Order{})
+}
+`
+
+const echoServer = `package main
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Setting struct {
+	Value   string ` + "`json:\"value\"`" + `
+	Enabled bool   ` + "`json:\"enabled\"`" + `
+}
+
+func main() {
+	e := echo.New()
+	g := e.Group("/admin", middleware)
+	g.PUT("/settings/:key", updateSetting, middleware)
+	e.Start(":8080")
+}
+
+func middleware(next echo.HandlerFunc) echo.HandlerFunc { return next }
+
+func updateSetting(c echo.Context) error {
+	s := new(Setting)
+	if err := c.Bind(s); err != nil {
+		return err
+	}
+	_ = c.Request().Header.Get("X-Tenant")
+	return c.JSON(http.StatusOK, s)
+}
+`
+
+const stdlibServer = `package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type Thing struct {
+	Name string ` + "`json:\"name\"`" + `
+}
+
+func main() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", health)
+	mux.HandleFunc("/things", things)
+	mux.Handle("DELETE /things/{id}", http.HandlerFunc(deleteThing))
+	http.ListenAndServe(":8080", mux)
+}
+
+func health(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+func things(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode([]Thing{})
+	case http.MethodPost:
+		var t Thing
+		json.NewDecoder(r.Body).Decode(&t)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(t)
+	}
+}
+
+func deleteThing(w http.ResponseWriter, r *http.Request) {
+	_ = r.PathValue("id")
+	w.WriteHeader(http.StatusNoContent)
+}
+`
+
+func analyze(t *testing.T, files ...analyzer.SourceFile) map[string]*schemair.SchemaIR {
+	t.Helper()
+	schemas, err := New().AnalyzeFiles(context.Background(), files, "Go", analyzer.ScanModeBackend)
+	if err != nil {
+		t.Fatalf("AnalyzeFiles: %v", err)
+	}
+	byKey := make(map[string]*schemair.SchemaIR)
+	for _, s := range schemas {
+		byKey[s.Method+" "+s.Endpoint] = s
+	}
+	return byKey
+}
+
+func endpoint(t *testing.T, schemas map[string]*schemair.SchemaIR, key string) *schemair.SchemaIR {
+	t.Helper()
+	s, ok := schemas[key]
+	if !ok {
+		keys := make([]string, 0, len(schemas))
+		for k := range schemas {
+			keys = append(keys, k)
+		}
+		t.Fatalf("endpoint %q not found, have %v", key, keys)
+	}
+	return s
+}
+
+func TestChi(t *testing.T) {
+	schemas := analyze(t,
+		analyzer.SourceFile{Path: "internal/models/user.go", Content: modelsFile},
+		analyzer.SourceFile{Path: "internal/server/router.go", Content: chiServer},
+	)
+	if len(schemas) != 4 {
+		t.Errorf("expected 4 endpoints, got %d", len(schemas))
+	}
+
+	list := endpoint(t, schemas, "GET /api/users")
+	if q := list.QueryParams["page"]; q == nil || q.Type != "int" || q.Required {
+		t.Errorf("page query param = %+v", q)
+	}
+	users := list.Response[200]
+	if users == nil || users.Type != "array" || users.Items == nil {
+		t.Fatalf("list response = %+v", users)
+	}
+	user := users.Items.Fields
+	checks := map[string]struct {
+		typ      string
+		required bool
+	}{
+		"id":         {"uuid", true},
+		"created_at": {"time", true},
+		"name":       {"string", true},
+		"email":      {"string", false},
+		"nickname":   {"string", false},
+		"role":       {"string", true},
+		"manager":    {"object", false},
+		"tags":       {"array", true},
+	}
+	for name, want := range checks {
+		f := user[name]
+		if f == nil {
+			t.Errorf("user field %q missing", name)
+			continue
+		}
+		if f.Type != want.typ || f.Required != want.required {
+			t.Errorf("user.%s = %s required=%v, want %s required=%v", name, f.Type, f.Required, want.typ, want.required)
+		}
+		if f.Confidence != 1.0 || f.SourceTag != schemair.SourceBackendStatic {
+			t.Errorf("user.%s confidence %v source %q", name, f.Confidence, f.SourceTag)
+		}
+	}
+	for _, hidden := range []string{"Password", "password", "internal", "Base"} {
+		if _, ok := user[hidden]; ok {
+			t.Errorf("user field %q should not be present", hidden)
+		}
+	}
+	if !user["email"].Nullable {
+		t.Error("pointer field email should be nullable")
+	}
+	if len(user["role"].Enum) != 2 {
+		t.Errorf("role enum = %v", user["role"].Enum)
+	}
+	if user["manager"].Nested != nil {
+		t.Error("self-referential manager should stop at a plain object")
+	}
+
+	create := endpoint(t, schemas, "POST /api/users")
+	req := create.Request
+	if req == nil || req.Fields["name"] == nil || !req.Fields["email"].Required || len(req.Fields["role"].Enum) != 2 {
+		t.Fatalf("create request = %+v", req)
+	}
+	if req.Fields["email"].Format != "email" || *req.Fields["name"].MinLength != 2 || *req.Fields["name"].MaxLength != 64 {
+		t.Errorf("create request constraints = %+v / %+v", req.Fields["email"], req.Fields["name"])
+	}
+	if create.Response[201] == nil || create.Response[201].Fields["name"] == nil {
+		t.Errorf("create 201 response = %+v", create.Response)
+	}
+	if errBody := create.Response[400]; errBody == nil || errBody.Fields["error"] == nil || errBody.Fields["error"].Type != "string" {
+		t.Errorf("create 400 response = %+v", errBody)
+	}
+
+	get := endpoint(t, schemas, "GET /api/users/{userID}")
+	if len(get.PathParams) != 1 || get.PathParams[0].Name != "userID" || get.PathParams[0].Field.Type != "uuid" {
+		t.Errorf("get path params = %+v", get.PathParams)
+	}
+	if get.Headers["x-request-id"] == nil {
+		t.Errorf("get headers = %+v", get.Headers)
+	}
+
+	del := endpoint(t, schemas, "DELETE /admin/users/{id}")
+	if len(del.PathParams) != 1 || del.PathParams[0].Field.Type != "int" {
+		t.Errorf("delete path params = %+v", del.PathParams)
+	}
+}
+
+func TestGin(t *testing.T) {
+	schemas := analyze(t, analyzer.SourceFile{Path: "main.go", Content: ginServer})
+
+	get := endpoint(t, schemas, "GET /v1/items/{id}")
+	ok := get.Response[200]
+	if ok == nil || ok.Fields["count"].Type != "int" || ok.Fields["ok"].Type != "bool" || ok.Fields["id"].Type != "string" {
+		t.Errorf("item 200 response = %+v", ok)
+	}
+	if nf := get.Response[404]; nf == nil || nf.Fields["error"] == nil {
+		t.Errorf("item 404 response = %+v", nf)
+	}
+
+	create := endpoint(t, schemas, "POST /v1/orders")
+	if create.Request == nil {
+		t.Fatal("order request not resolved")
+	}
+	fields := create.Request.Fields
+	if !fields["sku"].Required || fields["note"].Required || *fields["quantity"].Minimum != 1 {
+		t.Errorf("order request = %+v", fields)
+	}
+	if create.Response[400] == nil || create.Response[400].Fields["error"].Type != "string" {
+		t.Errorf("order 400 response = %+v", create.Response[400])
+	}
+
+	list := endpoint(t, schemas, "GET /v1/orders/{path}")
+	if list.QueryParams["status"] == nil || !list.QueryParams["limit"].Required || list.QueryParams["limit"].Type != "int" {
+		t.Errorf("order query params = %+v", list.QueryParams)
+	}
+	if list.Response[200] == nil || list.Response[200].Type != "array" {
+		t.Errorf("order list response = %+v", list.Response[200])
+	}
+}
+
+func TestEcho(t *testing.T) {
+	schemas := analyze(t, analyzer.SourceFile{Path: "main.go", Content: echoServer})
+
+	put := endpoint(t, schemas, "PUT /admin/settings/{key}")
+	if put.Request == nil || put.Request.Fields["enabled"].Type != "bool" {
+		t.Errorf("setting request = %+v", put.Request)
+	}
+	if put.Response[200] == nil || put.Response[200].Fields["value"] == nil {
+		t.Errorf("setting response = %+v", put.Response)
+	}
+	if put.Headers["x-tenant"] == nil {
+		t.Errorf("setting headers = %+v", put.Headers)
+	}
+}
+
+func TestNetHTTP(t *testing.T) {
+	schemas := analyze(t, analyzer.SourceFile{Path: "main.go", Content: stdlibServer})
+	if len(schemas) != 4 {
+		t.Errorf("expected 4 endpoints, got %d", len(schemas))
+	}
+
+	health := endpoint(t, schemas, "GET /health")
+	if health.Response[200] == nil || health.Response[200].Fields["status"] == nil {
+		t.Errorf("health response = %+v", health.Response)
+	}
+	list := endpoint(t, schemas, "GET /things")
+	if list.Request != nil || list.Response[200].Type != "array" {
+		t.Errorf("things GET = %+v", list)
+	}
+	create := endpoint(t, schemas, "POST /things")
+	if create.Request == nil || create.Request.Fields["name"] == nil || create.Response[201] == nil {
+		t.Errorf("things POST = %+v", create)
+	}
+	del := endpoint(t, schemas, "DELETE /things/{id}")
+	if len(del.PathParams) != 1 {
+		t.Errorf("delete path params = %+v", del.PathParams)
+	}
+}
+
+func TestAnalyzeDirectory(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go":              stdlibServer,
+		"main_test.go":         "package main\n\nfunc init() { http.HandleFunc(\"/test-only\", nil) }\n",
+		"vendor/x/x.go":        "package x\n\nfunc init() { http.HandleFunc(\"/vendored\", nil) }\n",
+		"web/broken.go":        "package web\n\nfunc {",
+		"frontend/src/app.tsx": "fetch('/things')",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	schemas, err := New().Analyze(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if len(schemas) != 4 {
+		t.Errorf("expected 4 endpoints, got %d", len(schemas))
+	}
+	for _, s := range schemas {
+		if s.Endpoint == "/test-only" || s.Endpoint == "/vendored" {
+			t.Errorf("unexpected endpoint %s", s.Endpoint)
+		}
+	}
+
+	if _, err := New().AnalyzeFiles(context.Background(), nil, "Go", analyzer.ScanModeFrontend); err == nil {
+		t.Error("expected frontend scans to be rejected")
+	}
+}
+
+func TestAnalyzeFilesCoverage(t *testing.T) {
+	_, coverage, err := New().AnalyzeFilesCoverage(context.Background(), []analyzer.SourceFile{
+		{Path: "internal/models/user.go", Content: modelsFile},
+		{Path: "internal/server/router.go", Content: chiServer},
+		{Path: "internal/rpc/server.go", Content: "package rpc\n\nfunc Serve(addr string) error { return listen(addr, dispatch) }\n"},
+	}, "Go", analyzer.ScanModeBackend)
+	if err != nil {
+		t.Fatalf("AnalyzeFilesCoverage: %v", err)
+	}
+	if want := []string{"internal/models/user.go", "internal/server/router.go"}; !reflect.DeepEqual(coverage.Files, want) {
+		t.Errorf("covered = %v, want %v", coverage.Files, want)
+	}
+}
+
+func TestSkippedTypes(t *testing.T) {
+	src := `package main
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+)
+
+type Price struct {
+	Amount   decimal.Decimal ` + "`json:\"amount\"`" + `
+	Currency Currency        ` + "`json:\"currency\"`" + `
+}
+
+func main() {
+	r := chi.NewRouter()
+	r.Get("/price", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, Price{})
+	})
+}
+`
+	schemas, coverage, err := New().AnalyzeFilesCoverage(context.Background(), []analyzer.SourceFile{{Path: "main.go", Content: src}}, "Go", analyzer.ScanModeBackend)
+	if err != nil {
+		t.Fatalf("AnalyzeFilesCoverage: %v", err)
+	}
+	want := []analyzer.SkippedType{
+		{Type: "Currency", Path: "main.go"},
+		{Type: "github.com/shopspring/decimal.Decimal", Path: "main.go"},
+	}
+	if !reflect.DeepEqual(coverage.SkippedTypes, want) {
+		t.Errorf("skipped types = %v, want %v", coverage.SkippedTypes, want)
+	}
+	if len(schemas) != 1 || schemas[0].Response[200].Fields["amount"].Type != "any" {
+		t.Errorf("schemas = %+v", schemas)
+	}
+}
@@ -0,0 +1,877 @@
+package goast
+
+import (
+	"go/ast"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+// maxResolveDepth bounds how far handlers and variable types are followed.
+const maxResolveDepth = 6
+
+var statusCodes = map[string]int{
+	"StatusOK":                  200,
+	"StatusCreated":             201,
+	"StatusAccepted":            202,
+	"StatusNoContent":           204,
+	"StatusMovedPermanently":    301,
+	"StatusFound":               302,
+	"StatusNotModified":         304,
+	"StatusBadRequest":          400,
+	"StatusUnauthorized":        401,
+	"StatusPaymentRequired":     402,
+	"StatusForbidden":           403,
+	"StatusNotFound":            404,
+	"StatusMethodNotAllowed":    405,
+	"StatusConflict":            409,
+	"StatusGone":                410,
+	"StatusUnprocessableEntity": 422,
+	"StatusTooManyRequests":     429,
+	"StatusInternalServerError": 500,
+	"StatusNotImplemented":      501,
+	"StatusBadGateway":          502,
+	"StatusServiceUnavailable":  503,
+}
+
+// bodyBinders decode the request body into their argument in gin and echo.
+var bodyBinders = map[string]bool{
+	"ShouldBindJSON": true, "BindJSON": true, "ShouldBind": true, "Bind": true,
+	"ShouldBindWith": true, "MustBindWith": true, "ShouldBindBodyWith": true,
+}
+
+var jsonResponders = map[string]bool{
+	"JSON": true, "IndentedJSON": true, "PureJSON": true, "SecureJSON": true,
+	"AsciiJSON": true, "AbortWithStatusJSON": true, "JSONPretty": true,
+}
+
+// responseHelper matches the names of local helpers like respondJSON or
+// writeJSON(w, status, v).
+var responseHelper = regexp.MustCompile(`(?i)json|respond|render|reply|write|send`)
+
+// skippedHeaders are handled by the transport rather than the handler.
+var skippedHeaders = map[string]bool{"content-type": true, "accept": true}
+
+// funcScope is a function body together with the declarations that name
+// its variables. Handlers are analysed in the scope of the function they
+// resolve to.
+type funcScope struct {
+	body   *ast.BlockStmt
+	recv   *ast.FieldList
+	params *ast.FieldList
+	file   *sourceFile
+}
+
+func declScope(fd *funcDecl) *funcScope {
+	return &funcScope{body: fd.decl.Body, recv: fd.decl.Recv, params: fd.decl.Type.Params, file: fd.file}
+}
+
+func litScope(lit *ast.FuncLit, f *sourceFile) *funcScope {
+	return &funcScope{body: lit.Body, params: lit.Type.Params, file: f}
+}
+
+// resolveHandler finds the function a handler expression in scope refers
+// to.
+func (ix *index) resolveHandler(expr ast.Expr, scope *funcScope, depth int) *funcScope {
+	if depth > maxResolveDepth {
+		return nil
+	}
+	switch e := expr.(type) {
+	case *ast.FuncLit:
+		return litScope(e, scope.file)
+	case *ast.ParenExpr:
+		return ix.resolveHandler(e.X, scope, depth+1)
+	case *ast.Ident, *ast.SelectorExpr:
+		if fd := ix.lookupCallee(e, scope); fd != nil && fd.decl.Body != nil {
+			return declScope(fd)
+		}
+	case *ast.CallExpr:
+		if selectorName(e.Fun) == "HandlerFunc" && len(e.Args) == 1 {
+			return ix.resolveHandler(e.Args[0], scope, depth+1)
+		}
+		// A factory such as h.listUsers() returns the handler; a
+		// middleware such as auth(h.listUsers) wraps it.
+		if fd := ix.lookupCallee(e.Fun, scope); fd != nil && fd.decl.Body != nil {
+			if lit := returnedFuncLit(fd.decl.Body); lit != nil {
+				s := litScope(lit, fd.file)
+				s.recv = fd.decl.Recv
+				return s
+			}
+		}
+		for i := len(e.Args) - 1; i >= 0; i-- {
+			if h := ix.resolveHandler(e.Args[i], scope, depth+1); h != nil {
+				return h
+			}
+		}
+	}
+	return nil
+}
+
+func returnedFuncLit(body *ast.BlockStmt) *ast.FuncLit {
+	for _, stmt := range body.List {
+		ret, ok := stmt.(*ast.ReturnStmt)
+		if !ok || len(ret.Results) != 1 {
+			continue
+		}
+		switch r := ret.Results[0].(type) {
+		case *ast.FuncLit:
+			return r
+		case *ast.CallExpr:
+			if len(r.Args) == 1 {
+				if lit, ok := r.Args[0].(*ast.FuncLit); ok {
+					return lit
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// endpointSchemas builds the schemas for one registration. A net/http
+// handler that switches on r.Method yields one endpoint per case; one that
+// only compares r.Method is registered for those methods; otherwise GET is
+// assumed, or POST when the handler reads a body.
+func (ix *index) endpointSchemas(rt route) []*schemair.SchemaIR {
+	ix.covered[rt.scope.file.path] = true
+	h := ix.resolveHandler(rt.handler, rt.scope, 0)
+	if h == nil {
+		if rt.method == "" {
+			rt.method = "GET"
+		}
+		return []*schemair.SchemaIR{newSchema(rt.method, rt.path, nil)}
+	}
+	ix.covered[h.file.path] = true
+	if rt.method != "" {
+		return []*schemair.SchemaIR{newSchema(rt.method, rt.path, ix.analyzeHandler(h, h.body))}
+	}
+
+	var schemas []*schemair.SchemaIR
+	ast.Inspect(h.body, func(n ast.Node) bool {
+		sw, ok := n.(*ast.SwitchStmt)
+		if !ok || selectorName(sw.Tag) != "Method" || schemas != nil {
+			return schemas == nil
+		}
+		for _, stmt := range sw.Body.List {
+			clause := stmt.(*ast.CaseClause)
+			for _, expr := range clause.List {
+				if m, ok := methodValue(expr); ok {
+					info := ix.analyzeHandler(h, &ast.BlockStmt{List: clause.Body})
+					schemas = append(schemas, newSchema(m, rt.path, info))
+				}
+			}
+		}
+		return false
+	})
+	if schemas != nil {
+		return schemas
+	}
+
+	info := ix.analyzeHandler(h, h.body)
+	methods := info.methods
+	if len(methods) == 0 {
+		methods = []string{"GET"}
+		if info.request != nil {
+			methods = []string{"POST"}
+		}
+	}
+	for _, m := range methods {
+		schemas = append(schemas, newSchema(m, rt.path, info))
+	}
+	return schemas
+}
+
+var pathParam = regexp.MustCompile(`\{([^}/]+)\}`)
+
+func newSchema(method, path string, info *handlerInfo) *schemair.SchemaIR {
+	schema := &schemair.SchemaIR{
+		Endpoint: path,
+		Method:   method,
+		Source:   schemair.SourceBackendStatic,
+	}
+	for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+		typ := "string"
+		if info != nil && info.pathParams[m[1]] != "" {
+			typ = info.pathParams[m[1]]
+		}
+		schema.PathParams = append(schema.PathParams, schemair.PathParam{
+			Name:  m[1],
+			Field: schemair.Field{Type: typ, Required: true},
+		})
+	}
+	if info == nil {
+		return schema
+	}
+	if method != "GET" && method != "HEAD" {
+		schema.Request = info.request
+	}
+	if len(info.response) > 0 {
+		schema.Response = info.response
+	}
+	if len(info.queryParams) > 0 {
+		schema.QueryParams = info.queryParams
+	}
+	if len(info.headers) > 0 {
+		schema.Headers = info.headers
+	}
+	return schema
+}
+
+// typeRef is a type expression together with the file it is written in.
+type typeRef struct {
+	expr ast.Expr
+	file *sourceFile
+}
+
+// varType finds the declared or inferred type of a local variable or
+// parameter of h.
+func (ix *index) varType(name string, h *funcScope, depth int) *typeRef {
+	if depth > maxResolveDepth {
+		return nil
+	}
+	for _, fields := range []*ast.FieldList{h.recv, h.params} {
+		if fields == nil {
+			continue
+		}
+		for _, p := range fields.List {
+			for _, n := range p.Names {
+				if n.Name == name {
+					return &typeRef{expr: p.Type, file: h.file}
+				}
+			}
+		}
+	}
+	if h.body == nil {
+		return nil
+	}
+
+	var found *typeRef
+	ast.Inspect(h.body, func(n ast.Node) bool {
+		if found != nil {
+			return false
+		}
+		switch s := n.(type) {
+		case *ast.ValueSpec:
+			for i, id := range s.Names {
+				if id.Name != name {
+					continue
+				}
+				if s.Type != nil {
+					found = &typeRef{expr: s.Type, file: h.file}
+				} else if i < len(s.Values) {
+					found = ix.exprType(s.Values[i], h, depth+1)
+				}
+				return false
+			}
+		case *ast.AssignStmt:
+			if s.Tok != token.DEFINE {
+				return true
+			}
+			for i, lhs := range s.Lhs {
+				if id, ok := lhs.(*ast.Ident); ok && id.Name == name {
+					if len(s.Lhs) == len(s.Rhs) {
+						found = ix.exprType(s.Rhs[i], h, depth+1)
+					} else if len(s.Rhs) == 1 {
+						found = ix.resultType(s.Rhs[0], i, h)
+					}
+					return false
+				}
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// exprType infers the type of an expression where the source makes it
+// explicit: composite literals, new, make, local variables and calls to
+// functions in the scan.
+func (ix *index) exprType(expr ast.Expr, h *funcScope, depth int) *typeRef {
+	switch e := expr.(type) {
+	case *ast.CompositeLit:
+		if e.Type != nil {
+			return &typeRef{expr: e.Type, file: h.file}
+		}
+	case *ast.UnaryExpr:
+		if e.Op == token.AND {
+			return ix.exprType(e.X, h, depth)
+		}
+	case *ast.StarExpr:
+		return ix.exprType(e.X, h, depth)
+	case *ast.ParenExpr:
+		return ix.exprType(e.X, h, depth)
+	case *ast.Ident:
+		return ix.varType(e.Name, h, depth+1)
+	case *ast.SelectorExpr:
+		if depth > maxResolveDepth {
+			return nil
+		}
+		if ref := ix.exprType(e.X, h, depth+1); ref != nil {
+			return ix.fieldType(ref, e.Sel.Name)
+		}
+	case *ast.CallExpr:
+		if id, ok := e.Fun.(*ast.Ident); ok && (id.Name == "new" || id.Name == "make") && len(e.Args) > 0 {
+			return &typeRef{expr: e.Args[0], file: h.file}
+		}
+		return ix.resultType(e, 0, h)
+	}
+	return nil
+}
+
+// fieldType returns the type of the named field of the struct ref refers
+// to.
+func (ix *index) fieldType(ref *typeRef, name string) *typeRef {
+	td := ix.lookupNamed(ref.expr, ref.file)
+	if td == nil {
+		return nil
+	}
+	st, ok := td.spec.Type.(*ast.StructType)
+	if !ok {
+		return nil
+	}
+	for _, fl := range st.Fields.List {
+		for _, n := range fl.Names {
+			if n.Name == name {
+				return &typeRef{expr: fl.Type, file: td.file}
+			}
+		}
+	}
+	return nil
+}
+
+// resultType returns the type of the i-th result of the function expr
+// calls.
+func (ix *index) resultType(expr ast.Expr, i int, scope *funcScope) *typeRef {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+	fd := ix.lookupCallee(call.Fun, scope)
+	if fd == nil || fd.decl.Type.Results == nil {
+		return nil
+	}
+	n := 0
+	for _, r := range fd.decl.Type.Results.List {
+		count := len(r.Names)
+		if count == 0 {
+			count = 1
+		}
+		if i < n+count {
+			return &typeRef{expr: r.Type, file: fd.file}
+		}
+		n += count
+	}
+	return nil
+}
+
+// exprField resolves the shape of a value passed to an encoder.
+func (ix *index) exprField(expr ast.Expr, h *funcScope) *schemair.Field {
+	switch e := expr.(type) {
+	case *ast.UnaryExpr:
+		if e.Op == token.AND {
+			return ix.exprField(e.X, h)
+		}
+	case *ast.ParenExpr:
+		return ix.exprField(e.X, h)
+	case *ast.CompositeLit:
+		if fields, ok := ix.mapLiteral(e, h); ok {
+			return objectField(fields)
+		}
+	case *ast.BasicLit:
+		switch e.Kind {
+		case token.STRING:
+			return newField("string")
+		case token.INT:
+			return newField("int")
+		case token.FLOAT:
+			return newField("float")
+		}
+	case *ast.Ident:
+		switch e.Name {
+		case "nil":
+			return nil
+		case "true", "false":
+			return newField("bool")
+		}
+	case *ast.CallExpr:
+		if name := selectorName(e.Fun); name == "Error" || name == "Sprintf" || name == "String" {
+			return newField("string")
+		}
+		if _, ok := paramCall(e, nil); ok {
+			return newField("string")
+		}
+	}
+	if ref := ix.exprType(expr, h, 0); ref != nil {
+		return ix.field(ref.expr, ref.file, make(map[*typeDecl]bool))
+	}
+	return newField("any")
+}
+
+// mapLiteral reads the keys of a map literal such as gin.H{"id": id}.
+func (ix *index) mapLiteral(lit *ast.CompositeLit, h *funcScope) (map[string]*schemair.Field, bool) {
+	switch t := lit.Type.(type) {
+	case *ast.MapType:
+	case *ast.SelectorExpr:
+		if t.Sel.Name != "H" && t.Sel.Name != "Map" {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+	fields := make(map[string]*schemair.Field)
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := stringLit(kv.Key)
+		if !ok {
+			continue
+		}
+		f := ix.exprField(kv.Value, h)
+		if f == nil {
+			f = &schemair.Field{Type: "any", Nullable: true}
+		}
+		f.Required = true
+		fields[key] = f
+	}
+	return fields, true
+}
+
+// paramRef names the path or query parameter a variable was read from.
+type paramRef struct {
+	query bool
+	name  string
+}
+
+// handlerInfo is what a handler body reveals about its endpoint.
+type handlerInfo struct {
+	request     *schemair.ObjectSchema
+	response    map[int]*schemair.ObjectSchema
+	pathParams  map[string]string
+	queryParams map[string]*schemair.Field
+	headers     map[string]*schemair.Field
+	methods     []string
+}
+
+func (ix *index) analyzeHandler(h *funcScope, body *ast.BlockStmt) *handlerInfo {
+	info := &handlerInfo{
+		response:    make(map[int]*schemair.ObjectSchema),
+		pathParams:  make(map[string]string),
+		queryParams: make(map[string]*schemair.Field),
+		headers:     make(map[string]*schemair.Field),
+	}
+	paramVars := make(map[string]paramRef)
+	queryVars := make(map[string]bool)
+	status := 0
+
+	respond := func(code int, value ast.Expr, scope *funcScope) {
+		if code == 0 {
+			code = 200
+		}
+		if _, ok := info.response[code]; ok {
+			return
+		}
+		if f := ix.exprField(value, scope); f != nil {
+			info.response[code] = asObject(f)
+		}
+	}
+	decode := func(target ast.Expr) {
+		if info.request != nil {
+			return
+		}
+		if f := ix.exprField(target, h); f != nil && f.Type != "any" {
+			info.request = asObject(f)
+		}
+	}
+	refine := func(arg ast.Expr, typ string) {
+		ref, ok := paramVars[identName(arg)]
+		if !ok {
+			ref, ok = paramCall(arg, queryVars)
+		}
+		if !ok {
+			return
+		}
+		if ref.query {
+			info.queryParams[ref.name] = newField(typ)
+		} else {
+			info.pathParams[ref.name] = typ
+		}
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if len(n.Lhs) < 1 || len(n.Rhs) != 1 {
+				return true
+			}
+			id, ok := n.Lhs[0].(*ast.Ident)
+			if !ok {
+				return true
+			}
+			if ref, ok := paramCall(n.Rhs[0], queryVars); ok {
+				paramVars[id.Name] = ref
+			} else if call, ok := n.Rhs[0].(*ast.CallExpr); ok && isURLQuery(call) {
+				queryVars[id.Name] = true
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.EQL || n.Op == token.NEQ {
+				if m, ok := methodComparison(n.X, n.Y); ok {
+					info.methods = appendUnique(info.methods, m)
+				}
+			}
+		case *ast.CallExpr:
+			ix.handlerCall(n, h, info, queryVars, &status, respond, decode, refine)
+		}
+		return true
+	})
+	return info
+}
+
+func (ix *index) handlerCall(call *ast.CallExpr, h *funcScope, info *handlerInfo, queryVars map[string]bool,
+	status *int, respond func(int, ast.Expr, *funcScope), decode func(ast.Expr), refine func(ast.Expr, string)) {
+	args := call.Args
+	name := selectorName(call.Fun)
+	if name == "" {
+		name = identName(call.Fun)
+	}
+	sel, _ := call.Fun.(*ast.SelectorExpr)
+	qualifier := ""
+	if sel != nil {
+		if id, ok := sel.X.(*ast.Ident); ok {
+			qualifier = h.file.imports[id.Name]
+		}
+	}
+
+	if ref, ok := paramCall(call, queryVars); ok {
+		if ref.query {
+			if _, seen := info.queryParams[ref.name]; !seen {
+				info.queryParams[ref.name] = newField("string")
+			}
+		} else if _, seen := info.pathParams[ref.name]; !seen {
+			info.pathParams[ref.name] = "string"
+		}
+		return
+	}
+
+	switch {
+	case name == "Decode" && len(args) == 1 && sel != nil && selectorName(callFun(sel.X)) == "NewDecoder":
+		decode(args[0])
+	case name == "Unmarshal" && len(args) == 2 && qualifier == "encoding/json":
+		decode(args[1])
+	case bodyBinders[name] && len(args) >= 1 && sel != nil && qualifier == "":
+		decode(args[0])
+	case (name == "ShouldBindQuery" || name == "BindQuery") && len(args) == 1:
+		ix.queryStruct(args[0], h, info)
+	case name == "Encode" && len(args) == 1 && sel != nil && selectorName(callFun(sel.X)) == "NewEncoder":
+		respond(*status, args[0], h)
+	case (name == "WriteHeader" || name == "Status") && len(args) == 1:
+		if code, ok := statusCode(args[0]); ok {
+			*status = code
+		}
+	case jsonResponders[name] && len(args) >= 2:
+		if code, ok := statusCode(args[0]); ok {
+			respond(code, args[1], h)
+		}
+	case name == "Get" && len(args) == 1 && sel != nil && isHeaderExpr(sel.X):
+		header(info, args[0])
+	case name == "GetHeader" && len(args) == 1:
+		header(info, args[0])
+	case qualifier != "" && packageName(qualifier) == "uuid" && (name == "Parse" || name == "MustParse") && len(args) == 1:
+		refine(args[0], "uuid")
+	case qualifier == "strconv" && len(args) >= 1:
+		switch name {
+		case "Atoi", "ParseInt", "ParseUint":
+			refine(args[0], "int")
+		case "ParseFloat":
+			refine(args[0], "float")
+		case "ParseBool":
+			refine(args[0], "bool")
+		}
+	case responseHelper.MatchString(name) && qualifier == "":
+		for i := 0; i+1 < len(args); i++ {
+			if code, ok := statusCode(args[i]); ok {
+				value, scope := ix.helperValue(call, i, h)
+				respond(code, value, scope)
+				break
+			}
+		}
+	}
+}
+
+// helperValue finds what a response helper called with a status at
+// argument statusIdx encodes. A helper that passes its argument through,
+// like respondJSON(w, status, v), encodes the caller's value; one that
+// wraps it, like respondError(w, status, msg), encodes a value that is
+// resolved in the helper's own scope.
+func (ix *index) helperValue(call *ast.CallExpr, statusIdx int, h *funcScope) (ast.Expr, *funcScope) {
+	fallback := call.Args[statusIdx+1]
+	fd := ix.lookupCallee(call.Fun, h)
+	if fd == nil || fd.decl.Body == nil {
+		return fallback, h
+	}
+	params := paramNames(fd.decl.Type.Params)
+	if statusIdx >= len(params) {
+		return fallback, h
+	}
+	statusParam := params[statusIdx]
+
+	var value ast.Expr
+	ast.Inspect(fd.decl.Body, func(n ast.Node) bool {
+		if value != nil {
+			return false
+		}
+		inner, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		name := selectorName(inner.Fun)
+		switch {
+		case name == "Encode" && len(inner.Args) == 1:
+			value = inner.Args[0]
+		case jsonResponders[name] && len(inner.Args) >= 2:
+			value = inner.Args[1]
+		default:
+			for i := 0; i+1 < len(inner.Args); i++ {
+				if identName(inner.Args[i]) == statusParam {
+					value = inner.Args[i+1]
+					break
+				}
+			}
+		}
+		return value == nil
+	})
+	if value == nil {
+		return fallback, h
+	}
+	if name := identName(value); name != "" {
+		for i, p := range params {
+			if p == name && i < len(call.Args) {
+				return call.Args[i], h
+			}
+		}
+	}
+	return value, declScope(fd)
+}
+
+func paramNames(fields *ast.FieldList) []string {
+	if fields == nil {
+		return nil
+	}
+	var names []string
+	for _, p := range fields.List {
+		if len(p.Names) == 0 {
+			names = append(names, "_")
+		}
+		for _, n := range p.Names {
+			names = append(names, n.Name)
+		}
+	}
+	return names
+}
+
+// queryStruct adds the fields of a struct bound from the query string,
+// named by their form or query tags.
+func (ix *index) queryStruct(target ast.Expr, h *funcScope, info *handlerInfo) {
+	ref := ix.exprType(target, h, 0)
+	if ref == nil {
+		return
+	}
+	td := ix.lookupNamed(ref.expr, ref.file)
+	if td == nil {
+		return
+	}
+	st, ok := td.spec.Type.(*ast.StructType)
+	if !ok {
+		return
+	}
+	for _, fl := range st.Fields.List {
+		var tag string
+		if fl.Tag != nil {
+			tag, _ = strconv.Unquote(fl.Tag.Value)
+		}
+		for _, n := range fl.Names {
+			if !ast.IsExported(n.Name) {
+				continue
+			}
+			key := tagName(tag, "form", "query")
+			if key == "-" {
+				continue
+			}
+			if key == "" {
+				key = n.Name
+			}
+			f := ix.field(fl.Type, td.file, make(map[*typeDecl]bool))
+			f.Required = false
+			if strings.Contains(tagName(tag, "binding", "validate"), "required") {
+				f.Required = true
+			}
+			info.queryParams[key] = f
+		}
+	}
+}
+
+func (ix *index) lookupNamed(expr ast.Expr, f *sourceFile) *typeDecl {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return ix.lookupType(t.Name, "", f)
+	case *ast.SelectorExpr:
+		if q, ok := t.X.(*ast.Ident); ok {
+			return ix.lookupType(t.Sel.Name, q.Name, f)
+		}
+	case *ast.StarExpr:
+		return ix.lookupNamed(t.X, f)
+	}
+	return nil
+}
+
+// tagName returns the value of the first of keys present in a struct tag,
+// without options.
+func tagName(tag string, keys ...string) string {
+	for _, k := range keys {
+		if v, ok := lookupTag(tag, k); ok {
+			if k == "binding" || k == "validate" {
+				return v
+			}
+			name, _, _ := strings.Cut(v, ",")
+			return name
+		}
+	}
+	return ""
+}
+
+func lookupTag(tag, key string) (string, bool) {
+	for _, part := range strings.Fields(tag) {
+		k, v, ok := strings.Cut(part, ":")
+		if ok && k == key {
+			s, err := strconv.Unquote(v)
+			return s, err == nil
+		}
+	}
+	return "", false
+}
+
+func header(info *handlerInfo, arg ast.Expr) {
+	name, ok := stringLit(arg)
+	if !ok {
+		return
+	}
+	name = strings.ToLower(name)
+	if skippedHeaders[name] {
+		return
+	}
+	if _, seen := info.headers[name]; !seen {
+		info.headers[name] = newField("string")
+	}
+}
+
+// paramCall recognises reads of a path or query parameter:
+// chi.URLParam(r, "id"), r.PathValue("id"), c.Param("id"),
+// r.URL.Query().Get("q"), c.Query("q") and c.QueryParam("q").
+func paramCall(expr ast.Expr, queryVars map[string]bool) (paramRef, bool) {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return paramRef{}, false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return paramRef{}, false
+	}
+	arg := func(i int) (string, bool) {
+		if i >= len(call.Args) {
+			return "", false
+		}
+		return stringLit(call.Args[i])
+	}
+
+	switch sel.Sel.Name {
+	case "URLParam":
+		if name, ok := arg(1); ok {
+			return paramRef{name: name}, true
+		}
+	case "Param", "PathValue":
+		if name, ok := arg(0); ok && len(call.Args) == 1 {
+			return paramRef{name: name}, true
+		}
+	case "Query", "DefaultQuery", "GetQuery", "QueryParam":
+		if name, ok := arg(0); ok {
+			return paramRef{query: true, name: name}, true
+		}
+	case "Get":
+		x, isCall := sel.X.(*ast.CallExpr)
+		if (isCall && isURLQuery(x)) || queryVars[identName(sel.X)] {
+			if name, ok := arg(0); ok {
+				return paramRef{query: true, name: name}, true
+			}
+		}
+	}
+	return paramRef{}, false
+}
+
+// isURLQuery matches r.URL.Query() and c.QueryParams().
+func isURLQuery(call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || len(call.Args) != 0 {
+		return false
+	}
+	if sel.Sel.Name == "QueryParams" {
+		return true
+	}
+	return sel.Sel.Name == "Query" && selectorName(sel.X) == "URL"
+}
+
+// isHeaderExpr matches r.Header, c.Request.Header and c.Request().Header.
+func isHeaderExpr(expr ast.Expr) bool {
+	return selectorName(expr) == "Header"
+}
+
+// methodComparison matches r.Method == "POST" and r.Method != http.MethodPost.
+func methodComparison(x, y ast.Expr) (string, bool) {
+	if selectorName(x) != "Method" {
+		x, y = y, x
+	}
+	if selectorName(x) != "Method" {
+		return "", false
+	}
+	return methodValue(y)
+}
+
+func statusCode(expr ast.Expr) (int, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.INT {
+			return 0, false
+		}
+		code, err := strconv.Atoi(e.Value)
+		return code, err == nil && code >= 100 && code < 600
+	case *ast.SelectorExpr:
+		code, ok := statusCodes[e.Sel.Name]
+		return code, ok
+	}
+	return 0, false
+}
+
+func callFun(expr ast.Expr) ast.Expr {
+	if call, ok := expr.(*ast.CallExpr); ok {
+		return call.Fun
+	}
+	return nil
+}
+
+func identName(expr ast.Expr) string {
+	if id, ok := expr.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
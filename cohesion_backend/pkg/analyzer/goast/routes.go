@@ -0,0 +1,505 @@
+package goast
+
+import (
+	"go/ast"
+	"strings"
+)
+
+// maxWalkDepth bounds how far router values are followed through calls.
+const maxWalkDepth = 8
+
+var httpMethods = map[string]bool{
+	"GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true,
+	"HEAD": true, "OPTIONS": true, "CONNECT": true, "TRACE": true,
+}
+
+// routerPackages are the import paths whose routers are recognised.
+var chiMethods = map[string]string{
+	"Get": "GET", "Post": "POST", "Put": "PUT", "Patch": "PATCH", "Delete": "DELETE",
+	"Head": "HEAD", "Options": "OPTIONS", "Connect": "CONNECT", "Trace": "TRACE",
+}
+
+var routerPackages = map[string]bool{
+	"net/http":                    true,
+	"github.com/go-chi/chi":       true,
+	"github.com/go-chi/chi/v5":    true,
+	"github.com/gin-gonic/gin":    true,
+	"github.com/labstack/echo":    true,
+	"github.com/labstack/echo/v4": true,
+}
+
+var routerTypes = map[string]bool{
+	"Router": true, "Mux": true, "ServeMux": true,
+	"Engine": true, "RouterGroup": true, "IRouter": true, "IRoutes": true,
+	"Echo": true, "Group": true,
+}
+
+var routerConstructors = map[string]bool{
+	"NewRouter": true, "NewMux": true, "NewServeMux": true, "New": true, "Default": true,
+}
+
+// route is a single registration. method is empty when the pattern does
+// not name one and it has to be read from the handler, which is resolved
+// in the scope of the function that registered it.
+type route struct {
+	method  string
+	path    string
+	handler ast.Expr
+	scope   *funcScope
+}
+
+// routeEnv tracks the path prefix of the routers in scope. Registrations
+// on a receiver it does not know get the base prefix.
+type routeEnv struct {
+	base string
+	vars map[string]string
+}
+
+func newRouteEnv(base string) *routeEnv {
+	return &routeEnv{base: base, vars: make(map[string]string)}
+}
+
+func (e *routeEnv) child(base string) *routeEnv {
+	c := newRouteEnv(base)
+	for k, v := range e.vars {
+		c.vars[k] = v
+	}
+	return c
+}
+
+func (e *routeEnv) prefixOf(x ast.Expr) string {
+	switch x := x.(type) {
+	case *ast.Ident:
+		if p, ok := e.vars[x.Name]; ok {
+			return p
+		}
+	case *ast.ParenExpr:
+		return e.prefixOf(x.X)
+	case *ast.CallExpr:
+		sel, ok := x.Fun.(*ast.SelectorExpr)
+		if !ok {
+			break
+		}
+		switch sel.Sel.Name {
+		case "Group":
+			if p, ok := firstStringArg(x); ok {
+				return joinPath(e.prefixOf(sel.X), p)
+			}
+		case "With", "Use":
+			return e.prefixOf(sel.X)
+		}
+	}
+	return e.base
+}
+
+type walker struct {
+	ix     *index
+	routes []route
+	// mounted holds functions that build a router for Mount; they are only
+	// walked from the mount so their routes get its prefix.
+	mounted map[*ast.FuncDecl]bool
+	// reached holds functions taking a router that were walked from a call.
+	reached map[*ast.FuncDecl]bool
+	active  map[*ast.FuncDecl]bool
+	depth   int
+	// scope is the function being walked.
+	scope *funcScope
+}
+
+func newWalker(ix *index) *walker {
+	return &walker{
+		ix:      ix,
+		mounted: make(map[*ast.FuncDecl]bool),
+		reached: make(map[*ast.FuncDecl]bool),
+		active:  make(map[*ast.FuncDecl]bool),
+	}
+}
+
+// discover walks every function that does not take a router, then the
+// router-taking functions no walk reached, e.g. ones registered through
+// code outside the scan.
+func (w *walker) discover() []route {
+	for _, f := range w.ix.files {
+		ast.Inspect(f.ast, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || selectorName(call.Fun) != "Mount" || len(call.Args) != 2 {
+				return true
+			}
+			if inner, ok := call.Args[1].(*ast.CallExpr); ok {
+				if fd := w.ix.lookupCallee(inner.Fun, &funcScope{file: f}); fd != nil {
+					w.mounted[fd.decl] = true
+				}
+			}
+			return true
+		})
+	}
+
+	var deferred []*funcDecl
+	for _, f := range w.ix.files {
+		for _, decl := range f.ast.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Body == nil || w.mounted[fd] {
+				continue
+			}
+			if len(routerParams(fd.Type, f)) > 0 {
+				deferred = append(deferred, &funcDecl{decl: fd, file: f})
+				continue
+			}
+			w.walkFunc(&funcDecl{decl: fd, file: f}, newRouteEnv(""))
+		}
+	}
+	for _, fd := range deferred {
+		if !w.reached[fd.decl] {
+			w.walkFunc(fd, newRouteEnv(""))
+		}
+	}
+	return w.routes
+}
+
+func (w *walker) walkFunc(fd *funcDecl, env *routeEnv) {
+	decl := fd.decl
+	if decl.Body == nil || w.active[decl] || w.depth > maxWalkDepth {
+		return
+	}
+	outer := w.scope
+	w.active[decl] = true
+	w.depth++
+	w.scope = declScope(fd)
+	defer func() {
+		delete(w.active, decl)
+		w.depth--
+		w.scope = outer
+	}()
+
+	for _, name := range routerParams(decl.Type, fd.file) {
+		if _, ok := env.vars[name]; !ok {
+			env.vars[name] = env.base
+		}
+	}
+	w.walkBody(decl.Body, fd.file, env)
+}
+
+func (w *walker) walkBody(body ast.Node, f *sourceFile, env *routeEnv) {
+	// Routers mounted by variable are usually filled in before the Mount
+	// call, so their prefixes are collected up front.
+	ast.Inspect(body, func(n ast.Node) bool {
+		if _, ok := n.(*ast.FuncLit); ok {
+			return false
+		}
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 2 {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "Mount" {
+			return true
+		}
+		p, ok := firstStringArg(call)
+		if id, isIdent := call.Args[1].(*ast.Ident); ok && isIdent {
+			env.vars[id.Name] = joinPath(env.prefixOf(sel.X), p)
+		}
+		return true
+	})
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			w.assign(n, f, env)
+		case *ast.CallExpr:
+			return w.call(n, f, env)
+		}
+		return true
+	})
+}
+
+// assign records router variables: new routers, groups and With chains.
+func (w *walker) assign(stmt *ast.AssignStmt, f *sourceFile, env *routeEnv) {
+	if len(stmt.Lhs) != len(stmt.Rhs) {
+		return
+	}
+	for i, rhs := range stmt.Rhs {
+		id, ok := stmt.Lhs[i].(*ast.Ident)
+		if !ok {
+			continue
+		}
+		call, ok := rhs.(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			continue
+		}
+		switch {
+		case sel.Sel.Name == "Group" || sel.Sel.Name == "With":
+			if _, ok := firstStringArg(call); ok || sel.Sel.Name == "With" {
+				env.vars[id.Name] = env.prefixOf(call)
+			}
+		case routerConstructors[sel.Sel.Name] && isRouterPackage(sel.X, f):
+			if _, mounted := env.vars[id.Name]; !mounted {
+				env.vars[id.Name] = env.base
+			}
+		}
+	}
+}
+
+// call handles a call in a routing function. It returns false when it
+// walked the call's function literal itself.
+func (w *walker) call(call *ast.CallExpr, f *sourceFile, env *routeEnv) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		w.followRouter(call, f, env)
+		return true
+	}
+	name := sel.Sel.Name
+	args := call.Args
+
+	switch {
+	case name == "Route" && len(args) == 2:
+		p, ok := firstStringArg(call)
+		fn, isLit := args[1].(*ast.FuncLit)
+		if !ok || !isLit {
+			break
+		}
+		base := joinPath(env.prefixOf(sel.X), p)
+		w.walkFuncLit(fn, f, env.child(base), base)
+		return false
+	case name == "Group" && len(args) == 1:
+		fn, ok := args[0].(*ast.FuncLit)
+		if !ok {
+			break
+		}
+		base := env.prefixOf(sel.X)
+		w.walkFuncLit(fn, f, env.child(base), base)
+		return false
+	case name == "Mount" && len(args) == 2:
+		p, ok := firstStringArg(call)
+		inner, isCall := args[1].(*ast.CallExpr)
+		if !ok || !isCall {
+			break
+		}
+		if fd := w.ix.lookupCallee(inner.Fun, w.scope); fd != nil {
+			w.walkFunc(fd, newRouteEnv(joinPath(env.prefixOf(sel.X), p)))
+		}
+		return false
+	}
+
+	if rt, ok := registration(name, args, f); ok {
+		rt.path = normalizePath(joinPath(env.prefixOf(sel.X), rt.path))
+		rt.scope = w.scope
+		w.routes = append(w.routes, rt)
+		return true
+	}
+
+	w.followRouter(call, f, env)
+	return true
+}
+
+// walkFuncLit walks the body of a Route or Group callback, whose only
+// parameter is the sub-router.
+func (w *walker) walkFuncLit(fn *ast.FuncLit, f *sourceFile, env *routeEnv, base string) {
+	for _, p := range fn.Type.Params.List {
+		for _, n := range p.Names {
+			env.vars[n.Name] = base
+		}
+	}
+	w.walkBody(fn.Body, f, env)
+}
+
+// followRouter walks into a local function that is handed a router, such
+// as registerUserRoutes(api) or h.Register(r.Group("/v1")).
+func (w *walker) followRouter(call *ast.CallExpr, f *sourceFile, env *routeEnv) {
+	prefixes := make(map[int]string)
+	for i, arg := range call.Args {
+		switch a := arg.(type) {
+		case *ast.Ident:
+			if p, ok := env.vars[a.Name]; ok {
+				prefixes[i] = p
+			}
+		case *ast.CallExpr:
+			if name := selectorName(a.Fun); name == "Group" || name == "With" {
+				prefixes[i] = env.prefixOf(a)
+			}
+		}
+	}
+	if len(prefixes) == 0 {
+		return
+	}
+	fd := w.ix.lookupCallee(call.Fun, w.scope)
+	if fd == nil || fd.decl.Type.Params == nil {
+		return
+	}
+
+	callee := newRouteEnv("")
+	i := 0
+	for _, p := range fd.decl.Type.Params.List {
+		names := p.Names
+		if len(names) == 0 {
+			i++
+			continue
+		}
+		for _, n := range names {
+			if prefix, ok := prefixes[i]; ok {
+				callee.vars[n.Name] = prefix
+			}
+			i++
+		}
+	}
+	if len(callee.vars) == 0 {
+		return
+	}
+	w.reached[fd.decl] = true
+	w.walkFunc(fd, callee)
+}
+
+// registration recognises a route registration call by its method name and
+// arguments. The pattern must be a string literal starting with "/" or,
+// for a group's root, empty.
+func registration(name string, args []ast.Expr, f *sourceFile) (route, bool) {
+	var method string
+	pathIdx, handlerIdx := 0, 1
+
+	switch {
+	case len(args) < 2:
+		return route{}, false
+	case chiMethods[name] != "":
+		method = chiMethods[name]
+	case httpMethods[name]:
+		// gin takes middleware before the handler, echo after it.
+		method = name
+		if !importsEcho(f) {
+			handlerIdx = len(args) - 1
+		}
+	case (name == "Handle" || name == "HandleFunc") && len(args) == 2:
+		// net/http and chi; the pattern may start with a method.
+	case (name == "Handle" || name == "Method" || name == "MethodFunc" || name == "Add") && len(args) >= 3:
+		m, ok := methodValue(args[0])
+		if !ok {
+			return route{}, false
+		}
+		method = m
+		pathIdx, handlerIdx = 1, 2
+		if name == "Handle" {
+			handlerIdx = len(args) - 1
+		}
+	default:
+		return route{}, false
+	}
+
+	pattern, ok := stringLit(args[pathIdx])
+	if !ok {
+		return route{}, false
+	}
+	if m, rest, found := strings.Cut(pattern, " "); found && method == "" && httpMethods[m] {
+		method = m
+		pattern = strings.TrimSpace(rest)
+	}
+	// Groups may register their own root with an empty pattern.
+	if pattern != "" && !strings.HasPrefix(pattern, "/") {
+		return route{}, false
+	}
+	return route{method: method, path: pattern, handler: args[handlerIdx]}, true
+}
+
+// methodValue reads an HTTP method given as a literal or http.MethodX.
+func methodValue(expr ast.Expr) (string, bool) {
+	if s, ok := stringLit(expr); ok {
+		s = strings.ToUpper(s)
+		return s, httpMethods[s]
+	}
+	if sel, ok := expr.(*ast.SelectorExpr); ok && strings.HasPrefix(sel.Sel.Name, "Method") {
+		m := strings.ToUpper(strings.TrimPrefix(sel.Sel.Name, "Method"))
+		return m, httpMethods[m]
+	}
+	return "", false
+}
+
+func importsEcho(f *sourceFile) bool {
+	for _, p := range f.imports {
+		if strings.HasPrefix(p, "github.com/labstack/echo") {
+			return true
+		}
+	}
+	return false
+}
+
+func isRouterPackage(x ast.Expr, f *sourceFile) bool {
+	id, ok := x.(*ast.Ident)
+	return ok && routerPackages[f.imports[id.Name]]
+}
+
+// routerParams returns the names of parameters typed as a known router.
+func routerParams(ft *ast.FuncType, f *sourceFile) []string {
+	if ft == nil || ft.Params == nil {
+		return nil
+	}
+	var names []string
+	for _, p := range ft.Params.List {
+		t := p.Type
+		if star, ok := t.(*ast.StarExpr); ok {
+			t = star.X
+		}
+		sel, ok := t.(*ast.SelectorExpr)
+		if !ok || !routerTypes[sel.Sel.Name] || !isRouterPackage(sel.X, f) {
+			continue
+		}
+		for _, n := range p.Names {
+			names = append(names, n.Name)
+		}
+	}
+	return names
+}
+
+func selectorName(expr ast.Expr) string {
+	if sel, ok := expr.(*ast.SelectorExpr); ok {
+		return sel.Sel.Name
+	}
+	return ""
+}
+
+func firstStringArg(call *ast.CallExpr) (string, bool) {
+	if len(call.Args) == 0 {
+		return "", false
+	}
+	return stringLit(call.Args[0])
+}
+
+func joinPath(prefix, p string) string {
+	if prefix == "" {
+		return p
+	}
+	if p == "" || p == "/" {
+		return prefix
+	}
+	return strings.TrimRight(prefix, "/") + "/" + strings.TrimLeft(p, "/")
+}
+
+// normalizePath rewrites router-specific parameter syntax to {name}:
+// gin and echo's :id and *path, chi's {id:[0-9]+} and net/http's
+// {path...}. Trailing slashes are dropped.
+func normalizePath(p string) string {
+	segments := strings.Split(p, "/")
+	out := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		switch {
+		case seg == "":
+			continue
+		case seg == "{$}":
+			continue
+		case strings.HasPrefix(seg, ":"):
+			seg = "{" + seg[1:] + "}"
+		case seg == "*":
+			seg = "{path}"
+		case strings.HasPrefix(seg, "*"):
+			seg = "{" + seg[1:] + "}"
+		case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}"):
+			name := seg[1 : len(seg)-1]
+			if i := strings.Index(name, ":"); i >= 0 {
+				name = name[:i]
+			}
+			seg = "{" + strings.TrimSuffix(name, "...") + "}"
+		}
+		out = append(out, seg)
+	}
+	return "/" + strings.Join(out, "/")
+}
@@ -0,0 +1,361 @@
+package goast
+
+import (
+	"go/ast"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+var builtinTypes = map[string]string{
+	"string":  "string",
+	"bool":    "bool",
+	"int":     "int",
+	"int8":    "int",
+	"int16":   "int",
+	"int32":   "int",
+	"int64":   "int",
+	"uint":    "int",
+	"uint8":   "int",
+	"uint16":  "int",
+	"uint32":  "int",
+	"uint64":  "int",
+	"uintptr": "int",
+	"byte":    "int",
+	"rune":    "int",
+	"float32": "float",
+	"float64": "float",
+	"any":     "any",
+	"error":   "any",
+}
+
+func newField(typ string) *schemair.Field {
+	return &schemair.Field{Type: typ}
+}
+
+func objectField(fields map[string]*schemair.Field) *schemair.Field {
+	return &schemair.Field{
+		Type:   "object",
+		Nested: &schemair.ObjectSchema{Type: "object", Fields: fields},
+	}
+}
+
+func arrayField(items *schemair.ObjectSchema) *schemair.Field {
+	return &schemair.Field{
+		Type:   "array",
+		Nested: &schemair.ObjectSchema{Type: "array", Items: items},
+	}
+}
+
+// asObject returns the schema a field describes when it is used as a whole
+// request or response body.
+func asObject(f *schemair.Field) *schemair.ObjectSchema {
+	if f.Nested != nil {
+		return f.Nested
+	}
+	return &schemair.ObjectSchema{Type: f.Type}
+}
+
+// externalField maps well-known types from packages outside the scan to
+// the shape encoding/json gives them. It returns nil for anything else.
+func externalField(importPath, name string) *schemair.Field {
+	switch importPath {
+	case "time":
+		switch name {
+		case "Time":
+			return newField("time")
+		case "Duration":
+			return newField("int")
+		}
+	case "encoding/json":
+		switch name {
+		case "RawMessage":
+			return newField("any")
+		case "Number":
+			return newField("float")
+		}
+	case "database/sql":
+		var typ string
+		switch name {
+		case "NullString":
+			typ = "string"
+		case "NullInt64", "NullInt32", "NullInt16", "NullByte":
+			typ = "int"
+		case "NullFloat64":
+			typ = "float"
+		case "NullBool":
+			typ = "bool"
+		case "NullTime":
+			typ = "time"
+		default:
+			return nil
+		}
+		return &schemair.Field{Type: typ, Nullable: true}
+	case "github.com/gin-gonic/gin":
+		if name == "H" {
+			return newField("object")
+		}
+	case "github.com/labstack/echo", "github.com/labstack/echo/v4":
+		if name == "Map" {
+			return newField("object")
+		}
+	}
+	if name == "UUID" && packageName(importPath) == "uuid" {
+		return newField("uuid")
+	}
+	return nil
+}
+
+// field resolves a type expression written in from to the field shape its
+// JSON encoding has. seen guards against self-referential types.
+func (ix *index) field(expr ast.Expr, from *sourceFile, seen map[*typeDecl]bool) *schemair.Field {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if typ, ok := builtinTypes[t.Name]; ok {
+			return newField(typ)
+		}
+		if td := ix.lookupType(t.Name, "", from); td != nil {
+			return ix.declField(td, seen)
+		}
+		ix.skipType(t.Name, from)
+	case *ast.SelectorExpr:
+		q, ok := t.X.(*ast.Ident)
+		if !ok {
+			break
+		}
+		if f := externalField(from.imports[q.Name], t.Sel.Name); f != nil {
+			return f
+		}
+		if td := ix.lookupType(t.Sel.Name, q.Name, from); td != nil {
+			return ix.declField(td, seen)
+		}
+		pkg := from.imports[q.Name]
+		if pkg == "" {
+			pkg = q.Name
+		}
+		ix.skipType(pkg+"."+t.Sel.Name, from)
+	case *ast.StarExpr:
+		f := ix.field(t.X, from, seen)
+		f.Nullable = true
+		return f
+	case *ast.ArrayType:
+		if elt, ok := t.Elt.(*ast.Ident); ok && (elt.Name == "byte" || elt.Name == "uint8") {
+			// []byte is encoded as a base64 string.
+			return newField("string")
+		}
+		return arrayField(asObject(ix.field(t.Elt, from, seen)))
+	case *ast.MapType:
+		return newField("object")
+	case *ast.StructType:
+		return objectField(ix.structFields(t, from, seen))
+	case *ast.InterfaceType:
+		return newField("any")
+	case *ast.IndexExpr:
+		return ix.field(t.X, from, seen)
+	case *ast.IndexListExpr:
+		return ix.field(t.X, from, seen)
+	case *ast.ParenExpr:
+		return ix.field(t.X, from, seen)
+	}
+	return newField("any")
+}
+
+// skipType records a type that could not be resolved and is reported as
+// "any".
+func (ix *index) skipType(name string, from *sourceFile) {
+	if _, ok := ix.unresolved[name]; !ok {
+		ix.unresolved[name] = from.path
+	}
+}
+
+func (ix *index) declField(td *typeDecl, seen map[*typeDecl]bool) *schemair.Field {
+	ix.covered[td.file.path] = true
+	key := td.file.dir + "." + td.spec.Name.Name
+	if ix.marshalers[key] {
+		return newField("any")
+	}
+	if seen[td] {
+		return newField("object")
+	}
+	seen[td] = true
+	defer delete(seen, td)
+
+	f := ix.field(td.spec.Type, td.file, seen)
+	if vals := ix.enums[key]; len(vals) > 0 && f.Nested == nil {
+		f.Enum = append([]interface{}(nil), vals...)
+	}
+	return f
+}
+
+// structFields lists a struct's JSON fields the way encoding/json does:
+// unexported and "-" fields are dropped, tag names win over Go names, and
+// fields of embedded structs are promoted unless a shallower field has the
+// same name. Pointers and omitempty make a field optional; a binding or
+// validate "required" rule makes it required again.
+func (ix *index) structFields(st *ast.StructType, from *sourceFile, seen map[*typeDecl]bool) map[string]*schemair.Field {
+	fields := make(map[string]*schemair.Field)
+	promoted := make(map[string]*schemair.Field)
+
+	for _, fl := range st.Fields.List {
+		var tag reflect.StructTag
+		if fl.Tag != nil {
+			raw, _ := strconv.Unquote(fl.Tag.Value)
+			tag = reflect.StructTag(raw)
+		}
+		jsonTag := tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		jsonName, opts := parseJSONTag(jsonTag)
+		_, isPtr := fl.Type.(*ast.StarExpr)
+
+		names := make([]string, 0, len(fl.Names))
+		for _, n := range fl.Names {
+			names = append(names, n.Name)
+		}
+		if len(fl.Names) == 0 {
+			typeName := embeddedName(fl.Type)
+			if jsonName == "" {
+				f := ix.field(fl.Type, from, seen)
+				if f.Nested != nil && f.Nested.Type == "object" {
+					for name, pf := range f.Nested.Fields {
+						if _, ok := promoted[name]; ok {
+							continue
+						}
+						if isPtr {
+							pf.Required = false
+						}
+						promoted[name] = pf
+					}
+					continue
+				}
+			}
+			names = []string{typeName}
+		}
+
+		for _, name := range names {
+			if !ast.IsExported(name) {
+				continue
+			}
+			key := jsonName
+			if key == "" {
+				key = name
+			}
+			f := ix.field(fl.Type, from, seen)
+			if opts.asString {
+				f.Type = "string"
+				f.Nested = nil
+			}
+			f.Required = !isPtr && !opts.omitEmpty
+			applyRules(f, tag.Get("binding"))
+			applyRules(f, tag.Get("validate"))
+			fields[key] = f
+		}
+	}
+
+	for name, f := range promoted {
+		if _, ok := fields[name]; !ok {
+			fields[name] = f
+		}
+	}
+	return fields
+}
+
+type jsonOptions struct {
+	omitEmpty bool
+	asString  bool
+}
+
+func parseJSONTag(tag string) (string, jsonOptions) {
+	var opts jsonOptions
+	parts := strings.Split(tag, ",")
+	for _, o := range parts[1:] {
+		switch o {
+		case "omitempty", "omitzero":
+			opts.omitEmpty = true
+		case "string":
+			opts.asString = true
+		}
+	}
+	return parts[0], opts
+}
+
+func embeddedName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	default:
+		return baseTypeName(t)
+	}
+}
+
+// applyRules reads go-playground/validator rules, as used by the validate
+// tag and gin's binding tag.
+func applyRules(f *schemair.Field, rules string) {
+	if rules == "" {
+		return
+	}
+	for _, rule := range strings.Split(rules, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			f.Required = true
+		case "email":
+			f.Format = "email"
+		case "url", "uri", "http_url":
+			f.Format = "uri"
+		case "uuid", "uuid4", "uuid_rfc4122":
+			f.Format = "uuid"
+		case "oneof":
+			f.Enum = nil
+			for _, v := range strings.Fields(arg) {
+				f.Enum = append(f.Enum, enumValue(f.Type, v))
+			}
+		case "min", "gte":
+			setBound(f, arg, true)
+		case "max", "lte":
+			setBound(f, arg, false)
+		case "len":
+			setBound(f, arg, true)
+			setBound(f, arg, false)
+		}
+	}
+}
+
+func enumValue(typ, v string) interface{} {
+	if typ == "int" || typ == "float" {
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	}
+	return v
+}
+
+// setBound applies a min or max rule: a length limit for strings and a
+// value limit for numbers.
+func setBound(f *schemair.Field, arg string, lower bool) {
+	switch f.Type {
+	case "string":
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			return
+		}
+		if lower {
+			f.MinLength = &n
+		} else {
+			f.MaxLength = &n
+		}
+	case "int", "float":
+		n, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return
+		}
+		if lower {
+			f.Minimum = &n
+		} else {
+			f.Maximum = &n
+		}
+	}
+}
@@ -11,3 +11,49 @@ type Analyzer interface {
 	Framework() string
 	Analyze(ctx context.Context, sourcePath string) ([]*schemair.SchemaIR, error)
 }
+
+// FileAnalyzer also analyzes files that were uploaded or fetched rather
+// than read from disk.
+type FileAnalyzer interface {
+	Analyzer
+	AnalyzeFiles(ctx context.Context, files []SourceFile, language string, mode ScanMode) ([]*schemair.SchemaIR, error)
+}
+
+// CoverageAnalyzer is a FileAnalyzer that also reports what it understood.
+// The registry passes the files it did not cover to the fallback; an
+// analyzer without it covers its whole language once it finds an endpoint.
+type CoverageAnalyzer interface {
+	FileAnalyzer
+	AnalyzeFilesCoverage(ctx context.Context, files []SourceFile, language string, mode ScanMode) ([]*schemair.SchemaIR, *Coverage, error)
+}
+
+// Coverage is what a CoverageAnalyzer reports besides its schemas.
+type Coverage struct {
+	// Files are the paths of the files the schemas were read from.
+	Files []string
+	// SkippedTypes lists the types the analyzer could not resolve.
+	SkippedTypes []SkippedType
+}
+
+// SkippedType is a type a deterministic analyzer could not resolve, such as
+// one declared in a module outside the scan. Fields of the type are
+// reported as "any".
+type SkippedType struct {
+	Type string `json:"type"`
+	// Path is the first file found using the type.
+	Path string `json:"path"`
+}
+
+type SourceFile struct {
+	Path    string
+	Content string
+}
+
+// ScanMode determines whether we extract endpoints a server serves (backend)
+// or API calls a client makes (frontend).
+type ScanMode string
+
+const (
+	ScanModeBackend  ScanMode = "backend"
+	ScanModeFrontend ScanMode = "frontend"
+)
@@ -0,0 +1,75 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/analyzer"
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+// Analyzer extracts endpoint schemas by prompting a language model with the
+// source files and parsing its JSON answer. It works with any provider.
+type Analyzer struct {
+	client Client
+}
+
+func New(client Client) *Analyzer {
+	return &Analyzer{client: client}
+}
+
+func (a *Analyzer) Language() string  { return "any" }
+func (a *Analyzer) Framework() string { return "any" }
+
+// Fingerprint identifies the model and prompts behind the analyzer's
+// results, so cached results are only reused when both are unchanged.
+func (a *Analyzer) Fingerprint() string {
+	return a.client.Provider() + "/" + a.client.Model() + "@prompt-v" + PromptVersion
+}
+
+func (a *Analyzer) Analyze(ctx context.Context, sourcePath string) ([]*schemair.SchemaIR, error) {
+	files, language := DiscoverFiles(sourcePath)
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no source files found in %s", sourcePath)
+	}
+	res, err := analyzer.AnalyzeChunked(ctx, a, files, language, analyzer.ScanModeBackend, analyzer.DefaultChunkOptions)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Skipped) > 0 {
+		log.Printf("[analyzer] %s: skipped %d of %d files", sourcePath, len(res.Skipped), len(files))
+	}
+	return res.Schemas, nil
+}
+
+func (a *Analyzer) AnalyzeFiles(ctx context.Context, files []analyzer.SourceFile, language string, mode analyzer.ScanMode) ([]*schemair.SchemaIR, error) {
+	return a.AnalyzeFilesWithContext(ctx, files, nil, language, mode)
+}
+
+// AnalyzeFilesWithContext analyzes one chunk of a larger tree, giving the
+// model the shared context files for reference.
+func (a *Analyzer) AnalyzeFilesWithContext(ctx context.Context, files, shared []analyzer.SourceFile, language string, mode analyzer.ScanMode) ([]*schemair.SchemaIR, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no source files provided")
+	}
+
+	prompt := BuildChunkPrompt(files, shared, language, mode)
+
+	rawJSON, err := a.client.Generate(ctx, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("%s analysis failed: %w", a.client.Provider(), err)
+	}
+
+	source := schemair.SourceBackendStatic
+	if mode == analyzer.ScanModeFrontend {
+		source = schemair.SourceFrontendStatic
+	}
+
+	schemas, err := ParseResponse(rawJSON, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s response: %w", a.client.Provider(), err)
+	}
+
+	return schemas, nil
+}
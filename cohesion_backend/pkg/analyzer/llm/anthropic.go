@@ -0,0 +1,65 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	anthropicVersion   = "2023-06-01"
+	anthropicMaxTokens = 16384
+)
+
+// anthropicClient calls the Anthropic Messages API.
+type anthropicClient struct {
+	cfg Config
+}
+
+type anthropicRequest struct {
+	Model       string          `json:"model"`
+	MaxTokens   int             `json:"max_tokens"`
+	Temperature float64         `json:"temperature"`
+	Messages    []openAIMessage `json:"messages"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+}
+
+func (c *anthropicClient) Provider() string { return ProviderAnthropic }
+func (c *anthropicClient) Model() string    { return c.cfg.Model }
+
+func (c *anthropicClient) Generate(ctx context.Context, prompt string) (string, error) {
+	var resp anthropicResponse
+	err := postJSON(ctx, c.cfg.HTTPClient, c.cfg.BaseURL+"/v1/messages", map[string]string{
+		"x-api-key":         c.cfg.APIKey,
+		"anthropic-version": anthropicVersion,
+	}, anthropicRequest{
+		Model:       c.cfg.Model,
+		MaxTokens:   anthropicMaxTokens,
+		Temperature: 0,
+		Messages:    []openAIMessage{{Role: "user", Content: prompt}},
+	}, &resp)
+	if err != nil {
+		return "", fmt.Errorf("anthropic generation failed: %w", err)
+	}
+
+	var sb strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			sb.WriteString(block.Text)
+		}
+	}
+	if sb.Len() == 0 {
+		return "", fmt.Errorf("anthropic returned empty response")
+	}
+	if resp.StopReason == "max_tokens" {
+		return "", fmt.Errorf("anthropic response was truncated at %d tokens", anthropicMaxTokens)
+	}
+	return sb.String(), nil
+}
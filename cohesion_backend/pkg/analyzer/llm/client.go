@@ -0,0 +1,155 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Supported providers. OpenAI covers any server exposing the OpenAI chat
+// completions API, such as Ollama, vLLM or LM Studio, when BaseURL is set.
+const (
+	ProviderGemini    = "gemini"
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+)
+
+// DefaultModels are used when Config.Model is empty.
+var DefaultModels = map[string]string{
+	ProviderGemini:    "gemini-2.5-flash",
+	ProviderOpenAI:    "gpt-4o-mini",
+	ProviderAnthropic: "claude-sonnet-4-5",
+}
+
+// Client sends a prompt to a language model and returns its text answer.
+// Implementations ask for deterministic JSON output where the provider
+// supports it.
+type Client interface {
+	Provider() string
+	Model() string
+	Generate(ctx context.Context, prompt string) (string, error)
+}
+
+// Config selects and configures a provider. APIKey is required except for
+// OpenAI-compatible servers with a BaseURL, which often run without auth.
+type Config struct {
+	Provider string
+	APIKey   string
+	Model    string
+	// BaseURL overrides the provider's API root, e.g.
+	// http://localhost:11434/v1 for Ollama.
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// ValidProvider reports whether provider is supported.
+func ValidProvider(provider string) bool {
+	_, ok := DefaultModels[provider]
+	return ok
+}
+
+// NewClient returns a client for the configured provider.
+func NewClient(cfg Config) (Client, error) {
+	if !ValidProvider(cfg.Provider) {
+		return nil, fmt.Errorf("unsupported LLM provider %q", cfg.Provider)
+	}
+	if cfg.APIKey == "" && !(cfg.Provider == ProviderOpenAI && cfg.BaseURL != "") {
+		return nil, fmt.Errorf("an API key is required for %s", cfg.Provider)
+	}
+	if cfg.Model == "" {
+		cfg.Model = DefaultModels[cfg.Provider]
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 5 * time.Minute}
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+
+	switch cfg.Provider {
+	case ProviderOpenAI:
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = "https://api.openai.com/v1"
+		}
+		return &openAIClient{cfg: cfg}, nil
+	case ProviderAnthropic:
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = "https://api.anthropic.com"
+		}
+		return &anthropicClient{cfg: cfg}, nil
+	default:
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = "https://generativelanguage.googleapis.com"
+		}
+		return &geminiClient{cfg: cfg}, nil
+	}
+}
+
+// postJSON posts body to url and decodes a successful response into out.
+// Error responses are returned with the provider's error message; the rest
+// of their body is dropped so a misconfigured base URL cannot echo back
+// arbitrary content.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if msg := errorMessage(io.LimitReader(resp.Body, 64*1024)); msg != "" {
+			return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, msg)
+		}
+		return fmt.Errorf("request failed with status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
+
+// maxErrorMessage caps the provider error message included in errors.
+const maxErrorMessage = 300
+
+// errorMessage extracts the message from a provider error body, which all
+// supported providers shape as {"error": "..."} or {"error": {"message":
+// "..."}}. It returns "" for any other body.
+func errorMessage(body io.Reader) string {
+	var resp struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.NewDecoder(body).Decode(&resp); err != nil || len(resp.Error) == 0 {
+		return ""
+	}
+	var msg string
+	if err := json.Unmarshal(resp.Error, &msg); err != nil {
+		var obj struct {
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(resp.Error, &obj); err != nil {
+			return ""
+		}
+		msg = obj.Message
+	}
+	msg = strings.TrimSpace(msg)
+	if len(msg) > maxErrorMessage {
+		msg = msg[:maxErrorMessage] + "..."
+	}
+	return msg
+}
@@ -0,0 +1,179 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/analyzer"
+)
+
+const fakeAnswer = "```json\n[{\"endpoint\": \"/users/{id}\", \"method\": \"get\", \"response\": {\"200\": {\"type\": \"object\", \"fields\": {\"id\": {\"type\": \"uuid\", \"required\": true, \"confidence\": 1.0}}}}}]\n```"
+
+// fakeProvider serves one provider's API, checking the request and
+// answering with fakeAnswer.
+func fakeProvider(t *testing.T, path string, check func(r *http.Request, body map[string]interface{}), answer func(text string) interface{}) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != path {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		check(r, body)
+		json.NewEncoder(w).Encode(answer(fakeAnswer))
+	}))
+}
+
+func TestProviders(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    Config
+		path   string
+		check  func(t *testing.T, r *http.Request, body map[string]interface{})
+		answer func(text string) interface{}
+	}{
+		{
+			name: "openai",
+			cfg:  Config{Provider: ProviderOpenAI, APIKey: "sk-test", Model: "gpt-test"},
+			path: "/chat/completions",
+			check: func(t *testing.T, r *http.Request, body map[string]interface{}) {
+				if r.Header.Get("Authorization") != "Bearer sk-test" || body["model"] != "gpt-test" {
+					t.Errorf("auth %q, model %v", r.Header.Get("Authorization"), body["model"])
+				}
+			},
+			answer: func(text string) interface{} {
+				return map[string]interface{}{"choices": []interface{}{map[string]interface{}{"message": map[string]string{"role": "assistant", "content": text}}}}
+			},
+		},
+		{
+			name: "openai-compatible without key",
+			cfg:  Config{Provider: ProviderOpenAI, Model: "llama3"},
+			path: "/chat/completions",
+			check: func(t *testing.T, r *http.Request, body map[string]interface{}) {
+				if r.Header.Get("Authorization") != "" {
+					t.Errorf("unexpected Authorization %q", r.Header.Get("Authorization"))
+				}
+			},
+			answer: func(text string) interface{} {
+				return map[string]interface{}{"choices": []interface{}{map[string]interface{}{"message": map[string]string{"content": text}}}}
+			},
+		},
+		{
+			name: "anthropic",
+			cfg:  Config{Provider: ProviderAnthropic, APIKey: "ant-test"},
+			path: "/v1/messages",
+			check: func(t *testing.T, r *http.Request, body map[string]interface{}) {
+				if r.Header.Get("x-api-key") != "ant-test" || r.Header.Get("anthropic-version") == "" {
+					t.Errorf("headers %v", r.Header)
+				}
+				if body["model"] != DefaultModels[ProviderAnthropic] || body["max_tokens"] == nil {
+					t.Errorf("body %v", body)
+				}
+			},
+			answer: func(text string) interface{} {
+				return map[string]interface{}{"content": []interface{}{map[string]string{"type": "text", "text": text}}, "stop_reason": "end_turn"}
+			},
+		},
+		{
+			name: "gemini",
+			cfg:  Config{Provider: ProviderGemini, APIKey: "g-test", Model: "gemini-test"},
+			path: "/v1beta/models/gemini-test:generateContent",
+			check: func(t *testing.T, r *http.Request, body map[string]interface{}) {
+				if r.Header.Get("x-goog-api-key") != "g-test" {
+					t.Errorf("x-goog-api-key %q", r.Header.Get("x-goog-api-key"))
+				}
+			},
+			answer: func(text string) interface{} {
+				return map[string]interface{}{"candidates": []interface{}{map[string]interface{}{"content": map[string]interface{}{"parts": []interface{}{map[string]string{"text": text}}}}}}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := fakeProvider(t, tt.path, func(r *http.Request, body map[string]interface{}) { tt.check(t, r, body) }, tt.answer)
+			defer srv.Close()
+
+			tt.cfg.BaseURL = srv.URL
+			client, err := NewClient(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			schemas, err := New(client).AnalyzeFiles(context.Background(),
+				[]analyzer.SourceFile{{Path: "main.go", Content: "package main"}}, "go", analyzer.ScanModeBackend)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(schemas) != 1 || schemas[0].Endpoint != "/users/{id}" || schemas[0].Method != "GET" {
+				t.Fatalf("schemas = %+v", schemas)
+			}
+			if f := schemas[0].Response[200].Fields["id"]; f == nil || f.Type != "uuid" {
+				t.Errorf("id field = %+v", f)
+			}
+		})
+	}
+}
+
+func TestProviderError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": "invalid api key"}`, http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(Config{Provider: ProviderAnthropic, APIKey: "bad", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Generate(context.Background(), "hi")
+	if err == nil || !strings.Contains(err.Error(), "401") || !strings.Contains(err.Error(), "invalid api key") {
+		t.Errorf("err = %v, want the status and message", err)
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	for body, want := range map[string]string{
+		`{"error": "invalid api key"}`:                                 "invalid api key",
+		`{"type": "error", "error": {"type": "x", "message": "slow"}}`: "slow",
+		`<html>internal admin page</html>`:                             "",
+		`{"status": "down"}`:                                           "",
+	} {
+		if got := errorMessage(strings.NewReader(body)); got != want {
+			t.Errorf("errorMessage(%s) = %q, want %q", body, got, want)
+		}
+	}
+}
+
+func TestNewClientValidation(t *testing.T) {
+	for _, cfg := range []Config{
+		{Provider: "mistral", APIKey: "k"},
+		{Provider: ProviderGemini},
+		{Provider: ProviderAnthropic},
+		{Provider: ProviderOpenAI},
+	} {
+		if _, err := NewClient(cfg); err == nil {
+			t.Errorf("NewClient(%+v) succeeded", cfg)
+		}
+	}
+	if _, err := NewClient(Config{Provider: ProviderOpenAI, BaseURL: "http://localhost:11434/v1"}); err != nil {
+		t.Errorf("OpenAI-compatible server without key: %v", err)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	client, err := NewClient(Config{Provider: ProviderAnthropic, APIKey: "k"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := New(client).Fingerprint(), "anthropic/"+DefaultModels[ProviderAnthropic]+"@prompt-v"+PromptVersion; got != want {
+		t.Errorf("Fingerprint() = %q, want %q", got, want)
+	}
+}
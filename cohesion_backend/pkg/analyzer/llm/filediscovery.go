@@ -1,4 +1,4 @@
-package gemini
+package llm
 
 import (
 	"os"
@@ -8,44 +8,10 @@ import (
 
 	ignore "github.com/sabhiram/go-gitignore"
 
+	"github.com/cohesion-api/cohesion_backend/pkg/analyzer"
 	"github.com/cohesion-api/cohesion_backend/pkg/sourcefile"
 )
 
-type SourceFile struct {
-	Path    string
-	Content string
-}
-
-const maxTokenBudget = 900_000
-const bytesPerToken = 4
-
-func filePriority(name string) int {
-	lower := strings.ToLower(name)
-
-	p1 := []string{"route", "router", "urls", "api", "endpoint", "path"}
-	for _, kw := range p1 {
-		if strings.Contains(lower, kw) {
-			return 1
-		}
-	}
-
-	p2 := []string{"handler", "controller", "view", "resource", "middleware"}
-	for _, kw := range p2 {
-		if strings.Contains(lower, kw) {
-			return 2
-		}
-	}
-
-	p3 := []string{"model", "schema", "dto", "type", "entity", "struct"}
-	for _, kw := range p3 {
-		if strings.Contains(lower, kw) {
-			return 3
-		}
-	}
-
-	return 4
-}
-
 type fileEntry struct {
 	path     string
 	relPath  string
@@ -140,7 +106,7 @@ func loadGitIgnoreMatcher(rootPath string) *ignore.GitIgnore {
 	return ignore.CompileIgnoreLines(allLines...)
 }
 
-func DetectLanguage(files []SourceFile) string {
+func DetectLanguage(files []analyzer.SourceFile) string {
 	extCount := make(map[string]int)
 	for _, f := range files {
 		ext := strings.ToLower(filepath.Ext(f.Path))
@@ -160,7 +126,7 @@ func DetectLanguage(files []SourceFile) string {
 	return language
 }
 
-func DiscoverFiles(rootPath string) ([]SourceFile, string) {
+func DiscoverFiles(rootPath string) ([]analyzer.SourceFile, string) {
 	var entries []fileEntry
 	extCount := make(map[string]int)
 	gitIgnoreMatcher := loadGitIgnoreMatcher(rootPath)
@@ -203,7 +169,7 @@ func DiscoverFiles(rootPath string) ([]SourceFile, string) {
 		entries = append(entries, fileEntry{
 			path:     path,
 			relPath:  relPath,
-			priority: filePriority(info.Name()),
+			priority: sourcefile.Priority(info.Name()),
 		})
 
 		return nil
@@ -213,29 +179,17 @@ func DiscoverFiles(rootPath string) ([]SourceFile, string) {
 		return entries[i].priority < entries[j].priority
 	})
 
-	var files []SourceFile
-	totalBytes := 0
-	maxBytes := maxTokenBudget * bytesPerToken
-
+	var files []analyzer.SourceFile
 	for _, entry := range entries {
-		if totalBytes >= maxBytes {
-			break
-		}
-
 		content, err := os.ReadFile(entry.path)
 		if err != nil {
 			continue
 		}
 
-		if totalBytes+len(content) > maxBytes {
-			break
-		}
-
-		files = append(files, SourceFile{
+		files = append(files, analyzer.SourceFile{
 			Path:    entry.relPath,
 			Content: string(content),
 		})
-		totalBytes += len(content)
 	}
 
 	language := ""
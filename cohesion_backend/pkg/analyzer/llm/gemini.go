@@ -0,0 +1,68 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// geminiClient calls the Gemini generateContent REST API.
+type geminiClient struct {
+	cfg Config
+}
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiRequest struct {
+	Contents         []geminiContent `json:"contents"`
+	GenerationConfig struct {
+		Temperature      float64 `json:"temperature"`
+		ResponseMIMEType string  `json:"responseMimeType"`
+	} `json:"generationConfig"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content *geminiContent `json:"content"`
+	} `json:"candidates"`
+}
+
+func (c *geminiClient) Provider() string { return ProviderGemini }
+func (c *geminiClient) Model() string    { return c.cfg.Model }
+
+func (c *geminiClient) Generate(ctx context.Context, prompt string) (string, error) {
+	req := geminiRequest{
+		Contents: []geminiContent{{Role: "user", Parts: []geminiPart{{Text: prompt}}}},
+	}
+	req.GenerationConfig.ResponseMIMEType = "application/json"
+
+	endpoint := fmt.Sprintf("%s/v1beta/models/%s:generateContent", c.cfg.BaseURL, url.PathEscape(c.cfg.Model))
+	var resp geminiResponse
+	err := postJSON(ctx, c.cfg.HTTPClient, endpoint, map[string]string{
+		"x-goog-api-key": c.cfg.APIKey,
+	}, req, &resp)
+	if err != nil {
+		return "", fmt.Errorf("gemini generation failed: %w", err)
+	}
+
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return "", fmt.Errorf("gemini returned no candidates")
+	}
+
+	var sb strings.Builder
+	for _, part := range resp.Candidates[0].Content.Parts {
+		sb.WriteString(part.Text)
+	}
+	if sb.Len() == 0 {
+		return "", fmt.Errorf("gemini returned empty response")
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,58 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+)
+
+// openAIClient calls the chat completions API of OpenAI or a compatible
+// server.
+type openAIClient struct {
+	cfg Config
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIRequest struct {
+	Model          string            `json:"model"`
+	Messages       []openAIMessage   `json:"messages"`
+	Temperature    float64           `json:"temperature"`
+	ResponseFormat map[string]string `json:"response_format,omitempty"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+	} `json:"choices"`
+}
+
+func (c *openAIClient) Provider() string { return ProviderOpenAI }
+func (c *openAIClient) Model() string    { return c.cfg.Model }
+
+func (c *openAIClient) Generate(ctx context.Context, prompt string) (string, error) {
+	headers := map[string]string{}
+	if c.cfg.APIKey != "" {
+		headers["Authorization"] = "Bearer " + c.cfg.APIKey
+	}
+
+	// The prompts ask for a top-level JSON array, which JSON mode does not
+	// allow, so the answer is requested as plain text and fences are
+	// stripped by the parser.
+	var resp openAIResponse
+	err := postJSON(ctx, c.cfg.HTTPClient, c.cfg.BaseURL+"/chat/completions", headers, openAIRequest{
+		Model:       c.cfg.Model,
+		Messages:    []openAIMessage{{Role: "user", Content: prompt}},
+		Temperature: 0,
+	}, &resp)
+	if err != nil {
+		return "", fmt.Errorf("openai generation failed: %w", err)
+	}
+
+	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
+		return "", fmt.Errorf("openai returned empty response")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
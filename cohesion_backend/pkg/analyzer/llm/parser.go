@@ -1,4 +1,4 @@
-package gemini
+package llm
 
 import (
 	"encoding/json"
@@ -15,6 +15,10 @@ type rawEndpoint struct {
 	Method   string                        `json:"method"`
 	Request  *schemair.ObjectSchema        `json:"request"`
 	Response map[string]*schemair.ObjectSchema `json:"response"`
+
+	PathParams  []schemair.PathParam       `json:"path_params"`
+	QueryParams map[string]*schemair.Field `json:"query_params"`
+	Headers     map[string]*schemair.Field `json:"headers"`
 }
 
 var validMethods = map[string]bool{
@@ -32,7 +36,7 @@ func ParseResponse(raw string, source schemair.SchemaSource) ([]*schemair.Schema
 
 	var endpoints []rawEndpoint
 	if err := json.Unmarshal([]byte(cleaned), &endpoints); err != nil {
-		return nil, fmt.Errorf("failed to parse Gemini response as JSON: %w", err)
+		return nil, fmt.Errorf("failed to parse model response as JSON: %w", err)
 	}
 
 	seen := make(map[string]bool)
@@ -43,7 +47,7 @@ func ParseResponse(raw string, source schemair.SchemaSource) ([]*schemair.Schema
 		ep.Endpoint = strings.TrimSpace(ep.Endpoint)
 
 		if !validateEndpoint(ep) {
-			log.Printf("[gemini-parser] dropping invalid endpoint: %s %s", ep.Method, ep.Endpoint)
+			log.Printf("[llm-parser] dropping invalid endpoint: %s %s", ep.Method, ep.Endpoint)
 			continue
 		}
 
@@ -58,6 +62,10 @@ func ParseResponse(raw string, source schemair.SchemaSource) ([]*schemair.Schema
 			Method:   ep.Method,
 			Source:   source,
 			Request:  ep.Request,
+
+			PathParams:  ep.PathParams,
+			QueryParams: ep.QueryParams,
+			Headers:     lowercaseHeaders(ep.Headers),
 		}
 
 		if ep.Response != nil {
@@ -65,7 +73,7 @@ func ParseResponse(raw string, source schemair.SchemaSource) ([]*schemair.Schema
 			for statusStr, obj := range ep.Response {
 				code, err := strconv.Atoi(statusStr)
 				if err != nil {
-					log.Printf("[gemini-parser] invalid status code %q for %s %s, skipping", statusStr, ep.Method, ep.Endpoint)
+					log.Printf("[llm-parser] invalid status code %q for %s %s, skipping", statusStr, ep.Method, ep.Endpoint)
 					continue
 				}
 				schema.Response[code] = obj
@@ -115,6 +123,35 @@ func validateSchema(schema *schemair.SchemaIR, source schemair.SchemaSource) {
 			validateObjectSchema(obj, source)
 		}
 	}
+	for i := range schema.PathParams {
+		schema.PathParams[i].Required = true
+		validateField(&schema.PathParams[i].Field, source)
+	}
+	for name, field := range schema.QueryParams {
+		if field == nil {
+			delete(schema.QueryParams, name)
+			continue
+		}
+		validateField(field, source)
+	}
+	for name, field := range schema.Headers {
+		if field == nil {
+			delete(schema.Headers, name)
+			continue
+		}
+		validateField(field, source)
+	}
+}
+
+func lowercaseHeaders(headers map[string]*schemair.Field) map[string]*schemair.Field {
+	if len(headers) == 0 {
+		return nil
+	}
+	out := make(map[string]*schemair.Field, len(headers))
+	for name, field := range headers {
+		out[strings.ToLower(strings.TrimSpace(name))] = field
+	}
+	return out
 }
 
 func validateObjectSchema(obj *schemair.ObjectSchema, source schemair.SchemaSource) {
@@ -125,16 +162,7 @@ func validateObjectSchema(obj *schemair.ObjectSchema, source schemair.SchemaSour
 		obj.Type = "object"
 	}
 	for _, field := range obj.Fields {
-		if field.Type == "" {
-			field.Type = "any"
-		}
-		if !validTypes[field.Type] {
-			field.Type = "any"
-		}
-		if field.Confidence == 0 {
-			field.Confidence = 0.8
-		}
-		field.SourceTag = source
+		validateField(field, source)
 		if field.Nested != nil {
 			validateObjectSchema(field.Nested, source)
 		}
@@ -143,3 +171,50 @@ func validateObjectSchema(obj *schemair.ObjectSchema, source schemair.SchemaSour
 		validateObjectSchema(obj.Items, source)
 	}
 }
+
+func validateField(field *schemair.Field, source schemair.SchemaSource) {
+	if field.Type == "" {
+		field.Type = "any"
+	}
+	if !validTypes[field.Type] {
+		field.Type = "any"
+	}
+	if field.Confidence == 0 {
+		field.Confidence = 0.8
+	}
+	field.SourceTag = source
+	validateConstraints(field)
+}
+
+// validateConstraints drops constraints that cannot be meaningful: non-scalar
+// enum values, negative lengths and inverted ranges.
+func validateConstraints(field *schemair.Field) {
+	field.Format = strings.ToLower(strings.TrimSpace(field.Format))
+
+	if len(field.Enum) > 0 {
+		values := field.Enum[:0]
+		for _, v := range field.Enum {
+			switch v.(type) {
+			case string, float64, bool:
+				values = append(values, v)
+			}
+		}
+		if len(values) == 0 {
+			values = nil
+		}
+		field.Enum = values
+	}
+
+	if field.MinLength != nil && *field.MinLength < 0 {
+		field.MinLength = nil
+	}
+	if field.MaxLength != nil && *field.MaxLength < 0 {
+		field.MaxLength = nil
+	}
+	if field.MinLength != nil && field.MaxLength != nil && *field.MinLength > *field.MaxLength {
+		field.MinLength, field.MaxLength = nil, nil
+	}
+	if field.Minimum != nil && field.Maximum != nil && *field.Minimum > *field.Maximum {
+		field.Minimum, field.Maximum = nil, nil
+	}
+}
@@ -1,35 +1,52 @@
-package gemini
+package llm
 
 import (
 	"fmt"
 	"strings"
-)
-
-// ScanMode determines whether we extract endpoints a server serves (backend)
-// or API calls a client makes (frontend).
-type ScanMode string
 
-const (
-	ScanModeBackend  ScanMode = "backend"
-	ScanModeFrontend ScanMode = "frontend"
+	"github.com/cohesion-api/cohesion_backend/pkg/analyzer"
 )
 
+// PromptVersion identifies the prompts and the answer format they ask for.
+// Bump it whenever either changes so cached analysis results made with the
+// old prompts are not reused.
+const PromptVersion = "2"
+
 // BuildPrompt builds a backend scan prompt (kept for backwards compatibility).
-func BuildPrompt(files []SourceFile, language string) string {
-	return BuildPromptForMode(files, language, ScanModeBackend)
+func BuildPrompt(files []analyzer.SourceFile, language string) string {
+	return BuildPromptForMode(files, language, analyzer.ScanModeBackend)
 }
 
 // BuildPromptForMode dispatches to the appropriate prompt builder based on mode.
-func BuildPromptForMode(files []SourceFile, language string, mode ScanMode) string {
+func BuildPromptForMode(files []analyzer.SourceFile, language string, mode analyzer.ScanMode) string {
 	switch mode {
-	case ScanModeFrontend:
+	case analyzer.ScanModeFrontend:
 		return buildFrontendPrompt(files, language)
 	default:
 		return buildBackendPrompt(files, language)
 	}
 }
 
-func buildBackendPrompt(files []SourceFile, language string) string {
+// BuildChunkPrompt builds the prompt for one chunk of a tree analyzed in
+// parts. The shared files are appended for reference only.
+func BuildChunkPrompt(files, shared []analyzer.SourceFile, language string, mode analyzer.ScanMode) string {
+	prompt := BuildPromptForMode(files, language, mode)
+	if len(shared) == 0 {
+		return prompt
+	}
+
+	var sb strings.Builder
+	sb.WriteString(prompt)
+	sb.WriteString(`The codebase is analyzed in parts and the files above are one part. The reference files below are shared with every part so you can resolve the routes, types and helpers the files above use. Do not report anything that is defined only in the reference files; another part covers them.
+
+Reference files:
+
+`)
+	appendFiles(&sb, shared)
+	return sb.String()
+}
+
+func buildBackendPrompt(files []analyzer.SourceFile, language string) string {
 	var sb strings.Builder
 
 	sb.WriteString(`You are an expert backend code analyzer. Extract ALL HTTP/REST API endpoints from the source code below.
@@ -39,6 +56,9 @@ For each endpoint, provide:
 - "method": HTTP method in uppercase (GET, POST, PUT, PATCH, DELETE)
 - "request": Request body schema (null if no body, e.g. GET requests)
 - "response": Response body schemas keyed by status code as strings
+- "path_params": Path parameters in the order they appear in the path, each {"name": "<name>", "type": "<type>"} plus any constraints
+- "query_params": Query parameters the handler reads, keyed by exact name, as fields
+- "headers": Request headers the handler reads, keyed by lowercase name, as fields (omit Content-Type, Accept and Authorization handled by middleware)
 
 For request/response schemas, use this structure:
 {
@@ -64,13 +84,25 @@ For nested objects, add a "nested" field:
   "nested": { "type": "object", "fields": { ... } }
 }
 
+Add these optional keys to a field when the code constrains its values:
+- "nullable": true if the field may be null (pointer/Optional/None types, "| null" unions, .nullable() validators)
+- "enum": list of allowed values (Go const blocks, enums, string literal unions, oneof/enum validators)
+- "format": string format such as "email", "uri", "uuid", "date", "date-time"
+- "minimum" / "maximum": numeric bounds
+- "min_length" / "max_length": string length bounds
+- "pattern": regular expression the value must match
+Omit a key when the code does not state the constraint.
+
 Rules:
 - Only extract endpoints that actually exist in the code - do not invent or guess
 - Use {param} syntax for path parameters (not :param or <param>)
 - Set confidence to 1.0 for fields explicitly defined in code, 0.7-0.9 for inferred fields
+- Only add constraints that are written in the code - never guess enums or bounds
 - Response keys must be status code strings like "200", "201", "404"
 - If you cannot determine the response schema, use {"type": "object"} with no fields
 - Include all middleware-injected or framework-standard response patterns you can identify
+- Keep query parameter names exactly as the code reads them (r.URL.Query().Get("page_size") is "page_size", not "pageSize")
+- Omit "path_params", "query_params" and "headers" when there are none
 
 `)
 
@@ -83,13 +115,18 @@ Rules:
   {
     "endpoint": "/api/users/{id}",
     "method": "GET",
+    "path_params": [{"name": "id", "type": "uuid", "required": true, "confidence": 1.0}],
+    "query_params": {"include": {"type": "string", "required": false, "confidence": 1.0, "enum": ["orders", "profile"]}},
+    "headers": {"x-tenant-id": {"type": "string", "required": true, "confidence": 1.0}},
     "request": null,
     "response": {
       "200": {
         "type": "object",
         "fields": {
           "id": {"type": "uuid", "required": true, "confidence": 1.0},
-          "name": {"type": "string", "required": true, "confidence": 1.0}
+          "name": {"type": "string", "required": true, "confidence": 1.0, "max_length": 100},
+          "status": {"type": "string", "required": true, "confidence": 1.0, "enum": ["active", "banned"]},
+          "deleted_at": {"type": "time", "required": false, "confidence": 1.0, "nullable": true}
         }
       }
     }
@@ -104,7 +141,7 @@ Source code files:
 	return sb.String()
 }
 
-func buildFrontendPrompt(files []SourceFile, language string) string {
+func buildFrontendPrompt(files []analyzer.SourceFile, language string) string {
 	var sb strings.Builder
 
 	sb.WriteString(`You are an expert frontend code analyzer. Extract ALL HTTP/REST API calls that this client-side code makes to a backend server.
@@ -122,6 +159,9 @@ For each API call, provide:
 - "method": HTTP method in uppercase (GET, POST, PUT, PATCH, DELETE)
 - "request": Request body schema (null if no body, e.g. GET requests)
 - "response": Response body schemas keyed by status code as strings (use "200" if status is unclear)
+- "path_params": Path parameters in the order they appear in the path, each {"name": "<name>", "type": "<type>"} plus any constraints
+- "query_params": Query parameters the call sends (URLSearchParams, axios "params", query strings in the URL), keyed by exact name, as fields
+- "headers": Custom request headers the call sends, keyed by lowercase name, as fields (omit Content-Type, Accept and Authorization)
 
 For request/response schemas, use this structure:
 {
@@ -147,6 +187,15 @@ For nested objects, add a "nested" field:
   "nested": { "type": "object", "fields": { ... } }
 }
 
+Add these optional keys to a field when the code constrains its values:
+- "nullable": true if the field may be null (pointer/Optional/None types, "| null" unions, .nullable() validators)
+- "enum": list of allowed values (Go const blocks, enums, string literal unions, oneof/enum validators)
+- "format": string format such as "email", "uri", "uuid", "date", "date-time"
+- "minimum" / "maximum": numeric bounds
+- "min_length" / "max_length": string length bounds
+- "pattern": regular expression the value must match
+Omit a key when the code does not state the constraint.
+
 Rules:
 - Only extract API calls that actually exist in the code - do not invent or guess
 - Strip base URLs and domains (e.g. "https://api.example.com/users" becomes "/users")
@@ -154,8 +203,11 @@ Rules:
 - Convert string concatenation to path params: "/users/" + id becomes "/users/{id}"
 - Use {param} syntax for path parameters
 - Set confidence to 1.0 for fields explicitly defined in code, 0.7-0.9 for inferred fields
+- Only add constraints that are written in the code, e.g. a TypeScript union "'active' | 'banned'" is an enum and "string | null" is nullable
 - Response keys must be status code strings like "200", "201", "404"
 - If response type is parsed via .json() but structure is unclear, use {"type": "object"} with no fields
+- Keep query parameter names exactly as the code sends them; a query parameter is required only if every call sends it
+- Omit "path_params", "query_params" and "headers" when there are none
 - Deduplicate: if the same endpoint+method is called in multiple places, merge their schemas
 
 `)
@@ -169,13 +221,17 @@ Rules:
   {
     "endpoint": "/api/users/{id}",
     "method": "GET",
+    "path_params": [{"name": "id", "type": "string", "required": true, "confidence": 1.0}],
+    "query_params": {"include": {"type": "string", "required": false, "confidence": 1.0}},
     "request": null,
     "response": {
       "200": {
         "type": "object",
         "fields": {
           "id": {"type": "uuid", "required": true, "confidence": 1.0},
-          "name": {"type": "string", "required": true, "confidence": 0.8}
+          "name": {"type": "string", "required": true, "confidence": 0.8},
+          "status": {"type": "string", "required": true, "confidence": 1.0, "enum": ["active", "banned"]},
+          "avatar_url": {"type": "string", "required": true, "confidence": 1.0, "nullable": true}
         }
       }
     }
@@ -190,7 +246,7 @@ Source code files:
 	return sb.String()
 }
 
-func appendFiles(sb *strings.Builder, files []SourceFile) {
+func appendFiles(sb *strings.Builder, files []analyzer.SourceFile) {
 	for _, f := range files {
 		sb.WriteString(fmt.Sprintf("=== File: %s ===\n", f.Path))
 		sb.WriteString(f.Content)
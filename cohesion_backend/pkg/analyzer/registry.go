@@ -0,0 +1,302 @@
+package analyzer
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+	"github.com/cohesion-api/cohesion_backend/pkg/sourcefile"
+)
+
+// Registry selects analyzers for a scanned tree. Deterministic analyzers
+// are registered once; the fallback, usually an LLM analyzer, is supplied
+// per scan and only sees the files no registered analyzer covered.
+type Registry struct {
+	entries  []*registration
+	chunking ChunkOptions
+}
+
+type registration struct {
+	analyzer   FileAnalyzer
+	frameworks []string
+	modes      []ScanMode
+}
+
+// Result is the outcome of a registry scan.
+type Result struct {
+	Schemas   []*schemair.SchemaIR
+	Detection Detection
+	// Analyzers names the registered analyzers that produced schemas.
+	Analyzers []string
+	// FallbackFiles counts the files left to the fallback analyzer,
+	// including those whose results came from the cache.
+	FallbackFiles int
+	// SkippedFiles counts the files left unanalyzed, which Skipped lists
+	// with the reason: no fallback was available, they did not fit the
+	// fallback's chunks, or their chunk failed.
+	SkippedFiles int
+	Skipped      []SkippedFile
+	// SkippedTypes lists the types registered analyzers could not resolve
+	// and reported as "any".
+	SkippedTypes []SkippedType
+	// Chunks is the number of chunks the fallback's files were split into.
+	Chunks int
+	// Cache reports how many of those chunks were served from the cache.
+	Cache CacheStats
+}
+
+func NewRegistry() *Registry {
+	return &Registry{chunking: DefaultChunkOptions}
+}
+
+// SetChunking sets how the fallback's files are split when they do not fit
+// in one prompt. Zero fields keep their defaults.
+func (r *Registry) SetChunking(opts ChunkOptions) {
+	r.chunking = opts.withDefaults()
+}
+
+// Register adds an analyzer for the given scan modes, or for every mode
+// when none are given. Its Language selects the files it receives and its
+// Framework, a comma-separated list, the trees it applies to.
+func (r *Registry) Register(a FileAnalyzer, modes ...ScanMode) {
+	var frameworks []string
+	for _, f := range strings.Split(a.Framework(), ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			frameworks = append(frameworks, f)
+		}
+	}
+	r.entries = append(r.entries, &registration{analyzer: a, frameworks: frameworks, modes: modes})
+}
+
+// Analyzers lists the registered analyzers.
+func (r *Registry) Analyzers() []FileAnalyzer {
+	list := make([]FileAnalyzer, len(r.entries))
+	for i, e := range r.entries {
+		list[i] = e.analyzer
+	}
+	return list
+}
+
+func (e *registration) name() string {
+	return e.analyzer.Language() + " (" + e.analyzer.Framework() + ")"
+}
+
+// appliesTo reports whether the analyzer handles mode and the tree has
+// files in its language. When the language's manifests name frameworks,
+// one of them must be among the analyzer's.
+func (e *registration) appliesTo(d Detection, mode ScanMode) bool {
+	if len(e.modes) > 0 {
+		supported := false
+		for _, m := range e.modes {
+			supported = supported || m == mode
+		}
+		if !supported {
+			return false
+		}
+	}
+	language := e.language(d)
+	if language == "" {
+		return false
+	}
+	detected := d.Frameworks[language]
+	if len(detected) == 0 {
+		return true
+	}
+	for _, f := range detected {
+		for _, own := range e.frameworks {
+			if strings.EqualFold(f, own) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// language returns the detected language name matching the analyzer's.
+func (e *registration) language(d Detection) string {
+	for language := range d.Languages {
+		if strings.EqualFold(language, e.analyzer.Language()) {
+			return language
+		}
+	}
+	return ""
+}
+
+// AnalyzeFiles runs every registered analyzer that applies to files and
+// merges their schemas. Files no analyzer covered, per CoverageAnalyzer or
+// else by language, are passed to the analyzer fallback returns, which is
+// only called when such files exist, split into chunks when they do not fit
+// in one prompt. If fallback fails after other analyzers produced schemas,
+// those schemas are returned and the files are reported as skipped.
+// language, when set, is the language hint for the fallback.
+func (r *Registry) AnalyzeFiles(ctx context.Context, files []SourceFile, language string, mode ScanMode, fallback func() (FileAnalyzer, error)) (*Result, error) {
+	return r.AnalyzeFilesCached(ctx, files, language, mode, fallback, nil)
+}
+
+// AnalyzeFilesCached is AnalyzeFiles reusing the fallback's results for
+// chunks found in cache, which may be nil. Registered analyzers are cheap
+// and always run.
+func (r *Registry) AnalyzeFilesCached(ctx context.Context, files []SourceFile, language string, mode ScanMode, fallback func() (FileAnalyzer, error), cache Cache) (*Result, error) {
+	d := Detect(files)
+	res := &Result{Detection: d}
+	merged := newSchemaSet()
+	// coveredLangs holds the languages of analyzers that do not report
+	// their coverage, coveredFiles the files of those that do.
+	coveredLangs := make(map[string]bool)
+	coveredFiles := make(map[string]bool)
+
+	for _, e := range r.entries {
+		if !e.appliesTo(d, mode) {
+			continue
+		}
+		lang := e.language(d)
+		var own []SourceFile
+		for _, f := range files {
+			if fileLanguage(f.Path) == lang {
+				own = append(own, f)
+			}
+		}
+		var schemas []*schemair.SchemaIR
+		var coverage *Coverage
+		var err error
+		if ca, ok := e.analyzer.(CoverageAnalyzer); ok {
+			schemas, coverage, err = ca.AnalyzeFilesCoverage(ctx, own, lang, mode)
+		} else {
+			schemas, err = e.analyzer.AnalyzeFiles(ctx, own, lang, mode)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("[analyzer] %s failed, falling back: %v", e.name(), err)
+			continue
+		}
+		if len(schemas) == 0 {
+			continue
+		}
+		if coverage != nil {
+			for _, p := range coverage.Files {
+				coveredFiles[p] = true
+			}
+			res.SkippedTypes = append(res.SkippedTypes, coverage.SkippedTypes...)
+		} else {
+			coveredLangs[lang] = true
+		}
+		res.Analyzers = append(res.Analyzers, e.name())
+		for _, s := range schemas {
+			merged.add(s)
+		}
+	}
+
+	var rest []SourceFile
+	for _, f := range files {
+		if sourcefile.IsManifest(f.Path) || coveredLangs[fileLanguage(f.Path)] || coveredFiles[f.Path] {
+			continue
+		}
+		rest = append(rest, f)
+	}
+
+	if len(rest) > 0 && fallback != nil {
+		fb, err := fallback()
+		if err != nil {
+			if len(merged.list) == 0 {
+				return nil, err
+			}
+			res.Skipped = skipAll(rest, err.Error())
+		} else {
+			if language == "" || len(res.Analyzers) > 0 {
+				language = Detect(rest).Language
+			}
+			opts := r.chunking
+			opts.Cache = cache
+			chunked, err := AnalyzeChunked(ctx, fb, rest, language, mode, opts)
+			if err != nil {
+				return nil, err
+			}
+			res.Chunks = chunked.Chunks
+			res.Cache = chunked.Cache
+			res.Skipped = chunked.Skipped
+			res.FallbackFiles = len(rest) - len(chunked.Skipped)
+			for _, s := range chunked.Schemas {
+				merged.add(s)
+			}
+		}
+	} else {
+		res.Skipped = skipAll(rest, "no analyzer covers it and no fallback is configured")
+	}
+	res.SkippedFiles = len(res.Skipped)
+
+	res.Schemas = merged.list
+	return res, nil
+}
+
+func skipAll(files []SourceFile, reason string) []SkippedFile {
+	skipped := make([]SkippedFile, len(files))
+	for i, f := range files {
+		skipped[i] = SkippedFile{Path: f.Path, Reason: reason}
+	}
+	return skipped
+}
+
+// schemaSet merges schemas for the same endpoint. The first schema seen
+// wins; later ones only fill in what it lacks.
+type schemaSet struct {
+	byKey map[string]*schemair.SchemaIR
+	list  []*schemair.SchemaIR
+}
+
+func newSchemaSet() *schemaSet {
+	return &schemaSet{byKey: make(map[string]*schemair.SchemaIR)}
+}
+
+func (s *schemaSet) add(schema *schemair.SchemaIR) {
+	key := endpointKey(schema.Method, schema.Endpoint)
+	dst, ok := s.byKey[key]
+	if !ok {
+		s.byKey[key] = schema
+		s.list = append(s.list, schema)
+		return
+	}
+	if dst.Request == nil {
+		dst.Request = schema.Request
+	}
+	for code, body := range schema.Response {
+		if dst.Response == nil {
+			dst.Response = make(map[int]*schemair.ObjectSchema)
+		}
+		if _, ok := dst.Response[code]; !ok {
+			dst.Response[code] = body
+		}
+	}
+	if len(dst.PathParams) == 0 {
+		dst.PathParams = schema.PathParams
+	}
+	dst.QueryParams = mergeFields(dst.QueryParams, schema.QueryParams)
+	dst.Headers = mergeFields(dst.Headers, schema.Headers)
+}
+
+var pathParam = regexp.MustCompile(`\{[^}]*\}`)
+
+// endpointKey identifies an endpoint regardless of how its path parameters
+// are named or whether its path ends in a slash.
+func endpointKey(method, endpoint string) string {
+	endpoint = pathParam.ReplaceAllString(endpoint, "{}")
+	if len(endpoint) > 1 {
+		endpoint = strings.TrimSuffix(endpoint, "/")
+	}
+	return strings.ToUpper(method) + " " + endpoint
+}
+
+func mergeFields(dst, src map[string]*schemair.Field) map[string]*schemair.Field {
+	for name, f := range src {
+		if dst == nil {
+			dst = make(map[string]*schemair.Field)
+		}
+		if _, ok := dst[name]; !ok {
+			dst[name] = f
+		}
+	}
+	return dst
+}
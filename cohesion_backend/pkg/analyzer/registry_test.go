@@ -0,0 +1,208 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+// fakeAnalyzer records the files it is given and returns fixed schemas.
+type fakeAnalyzer struct {
+	language, framework string
+	schemas             []*schemair.SchemaIR
+	err                 error
+	got                 []string
+}
+
+func (f *fakeAnalyzer) Language() string  { return f.language }
+func (f *fakeAnalyzer) Framework() string { return f.framework }
+
+func (f *fakeAnalyzer) Analyze(ctx context.Context, sourcePath string) ([]*schemair.SchemaIR, error) {
+	return nil, errors.New("not used")
+}
+
+func (f *fakeAnalyzer) AnalyzeFiles(ctx context.Context, files []SourceFile, language string, mode ScanMode) ([]*schemair.SchemaIR, error) {
+	for _, file := range files {
+		f.got = append(f.got, file.Path)
+	}
+	sort.Strings(f.got)
+	return f.schemas, f.err
+}
+
+func fallbackTo(a FileAnalyzer) func() (FileAnalyzer, error) {
+	return func() (FileAnalyzer, error) { return a, nil }
+}
+
+var mixedTree = []SourceFile{
+	{Path: "go.mod", Content: "module example.com/app\n\ngo 1.22\n\nrequire (\n\tgithub.com/go-chi/chi/v5 v5.0.12\n\tgithub.com/google/uuid v1.6.0\n)\n"},
+	{Path: "cmd/server/main.go", Content: "package main"},
+	{Path: "internal/api/users.go", Content: "package api"},
+	{Path: "scripts/seed.py", Content: "import requests"},
+	{Path: "web/package.json", Content: `{"dependencies": {"react": "19.0.0", "axios": "1.7.0"}, "devDependencies": {"typescript": "5.6.0"}}`},
+	{Path: "web/api.ts", Content: "export {}"},
+	{Path: "requirements.txt", Content: "Flask==3.0.0\nrequests\n"},
+}
+
+func TestDetect(t *testing.T) {
+	d := Detect(mixedTree)
+	if d.Language != "Go" || d.Languages["Go"] != 2 || d.Languages["Python"] != 1 || d.Languages["TypeScript"] != 1 {
+		t.Errorf("languages = %s %v", d.Language, d.Languages)
+	}
+	want := map[string][]string{
+		"Go":         {"chi", "net/http"},
+		"TypeScript": {"axios", "react"},
+		"Python":     {"flask"},
+	}
+	if !reflect.DeepEqual(d.Frameworks, want) {
+		t.Errorf("frameworks = %v, want %v", d.Frameworks, want)
+	}
+}
+
+func TestRegistryFallsBackForUncoveredFiles(t *testing.T) {
+	goAnalyzer := &fakeAnalyzer{language: "go", framework: "net/http, chi", schemas: []*schemair.SchemaIR{
+		{Endpoint: "/users", Method: "GET", Response: map[int]*schemair.ObjectSchema{200: {Type: "array"}}},
+	}}
+	llm := &fakeAnalyzer{language: "any", framework: "any", schemas: []*schemair.SchemaIR{
+		{Endpoint: "/users", Method: "GET", Response: map[int]*schemair.ObjectSchema{200: {Type: "object"}, 404: {Type: "object"}}},
+		{Endpoint: "/seed", Method: "POST"},
+	}}
+	r := NewRegistry()
+	r.Register(goAnalyzer, ScanModeBackend)
+
+	res, err := r.AnalyzeFiles(context.Background(), mixedTree, "", ScanModeBackend, fallbackTo(llm))
+	if err != nil {
+		t.Fatalf("AnalyzeFiles: %v", err)
+	}
+	if want := []string{"cmd/server/main.go", "internal/api/users.go"}; !reflect.DeepEqual(goAnalyzer.got, want) {
+		t.Errorf("go analyzer got %v, want %v", goAnalyzer.got, want)
+	}
+	if want := []string{"scripts/seed.py", "web/api.ts"}; !reflect.DeepEqual(llm.got, want) {
+		t.Errorf("fallback got %v, want %v", llm.got, want)
+	}
+	if res.FallbackFiles != 2 || len(res.Analyzers) != 1 || res.Analyzers[0] != "go (net/http, chi)" {
+		t.Errorf("result = %+v", res)
+	}
+	if len(res.Schemas) != 2 {
+		t.Fatalf("expected 2 merged schemas, got %d", len(res.Schemas))
+	}
+	users := res.Schemas[0]
+	if users.Response[200].Type != "array" || users.Response[404] == nil {
+		t.Errorf("merged /users responses = %+v", users.Response)
+	}
+}
+
+func TestRegistrySkipsFallbackWhenCovered(t *testing.T) {
+	goAnalyzer := &fakeAnalyzer{language: "go", framework: "chi", schemas: []*schemair.SchemaIR{{Endpoint: "/users", Method: "GET"}}}
+	r := NewRegistry()
+	r.Register(goAnalyzer)
+
+	called := false
+	res, err := r.AnalyzeFiles(context.Background(), mixedTree[:3], "", ScanModeBackend, func() (FileAnalyzer, error) {
+		called = true
+		return nil, errors.New("no LLM configured")
+	})
+	if err != nil || called {
+		t.Fatalf("err = %v, fallback called = %v", err, called)
+	}
+	if len(res.Schemas) != 1 || res.FallbackFiles != 0 || res.SkippedFiles != 0 {
+		t.Errorf("result = %+v", res)
+	}
+}
+
+// coverageAnalyzer reports the files it covered.
+type coverageAnalyzer struct {
+	fakeAnalyzer
+	covered []string
+}
+
+func (c *coverageAnalyzer) AnalyzeFilesCoverage(ctx context.Context, files []SourceFile, language string, mode ScanMode) ([]*schemair.SchemaIR, *Coverage, error) {
+	schemas, err := c.AnalyzeFiles(ctx, files, language, mode)
+	return schemas, &Coverage{Files: c.covered, SkippedTypes: []SkippedType{{Type: "decimal.Decimal", Path: c.covered[0]}}}, err
+}
+
+func TestRegistryFallsBackForFilesOutsideCoverage(t *testing.T) {
+	goAnalyzer := &coverageAnalyzer{
+		fakeAnalyzer: fakeAnalyzer{language: "go", framework: "chi", schemas: []*schemair.SchemaIR{{Endpoint: "/users", Method: "GET"}}},
+		covered:      []string{"internal/api/users.go"},
+	}
+	llm := &fakeAnalyzer{language: "any", framework: "any"}
+	r := NewRegistry()
+	r.Register(goAnalyzer)
+
+	res, err := r.AnalyzeFiles(context.Background(), mixedTree[:3], "", ScanModeBackend, fallbackTo(llm))
+	if err != nil {
+		t.Fatalf("AnalyzeFiles: %v", err)
+	}
+	if want := []string{"cmd/server/main.go"}; !reflect.DeepEqual(llm.got, want) {
+		t.Errorf("fallback got %v, want %v", llm.got, want)
+	}
+	if res.FallbackFiles != 1 || len(res.Schemas) != 1 || len(res.SkippedTypes) != 1 {
+		t.Errorf("result = %+v", res)
+	}
+}
+
+func TestRegistrySelection(t *testing.T) {
+	tests := []struct {
+		name      string
+		framework string
+		modes     []ScanMode
+		mode      ScanMode
+		empty     bool
+		fails     bool
+		wantUsed  bool
+	}{
+		{name: "framework matches go.mod", framework: "chi", mode: ScanModeBackend, wantUsed: true},
+		{name: "framework missing from go.mod", framework: "gin", mode: ScanModeBackend},
+		{name: "mode not supported", framework: "chi", modes: []ScanMode{ScanModeBackend}, mode: ScanModeFrontend},
+		{name: "no endpoints found", framework: "chi", mode: ScanModeBackend, empty: true},
+		{name: "analyzer error", framework: "chi", mode: ScanModeBackend, fails: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &fakeAnalyzer{language: "go", framework: tt.framework}
+			if !tt.empty {
+				a.schemas = []*schemair.SchemaIR{{Endpoint: "/users", Method: "GET"}}
+			}
+			if tt.fails {
+				a.err = errors.New("parse failure")
+			}
+			llm := &fakeAnalyzer{language: "any", framework: "any"}
+			r := NewRegistry()
+			r.Register(a, tt.modes...)
+
+			res, err := r.AnalyzeFiles(context.Background(), mixedTree[:3], "", tt.mode, fallbackTo(llm))
+			if err != nil {
+				t.Fatalf("AnalyzeFiles: %v", err)
+			}
+			if used := len(res.Analyzers) == 1; used != tt.wantUsed {
+				t.Errorf("analyzer used = %v, want %v", used, tt.wantUsed)
+			}
+			if wantFallback := !tt.wantUsed; (len(llm.got) > 0) != wantFallback {
+				t.Errorf("fallback got %v", llm.got)
+			}
+		})
+	}
+}
+
+func TestRegistryKeepsResultsWithoutFallback(t *testing.T) {
+	goAnalyzer := &fakeAnalyzer{language: "go", framework: "chi", schemas: []*schemair.SchemaIR{{Endpoint: "/users", Method: "GET"}}}
+	r := NewRegistry()
+	r.Register(goAnalyzer)
+
+	unavailable := func() (FileAnalyzer, error) { return nil, errors.New("no LLM configured") }
+	res, err := r.AnalyzeFiles(context.Background(), mixedTree, "", ScanModeBackend, unavailable)
+	if err != nil {
+		t.Fatalf("AnalyzeFiles: %v", err)
+	}
+	if len(res.Schemas) != 1 || res.SkippedFiles != 2 {
+		t.Errorf("result = %+v", res)
+	}
+
+	if _, err := NewRegistry().AnalyzeFiles(context.Background(), mixedTree, "", ScanModeBackend, unavailable); err == nil {
+		t.Error("expected the fallback error when nothing else produced schemas")
+	}
+}
@@ -0,0 +1,397 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+type ChangeKind string
+
+const (
+	ChangeAdded         ChangeKind = "added"
+	ChangeRemoved       ChangeKind = "removed"
+	ChangeTypeChanged   ChangeKind = "type_changed"
+	ChangeMadeRequired  ChangeKind = "made_required"
+	ChangeMadeOptional  ChangeKind = "made_optional"
+	ChangeStatusAdded   ChangeKind = "status_added"
+	ChangeStatusRemoved ChangeKind = "status_removed"
+	ChangeMadeNullable  ChangeKind = "made_nullable"
+	ChangeMadeNonNull   ChangeKind = "made_non_nullable"
+	ChangeEnumNarrowed  ChangeKind = "enum_narrowed"
+	ChangeEnumWidened   ChangeKind = "enum_widened"
+)
+
+type Change struct {
+	Path        string      `json:"path"`
+	Kind        ChangeKind  `json:"kind"`
+	Section     string      `json:"section"`
+	Breaking    bool        `json:"breaking"`
+	Description string      `json:"description"`
+	Before      interface{} `json:"before,omitempty"`
+	After       interface{} `json:"after,omitempty"`
+}
+
+type ChangeReport struct {
+	Endpoint      string                `json:"endpoint"`
+	Method        string                `json:"method"`
+	Source        schemair.SchemaSource `json:"source"`
+	FromVersion   int                   `json:"from_version"`
+	ToVersion     int                   `json:"to_version"`
+	Changes       []Change              `json:"changes"`
+	Breaking      bool                  `json:"breaking"`
+	BreakingCount int                   `json:"breaking_count"`
+}
+
+// isConsumerSource reports whether a source describes the client side of
+// the contract. Producers (the backend, its spec and its observed traffic)
+// break clients by taking things away from responses or demanding more in
+// requests; consumers break servers the other way round.
+func isConsumerSource(source schemair.SchemaSource) bool {
+	return source == schemair.SourceFrontendStatic
+}
+
+// CompareVersions classifies the changes between two versions of the same
+// source's schema for one endpoint.
+func (e *Engine) CompareVersions(endpoint, method string, before, after schemair.SchemaIR, fromVersion, toVersion int) *ChangeReport {
+	report := &ChangeReport{
+		Endpoint:    endpoint,
+		Method:      method,
+		Source:      after.Source,
+		FromVersion: fromVersion,
+		ToVersion:   toVersion,
+		Changes:     []Change{},
+	}
+	consumer := isConsumerSource(after.Source)
+
+	report.Changes = append(report.Changes,
+		compareSection(pathParamsObject(before.PathParams), pathParamsObject(after.PathParams), "path.", "path", consumer)...)
+	report.Changes = append(report.Changes,
+		compareSection(paramsObject(before.QueryParams), paramsObject(after.QueryParams), "query.", "query", consumer)...)
+	report.Changes = append(report.Changes,
+		compareSection(paramsObject(before.Headers), paramsObject(after.Headers), "headers.", "headers", consumer)...)
+
+	if methodHasRequestBody(method) {
+		report.Changes = append(report.Changes,
+			compareSection(before.Request, after.Request, "request.", "request", consumer)...)
+	}
+
+	codes := make(map[int]bool)
+	for code := range before.Response {
+		codes[code] = true
+	}
+	for code := range after.Response {
+		codes[code] = true
+	}
+	sortedCodes := make([]int, 0, len(codes))
+	for code := range codes {
+		sortedCodes = append(sortedCodes, code)
+	}
+	sort.Ints(sortedCodes)
+
+	for _, code := range sortedCodes {
+		oldResp, hadOld := before.Response[code]
+		newResp, hasNew := after.Response[code]
+		path := "response." + strconv.Itoa(code)
+
+		switch {
+		case hadOld && !hasNew:
+			report.Changes = append(report.Changes, Change{
+				Path:        path,
+				Kind:        ChangeStatusRemoved,
+				Section:     "response",
+				Breaking:    !consumer,
+				Description: fmt.Sprintf("Status %d is no longer documented", code),
+			})
+			continue
+		case !hadOld && hasNew:
+			report.Changes = append(report.Changes, Change{
+				Path:        path,
+				Kind:        ChangeStatusAdded,
+				Section:     "response",
+				Breaking:    consumer,
+				Description: fmt.Sprintf("Status %d was added", code),
+			})
+		}
+
+		report.Changes = append(report.Changes,
+			compareSection(oldResp, newResp, path+".", "response", consumer)...)
+	}
+
+	for _, c := range report.Changes {
+		if c.Breaking {
+			report.BreakingCount++
+		}
+	}
+	report.Breaking = report.BreakingCount > 0
+	return report
+}
+
+// versionField is a field as seen in one version. Names are kept verbatim:
+// within a single source a casing change is a rename, not noise.
+type versionField struct {
+	typ      string
+	required bool
+	nullable bool
+	enum     []interface{}
+}
+
+func flattenVersionFields(obj *schemair.ObjectSchema, prefix string, out map[string]versionField) {
+	if obj == nil {
+		return
+	}
+	for name, field := range obj.Fields {
+		if field == nil {
+			continue
+		}
+		path := prefix + name
+		out[path] = versionField{typ: field.Type, required: field.Required, nullable: field.Nullable, enum: field.Enum}
+		if field.Nested != nil {
+			flattenVersionFields(field.Nested, path+".", out)
+		}
+	}
+	if obj.Items != nil {
+		flattenVersionFields(obj.Items, prefix+"[].", out)
+	}
+}
+
+// pathParamsObject keys path parameters by position, like the diff engine:
+// renaming a parameter does not change the contract.
+func pathParamsObject(params []schemair.PathParam) *schemair.ObjectSchema {
+	if len(params) == 0 {
+		return nil
+	}
+	obj := &schemair.ObjectSchema{Type: "object", Fields: make(map[string]*schemair.Field, len(params))}
+	for i := range params {
+		field := params[i].Field
+		field.Required = true
+		obj.Fields[strconv.Itoa(i+1)] = &field
+	}
+	return obj
+}
+
+func paramsObject(params map[string]*schemair.Field) *schemair.ObjectSchema {
+	if len(params) == 0 {
+		return nil
+	}
+	return &schemair.ObjectSchema{Type: "object", Fields: params}
+}
+
+// compareSection diffs one section: path, query, headers, request or
+// response. For a producer, everything but the response is input (new
+// demands break clients) and the response is output (removals break
+// clients); a consumer flips both.
+func compareSection(before, after *schemair.ObjectSchema, prefix, section string, consumer bool) []Change {
+	oldFields := make(map[string]versionField)
+	newFields := make(map[string]versionField)
+	flattenVersionFields(before, prefix, oldFields)
+	flattenVersionFields(after, prefix, newFields)
+
+	isInput := (section != "response") != consumer
+
+	paths := make(map[string]bool)
+	for p := range oldFields {
+		paths[p] = true
+	}
+	for p := range newFields {
+		paths[p] = true
+	}
+	sortedPaths := make([]string, 0, len(paths))
+	for p := range paths {
+		sortedPaths = append(sortedPaths, p)
+	}
+	sort.Strings(sortedPaths)
+
+	var changes []Change
+	for _, path := range sortedPaths {
+		oldField, hadOld := oldFields[path]
+		newField, hasNew := newFields[path]
+
+		switch {
+		case hadOld && !hasNew:
+			if parentRemoved(path, oldFields, newFields) {
+				continue
+			}
+			changes = append(changes, Change{
+				Path:        path,
+				Kind:        ChangeRemoved,
+				Section:     section,
+				Breaking:    !isInput,
+				Description: fmt.Sprintf("Field removed (was %s)", oldField.typ),
+				Before:      oldField.typ,
+			})
+
+		case !hadOld && hasNew:
+			if parentAdded(path, oldFields, newFields) && !(isInput && newField.required) {
+				continue
+			}
+			breaking := isInput && newField.required
+			desc := fmt.Sprintf("Optional field added (%s)", newField.typ)
+			if newField.required {
+				desc = fmt.Sprintf("Required field added (%s)", newField.typ)
+			}
+			changes = append(changes, Change{
+				Path:        path,
+				Kind:        ChangeAdded,
+				Section:     section,
+				Breaking:    breaking,
+				Description: desc,
+				After:       newField.typ,
+			})
+
+		default:
+			if canonicalType(oldField.typ) != canonicalType(newField.typ) {
+				// Inputs may widen (accept more than before) and outputs may
+				// narrow (promise more than before); anything else breaks.
+				compatible := isSubtypeOf(newField.typ, oldField.typ)
+				if isInput {
+					compatible = isSubtypeOf(oldField.typ, newField.typ)
+				}
+				changes = append(changes, Change{
+					Path:        path,
+					Kind:        ChangeTypeChanged,
+					Section:     section,
+					Breaking:    !compatible,
+					Description: fmt.Sprintf("Type changed from '%s' to '%s'", oldField.typ, newField.typ),
+					Before:      oldField.typ,
+					After:       newField.typ,
+				})
+			}
+			if oldField.required != newField.required {
+				kind := ChangeMadeOptional
+				breaking := !isInput
+				if newField.required {
+					kind = ChangeMadeRequired
+					breaking = isInput
+				}
+				changes = append(changes, Change{
+					Path:        path,
+					Kind:        kind,
+					Section:     section,
+					Breaking:    breaking,
+					Description: fmt.Sprintf("Required changed from %v to %v", oldField.required, newField.required),
+					Before:      oldField.required,
+					After:       newField.required,
+				})
+			}
+			if oldField.nullable != newField.nullable {
+				// Null is one more value: accepting it widens an input,
+				// sending it widens an output.
+				kind, breaking := ChangeMadeNullable, !isInput
+				if !newField.nullable {
+					kind, breaking = ChangeMadeNonNull, isInput
+				}
+				changes = append(changes, Change{
+					Path:        path,
+					Kind:        kind,
+					Section:     section,
+					Breaking:    breaking,
+					Description: fmt.Sprintf("Nullable changed from %v to %v", oldField.nullable, newField.nullable),
+					Before:      oldField.nullable,
+					After:       newField.nullable,
+				})
+			}
+			changes = append(changes, compareEnumVersions(path, section, isInput, oldField.enum, newField.enum)...)
+		}
+	}
+	return changes
+}
+
+// compareEnumVersions reports values an enum lost or gained. An enum that
+// appears restricts the field and one that disappears lifts the
+// restriction; narrowing breaks inputs and widening breaks outputs.
+func compareEnumVersions(path, section string, isInput bool, before, after []interface{}) []Change {
+	var narrowed, widened string
+	switch {
+	case len(before) == 0 && len(after) == 0:
+		return nil
+	case len(before) == 0:
+		narrowed = fmt.Sprintf("Restricted to enum %v", after)
+	case len(after) == 0:
+		widened = fmt.Sprintf("Enum %v no longer restricts the field", before)
+	default:
+		if removed := enumValuesNotIn(before, after); len(removed) > 0 {
+			narrowed = fmt.Sprintf("Enum values %v removed", removed)
+		}
+		if added := enumValuesNotIn(after, before); len(added) > 0 {
+			widened = fmt.Sprintf("Enum values %v added", added)
+		}
+	}
+
+	var changes []Change
+	if narrowed != "" {
+		changes = append(changes, Change{
+			Path:        path,
+			Kind:        ChangeEnumNarrowed,
+			Section:     section,
+			Breaking:    isInput,
+			Description: narrowed,
+			Before:      before,
+			After:       after,
+		})
+	}
+	if widened != "" {
+		changes = append(changes, Change{
+			Path:        path,
+			Kind:        ChangeEnumWidened,
+			Section:     section,
+			Breaking:    !isInput,
+			Description: widened,
+			Before:      before,
+			After:       after,
+		})
+	}
+	return changes
+}
+
+// isSubtypeOf reports whether every value of type sub is also a value of
+// type super, such as uuid of string or int64 of number.
+func isSubtypeOf(sub, super string) bool {
+	target := canonicalType(super)
+	for t := strings.ToLower(strings.TrimSpace(sub)); t != ""; t = typeSubtypes[t] {
+		if canonicalType(t) == target {
+			return true
+		}
+	}
+	return false
+}
+
+// parentRemoved reports whether an ancestor of path was itself removed, so
+// a dropped object is reported once rather than once per descendant.
+func parentRemoved(path string, oldFields, newFields map[string]versionField) bool {
+	for parent := parentPath(path); parent != ""; parent = parentPath(parent) {
+		if _, had := oldFields[parent]; had {
+			if _, has := newFields[parent]; !has {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func parentAdded(path string, oldFields, newFields map[string]versionField) bool {
+	for parent := parentPath(path); parent != ""; parent = parentPath(parent) {
+		if _, has := newFields[parent]; has {
+			if _, had := oldFields[parent]; !had {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func parentPath(path string) string {
+	path = strings.TrimSuffix(path, ".[]")
+	i := strings.LastIndex(path, ".")
+	if i < 0 {
+		return ""
+	}
+	parent := strings.TrimSuffix(path[:i], ".[]")
+	if parent == "request" || strings.HasPrefix(parent, "response.") && strings.Count(parent, ".") == 1 {
+		return ""
+	}
+	return parent
+}
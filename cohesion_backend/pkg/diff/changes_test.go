@@ -0,0 +1,239 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+func findChange(report *ChangeReport, path string, kind ChangeKind) *Change {
+	for i := range report.Changes {
+		if report.Changes[i].Path == path && report.Changes[i].Kind == kind {
+			return &report.Changes[i]
+		}
+	}
+	return nil
+}
+
+func TestCompareVersions(t *testing.T) {
+	engine := NewEngine()
+
+	before := schemair.SchemaIR{
+		Endpoint: "/api/users",
+		Method:   "POST",
+		Source:   schemair.SourceBackendStatic,
+		Request: &schemair.ObjectSchema{Type: "object", Fields: map[string]*schemair.Field{
+			"name":  {Type: "string", Required: true},
+			"email": {Type: "string", Required: false},
+		}},
+		Response: map[int]*schemair.ObjectSchema{
+			201: {Type: "object", Fields: map[string]*schemair.Field{
+				"id":   {Type: "uuid", Required: true},
+				"name": {Type: "string", Required: true},
+				"profile": {Type: "object", Required: false, Nested: &schemair.ObjectSchema{
+					Type: "object", Fields: map[string]*schemair.Field{
+						"bio":    {Type: "string"},
+						"avatar": {Type: "string"},
+					},
+				}},
+			}},
+			409: {Type: "object", Fields: map[string]*schemair.Field{
+				"error": {Type: "string", Required: true},
+			}},
+		},
+	}
+
+	t.Run("Identical versions have no changes", func(t *testing.T) {
+		report := engine.CompareVersions("/api/users", "POST", before, before, 1, 2)
+		if len(report.Changes) != 0 || report.Breaking {
+			t.Errorf("Expected no changes, got %+v", report.Changes)
+		}
+	})
+
+	t.Run("Producer direction", func(t *testing.T) {
+		after := schemair.SchemaIR{
+			Endpoint: "/api/users",
+			Method:   "POST",
+			Source:   schemair.SourceBackendStatic,
+			Request: &schemair.ObjectSchema{Type: "object", Fields: map[string]*schemair.Field{
+				"name":     {Type: "string", Required: true},
+				"email":    {Type: "string", Required: true},
+				"nickname": {Type: "string", Required: false},
+			}},
+			Response: map[int]*schemair.ObjectSchema{
+				201: {Type: "object", Fields: map[string]*schemair.Field{
+					"id":         {Type: "uuid", Required: true},
+					"created_at": {Type: "time", Required: true},
+				}},
+			},
+		}
+
+		report := engine.CompareVersions("/api/users", "POST", before, after, 1, 2)
+
+		cases := []struct {
+			path     string
+			kind     ChangeKind
+			breaking bool
+		}{
+			{"request.nickname", ChangeAdded, false},
+			{"request.email", ChangeMadeRequired, true},
+			{"response.201.name", ChangeRemoved, true},
+			{"response.201.profile", ChangeRemoved, true},
+			{"response.201.created_at", ChangeAdded, false},
+			{"response.409", ChangeStatusRemoved, true},
+		}
+		for _, tc := range cases {
+			c := findChange(report, tc.path, tc.kind)
+			if c == nil {
+				t.Errorf("Expected %s change at %s, got %+v", tc.kind, tc.path, report.Changes)
+				continue
+			}
+			if c.Breaking != tc.breaking {
+				t.Errorf("Expected %s at %s breaking=%v, got %v", tc.kind, tc.path, tc.breaking, c.Breaking)
+			}
+		}
+
+		if findChange(report, "response.201.profile.bio", ChangeRemoved) != nil {
+			t.Errorf("Expected children of a removed object to be folded into the parent")
+		}
+		if report.BreakingCount != 4 || !report.Breaking {
+			t.Errorf("Expected 4 breaking changes, got %d", report.BreakingCount)
+		}
+	})
+
+	t.Run("Consumer direction is inverted", func(t *testing.T) {
+		feBefore := before
+		feBefore.Source = schemair.SourceFrontendStatic
+		feAfter := schemair.SchemaIR{
+			Endpoint: "/api/users",
+			Method:   "POST",
+			Source:   schemair.SourceFrontendStatic,
+			Request: &schemair.ObjectSchema{Type: "object", Fields: map[string]*schemair.Field{
+				"name": {Type: "string", Required: true},
+			}},
+			Response: map[int]*schemair.ObjectSchema{
+				201: {Type: "object", Fields: map[string]*schemair.Field{
+					"id":      {Type: "uuid", Required: true},
+					"name":    {Type: "string", Required: true},
+					"profile": before.Response[201].Fields["profile"],
+					"role":    {Type: "string", Required: true},
+				}},
+				409: before.Response[409],
+			},
+		}
+
+		report := engine.CompareVersions("/api/users", "POST", feBefore, feAfter, 1, 2)
+
+		if c := findChange(report, "request.email", ChangeRemoved); c == nil || !c.Breaking {
+			t.Errorf("Expected a client dropping a sent field to be breaking, got %+v", c)
+		}
+		if c := findChange(report, "response.201.role", ChangeAdded); c == nil || !c.Breaking {
+			t.Errorf("Expected a client reading a new required field to be breaking, got %+v", c)
+		}
+	})
+
+	t.Run("Type changes depend on direction", func(t *testing.T) {
+		after := before
+		after.Request = &schemair.ObjectSchema{Type: "object", Fields: map[string]*schemair.Field{
+			"name":  {Type: "uuid", Required: true},
+			"email": {Type: "string", Required: false},
+		}}
+		after.Response = map[int]*schemair.ObjectSchema{
+			201: {Type: "object", Fields: map[string]*schemair.Field{
+				"id":      {Type: "string", Required: true},
+				"name":    {Type: "int", Required: true},
+				"profile": before.Response[201].Fields["profile"],
+			}},
+			409: before.Response[409],
+		}
+
+		report := engine.CompareVersions("/api/users", "POST", before, after, 1, 2)
+		if c := findChange(report, "response.201.id", ChangeTypeChanged); c == nil || !c.Breaking {
+			t.Errorf("Expected a response widening uuid → string to be breaking, got %+v", c)
+		}
+		if c := findChange(report, "request.name", ChangeTypeChanged); c == nil || !c.Breaking {
+			t.Errorf("Expected a request narrowing string → uuid to be breaking, got %+v", c)
+		}
+		if c := findChange(report, "response.201.name", ChangeTypeChanged); c == nil || !c.Breaking {
+			t.Errorf("Expected string → int to be breaking, got %+v", c)
+		}
+
+		// The same changes the other way round are safe.
+		report = engine.CompareVersions("/api/users", "POST", after, before, 2, 3)
+		if c := findChange(report, "response.201.id", ChangeTypeChanged); c == nil || c.Breaking {
+			t.Errorf("Expected a response narrowing string → uuid to be non-breaking, got %+v", c)
+		}
+		if c := findChange(report, "request.name", ChangeTypeChanged); c == nil || c.Breaking {
+			t.Errorf("Expected a request widening uuid → string to be non-breaking, got %+v", c)
+		}
+	})
+
+	t.Run("Parameters, enums and nullability", func(t *testing.T) {
+		status := func(values ...interface{}) *schemair.Field {
+			return &schemair.Field{Type: "string", Enum: values}
+		}
+		v1 := schemair.SchemaIR{
+			Endpoint:    "/api/users/{id}",
+			Method:      "GET",
+			Source:      schemair.SourceBackendStatic,
+			PathParams:  []schemair.PathParam{{Name: "id", Field: schemair.Field{Type: "string"}}},
+			QueryParams: map[string]*schemair.Field{"status": status("active", "banned")},
+			Response: map[int]*schemair.ObjectSchema{
+				200: {Type: "object", Fields: map[string]*schemair.Field{
+					"email": {Type: "string", Required: true},
+					"role":  status("admin", "member"),
+				}},
+			},
+		}
+		v2 := v1
+		v2.PathParams = []schemair.PathParam{{Name: "userId", Field: schemair.Field{Type: "string"}}}
+		v2.QueryParams = map[string]*schemair.Field{
+			"status": status("active"),
+			"org":    {Type: "string", Required: true},
+		}
+		v2.Headers = map[string]*schemair.Field{"x-tenant": {Type: "string"}}
+		v2.Response = map[int]*schemair.ObjectSchema{
+			200: {Type: "object", Fields: map[string]*schemair.Field{
+				"email": {Type: "string", Required: true, Nullable: true},
+				"role":  status("admin", "member", "owner"),
+			}},
+		}
+
+		report := engine.CompareVersions("/api/users/{id}", "GET", v1, v2, 1, 2)
+
+		cases := []struct {
+			path     string
+			kind     ChangeKind
+			breaking bool
+		}{
+			{"query.org", ChangeAdded, true},
+			{"query.status", ChangeEnumNarrowed, true},
+			{"headers.x-tenant", ChangeAdded, false},
+			{"response.200.email", ChangeMadeNullable, true},
+			{"response.200.role", ChangeEnumWidened, true},
+		}
+		for _, tc := range cases {
+			c := findChange(report, tc.path, tc.kind)
+			if c == nil {
+				t.Errorf("Expected %s change at %s, got %+v", tc.kind, tc.path, report.Changes)
+				continue
+			}
+			if c.Breaking != tc.breaking {
+				t.Errorf("Expected %s at %s breaking=%v, got %v", tc.kind, tc.path, tc.breaking, c.Breaking)
+			}
+		}
+		for _, c := range report.Changes {
+			if c.Section == "path" {
+				t.Errorf("Expected a renamed path parameter to be no change, got %+v", c)
+			}
+		}
+
+		report = engine.CompareVersions("/api/users/{id}", "GET", v2, v1, 2, 3)
+		if c := findChange(report, "query.status", ChangeEnumWidened); c == nil || c.Breaking {
+			t.Errorf("Expected accepting a new query enum value to be non-breaking, got %+v", c)
+		}
+		if c := findChange(report, "response.200.email", ChangeMadeNonNull); c == nil || c.Breaking {
+			t.Errorf("Expected a response field no longer null to be non-breaking, got %+v", c)
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+// compareNullability reports a field some sources allow to be null and
+// others do not. Runtime observation only proves nullability: not having
+// seen a null says nothing about whether one can occur.
+func (e *Engine) compareNullability(section, path string, sourceMap map[schemair.SchemaSource]fieldInfo, presentSources []schemair.SchemaSource) []Mismatch {
+	var nullable, notNullable []schemair.SchemaSource
+	for _, src := range presentSources {
+		switch {
+		case sourceMap[src].field.Nullable:
+			nullable = append(nullable, src)
+		case src != schemair.SourceRuntime:
+			notNullable = append(notNullable, src)
+		}
+	}
+	if len(nullable) == 0 || len(notNullable) == 0 {
+		return nil
+	}
+
+	return []Mismatch{{
+		Path:        path,
+		Type:        MismatchNullability,
+		Description: fmt.Sprintf("Nullability mismatch: nullable in %v, not nullable in %v", nullable, notNullable),
+		InSources:   presentSources,
+		Severity:    e.policy.Severity(section, MismatchNullability, nullable, notNullable),
+		Suggestion:  "Handle null on the receiving side or stop sending it",
+	}}
+}
+
+// compareEnums reports, for every pair of sources declaring an enum, the
+// values one uses that the other lacks. Runtime enums are inferred from the
+// values seen so far, so declared values it has not observed are ignored.
+func (e *Engine) compareEnums(section, path string, sourceMap map[schemair.SchemaSource]fieldInfo, presentSources []schemair.SchemaSource) []Mismatch {
+	var mismatches []Mismatch
+	for i := 0; i < len(presentSources); i++ {
+		for j := i + 1; j < len(presentSources); j++ {
+			srcA, srcB := presentSources[i], presentSources[j]
+			enumA, enumB := sourceMap[srcA].field.Enum, sourceMap[srcB].field.Enum
+			if len(enumA) == 0 || len(enumB) == 0 {
+				continue
+			}
+
+			if srcB != schemair.SourceRuntime {
+				if extra := enumValuesNotIn(enumA, enumB); len(extra) > 0 {
+					mismatches = append(mismatches, e.enumMismatch(section, path, srcA, srcB, extra, enumB, presentSources))
+				}
+			}
+			if srcA != schemair.SourceRuntime {
+				if extra := enumValuesNotIn(enumB, enumA); len(extra) > 0 {
+					mismatches = append(mismatches, e.enumMismatch(section, path, srcB, srcA, extra, enumA, presentSources))
+				}
+			}
+		}
+	}
+	return mismatches
+}
+
+func (e *Engine) enumMismatch(section, path string, has, lacks schemair.SchemaSource, extra, accepted []interface{}, presentSources []schemair.SchemaSource) Mismatch {
+	return Mismatch{
+		Path:        path,
+		Type:        MismatchEnum,
+		Description: fmt.Sprintf("Enum values %v used by %s are not in %s's enum", extra, has, lacks),
+		Expected:    accepted,
+		Actual:      extra,
+		InSources:   presentSources,
+		Severity:    e.policy.Severity(section, MismatchEnum, []schemair.SchemaSource{has}, []schemair.SchemaSource{lacks}),
+		Suggestion:  fmt.Sprintf("Add %v to %s or stop using them", extra, lacks),
+	}
+}
+
+func enumValuesNotIn(a, b []interface{}) []interface{} {
+	seen := make(map[string]bool, len(b))
+	for _, v := range b {
+		seen[fmt.Sprint(v)] = true
+	}
+	var out []interface{}
+	for _, v := range a {
+		if !seen[fmt.Sprint(v)] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+var formatAliases = map[string]string{
+	"datetime":  "date-time",
+	"timestamp": "date-time",
+	"url":       "uri",
+}
+
+func canonicalFormat(f string) string {
+	f = strings.ToLower(strings.TrimSpace(f))
+	if alias, ok := formatAliases[f]; ok {
+		return alias
+	}
+	return f
+}
+
+// compareConstraints reports formats and bounds that both sources of a pair
+// declare but disagree on. Patterns are not compared because regex dialects
+// differ between languages.
+func (e *Engine) compareConstraints(section, path string, sourceMap map[schemair.SchemaSource]fieldInfo, presentSources []schemair.SchemaSource) []Mismatch {
+	var mismatches []Mismatch
+	for i := 0; i < len(presentSources); i++ {
+		for j := i + 1; j < len(presentSources); j++ {
+			srcA, srcB := presentSources[i], presentSources[j]
+			a, b := sourceMap[srcA].field, sourceMap[srcB].field
+
+			var diffs []string
+			if a.Format != "" && b.Format != "" && canonicalFormat(a.Format) != canonicalFormat(b.Format) {
+				diffs = append(diffs, fmt.Sprintf("format %s vs %s", a.Format, b.Format))
+			}
+			diffs = appendBoundDiff(diffs, "minimum", a.Minimum, b.Minimum)
+			diffs = appendBoundDiff(diffs, "maximum", a.Maximum, b.Maximum)
+			diffs = appendBoundDiff(diffs, "min_length", intBound(a.MinLength), intBound(b.MinLength))
+			diffs = appendBoundDiff(diffs, "max_length", intBound(a.MaxLength), intBound(b.MaxLength))
+			if len(diffs) == 0 {
+				continue
+			}
+
+			mismatches = append(mismatches, Mismatch{
+				Path:        path,
+				Type:        MismatchConstraint,
+				Description: fmt.Sprintf("Constraint mismatch between %s and %s: %s", srcA, srcB, strings.Join(diffs, "; ")),
+				InSources:   presentSources,
+				Severity:    e.policy.Severity(section, MismatchConstraint, []schemair.SchemaSource{srcA, srcB}, nil),
+				Suggestion:  "Align formats and bounds so both sides validate the same values",
+			})
+		}
+	}
+	return mismatches
+}
+
+func appendBoundDiff(diffs []string, name string, a, b *float64) []string {
+	if a == nil || b == nil || *a == *b {
+		return diffs
+	}
+	return append(diffs, fmt.Sprintf("%s %v vs %v", name, *a, *b))
+}
+
+func intBound(v *int) *float64 {
+	if v == nil {
+		return nil
+	}
+	f := float64(*v)
+	return &f
+}
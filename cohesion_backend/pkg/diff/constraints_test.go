@@ -0,0 +1,158 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+func responseSchema(source schemair.SchemaSource, fields map[string]*schemair.Field) schemair.SchemaIR {
+	return schemair.SchemaIR{
+		Source: source,
+		Response: map[int]*schemair.ObjectSchema{
+			200: {Type: "object", Fields: fields},
+		},
+	}
+}
+
+func mismatchesOfType(result *Result, typ MismatchType) []Mismatch {
+	var out []Mismatch
+	for _, m := range result.Mismatches {
+		if m.Type == typ {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func TestCompareNullability(t *testing.T) {
+	t.Run("backend may send null the frontend does not expect", func(t *testing.T) {
+		result := NewEngine().Compare("/api/users", "GET", []schemair.SchemaIR{
+			responseSchema(schemair.SourceBackendStatic, map[string]*schemair.Field{
+				"avatar": {Type: "string", Required: true, Nullable: true},
+			}),
+			responseSchema(schemair.SourceFrontendStatic, map[string]*schemair.Field{
+				"avatar": {Type: "string", Required: true},
+			}),
+		})
+		got := mismatchesOfType(result, MismatchNullability)
+		if len(got) != 1 || got[0].Severity != SeverityCritical {
+			t.Fatalf("Expected one critical nullability mismatch, got %+v", got)
+		}
+	})
+
+	t.Run("frontend tolerating null is harmless", func(t *testing.T) {
+		result := NewEngine().Compare("/api/users", "GET", []schemair.SchemaIR{
+			responseSchema(schemair.SourceBackendStatic, map[string]*schemair.Field{
+				"avatar": {Type: "string", Required: true},
+			}),
+			responseSchema(schemair.SourceFrontendStatic, map[string]*schemair.Field{
+				"avatar": {Type: "string", Required: true, Nullable: true},
+			}),
+		})
+		got := mismatchesOfType(result, MismatchNullability)
+		if len(got) != 1 || got[0].Severity != SeverityInfo {
+			t.Fatalf("Expected one info nullability mismatch, got %+v", got)
+		}
+	})
+
+	t.Run("runtime never seeing null proves nothing", func(t *testing.T) {
+		result := NewEngine().Compare("/api/users", "GET", []schemair.SchemaIR{
+			responseSchema(schemair.SourceBackendStatic, map[string]*schemair.Field{
+				"avatar": {Type: "string", Required: true, Nullable: true},
+			}),
+			responseSchema(schemair.SourceRuntime, map[string]*schemair.Field{
+				"avatar": {Type: "string", Required: true},
+			}),
+		})
+		if got := mismatchesOfType(result, MismatchNullability); len(got) != 0 {
+			t.Errorf("Expected no nullability mismatch, got %+v", got)
+		}
+	})
+
+	t.Run("null-only runtime field is not a type mismatch", func(t *testing.T) {
+		result := NewEngine().Compare("/api/users", "GET", []schemair.SchemaIR{
+			responseSchema(schemair.SourceFrontendStatic, map[string]*schemair.Field{
+				"avatar": {Type: "string", Required: true},
+			}),
+			responseSchema(schemair.SourceRuntime, map[string]*schemair.Field{
+				"avatar": {Type: "null", Required: true, Nullable: true},
+			}),
+		})
+		if got := mismatchesOfType(result, MismatchTypeDiff); len(got) != 0 {
+			t.Errorf("Expected no type mismatch, got %+v", got)
+		}
+		if got := mismatchesOfType(result, MismatchNullability); len(got) != 1 || got[0].Severity != SeverityCritical {
+			t.Errorf("Expected critical nullability mismatch, got %+v", got)
+		}
+	})
+}
+
+func TestCompareEnums(t *testing.T) {
+	t.Run("request values the backend rejects", func(t *testing.T) {
+		schemas := []schemair.SchemaIR{
+			{Source: schemair.SourceBackendStatic, Request: &schemair.ObjectSchema{Type: "object", Fields: map[string]*schemair.Field{
+				"status": {Type: "string", Required: true, Enum: []interface{}{"active", "banned"}},
+			}}},
+			{Source: schemair.SourceFrontendStatic, Request: &schemair.ObjectSchema{Type: "object", Fields: map[string]*schemair.Field{
+				"status": {Type: "string", Required: true, Enum: []interface{}{"active", "suspended"}},
+			}}},
+		}
+		got := mismatchesOfType(NewEngine().Compare("/api/users", "POST", schemas), MismatchEnum)
+		if len(got) != 2 {
+			t.Fatalf("Expected a mismatch per direction, got %+v", got)
+		}
+		for _, m := range got {
+			want := SeverityInfo
+			if m.Actual.([]interface{})[0] == "suspended" {
+				want = SeverityCritical
+			}
+			if m.Severity != want {
+				t.Errorf("%s: expected %s, got %s", m.Description, want, m.Severity)
+			}
+		}
+	})
+
+	t.Run("runtime only reports values it observed", func(t *testing.T) {
+		result := NewEngine().Compare("/api/users", "GET", []schemair.SchemaIR{
+			responseSchema(schemair.SourceBackendStatic, map[string]*schemair.Field{
+				"status": {Type: "string", Required: true, Enum: []interface{}{"active", "banned", "deleted"}},
+			}),
+			responseSchema(schemair.SourceRuntime, map[string]*schemair.Field{
+				"status": {Type: "string", Required: true, Enum: []interface{}{"active", "legacy"}},
+			}),
+		})
+		got := mismatchesOfType(result, MismatchEnum)
+		if len(got) != 1 {
+			t.Fatalf("Expected one enum mismatch, got %+v", got)
+		}
+		if extra := got[0].Actual.([]interface{}); len(extra) != 1 || extra[0] != "legacy" {
+			t.Errorf("Expected only the unobserved-by-backend value, got %v", extra)
+		}
+	})
+}
+
+func TestCompareConstraints(t *testing.T) {
+	max50, max100 := 50.0, 100.0
+	result := NewEngine().Compare("/api/users", "GET", []schemair.SchemaIR{
+		responseSchema(schemair.SourceBackendStatic, map[string]*schemair.Field{
+			"contact": {Type: "string", Required: true, Format: "email"},
+			"age":     {Type: "int", Required: true, Maximum: &max100},
+			"seen_at": {Type: "string", Required: true, Format: "date-time"},
+		}),
+		responseSchema(schemair.SourceOpenAPI, map[string]*schemair.Field{
+			"contact": {Type: "string", Required: true, Format: "uri"},
+			"age":     {Type: "int", Required: true, Maximum: &max50},
+			"seen_at": {Type: "string", Required: true, Format: "datetime"},
+		}),
+	})
+	got := mismatchesOfType(result, MismatchConstraint)
+	if len(got) != 2 {
+		t.Fatalf("Expected format and maximum mismatches only, got %+v", got)
+	}
+	for _, m := range got {
+		if m.Path == "response.200.seen_at" {
+			t.Errorf("Expected date-time aliases to match")
+		}
+	}
+}
@@ -4,15 +4,35 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
 )
 
-type Engine struct{}
+type Engine struct {
+	policy  *Policy
+	waivers []Waiver
+	now     func() time.Time
+}
 
 func NewEngine() *Engine {
-	return &Engine{}
+	return &Engine{policy: DefaultPolicy(), now: time.Now}
+}
+
+// WithPolicy returns a copy of the engine that assigns severities from p.
+func (e *Engine) WithPolicy(p *Policy) *Engine {
+	c := *e
+	c.policy = p
+	return &c
+}
+
+// WithWaivers returns a copy of the engine that suppresses mismatches
+// matched by any active waiver.
+func (e *Engine) WithWaivers(waivers []Waiver) *Engine {
+	c := *e
+	c.waivers = waivers
+	return &c
 }
 
 var typeCompatGroups = [][]string{
@@ -148,8 +168,11 @@ func (e *Engine) Compare(endpoint, method string, schemas []schemair.SchemaIR) *
 	}
 
 	result.Mismatches = e.compareSchemas(method, schemas)
+	if len(e.waivers) > 0 {
+		result.SuppressedCount = applyWaivers(e.waivers, e.now(), endpoint, method, result.Mismatches)
+	}
 
-	if len(result.Mismatches) == 0 {
+	if len(result.Mismatches) == result.SuppressedCount {
 		result.Status = schemair.StatusMatch
 	} else if e.hasViolations(result.Mismatches) {
 		result.Status = schemair.StatusViolation
@@ -172,7 +195,7 @@ func methodHasRequestBody(method string) bool {
 }
 
 func (e *Engine) compareSchemas(method string, schemas []schemair.SchemaIR) []Mismatch {
-	var mismatches []Mismatch
+	mismatches := e.compareParams(schemas)
 
 	if methodHasRequestBody(method) {
 		requestMismatches := e.compareRequests(schemas)
@@ -189,6 +212,7 @@ type fieldInfo struct {
 	originalName string
 	typ          string
 	required     bool
+	field        *schemair.Field
 }
 
 func collectFields(obj *schemair.ObjectSchema, prefix string, source schemair.SchemaSource,
@@ -212,6 +236,7 @@ func collectFields(obj *schemair.ObjectSchema, prefix string, source schemair.Sc
 			originalName: fieldName,
 			typ:          field.Type,
 			required:     field.Required,
+			field:        field,
 		}
 
 		if field.Nested != nil {
@@ -314,15 +339,16 @@ func (e *Engine) detectMismatches(fieldPresence map[string]map[schemair.SchemaSo
 				}
 			}
 
-			severity := e.missingFieldSeverity(presentSources, missingSources, section)
+			severity := e.policy.Severity(section, MismatchMissing, presentSources, missingSources)
 
 			mismatches = append(mismatches, Mismatch{
 				Path:        path,
 				Type:        MismatchMissing,
 				Description: fmt.Sprintf("Field missing in: %v (present in: %v)", missingSources, presentSources),
 				InSources:   presentSources,
+				MissingFrom: missingSources,
 				Severity:    severity,
-				Suggestion:  e.missingSuggestion(presentSources, missingSources, section),
+				Suggestion:  missingSuggestion(severity),
 			})
 		}
 
@@ -335,7 +361,9 @@ func (e *Engine) detectMismatches(fieldPresence map[string]map[schemair.SchemaSo
 					infoA, infoB := sourceMap[srcA], sourceMap[srcB]
 					canonA, canonB := canonicalType(infoA.typ), canonicalType(infoB.typ)
 
-					if canonA == canonB {
+					// A field only ever observed as null has no type to
+					// compare; the nullability check covers it.
+					if canonA == canonB || canonA == "null" || canonB == "null" {
 						continue
 					}
 
@@ -348,9 +376,10 @@ func (e *Engine) detectMismatches(fieldPresence map[string]map[schemair.SchemaSo
 					}
 					seen[pairKey] = true
 
-					severity := SeverityCritical
+					severity := e.policy.Severity(section, MismatchTypeDiff, []schemair.SchemaSource{srcA, srcB}, nil)
 					suggestion := fmt.Sprintf("Align type to '%s' across all sources", infoA.typ)
 
+					// Wire-compatible types are never more than informational.
 					if areSubtypeCompatible(infoA.typ, infoB.typ) {
 						severity = SeverityInfo
 						suggestion = fmt.Sprintf("'%s' and '%s' are wire-compatible (both serialize as %s in JSON)", infoA.typ, infoB.typ, wireType(infoA.typ))
@@ -384,48 +413,24 @@ func (e *Engine) detectMismatches(fieldPresence map[string]map[schemair.SchemaSo
 						Expected:    refReq,
 						Actual:      sourceMap[src].required,
 						InSources:   presentSources,
-						Severity:    SeverityWarning,
+						Severity:    e.policy.Severity(section, MismatchOptionality, []schemair.SchemaSource{refSource, src}, nil),
 						Suggestion:  "Consider aligning optionality across sources",
 					})
 					break
 				}
 			}
-		}
-	}
-
-	return mismatches
-}
 
-func (e *Engine) missingFieldSeverity(presentIn, missingFrom []schemair.SchemaSource, section string) Severity {
-	hasBE := sourceIn(presentIn, schemair.SourceBackendStatic)
-	hasFE := sourceIn(presentIn, schemair.SourceFrontendStatic)
-	missingBE := sourceIn(missingFrom, schemair.SourceBackendStatic)
-	missingFE := sourceIn(missingFrom, schemair.SourceFrontendStatic)
-
-	if section == "response" {
-		if hasBE && missingFE {
-			return SeverityInfo
-		}
-		if hasFE && missingBE {
-			return SeverityCritical
-		}
-	}
-
-	if section == "request" {
-		if hasFE && missingBE {
-			return SeverityInfo
-		}
-		if hasBE && missingFE {
-			return SeverityWarning
+			mismatches = append(mismatches, e.compareNullability(section, path, sourceMap, presentSources)...)
+			mismatches = append(mismatches, e.compareEnums(section, path, sourceMap, presentSources)...)
+			mismatches = append(mismatches, e.compareConstraints(section, path, sourceMap, presentSources)...)
 		}
 	}
 
-	return SeverityWarning
+	return mismatches
 }
 
-func (e *Engine) missingSuggestion(presentIn, missingFrom []schemair.SchemaSource, section string) string {
-	sev := e.missingFieldSeverity(presentIn, missingFrom, section)
-	switch sev {
+func missingSuggestion(severity Severity) string {
+	switch severity {
 	case SeverityInfo:
 		return "Extra field — safe to ignore unless you want strict contracts"
 	case SeverityWarning:
@@ -446,7 +451,7 @@ func sourceIn(sources []schemair.SchemaSource, target schemair.SchemaSource) boo
 
 func (e *Engine) hasViolations(mismatches []Mismatch) bool {
 	for _, m := range mismatches {
-		if m.Severity == SeverityCritical {
+		if m.Severity == SeverityCritical && !m.Suppressed {
 			return true
 		}
 	}
@@ -477,8 +482,15 @@ func (e *Engine) calculateConfidence(schemas []schemair.SchemaIR, mismatches []M
 		score += 20
 		conf.Factors = append(conf.Factors, "Runtime observation present (+20)")
 	}
+	if sources[schemair.SourceOpenAPI] {
+		score += 20
+		conf.Factors = append(conf.Factors, "OpenAPI specification present (+20)")
+	}
 
 	for _, m := range mismatches {
+		if m.Suppressed {
+			continue
+		}
 		switch m.Severity {
 		case SeverityCritical:
 			score -= 10
@@ -0,0 +1,112 @@
+package diff
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string         `xml:"name,attr"`
+	Classname string         `xml:"classname,attr"`
+	Failures  []junitFailure `xml:"failure"`
+	Skipped   *junitSkipped  `xml:"skipped"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// WriteJUnit renders results as a JUnit XML report with one test case per
+// endpoint and one failure per mismatch. Endpoints seen by fewer than two
+// sources are reported as skipped since nothing was compared.
+func WriteJUnit(w io.Writer, name string, results []*Result) error {
+	suite := junitTestSuite{Name: name}
+	for _, r := range results {
+		tc := junitTestCase{
+			Name:      r.Method + " " + r.Endpoint,
+			Classname: name,
+		}
+		if len(r.SourcesCompared) < 2 {
+			tc.Skipped = &junitSkipped{Message: "fewer than two sources to compare"}
+			suite.Skipped++
+		}
+		for _, m := range r.ActiveMismatches() {
+			tc.Failures = append(tc.Failures, junitFailure{
+				Message: fmt.Sprintf("[%s] %s: %s", m.Severity, m.Path, m.Description),
+				Type:    string(m.Type),
+				Text:    junitFailureText(m),
+			})
+		}
+		if len(tc.Failures) > 0 {
+			suite.Failures++
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	doc := junitTestSuites{
+		Name:     name,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func junitFailureText(m Mismatch) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "path: %s\nseverity: %s\n", m.Path, m.Severity)
+	if len(m.InSources) > 0 {
+		sources := make([]string, len(m.InSources))
+		for i, s := range m.InSources {
+			sources[i] = string(s)
+		}
+		fmt.Fprintf(&b, "sources: %s\n", strings.Join(sources, ", "))
+	}
+	if m.Expected != nil {
+		fmt.Fprintf(&b, "expected: %v\n", m.Expected)
+	}
+	if m.Actual != nil {
+		fmt.Fprintf(&b, "actual: %v\n", m.Actual)
+	}
+	if m.Suggestion != "" {
+		fmt.Fprintf(&b, "suggestion: %s\n", m.Suggestion)
+	}
+	return b.String()
+}
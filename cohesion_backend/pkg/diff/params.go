@@ -0,0 +1,110 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+// compareParams compares path parameters by position, query parameters by
+// exact name and headers by lowercase name. Unlike body fields, query names
+// are not normalized: the server only sees the name the client sends, so
+// pageSize and page_size are different parameters.
+func (e *Engine) compareParams(schemas []schemair.SchemaIR) []Mismatch {
+	var mismatches []Mismatch
+
+	pathPresence := make(map[string]map[schemair.SchemaSource]fieldInfo)
+	var pathSources []schemair.SchemaSource
+	for _, schema := range schemas {
+		if len(schema.PathParams) == 0 {
+			continue
+		}
+		pathSources = append(pathSources, schema.Source)
+		for i := range schema.PathParams {
+			p := schema.PathParams[i]
+			// A path parameter is always present when the route matches.
+			field := p.Field
+			field.Required = true
+			addParam(pathPresence, fmt.Sprintf("path.%d", i+1), p.Name, schema.Source, &field)
+		}
+	}
+	if len(pathSources) >= 2 {
+		mismatches = append(mismatches, e.detectMismatches(pathPresence, pathSources, "path")...)
+	}
+
+	queryPresence := make(map[string]map[schemair.SchemaSource]fieldInfo)
+	var querySources []schemair.SchemaSource
+	for _, schema := range schemas {
+		if len(schema.QueryParams) == 0 {
+			continue
+		}
+		querySources = append(querySources, schema.Source)
+		for name, field := range schema.QueryParams {
+			if field != nil {
+				addParam(queryPresence, "query."+name, name, schema.Source, field)
+			}
+		}
+	}
+	if len(querySources) >= 2 {
+		queryMismatches := e.detectMismatches(queryPresence, querySources, "query")
+		suggestRenames(queryMismatches, queryPresence)
+		mismatches = append(mismatches, queryMismatches...)
+	}
+
+	headerPresence := make(map[string]map[schemair.SchemaSource]fieldInfo)
+	var headerSources []schemair.SchemaSource
+	for _, schema := range schemas {
+		if len(schema.Headers) == 0 {
+			continue
+		}
+		headerSources = append(headerSources, schema.Source)
+		for name, field := range schema.Headers {
+			if field != nil {
+				addParam(headerPresence, "headers."+strings.ToLower(name), name, schema.Source, field)
+			}
+		}
+	}
+	if len(headerSources) >= 2 {
+		mismatches = append(mismatches, e.detectMismatches(headerPresence, headerSources, "headers")...)
+	}
+
+	return mismatches
+}
+
+func addParam(presence map[string]map[schemair.SchemaSource]fieldInfo, path, name string, source schemair.SchemaSource, field *schemair.Field) {
+	if presence[path] == nil {
+		presence[path] = make(map[schemair.SchemaSource]fieldInfo)
+	}
+	presence[path][source] = fieldInfo{
+		originalName: name,
+		typ:          field.Type,
+		required:     field.Required,
+		field:        field,
+	}
+}
+
+// suggestRenames points missing query parameters at a parameter of the same
+// name in another case style, the usual cause of a silently ignored filter.
+func suggestRenames(mismatches []Mismatch, presence map[string]map[schemair.SchemaSource]fieldInfo) {
+	byNormal := make(map[string][]string)
+	for path := range presence {
+		name := strings.TrimPrefix(path, "query.")
+		byNormal[normalizeFieldName(name)] = append(byNormal[normalizeFieldName(name)], name)
+	}
+
+	for i := range mismatches {
+		m := &mismatches[i]
+		if m.Type != MismatchMissing {
+			continue
+		}
+		name := strings.TrimPrefix(m.Path, "query.")
+		for _, other := range byNormal[normalizeFieldName(name)] {
+			if other == name {
+				continue
+			}
+			m.Suggestion = fmt.Sprintf("'%s' and '%s' differ only in case style — the server will not read one of them; use the same name on both sides", name, other)
+			break
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+func TestCompareParams(t *testing.T) {
+	t.Run("query names are compared exactly", func(t *testing.T) {
+		result := NewEngine().Compare("/api/users", "GET", []schemair.SchemaIR{
+			{Source: schemair.SourceBackendStatic, QueryParams: map[string]*schemair.Field{
+				"page_size": {Type: "int", Required: false},
+			}},
+			{Source: schemair.SourceFrontendStatic, QueryParams: map[string]*schemair.Field{
+				"pageSize": {Type: "int", Required: false},
+			}},
+		})
+		got := mismatchesOfType(result, MismatchMissing)
+		if len(got) != 2 {
+			t.Fatalf("Expected both names reported missing, got %+v", got)
+		}
+		for _, m := range got {
+			if !strings.Contains(m.Suggestion, "case style") {
+				t.Errorf("Expected a rename suggestion on %s, got %q", m.Path, m.Suggestion)
+			}
+			want := SeverityInfo
+			if m.Path == "query.page_size" {
+				want = SeverityWarning
+			}
+			if m.Severity != want {
+				t.Errorf("%s: expected %s, got %s", m.Path, want, m.Severity)
+			}
+		}
+	})
+
+	t.Run("path params are compared by position", func(t *testing.T) {
+		result := NewEngine().Compare("/api/users/{}", "DELETE", []schemair.SchemaIR{
+			{Source: schemair.SourceBackendStatic, PathParams: []schemair.PathParam{
+				{Name: "id", Field: schemair.Field{Type: "int"}},
+			}},
+			{Source: schemair.SourceFrontendStatic, PathParams: []schemair.PathParam{
+				{Name: "userId", Field: schemair.Field{Type: "string", Required: true}},
+			}},
+		})
+		if len(result.Mismatches) != 1 {
+			t.Fatalf("Expected only a type mismatch, got %+v", result.Mismatches)
+		}
+		if m := result.Mismatches[0]; m.Path != "path.1" || m.Type != MismatchTypeDiff {
+			t.Errorf("Expected type mismatch on path.1, got %+v", m)
+		}
+	})
+
+	t.Run("headers missing from the frontend", func(t *testing.T) {
+		result := NewEngine().Compare("/api/users", "GET", []schemair.SchemaIR{
+			{Source: schemair.SourceBackendStatic, Headers: map[string]*schemair.Field{
+				"x-tenant-id": {Type: "string", Required: true},
+			}},
+			{Source: schemair.SourceFrontendStatic, Headers: map[string]*schemair.Field{
+				"X-Trace-Id": {Type: "string"},
+			}},
+		})
+		for _, m := range result.Mismatches {
+			switch m.Path {
+			case "headers.x-tenant-id":
+				if m.Severity != SeverityWarning {
+					t.Errorf("Expected warning for unsent header, got %s", m.Severity)
+				}
+			case "headers.x-trace-id":
+				if m.Severity != SeverityInfo {
+					t.Errorf("Expected info for ignored header, got %s", m.Severity)
+				}
+			default:
+				t.Errorf("Unexpected mismatch %+v", m)
+			}
+		}
+	})
+}
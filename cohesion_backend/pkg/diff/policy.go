@@ -0,0 +1,139 @@
+package diff
+
+import (
+	"fmt"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+// PolicyRule assigns a severity to mismatches matching all of its non-empty
+// criteria. PresentIn and MissingFrom match when every listed source is
+// among the sources that have, or lack, the field.
+type PolicyRule struct {
+	Section     string                  `json:"section,omitempty"`
+	Type        MismatchType            `json:"mismatch_type,omitempty"`
+	PresentIn   []schemair.SchemaSource `json:"present_in,omitempty"`
+	MissingFrom []schemair.SchemaSource `json:"missing_from,omitempty"`
+	Severity    Severity                `json:"severity"`
+}
+
+// Policy maps mismatches to severities. Rules are evaluated in order and the
+// first match wins.
+type Policy struct {
+	Rules []PolicyRule `json:"rules"`
+}
+
+// defaultRules encode the engine's built-in strictness: a response field the
+// frontend reads but the backend never sends is critical, a request field
+// the backend needs but the frontend never sends is a warning, and the
+// opposite directions are harmless extras.
+//
+// For nullability mismatches PresentIn holds the sources that allow null and
+// MissingFrom those that do not; for enum mismatches they hold the sources
+// that use a value and those that lack it. The same direction rules apply:
+// the receiving side not expecting what the sending side produces is the
+// problem.
+//
+// Query parameters and headers follow the request rules: a parameter the
+// frontend sends that the backend ignores is harmless, one the backend reads
+// that the frontend never sends is a warning. Runtime capture only sees the
+// parameters traffic happened to use and headers that clients and proxies
+// add, so its side of a missing parameter is informational.
+var defaultRules = []PolicyRule{
+	{Section: "response", Type: MismatchMissing, PresentIn: []schemair.SchemaSource{schemair.SourceBackendStatic}, MissingFrom: []schemair.SchemaSource{schemair.SourceFrontendStatic}, Severity: SeverityInfo},
+	{Section: "response", Type: MismatchMissing, PresentIn: []schemair.SchemaSource{schemair.SourceFrontendStatic}, MissingFrom: []schemair.SchemaSource{schemair.SourceBackendStatic}, Severity: SeverityCritical},
+	{Section: "request", Type: MismatchMissing, PresentIn: []schemair.SchemaSource{schemair.SourceFrontendStatic}, MissingFrom: []schemair.SchemaSource{schemair.SourceBackendStatic}, Severity: SeverityInfo},
+	{Section: "request", Type: MismatchMissing, PresentIn: []schemair.SchemaSource{schemair.SourceBackendStatic}, MissingFrom: []schemair.SchemaSource{schemair.SourceFrontendStatic}, Severity: SeverityWarning},
+	{Section: "query", Type: MismatchMissing, PresentIn: []schemair.SchemaSource{schemair.SourceFrontendStatic}, MissingFrom: []schemair.SchemaSource{schemair.SourceBackendStatic}, Severity: SeverityInfo},
+	{Section: "query", Type: MismatchMissing, PresentIn: []schemair.SchemaSource{schemair.SourceBackendStatic}, MissingFrom: []schemair.SchemaSource{schemair.SourceFrontendStatic}, Severity: SeverityWarning},
+	{Section: "headers", Type: MismatchMissing, PresentIn: []schemair.SchemaSource{schemair.SourceFrontendStatic}, MissingFrom: []schemair.SchemaSource{schemair.SourceBackendStatic}, Severity: SeverityInfo},
+	{Section: "headers", Type: MismatchMissing, PresentIn: []schemair.SchemaSource{schemair.SourceBackendStatic}, MissingFrom: []schemair.SchemaSource{schemair.SourceFrontendStatic}, Severity: SeverityWarning},
+	{Section: "headers", Type: MismatchMissing, PresentIn: []schemair.SchemaSource{schemair.SourceRuntime}, Severity: SeverityInfo},
+	{Section: "headers", Type: MismatchMissing, MissingFrom: []schemair.SchemaSource{schemair.SourceRuntime}, Severity: SeverityInfo},
+	{Section: "query", Type: MismatchMissing, MissingFrom: []schemair.SchemaSource{schemair.SourceRuntime}, Severity: SeverityInfo},
+	{Type: MismatchMissing, Severity: SeverityWarning},
+	{Type: MismatchTypeDiff, Severity: SeverityCritical},
+	{Type: MismatchOptionality, Severity: SeverityWarning},
+	{Type: MismatchExtra, Severity: SeverityInfo},
+
+	{Section: "response", Type: MismatchNullability, PresentIn: []schemair.SchemaSource{schemair.SourceBackendStatic}, MissingFrom: []schemair.SchemaSource{schemair.SourceFrontendStatic}, Severity: SeverityCritical},
+	{Section: "response", Type: MismatchNullability, PresentIn: []schemair.SchemaSource{schemair.SourceRuntime}, MissingFrom: []schemair.SchemaSource{schemair.SourceFrontendStatic}, Severity: SeverityCritical},
+	{Section: "request", Type: MismatchNullability, PresentIn: []schemair.SchemaSource{schemair.SourceFrontendStatic}, MissingFrom: []schemair.SchemaSource{schemair.SourceBackendStatic}, Severity: SeverityCritical},
+	{Section: "response", Type: MismatchNullability, PresentIn: []schemair.SchemaSource{schemair.SourceFrontendStatic}, Severity: SeverityInfo},
+	{Section: "request", Type: MismatchNullability, PresentIn: []schemair.SchemaSource{schemair.SourceBackendStatic}, Severity: SeverityInfo},
+	{Type: MismatchNullability, Severity: SeverityWarning},
+	{Section: "request", Type: MismatchEnum, PresentIn: []schemair.SchemaSource{schemair.SourceFrontendStatic}, MissingFrom: []schemair.SchemaSource{schemair.SourceBackendStatic}, Severity: SeverityCritical},
+	{Section: "response", Type: MismatchEnum, PresentIn: []schemair.SchemaSource{schemair.SourceFrontendStatic}, Severity: SeverityInfo},
+	{Section: "request", Type: MismatchEnum, PresentIn: []schemair.SchemaSource{schemair.SourceBackendStatic}, Severity: SeverityInfo},
+	{Type: MismatchEnum, Severity: SeverityWarning},
+	{Type: MismatchConstraint, Severity: SeverityWarning},
+}
+
+// DefaultPolicy returns a copy of the built-in rules.
+func DefaultPolicy() *Policy {
+	rules := make([]PolicyRule, len(defaultRules))
+	copy(rules, defaultRules)
+	return &Policy{Rules: rules}
+}
+
+// WithDefaults returns a policy that evaluates rules first and falls back to
+// the default rules for anything they do not cover.
+func WithDefaults(rules []PolicyRule) *Policy {
+	all := make([]PolicyRule, 0, len(rules)+len(defaultRules))
+	all = append(all, rules...)
+	all = append(all, defaultRules...)
+	return &Policy{Rules: all}
+}
+
+// Severity returns the severity of the first rule matching the mismatch, or
+// SeverityWarning when none does.
+func (p *Policy) Severity(section string, typ MismatchType, presentIn, missingFrom []schemair.SchemaSource) Severity {
+	for i := range p.Rules {
+		if p.Rules[i].matches(section, typ, presentIn, missingFrom) {
+			return p.Rules[i].Severity
+		}
+	}
+	return SeverityWarning
+}
+
+func (r *PolicyRule) matches(section string, typ MismatchType, presentIn, missingFrom []schemair.SchemaSource) bool {
+	if r.Section != "" && r.Section != section {
+		return false
+	}
+	if r.Type != "" && r.Type != typ {
+		return false
+	}
+	for _, src := range r.PresentIn {
+		if !sourceIn(presentIn, src) {
+			return false
+		}
+	}
+	for _, src := range r.MissingFrom {
+		if !sourceIn(missingFrom, src) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks that every rule names a known severity, section, mismatch
+// type and sources.
+func (r *PolicyRule) Validate() error {
+	if !r.Severity.IsKnown() {
+		return fmt.Errorf("invalid severity %q", r.Severity)
+	}
+	switch r.Section {
+	case "", "request", "response", "path", "query", "headers":
+	default:
+		return fmt.Errorf("invalid section %q", r.Section)
+	}
+	if r.Type != "" && !r.Type.IsKnown() {
+		return fmt.Errorf("invalid mismatch_type %q", r.Type)
+	}
+	for _, src := range append(append([]schemair.SchemaSource{}, r.PresentIn...), r.MissingFrom...) {
+		if !src.IsKnown() {
+			return fmt.Errorf("invalid source %q", src)
+		}
+	}
+	return nil
+}
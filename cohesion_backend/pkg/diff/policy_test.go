@@ -0,0 +1,101 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+func TestCompareWithPolicy(t *testing.T) {
+	schemas := []schemair.SchemaIR{
+		{
+			Source: schemair.SourceBackendStatic,
+			Request: &schemair.ObjectSchema{Type: "object", Fields: map[string]*schemair.Field{
+				"name": {Type: "string", Required: true},
+			}},
+			Response: map[int]*schemair.ObjectSchema{
+				200: {Type: "object", Fields: map[string]*schemair.Field{
+					"id": {Type: "string", Required: true},
+				}},
+			},
+		},
+		{
+			Source: schemair.SourceFrontendStatic,
+			Request: &schemair.ObjectSchema{Type: "object", Fields: map[string]*schemair.Field{
+				"name": {Type: "string", Required: false},
+			}},
+			Response: map[int]*schemair.ObjectSchema{
+				200: {Type: "object", Fields: map[string]*schemair.Field{
+					"id":    {Type: "string", Required: true},
+					"email": {Type: "string", Required: true},
+				}},
+			},
+		},
+	}
+
+	severityOf := func(result *Result, path string) Severity {
+		for _, m := range result.Mismatches {
+			if m.Path == path {
+				return m.Severity
+			}
+		}
+		t.Fatalf("No mismatch at %s", path)
+		return ""
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		result := NewEngine().Compare("/api/users", "POST", schemas)
+		if got := severityOf(result, "response.200.email"); got != SeverityCritical {
+			t.Errorf("Expected critical for missing response field, got %s", got)
+		}
+		if got := severityOf(result, "request.name"); got != SeverityWarning {
+			t.Errorf("Expected warning for optionality mismatch, got %s", got)
+		}
+	})
+
+	t.Run("project rules take precedence", func(t *testing.T) {
+		policy := WithDefaults([]PolicyRule{
+			{Section: "response", Type: MismatchMissing, MissingFrom: []schemair.SchemaSource{schemair.SourceBackendStatic}, Severity: SeverityWarning},
+			{Type: MismatchOptionality, Severity: SeverityInfo},
+		})
+		result := NewEngine().WithPolicy(policy).Compare("/api/users", "POST", schemas)
+		if got := severityOf(result, "response.200.email"); got != SeverityWarning {
+			t.Errorf("Expected warning, got %s", got)
+		}
+		if got := severityOf(result, "request.name"); got != SeverityInfo {
+			t.Errorf("Expected info, got %s", got)
+		}
+		if result.Status != schemair.StatusPartial {
+			t.Errorf("Expected partial once nothing is critical, got %s", result.Status)
+		}
+	})
+
+	t.Run("unmatched source rule falls through", func(t *testing.T) {
+		policy := WithDefaults([]PolicyRule{
+			{Type: MismatchMissing, PresentIn: []schemair.SchemaSource{schemair.SourceRuntime}, Severity: SeverityInfo},
+		})
+		result := NewEngine().WithPolicy(policy).Compare("/api/users", "POST", schemas)
+		if got := severityOf(result, "response.200.email"); got != SeverityCritical {
+			t.Errorf("Expected default critical, got %s", got)
+		}
+	})
+}
+
+func TestPolicyRuleValidate(t *testing.T) {
+	valid := PolicyRule{Section: "response", Type: MismatchTypeDiff, Severity: SeverityWarning}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected valid rule, got %v", err)
+	}
+
+	invalid := []PolicyRule{
+		{Severity: "fatal"},
+		{Section: "cookies", Severity: SeverityInfo},
+		{Type: "renamed", Severity: SeverityInfo},
+		{MissingFrom: []schemair.SchemaSource{"mobile"}, Severity: SeverityInfo},
+	}
+	for i, r := range invalid {
+		if err := r.Validate(); err == nil {
+			t.Errorf("Expected rule %d to be rejected", i)
+		}
+	}
+}
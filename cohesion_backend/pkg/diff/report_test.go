@@ -0,0 +1,96 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+func reportFixture() []*Result {
+	return []*Result{
+		{
+			Endpoint:        "/api/users/{}",
+			Method:          "GET",
+			SourcesCompared: []schemair.SchemaSource{schemair.SourceBackendStatic, schemair.SourceFrontendStatic},
+			Status:          schemair.StatusViolation,
+			Mismatches: []Mismatch{
+				{Path: "response.200.id", Type: MismatchTypeDiff, Severity: SeverityCritical, Description: "Type mismatch"},
+				{Path: "response.200.nickname", Type: MismatchMissing, Severity: SeverityInfo, Description: "Field missing"},
+			},
+		},
+		{
+			Endpoint:        "/api/health",
+			Method:          "GET",
+			SourcesCompared: []schemair.SchemaSource{schemair.SourceRuntime},
+			Status:          schemair.StatusMatch,
+			Mismatches:      []Mismatch{},
+		},
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, reportFixture(), SARIFOptions{ArtifactURI: "openapi.yaml"}); err != nil {
+		t.Fatalf("WriteSARIF failed: %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("Expected one SARIF 2.1.0 run, got version %s with %d runs", log.Version, len(log.Runs))
+	}
+
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != len(sarifRules) {
+		t.Errorf("Expected one rule per mismatch type, got %d", len(run.Tool.Driver.Rules))
+	}
+	if len(run.Results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(run.Results))
+	}
+
+	first := run.Results[0]
+	if first.RuleID != string(MismatchTypeDiff) || run.Tool.Driver.Rules[first.RuleIndex].ID != first.RuleID {
+		t.Errorf("Expected result to reference the type_mismatch rule, got %s (index %d)", first.RuleID, first.RuleIndex)
+	}
+	if first.Level != "error" || run.Results[1].Level != "note" {
+		t.Errorf("Expected levels error and note, got %s and %s", first.Level, run.Results[1].Level)
+	}
+	loc := first.Locations[0]
+	if loc.PhysicalLocation == nil || loc.PhysicalLocation.ArtifactLocation.URI != "openapi.yaml" {
+		t.Errorf("Expected artifact location to be set")
+	}
+	if loc.LogicalLocations[0].FullyQualifiedName != "GET /api/users/{}#response.200.id" {
+		t.Errorf("Unexpected logical location %s", loc.LogicalLocations[0].FullyQualifiedName)
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, "acme", reportFixture()); err != nil {
+		t.Fatalf("WriteJUnit failed: %v", err)
+	}
+
+	var doc junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Invalid XML: %v", err)
+	}
+	if doc.Tests != 2 || doc.Failures != 1 || doc.Skipped != 1 {
+		t.Errorf("Expected 2 tests, 1 failure, 1 skipped, got %d/%d/%d", doc.Tests, doc.Failures, doc.Skipped)
+	}
+
+	cases := doc.Suites[0].Cases
+	if cases[0].Name != "GET /api/users/{}" || len(cases[0].Failures) != 2 {
+		t.Errorf("Expected one failure per mismatch on %s, got %d", cases[0].Name, len(cases[0].Failures))
+	}
+	if cases[0].Failures[0].Type != string(MismatchTypeDiff) {
+		t.Errorf("Expected failure type %s, got %s", MismatchTypeDiff, cases[0].Failures[0].Type)
+	}
+	if cases[1].Skipped == nil {
+		t.Errorf("Expected single-source endpoint to be skipped")
+	}
+}
@@ -0,0 +1,231 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type SARIFOptions struct {
+	ToolVersion string
+	// ArtifactURI is attached to every result as its physical location.
+	// Code scanning uploads need one, e.g. the spec or schema file checked.
+	ArtifactURI string
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string            `json:"id"`
+	Name                 string            `json:"name"`
+	ShortDescription     sarifMessage      `json:"shortDescription"`
+	FullDescription      sarifMessage      `json:"fullDescription"`
+	DefaultConfiguration sarifRuleDefaults `json:"defaultConfiguration"`
+}
+
+type sarifRuleDefaults struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string                 `json:"ruleId"`
+	RuleIndex           int                    `json:"ruleIndex"`
+	Level               string                 `json:"level"`
+	Message             sarifMessage           `json:"message"`
+	Locations           []sarifLocation        `json:"locations"`
+	PartialFingerprints map[string]string      `json:"partialFingerprints"`
+	Suppressions        []sarifSuppression     `json:"suppressions,omitempty"`
+	Properties          map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// sarifRules lists one rule per mismatch type, in a fixed order so rule
+// indexes are stable between runs.
+var sarifRules = []struct {
+	typ   MismatchType
+	name  string
+	short string
+	full  string
+	level string
+}{
+	{MismatchMissing, "MissingField", "Field missing from a source",
+		"A field is present in at least one source's schema for the endpoint but absent from another.", "warning"},
+	{MismatchTypeDiff, "TypeMismatch", "Field type differs between sources",
+		"The same field has incompatible types in different sources and will fail to decode at runtime.", "error"},
+	{MismatchOptionality, "OptionalityMismatch", "Field required in one source but optional in another",
+		"Sources disagree on whether the field is required, which can cause validation failures.", "warning"},
+	{MismatchExtra, "ExtraField", "Field only present on one side",
+		"A field is sent or returned by one side and ignored by the other.", "note"},
+	{MismatchNullability, "NullabilityMismatch", "Field nullable in one source but not in another",
+		"One side may send null for a field the other side does not expect to be null.", "error"},
+	{MismatchEnum, "EnumMismatch", "Enum values differ between sources",
+		"One side uses enum values the other side does not accept or handle.", "warning"},
+	{MismatchConstraint, "ConstraintMismatch", "Field format or bounds differ between sources",
+		"Sources declare different formats or value ranges for the same field.", "warning"},
+}
+
+func sarifLevel(s Severity) string {
+	switch s {
+	case SeverityCritical:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// WriteSARIF renders results as a SARIF 2.1.0 log. Each mismatch becomes a
+// result whose logical location is "METHOD /path#field.path"; waived ones
+// carry an external suppression.
+func WriteSARIF(w io.Writer, results []*Result, opts SARIFOptions) error {
+	ruleIndex := make(map[MismatchType]int, len(sarifRules))
+	rules := make([]sarifRule, 0, len(sarifRules))
+	for i, r := range sarifRules {
+		ruleIndex[r.typ] = i
+		rules = append(rules, sarifRule{
+			ID:                   string(r.typ),
+			Name:                 r.name,
+			ShortDescription:     sarifMessage{Text: r.short},
+			FullDescription:      sarifMessage{Text: r.full},
+			DefaultConfiguration: sarifRuleDefaults{Level: r.level},
+		})
+	}
+
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "cohesion",
+			Version:        opts.ToolVersion,
+			InformationURI: "https://github.com/Tanush1912/cohesion-app",
+			Rules:          rules,
+		}},
+		Results: []sarifResult{},
+	}
+
+	for _, r := range results {
+		for _, m := range r.Mismatches {
+			idx, ok := ruleIndex[m.Type]
+			if !ok {
+				rules = append(rules, sarifRule{
+					ID:                   string(m.Type),
+					Name:                 string(m.Type),
+					ShortDescription:     sarifMessage{Text: string(m.Type)},
+					FullDescription:      sarifMessage{Text: string(m.Type)},
+					DefaultConfiguration: sarifRuleDefaults{Level: "warning"},
+				})
+				idx = len(rules) - 1
+				ruleIndex[m.Type] = idx
+			}
+
+			qualified := fmt.Sprintf("%s %s#%s", r.Method, r.Endpoint, m.Path)
+			loc := sarifLocation{
+				LogicalLocations: []sarifLogicalLocation{{
+					Name:               m.Path,
+					FullyQualifiedName: qualified,
+					Kind:               "member",
+				}},
+			}
+			if opts.ArtifactURI != "" {
+				loc.PhysicalLocation = &sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: opts.ArtifactURI},
+				}
+			}
+
+			text := fmt.Sprintf("%s %s: %s", r.Method, r.Endpoint, m.Description)
+			if m.Suggestion != "" {
+				text += ". " + m.Suggestion
+			}
+
+			sources := make([]string, len(m.InSources))
+			for i, s := range m.InSources {
+				sources[i] = string(s)
+			}
+
+			var suppressions []sarifSuppression
+			if m.Suppressed {
+				suppressions = []sarifSuppression{{Kind: "external", Justification: m.WaiverReason}}
+			}
+
+			run.Results = append(run.Results, sarifResult{
+				RuleID:    string(m.Type),
+				RuleIndex: idx,
+				Level:     sarifLevel(m.Severity),
+				Message:   sarifMessage{Text: text},
+				Locations: []sarifLocation{loc},
+				PartialFingerprints: map[string]string{
+					"cohesionMismatch/v1": string(m.Type) + ":" + qualified,
+				},
+				Suppressions: suppressions,
+				Properties: map[string]interface{}{
+					"endpoint": r.Endpoint,
+					"method":   r.Method,
+					"path":     m.Path,
+					"severity": m.Severity,
+					"sources":  strings.Join(sources, ","),
+				},
+			})
+		}
+	}
+	run.Tool.Driver.Rules = rules
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	})
+}
@@ -9,8 +9,20 @@ const (
 	MismatchTypeDiff    MismatchType = "type_mismatch"
 	MismatchOptionality MismatchType = "optionality_mismatch"
 	MismatchExtra       MismatchType = "extra_field"
+	MismatchNullability MismatchType = "nullability_mismatch"
+	MismatchEnum        MismatchType = "enum_mismatch"
+	MismatchConstraint  MismatchType = "constraint_mismatch"
 )
 
+func (t MismatchType) IsKnown() bool {
+	switch t {
+	case MismatchMissing, MismatchTypeDiff, MismatchOptionality, MismatchExtra,
+		MismatchNullability, MismatchEnum, MismatchConstraint:
+		return true
+	}
+	return false
+}
+
 type Severity string
 
 const (
@@ -24,10 +36,15 @@ type Mismatch struct {
 	Type        MismatchType            `json:"type"`
 	Description string                  `json:"description"`
 	InSources   []schemair.SchemaSource `json:"in_sources"`
+	MissingFrom []schemair.SchemaSource `json:"missing_from,omitempty"`
 	Expected    interface{}             `json:"expected,omitempty"`
 	Actual      interface{}             `json:"actual,omitempty"`
 	Severity    Severity                `json:"severity"`
 	Suggestion  string                  `json:"suggestion,omitempty"`
+
+	Suppressed   bool   `json:"suppressed,omitempty"`
+	WaiverID     string `json:"waiver_id,omitempty"`
+	WaiverReason string `json:"waiver_reason,omitempty"`
 }
 
 type Result struct {
@@ -35,6 +52,7 @@ type Result struct {
 	Method          string                  `json:"method"`
 	SourcesCompared []schemair.SchemaSource `json:"sources_compared"`
 	Mismatches      []Mismatch              `json:"mismatches"`
+	SuppressedCount int                     `json:"suppressed_count,omitempty"`
 	Status          schemair.MatchStatus    `json:"status"`
 	Confidence      *EndpointConfidence     `json:"confidence,omitempty"`
 }
@@ -43,3 +61,30 @@ type EndpointConfidence struct {
 	Score   float64  `json:"score"`
 	Factors []string `json:"factors"`
 }
+
+var severityRank = map[Severity]int{
+	SeverityInfo:     1,
+	SeverityWarning:  2,
+	SeverityCritical: 3,
+}
+
+// AtLeast reports whether s is as severe as threshold or more.
+func (s Severity) AtLeast(threshold Severity) bool {
+	return severityRank[s] >= severityRank[threshold]
+}
+
+func (s Severity) IsKnown() bool {
+	_, ok := severityRank[s]
+	return ok
+}
+
+// ActiveMismatches returns the mismatches not suppressed by a waiver.
+func (r *Result) ActiveMismatches() []Mismatch {
+	active := make([]Mismatch, 0, len(r.Mismatches))
+	for _, m := range r.Mismatches {
+		if !m.Suppressed {
+			active = append(active, m)
+		}
+	}
+	return active
+}
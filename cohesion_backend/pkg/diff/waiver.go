@@ -0,0 +1,120 @@
+package diff
+
+import (
+	"strings"
+	"time"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+// Waiver accepts mismatches matching all of its non-empty criteria. Waived
+// mismatches stay in the result, marked Suppressed, but no longer count
+// towards Status or confidence.
+type Waiver struct {
+	ID string `json:"id"`
+	// Endpoint is a glob over the normalized endpoint path, where "*"
+	// matches one path segment and "**" any number of them.
+	Endpoint string `json:"endpoint_pattern,omitempty"`
+	Method   string `json:"method,omitempty"`
+	// FieldPath is a glob over the mismatch path with "." as separator,
+	// e.g. "response.200.legacy_*" or "response.*.items.[].**".
+	FieldPath string       `json:"field_path,omitempty"`
+	Type      MismatchType `json:"mismatch_type,omitempty"`
+	// Sources must all be involved in the mismatch, either holding the
+	// field or missing it.
+	Sources   []schemair.SchemaSource `json:"sources,omitempty"`
+	ExpiresAt *time.Time              `json:"expires_at,omitempty"`
+	Reason    string                  `json:"reason"`
+}
+
+func (w *Waiver) Active(now time.Time) bool {
+	return w.ExpiresAt == nil || now.Before(*w.ExpiresAt)
+}
+
+// Matches reports whether the waiver covers mismatch m on endpoint/method.
+// Expiry is not checked.
+func (w *Waiver) Matches(endpoint, method string, m *Mismatch) bool {
+	if w.Method != "" && !strings.EqualFold(w.Method, method) {
+		return false
+	}
+	if w.Type != "" && w.Type != m.Type {
+		return false
+	}
+	if w.Endpoint != "" && !globMatch(w.Endpoint, endpoint, "/") {
+		return false
+	}
+	if w.FieldPath != "" && !globMatch(w.FieldPath, m.Path, ".") {
+		return false
+	}
+	for _, src := range w.Sources {
+		if !sourceIn(m.InSources, src) && !sourceIn(m.MissingFrom, src) {
+			return false
+		}
+	}
+	return true
+}
+
+// applyWaivers marks mismatches covered by an active waiver as suppressed
+// and returns how many were.
+func applyWaivers(waivers []Waiver, now time.Time, endpoint, method string, mismatches []Mismatch) int {
+	suppressed := 0
+	for i := range mismatches {
+		for j := range waivers {
+			w := &waivers[j]
+			if !w.Active(now) || !w.Matches(endpoint, method, &mismatches[i]) {
+				continue
+			}
+			mismatches[i].Suppressed = true
+			mismatches[i].WaiverID = w.ID
+			mismatches[i].WaiverReason = w.Reason
+			suppressed++
+			break
+		}
+	}
+	return suppressed
+}
+
+// globMatch matches name against pattern segment by segment. "*" within a
+// segment matches any run of characters, and a "**" segment matches zero or
+// more whole segments.
+func globMatch(pattern, name, sep string) bool {
+	return matchSegments(strings.Split(pattern, sep), strings.Split(name, sep))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 || !matchSegment(pattern[0], name[0]) {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+func matchSegment(pattern, s string) bool {
+	star := strings.IndexByte(pattern, '*')
+	if star < 0 {
+		return pattern == s
+	}
+	prefix := pattern[:star]
+	if !strings.HasPrefix(s, prefix) {
+		return false
+	}
+	rest := pattern[star+1:]
+	s = s[len(prefix):]
+	for i := 0; i <= len(s); i++ {
+		if matchSegment(rest, s[i:]) {
+			return true
+		}
+	}
+	return false
+}
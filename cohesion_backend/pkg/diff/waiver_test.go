@@ -0,0 +1,109 @@
+package diff
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+func TestGlobMatch(t *testing.T) {
+	cases := []struct {
+		pattern, name, sep string
+		want               bool
+	}{
+		{"/api/users/{}", "/api/users/{}", "/", true},
+		{"/api/users/*", "/api/users/{}", "/", true},
+		{"/api/users/*", "/api/users/{}/posts", "/", false},
+		{"/api/**", "/api/users/{}/posts", "/", true},
+		{"/api/**/posts", "/api/users/{}/posts", "/", true},
+		{"response.200.legacy_*", "response.200.legacy_id", ".", true},
+		{"response.*.items.[].**", "response.200.items.[].owner.id", ".", true},
+		{"request.name", "request.name_full", ".", false},
+	}
+	for _, tc := range cases {
+		if got := globMatch(tc.pattern, tc.name, tc.sep); got != tc.want {
+			t.Errorf("globMatch(%q, %q) = %v, expected %v", tc.pattern, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCompareWithWaivers(t *testing.T) {
+	schemas := []schemair.SchemaIR{
+		{
+			Source: schemair.SourceBackendStatic,
+			Response: map[int]*schemair.ObjectSchema{
+				200: {Type: "object", Fields: map[string]*schemair.Field{
+					"id": {Type: "string", Required: true},
+				}},
+			},
+		},
+		{
+			Source: schemair.SourceFrontendStatic,
+			Response: map[int]*schemair.ObjectSchema{
+				200: {Type: "object", Fields: map[string]*schemair.Field{
+					"id":        {Type: "string", Required: true},
+					"legacy_id": {Type: "int", Required: true},
+				}},
+			},
+		},
+	}
+
+	baseline := NewEngine().Compare("/api/users/{}", "GET", schemas)
+	if baseline.Status != schemair.StatusViolation {
+		t.Fatalf("Expected baseline violation, got %s", baseline.Status)
+	}
+
+	future := time.Now().Add(24 * time.Hour)
+	past := time.Now().Add(-24 * time.Hour)
+	waiver := Waiver{
+		ID:        "w1",
+		Endpoint:  "/api/users/*",
+		Method:    "get",
+		FieldPath: "response.*.legacy_*",
+		Type:      MismatchMissing,
+		Sources:   []schemair.SchemaSource{schemair.SourceBackendStatic, schemair.SourceFrontendStatic},
+		ExpiresAt: &future,
+		Reason:    "Deprecated field still read defensively",
+	}
+
+	t.Run("Active waiver suppresses the mismatch", func(t *testing.T) {
+		result := NewEngine().WithWaivers([]Waiver{waiver}).Compare("/api/users/{}", "GET", schemas)
+
+		if result.Status != schemair.StatusMatch {
+			t.Errorf("Expected match once waived, got %s", result.Status)
+		}
+		if result.SuppressedCount != 1 || len(result.Mismatches) != 1 {
+			t.Fatalf("Expected 1 suppressed mismatch kept in the result, got %d of %d", result.SuppressedCount, len(result.Mismatches))
+		}
+		m := result.Mismatches[0]
+		if !m.Suppressed || m.WaiverID != "w1" || m.WaiverReason == "" {
+			t.Errorf("Expected mismatch to carry the waiver, got %+v", m)
+		}
+		if result.Confidence.Score <= baseline.Confidence.Score {
+			t.Errorf("Expected waived mismatch to be excluded from confidence, got %.0f vs %.0f",
+				result.Confidence.Score, baseline.Confidence.Score)
+		}
+		if len(result.ActiveMismatches()) != 0 {
+			t.Errorf("Expected no active mismatches")
+		}
+	})
+
+	t.Run("Expired waiver is ignored", func(t *testing.T) {
+		expired := waiver
+		expired.ExpiresAt = &past
+		result := NewEngine().WithWaivers([]Waiver{expired}).Compare("/api/users/{}", "GET", schemas)
+		if result.Status != schemair.StatusViolation || result.SuppressedCount != 0 {
+			t.Errorf("Expected expired waiver to have no effect, got %s with %d suppressed", result.Status, result.SuppressedCount)
+		}
+	})
+
+	t.Run("Non-matching criteria", func(t *testing.T) {
+		other := waiver
+		other.Sources = []schemair.SchemaSource{schemair.SourceRuntime}
+		result := NewEngine().WithWaivers([]Waiver{other}).Compare("/api/users/{}", "GET", schemas)
+		if result.SuppressedCount != 0 {
+			t.Errorf("Expected a waiver for another source pair not to apply")
+		}
+	})
+}
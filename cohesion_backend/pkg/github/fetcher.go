@@ -5,17 +5,28 @@ import (
 	"encoding/base64"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	gh "github.com/google/go-github/v68/github"
 
-	"github.com/cohesion-api/cohesion_backend/pkg/analyzer/gemini"
+	"github.com/cohesion-api/cohesion_backend/pkg/analyzer"
 	"github.com/cohesion-api/cohesion_backend/pkg/sourcefile"
 )
 
-const maxTotalBytes = 900_000 * 4
+// maxTotalBytes bounds the memory and blob requests of one fetch. Trees
+// larger than one prompt are analyzed in chunks, so this is well above it.
+const maxTotalBytes = 16 * 1024 * 1024
 const maxFileBytes = 100 * 1024
 
+// RepoFiles is the outcome of a fetch: the source files and manifests, the
+// primary language, and the files left out with the reason.
+type RepoFiles struct {
+	Files    []analyzer.SourceFile
+	Language string
+	Skipped  []analyzer.SkippedFile
+}
+
 func ParseRepoURL(input string) (owner, repo string, err error) {
 	input = strings.TrimSpace(input)
 	input = strings.TrimSuffix(input, ".git")
@@ -39,7 +50,7 @@ func ParseRepoURL(input string) (owner, repo string, err error) {
 	return "", "", fmt.Errorf("cannot parse repo from: %s (use owner/repo or https://github.com/owner/repo)", input)
 }
 
-func FetchRepoFiles(ctx context.Context, token, owner, repo, branch, subPath string) ([]gemini.SourceFile, string, error) {
+func FetchRepoFiles(ctx context.Context, token, owner, repo, branch, subPath string) ([]analyzer.SourceFile, string, error) {
 	client := gh.NewClient(nil)
 	if token != "" {
 		client = client.WithAuthToken(token)
@@ -47,7 +58,17 @@ func FetchRepoFiles(ctx context.Context, token, owner, repo, branch, subPath str
 	return FetchRepoFilesWithClient(ctx, client, owner, repo, branch, subPath)
 }
 
-func FetchRepoFilesWithClient(ctx context.Context, client *gh.Client, owner, repo, branch, subPath string) ([]gemini.SourceFile, string, error) {
+func FetchRepoFilesWithClient(ctx context.Context, client *gh.Client, owner, repo, branch, subPath string) ([]analyzer.SourceFile, string, error) {
+	fetched, err := FetchRepoWithClient(ctx, client, owner, repo, branch, subPath)
+	if err != nil {
+		return nil, "", err
+	}
+	return fetched.Files, fetched.Language, nil
+}
+
+// FetchRepoWithClient fetches a repository's source files and manifests,
+// route and handler files first, and reports the files it left out.
+func FetchRepoWithClient(ctx context.Context, client *gh.Client, owner, repo, branch, subPath string) (*RepoFiles, error) {
 	if branch == "" {
 		branch = "main"
 	}
@@ -56,7 +77,7 @@ func FetchRepoFilesWithClient(ctx context.Context, client *gh.Client, owner, rep
 		return client.Git.GetRef(ctx, owner, repo, "refs/heads/"+branch)
 	})
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to resolve branch %q: %w", branch, err)
+		return nil, fmt.Errorf("failed to resolve branch %q: %w", branch, err)
 	}
 	sha := ref.GetObject().GetSHA()
 
@@ -64,7 +85,7 @@ func FetchRepoFilesWithClient(ctx context.Context, client *gh.Client, owner, rep
 		return client.Git.GetTree(ctx, owner, repo, sha, true)
 	})
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to get repo tree: %w", err)
+		return nil, fmt.Errorf("failed to get repo tree: %w", err)
 	}
 
 	subPath = strings.TrimPrefix(strings.TrimSuffix(subPath, "/"), "/")
@@ -76,6 +97,13 @@ func FetchRepoFilesWithClient(ctx context.Context, client *gh.Client, owner, rep
 	}
 	var candidates []candidate
 	extCount := make(map[string]int)
+	fetched := &RepoFiles{}
+	relative := func(path string) string {
+		if subPath != "" {
+			return strings.TrimPrefix(path, subPath+"/")
+		}
+		return path
+	}
 
 	for _, entry := range tree.Entries {
 		if entry.GetType() != "blob" {
@@ -92,8 +120,11 @@ func FetchRepoFilesWithClient(ctx context.Context, client *gh.Client, owner, rep
 			continue
 		}
 
+		// Manifests are fetched too so the analyzer registry can detect
+		// frameworks.
 		ext := strings.ToLower(filepath.Ext(path))
-		if !sourcefile.SourceExtensions[ext] {
+		manifest := sourcefile.IsManifest(path)
+		if !sourcefile.SourceExtensions[ext] && !manifest {
 			continue
 		}
 
@@ -103,10 +134,13 @@ func FetchRepoFilesWithClient(ctx context.Context, client *gh.Client, owner, rep
 
 		size := entry.GetSize()
 		if size > maxFileBytes {
+			fetched.Skipped = append(fetched.Skipped, analyzer.SkippedFile{Path: relative(path), Reason: "larger than 100 KB"})
 			continue
 		}
 
-		extCount[ext]++
+		if !manifest {
+			extCount[ext]++
+		}
 		candidates = append(candidates, candidate{
 			path:    path,
 			blobSHA: entry.GetSHA(),
@@ -115,21 +149,30 @@ func FetchRepoFilesWithClient(ctx context.Context, client *gh.Client, owner, rep
 	}
 
 	if len(candidates) == 0 {
-		return nil, "", fmt.Errorf("no source files found in %s/%s", owner, repo)
+		return nil, fmt.Errorf("no source files found in %s/%s", owner, repo)
 	}
 
-	var files []gemini.SourceFile
-	totalBytes := 0
+	// Manifests and likely route and handler files first, so they are kept
+	// if the repository exceeds maxTotalBytes.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidatePriority(candidates[i].path) < candidatePriority(candidates[j].path)
+	})
 
+	totalBytes := 0
 	for _, c := range candidates {
 		if totalBytes+c.size > maxTotalBytes {
-			break
+			fetched.Skipped = append(fetched.Skipped, analyzer.SkippedFile{Path: relative(c.path), Reason: "over the repository size limit"})
+			continue
 		}
 
 		blob, _, err := withRetry(ctx, func() (*gh.Blob, *gh.Response, error) {
 			return client.Git.GetBlob(ctx, owner, repo, c.blobSHA)
 		})
 		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			fetched.Skipped = append(fetched.Skipped, analyzer.SkippedFile{Path: relative(c.path), Reason: "fetch failed: " + err.Error()})
 			continue
 		}
 
@@ -138,35 +181,38 @@ func FetchRepoFilesWithClient(ctx context.Context, client *gh.Client, owner, rep
 		case "base64":
 			decoded, err := base64.StdEncoding.DecodeString(blob.GetContent())
 			if err != nil {
+				fetched.Skipped = append(fetched.Skipped, analyzer.SkippedFile{Path: relative(c.path), Reason: "undecodable content"})
 				continue
 			}
 			content = string(decoded)
 		case "utf-8", "":
 			content = blob.GetContent()
 		default:
+			fetched.Skipped = append(fetched.Skipped, analyzer.SkippedFile{Path: relative(c.path), Reason: "unsupported encoding " + blob.GetEncoding()})
 			continue
 		}
 
-		relPath := c.path
-		if subPath != "" {
-			relPath = strings.TrimPrefix(c.path, subPath+"/")
-		}
-
-		files = append(files, gemini.SourceFile{
-			Path:    relPath,
+		fetched.Files = append(fetched.Files, analyzer.SourceFile{
+			Path:    relative(c.path),
 			Content: content,
 		})
 		totalBytes += len(content)
 	}
 
-	language := ""
 	maxCount := 0
 	for ext, count := range extCount {
 		if count > maxCount {
 			maxCount = count
-			language = sourcefile.LanguageHints[ext]
+			fetched.Language = sourcefile.LanguageHints[ext]
 		}
 	}
 
-	return files, language, nil
+	return fetched, nil
+}
+
+func candidatePriority(path string) int {
+	if sourcefile.IsManifest(path) {
+		return 0
+	}
+	return sourcefile.Priority(path)
 }
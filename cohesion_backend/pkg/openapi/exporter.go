@@ -0,0 +1,371 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+const exportVersion = "3.1.0"
+
+// DefaultAuthority is the source preference used when rendering an endpoint
+// whose preferred source has no schema.
+var DefaultAuthority = []schemair.SchemaSource{
+	schemair.SourceBackendStatic,
+	schemair.SourceOpenAPI,
+	schemair.SourceRuntime,
+	schemair.SourceFrontendStatic,
+}
+
+type ExportOptions struct {
+	Title   string
+	Version string
+	// Authority is the preferred source. Endpoints without a schema from it
+	// fall back to DefaultAuthority order unless Strict is set.
+	Authority schemair.SchemaSource
+	Strict    bool
+}
+
+// Export renders the schemas of every endpoint into an OpenAPI 3.1 document.
+// schemas may contain several sources per endpoint; one is chosen per
+// endpoint according to opts.Authority.
+func Export(schemas []schemair.SchemaIR, opts ExportOptions) *Document {
+	title := opts.Title
+	if title == "" {
+		title = "Cohesion contract"
+	}
+	version := opts.Version
+	if version == "" {
+		version = "1.0.0"
+	}
+
+	doc := &Document{
+		OpenAPI: exportVersion,
+		Info: Info{
+			Title:   title,
+			Version: version,
+		},
+		Paths: make(map[string]*PathItem),
+	}
+	if opts.Authority != "" {
+		doc.Info.Description = fmt.Sprintf("Reconciled by Cohesion with %s as the authoritative source.", opts.Authority)
+	}
+
+	priority := []schemair.SchemaSource{}
+	if opts.Authority != "" {
+		priority = append(priority, opts.Authority)
+	}
+	if !opts.Strict || opts.Authority == "" {
+		priority = append(priority, DefaultAuthority...)
+	}
+
+	type opKey struct{ path, method string }
+	bySource := make(map[opKey]map[schemair.SchemaSource]schemair.SchemaIR)
+	var keys []opKey
+	for _, s := range schemas {
+		k := opKey{s.Endpoint, strings.ToUpper(s.Method)}
+		if bySource[k] == nil {
+			bySource[k] = make(map[schemair.SchemaSource]schemair.SchemaIR)
+			keys = append(keys, k)
+		}
+		bySource[k][s.Source] = s
+	}
+
+	// typed records which path parameters have a schema from an operation;
+	// the others keep the string default until a later operation has one.
+	typed := make(map[string][]bool)
+	for _, k := range keys {
+		chosen, ok := pickSource(bySource[k], priority)
+		if !ok {
+			continue
+		}
+
+		path, params := templatePath(k.path)
+		item := doc.Paths[path]
+		if item == nil {
+			item = &PathItem{Parameters: params}
+			doc.Paths[path] = item
+			typed[path] = make([]bool, len(params))
+		}
+		for i := range chosen.PathParams {
+			if i < len(item.Parameters) && !typed[path][i] {
+				item.Parameters[i].Schema = exportField(&chosen.PathParams[i].Field)
+				typed[path][i] = true
+			}
+		}
+		setOperation(item, k.method, exportOperation(chosen))
+	}
+
+	return doc
+}
+
+func pickSource(sources map[schemair.SchemaSource]schemair.SchemaIR, priority []schemair.SchemaSource) (schemair.SchemaIR, bool) {
+	for _, src := range priority {
+		if s, ok := sources[src]; ok {
+			return s, true
+		}
+	}
+	return schemair.SchemaIR{}, false
+}
+
+func setOperation(item *PathItem, method string, op *Operation) {
+	switch method {
+	case http.MethodGet:
+		item.Get = op
+	case http.MethodPut:
+		item.Put = op
+	case http.MethodPost:
+		item.Post = op
+	case http.MethodDelete:
+		item.Delete = op
+	case http.MethodOptions:
+		item.Options = op
+	case http.MethodHead:
+		item.Head = op
+	case http.MethodPatch:
+		item.Patch = op
+	}
+}
+
+// templatePath names the anonymous "{}" segments produced by path
+// normalization, since OpenAPI requires every template to be declared.
+// "/projects/{}/endpoints/{}" becomes "/projects/{projectId}/endpoints/{endpointId}".
+func templatePath(path string) (string, []*Parameter) {
+	segments := strings.Split(path, "/")
+	used := make(map[string]bool)
+	var params []*Parameter
+
+	for i, seg := range segments {
+		if !strings.HasPrefix(seg, "{") || !strings.HasSuffix(seg, "}") {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(seg, "{"), "}")
+		if name == "" {
+			name = paramNameFor(segments[:i])
+		}
+		base := name
+		for n := 2; used[name]; n++ {
+			name = base + strconv.Itoa(n)
+		}
+		used[name] = true
+		segments[i] = "{" + name + "}"
+		params = append(params, &Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: SchemaType{"string"}},
+		})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func paramNameFor(preceding []string) string {
+	for i := len(preceding) - 1; i >= 0; i-- {
+		seg := preceding[i]
+		if seg == "" || strings.HasPrefix(seg, "{") {
+			continue
+		}
+		seg = strings.TrimSuffix(seg, "s")
+		seg = strings.NewReplacer("-", "_", ".", "_").Replace(seg)
+		if seg == "" {
+			break
+		}
+		return seg + "Id"
+	}
+	return "id"
+}
+
+func exportOperation(s schemair.SchemaIR) *Operation {
+	op := &Operation{
+		Responses: make(map[string]*Response),
+		Source:    string(s.Source),
+	}
+	op.Parameters = append(exportParameters(s.QueryParams, "query"), exportParameters(s.Headers, "header")...)
+
+	if s.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]*MediaType{
+				mediaType(s.Request): {Schema: exportObject(s.Request)},
+			},
+		}
+	}
+
+	codes := make([]int, 0, len(s.Response))
+	for code := range s.Response {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		resp := &Response{Description: statusDescription(code)}
+		if obj := s.Response[code]; hasBody(code, obj) {
+			resp.Content = map[string]*MediaType{
+				mediaType(obj): {Schema: exportObject(obj)},
+			}
+		}
+		op.Responses[strconv.Itoa(code)] = resp
+	}
+	if len(op.Responses) == 0 {
+		op.Responses["default"] = &Response{Description: "Response not captured by any source"}
+	}
+
+	return op
+}
+
+// hasBody reports whether a response is documented with content: any
+// typed body, including scalars and empty objects, except for statuses
+// that never carry one.
+func hasBody(code int, obj *schemair.ObjectSchema) bool {
+	if obj == nil || code == http.StatusNoContent || code == http.StatusNotModified {
+		return false
+	}
+	return obj.Type != "" || len(obj.Fields) > 0 || obj.Items != nil
+}
+
+func exportParameters(fields map[string]*schemair.Field, in string) []*Parameter {
+	names := make([]string, 0, len(fields))
+	for name, field := range fields {
+		if field != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	params := make([]*Parameter, 0, len(names))
+	for _, name := range names {
+		params = append(params, &Parameter{
+			Name:     name,
+			In:       in,
+			Required: fields[name].Required,
+			Schema:   exportField(fields[name]),
+		})
+	}
+	return params
+}
+
+func mediaType(obj *schemair.ObjectSchema) string {
+	if obj.ContentType != "" {
+		return obj.ContentType
+	}
+	return "application/json"
+}
+
+func statusDescription(code int) string {
+	if text := http.StatusText(code); text != "" {
+		return text
+	}
+	return "Status " + strconv.Itoa(code)
+}
+
+func exportObject(obj *schemair.ObjectSchema) *Schema {
+	if obj == nil {
+		return &Schema{}
+	}
+	out := typeSchema(obj.Type)
+	if obj.Items != nil {
+		out.Type = SchemaType{"array"}
+		out.Items = exportObject(obj.Items)
+	}
+	if len(obj.Fields) > 0 {
+		out.Type = SchemaType{"object"}
+		out.Properties = make(map[string]*Schema, len(obj.Fields))
+		for name, field := range obj.Fields {
+			if field == nil {
+				continue
+			}
+			out.Properties[name] = exportField(field)
+			if field.Required {
+				out.Required = append(out.Required, name)
+			}
+		}
+		sort.Strings(out.Required)
+	}
+	return out
+}
+
+func exportField(f *schemair.Field) *Schema {
+	var out *Schema
+	switch {
+	case f.Nested != nil && isArrayType(f.Type):
+		items := f.Nested
+		if items.Items != nil {
+			items = items.Items
+		}
+		out = &Schema{Type: SchemaType{"array"}, Items: exportObject(items)}
+	case f.Nested != nil:
+		out = exportObject(f.Nested)
+	default:
+		out = typeSchema(f.Type)
+	}
+	if f.Confidence > 0 {
+		c := f.Confidence
+		out.Confidence = &c
+	}
+	exportConstraints(out, f)
+	return out
+}
+
+// exportConstraints writes a field's value constraints onto its schema.
+// Nullability uses the 3.1 form of a type list including "null".
+func exportConstraints(out *Schema, f *schemair.Field) {
+	if f.Nullable && len(out.Type) == 1 && out.Type[0] != "null" {
+		out.Type = append(out.Type, "null")
+	}
+	if f.Format != "" && out.Format == "" {
+		out.Format = f.Format
+	}
+	out.Enum = f.Enum
+	out.Pattern = f.Pattern
+	out.Minimum = f.Minimum
+	out.Maximum = f.Maximum
+	out.MinLength = f.MinLength
+	out.MaxLength = f.MaxLength
+}
+
+func isArrayType(t string) bool {
+	t = strings.ToLower(strings.TrimSpace(t))
+	return t == "array" || t == "list"
+}
+
+// typeSchema maps an IR type name back onto an OpenAPI type and format.
+// Unknown types such as "any" produce an unconstrained schema.
+func typeSchema(t string) *Schema {
+	switch strings.ToLower(strings.TrimSpace(t)) {
+	case "string", "str":
+		return &Schema{Type: SchemaType{"string"}}
+	case "uuid":
+		return &Schema{Type: SchemaType{"string"}, Format: "uuid"}
+	case "time", "datetime", "date-time", "timestamp":
+		return &Schema{Type: SchemaType{"string"}, Format: "date-time"}
+	case "date":
+		return &Schema{Type: SchemaType{"string"}, Format: "date"}
+	case "email":
+		return &Schema{Type: SchemaType{"string"}, Format: "email"}
+	case "uri":
+		return &Schema{Type: SchemaType{"string"}, Format: "uri"}
+	case "int", "integer", "int32":
+		return &Schema{Type: SchemaType{"integer"}}
+	case "int64":
+		return &Schema{Type: SchemaType{"integer"}, Format: "int64"}
+	case "float", "float32":
+		return &Schema{Type: SchemaType{"number"}, Format: "float"}
+	case "double", "float64":
+		return &Schema{Type: SchemaType{"number"}, Format: "double"}
+	case "number":
+		return &Schema{Type: SchemaType{"number"}}
+	case "bool", "boolean":
+		return &Schema{Type: SchemaType{"boolean"}}
+	case "object", "map":
+		return &Schema{Type: SchemaType{"object"}}
+	case "array", "list":
+		return &Schema{Type: SchemaType{"array"}}
+	case "null":
+		return &Schema{Type: SchemaType{"null"}}
+	default:
+		return &Schema{}
+	}
+}
@@ -0,0 +1,166 @@
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+func TestExport(t *testing.T) {
+	schemas := []schemair.SchemaIR{
+		{
+			Endpoint: "/api/projects/{}/endpoints/{}",
+			Method:   "GET",
+			Source:   schemair.SourceBackendStatic,
+			Response: map[int]*schemair.ObjectSchema{
+				200: {Type: "object", Fields: map[string]*schemair.Field{
+					"id":    {Type: "uuid", Required: true, Confidence: 1.0},
+					"count": {Type: "int", Required: false, Confidence: 0.8},
+					"tags": {Type: "array", Required: true, Nested: &schemair.ObjectSchema{
+						Type: "array", Items: &schemair.ObjectSchema{Type: "object", Fields: map[string]*schemair.Field{
+							"label": {Type: "string", Required: true},
+						}},
+					}},
+				}},
+			},
+		},
+		{
+			Endpoint: "/api/projects/{}/endpoints/{}",
+			Method:   "GET",
+			Source:   schemair.SourceRuntime,
+			Response: map[int]*schemair.ObjectSchema{
+				200: {Type: "object", Fields: map[string]*schemair.Field{
+					"runtime_only": {Type: "string"},
+				}},
+			},
+		},
+		{
+			Endpoint: "/api/health",
+			Method:   "GET",
+			Source:   schemair.SourceRuntime,
+			Response: map[int]*schemair.ObjectSchema{
+				200: {Type: "object", Fields: map[string]*schemair.Field{
+					"status": {Type: "string", Required: true},
+				}},
+			},
+		},
+	}
+
+	t.Run("Authority selection and fallback", func(t *testing.T) {
+		doc := Export(schemas, ExportOptions{Title: "acme", Authority: schemair.SourceBackendStatic})
+		if doc.OpenAPI != "3.1.0" {
+			t.Errorf("Expected 3.1.0, got %s", doc.OpenAPI)
+		}
+
+		item := doc.Paths["/api/projects/{projectId}/endpoints/{endpointId}"]
+		if item == nil || item.Get == nil {
+			t.Fatalf("Expected named path template, got paths %v", doc.Paths)
+		}
+		if len(item.Parameters) != 2 || item.Parameters[0].Name != "projectId" {
+			t.Errorf("Expected declared path parameters, got %+v", item.Parameters)
+		}
+		if item.Get.Source != string(schemair.SourceBackendStatic) {
+			t.Errorf("Expected backend-static operation, got %s", item.Get.Source)
+		}
+
+		schema := item.Get.Responses["200"].Content["application/json"].Schema
+		if _, ok := schema.Properties["runtime_only"]; ok {
+			t.Errorf("Non-authoritative source leaked into the export")
+		}
+		if len(schema.Required) != 2 {
+			t.Errorf("Expected 2 required properties, got %v", schema.Required)
+		}
+		count := schema.Properties["count"]
+		if count.Confidence == nil || *count.Confidence != 0.8 {
+			t.Errorf("Expected x-cohesion-confidence 0.8, got %v", count.Confidence)
+		}
+		if tags := schema.Properties["tags"]; tags.Items == nil || tags.Items.Properties["label"] == nil {
+			t.Errorf("Expected array items to be exported")
+		}
+
+		if health := doc.Paths["/api/health"]; health == nil || health.Get.Source != string(schemair.SourceRuntime) {
+			t.Errorf("Expected runtime fallback for /api/health")
+		}
+	})
+
+	t.Run("Strict authority drops other sources", func(t *testing.T) {
+		doc := Export(schemas, ExportOptions{Authority: schemair.SourceBackendStatic, Strict: true})
+		if _, ok := doc.Paths["/api/health"]; ok {
+			t.Errorf("Expected /api/health to be omitted in strict mode")
+		}
+	})
+
+	t.Run("Round trip through the importer", func(t *testing.T) {
+		doc := Export(schemas, ExportOptions{Authority: schemair.SourceBackendStatic})
+		data, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		imported, err := Import(data)
+		if err != nil {
+			t.Fatalf("Re-import failed: %v", err)
+		}
+		get := findSchema(t, imported, "GET", "/api/projects/{projectId}/endpoints/{endpointId}")
+		if f := get.Response[200].Fields["id"]; f == nil || f.Type != "uuid" || !f.Required {
+			t.Errorf("Expected required uuid id after round trip, got %+v", f)
+		}
+	})
+}
+
+func TestExportScalarResponse(t *testing.T) {
+	doc := Export([]schemair.SchemaIR{{
+		Endpoint: "/api/version",
+		Method:   "GET",
+		Source:   schemair.SourceRuntime,
+		Response: map[int]*schemair.ObjectSchema{
+			200: {Type: "string", ContentType: "text/plain"},
+			204: {Type: "object"},
+		},
+	}}, ExportOptions{})
+
+	op := doc.Paths["/api/version"].Get
+	media := op.Responses["200"].Content["text/plain"]
+	if media == nil || len(media.Schema.Type) != 1 || media.Schema.Type[0] != "string" {
+		t.Errorf("Expected a text/plain string body, got %+v", op.Responses["200"].Content)
+	}
+	if op.Responses["204"].Content != nil {
+		t.Errorf("Expected no content for 204, got %+v", op.Responses["204"].Content)
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if strings.Contains(string(data), `"components"`) {
+		t.Errorf("Expected no empty components object, got %s", data)
+	}
+}
+
+func TestExportPathParamsAcrossMethods(t *testing.T) {
+	doc := Export([]schemair.SchemaIR{
+		{
+			Endpoint: "/api/users/{}",
+			Method:   "DELETE",
+			Source:   schemair.SourceBackendStatic,
+		},
+		{
+			Endpoint:   "/api/users/{}",
+			Method:     "GET",
+			Source:     schemair.SourceBackendStatic,
+			PathParams: []schemair.PathParam{{Name: "id", Field: schemair.Field{Type: "int", Required: true}}},
+		},
+	}, ExportOptions{})
+
+	item := doc.Paths["/api/users/{userId}"]
+	if item == nil || item.Get == nil || item.Delete == nil {
+		t.Fatalf("Expected GET and DELETE on one path, got %v", doc.Paths)
+	}
+	if len(item.Parameters) != 1 {
+		t.Fatalf("Expected one path parameter, got %+v", item.Parameters)
+	}
+	if typ := item.Parameters[0].Schema.Type; len(typ) != 1 || typ[0] != "integer" {
+		t.Errorf("Expected the GET operation's integer type, got %v", typ)
+	}
+}
@@ -0,0 +1,672 @@
+package openapi
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+// maxRefDepth bounds $ref/allOf expansion so recursive schemas terminate.
+const maxRefDepth = 16
+
+// Parse decodes a YAML or JSON OpenAPI 3.x document.
+func Parse(data []byte) (*Document, error) {
+	var doc Document
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q (expected 3.0 or 3.1)", doc.OpenAPI)
+	}
+	if len(doc.Paths) == 0 {
+		return nil, fmt.Errorf("OpenAPI document defines no paths")
+	}
+	return &doc, nil
+}
+
+// Import parses an OpenAPI 3.x document and converts every operation into a
+// SchemaIR tagged with schemair.SourceOpenAPI.
+func Import(data []byte) ([]schemair.SchemaIR, error) {
+	doc, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return NewImporter(doc).Convert()
+}
+
+type Importer struct {
+	doc        *Document
+	components *Components
+	basePath   string
+}
+
+func NewImporter(doc *Document) *Importer {
+	components := doc.Components
+	if components == nil {
+		components = &Components{}
+	}
+	return &Importer{
+		doc:        doc,
+		components: components,
+		basePath:   serverBasePath(doc.Servers),
+	}
+}
+
+// serverBasePath returns the path component of the first server URL, so
+// specs declared against e.g. https://api.example.com/v1 line up with the
+// routes found by static analysis.
+func serverBasePath(servers []Server) string {
+	if len(servers) == 0 {
+		return ""
+	}
+	raw := servers[0].URL
+	if strings.Contains(raw, "{") {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(u.Path, "/")
+}
+
+func (im *Importer) Convert() ([]schemair.SchemaIR, error) {
+	paths := make([]string, 0, len(im.doc.Paths))
+	for p := range im.doc.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var result []schemair.SchemaIR
+	for _, path := range paths {
+		item := im.doc.Paths[path]
+		if item == nil {
+			continue
+		}
+		if item.Ref != "" {
+			return nil, fmt.Errorf("path %s: external path item references are not supported", path)
+		}
+
+		ops := item.Operations()
+		methods := make([]string, 0, len(ops))
+		for m := range ops {
+			methods = append(methods, m)
+		}
+		sort.Strings(methods)
+
+		for _, method := range methods {
+			ir, err := im.convertOperation(im.basePath+path, method, item, ops[method])
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+			result = append(result, *ir)
+		}
+	}
+	return result, nil
+}
+
+func (im *Importer) convertOperation(path, method string, item *PathItem, op *Operation) (*schemair.SchemaIR, error) {
+	params, err := im.resolveParameters(item.Parameters, op.Parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	ir := &schemair.SchemaIR{
+		Endpoint: path,
+		Method:   method,
+		Source:   schemair.SourceOpenAPI,
+	}
+	if err := im.convertParameters(ir, params); err != nil {
+		return nil, err
+	}
+
+	if op.RequestBody != nil {
+		body, err := im.resolveRequestBody(op.RequestBody)
+		if err != nil {
+			return nil, err
+		}
+		if schema, mediaType := requestMediaSchema(body.Content); schema != nil {
+			obj, err := im.convertObject(schema, 0)
+			if err != nil {
+				return nil, fmt.Errorf("request body: %w", err)
+			}
+			obj.ContentType = mediaType
+			ir.Request = obj
+		}
+	}
+
+	// Explicit codes are converted before ranges so that, when both "200"
+	// and "2XX" are present, the explicit response is the one kept.
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		ri, rj := isStatusRange(codes[i]), isStatusRange(codes[j])
+		if ri != rj {
+			return rj
+		}
+		return codes[i] < codes[j]
+	})
+
+	for _, code := range codes {
+		resp := op.Responses[code]
+		status, ok := parseStatusCode(code)
+		if !ok {
+			continue
+		}
+		if _, exists := ir.Response[status]; exists && isStatusRange(code) {
+			continue
+		}
+		resolved, err := im.resolveResponse(resp)
+		if err != nil {
+			return nil, err
+		}
+		obj := &schemair.ObjectSchema{Type: "object"}
+		if schema := jsonMediaSchema(resolved.Content); schema != nil {
+			obj, err = im.convertObject(schema, 0)
+			if err != nil {
+				return nil, fmt.Errorf("response %s: %w", code, err)
+			}
+		}
+		if ir.Response == nil {
+			ir.Response = make(map[int]*schemair.ObjectSchema)
+		}
+		ir.Response[status] = obj
+	}
+
+	return ir, nil
+}
+
+// resolveParameters merges path-level and operation-level parameters, with
+// operation parameters overriding path parameters of the same name and location.
+func (im *Importer) resolveParameters(pathParams, opParams []*Parameter) ([]*Parameter, error) {
+	type paramKey struct{ name, in string }
+	merged := make(map[paramKey]*Parameter)
+	var order []paramKey
+
+	for _, list := range [][]*Parameter{pathParams, opParams} {
+		for _, p := range list {
+			resolved, err := im.resolveParameter(p)
+			if err != nil {
+				return nil, err
+			}
+			k := paramKey{resolved.Name, resolved.In}
+			if _, exists := merged[k]; !exists {
+				order = append(order, k)
+			}
+			merged[k] = resolved
+		}
+	}
+
+	params := make([]*Parameter, 0, len(order))
+	for _, k := range order {
+		params = append(params, merged[k])
+	}
+	return params, nil
+}
+
+// convertParameters fills the IR's path, query and header parameters. Path
+// parameters are ordered by their position in the path; any the spec fails
+// to declare are added as strings so positions still line up.
+func (im *Importer) convertParameters(ir *schemair.SchemaIR, params []*Parameter) error {
+	byName := make(map[string]*Parameter)
+	for _, p := range params {
+		var err error
+		switch p.In {
+		case "path":
+			byName[p.Name] = p
+		case "query":
+			if ir.QueryParams == nil {
+				ir.QueryParams = make(map[string]*schemair.Field)
+			}
+			ir.QueryParams[p.Name], err = im.convertField(p.Schema, p.Required, 0)
+		case "header":
+			name := strings.ToLower(p.Name)
+			// OpenAPI ignores these as parameters; they are described by
+			// content types and security schemes instead.
+			if name == "accept" || name == "content-type" || name == "authorization" {
+				continue
+			}
+			if ir.Headers == nil {
+				ir.Headers = make(map[string]*schemair.Field)
+			}
+			ir.Headers[name], err = im.convertField(p.Schema, p.Required, 0)
+		}
+		if err != nil {
+			return fmt.Errorf("parameter %s: %w", p.Name, err)
+		}
+	}
+
+	for _, seg := range strings.Split(ir.Endpoint, "/") {
+		if !strings.HasPrefix(seg, "{") || !strings.HasSuffix(seg, "}") {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(seg, "{"), "}")
+		param := schemair.PathParam{
+			Name:  name,
+			Field: schemair.Field{Type: "string", Required: true, Confidence: 1.0, SourceTag: schemair.SourceOpenAPI},
+		}
+		if p, ok := byName[name]; ok {
+			field, err := im.convertField(p.Schema, true, 0)
+			if err != nil {
+				return fmt.Errorf("parameter %s: %w", name, err)
+			}
+			param.Field = *field
+		}
+		ir.PathParams = append(ir.PathParams, param)
+	}
+	return nil
+}
+
+func (im *Importer) resolveParameter(p *Parameter) (*Parameter, error) {
+	for hops := 0; p != nil && p.Ref != ""; hops++ {
+		if hops > maxRefDepth {
+			return nil, fmt.Errorf("parameter reference cycle at %s", p.Ref)
+		}
+		name, err := refName(p.Ref, "parameters")
+		if err != nil {
+			return nil, err
+		}
+		next, ok := im.components.Parameters[name]
+		if !ok {
+			return nil, fmt.Errorf("unresolved reference %s", p.Ref)
+		}
+		p = next
+	}
+	if p == nil {
+		return nil, fmt.Errorf("empty parameter")
+	}
+	return p, nil
+}
+
+func (im *Importer) resolveRequestBody(b *RequestBody) (*RequestBody, error) {
+	for hops := 0; b.Ref != ""; hops++ {
+		if hops > maxRefDepth {
+			return nil, fmt.Errorf("request body reference cycle at %s", b.Ref)
+		}
+		name, err := refName(b.Ref, "requestBodies")
+		if err != nil {
+			return nil, err
+		}
+		next, ok := im.components.RequestBodies[name]
+		if !ok || next == nil {
+			return nil, fmt.Errorf("unresolved reference %s", b.Ref)
+		}
+		b = next
+	}
+	return b, nil
+}
+
+func (im *Importer) resolveResponse(r *Response) (*Response, error) {
+	if r == nil {
+		return &Response{}, nil
+	}
+	for hops := 0; r.Ref != ""; hops++ {
+		if hops > maxRefDepth {
+			return nil, fmt.Errorf("response reference cycle at %s", r.Ref)
+		}
+		name, err := refName(r.Ref, "responses")
+		if err != nil {
+			return nil, err
+		}
+		next, ok := im.components.Responses[name]
+		if !ok || next == nil {
+			return nil, fmt.Errorf("unresolved reference %s", r.Ref)
+		}
+		r = next
+	}
+	return r, nil
+}
+
+func (im *Importer) resolveSchema(s *Schema) (*Schema, error) {
+	for hops := 0; s != nil && s.Ref != ""; hops++ {
+		if hops > maxRefDepth {
+			return nil, fmt.Errorf("schema reference cycle at %s", s.Ref)
+		}
+		name, err := refName(s.Ref, "schemas")
+		if err != nil {
+			return nil, err
+		}
+		next, ok := im.components.Schemas[name]
+		if !ok || next == nil {
+			return nil, fmt.Errorf("unresolved reference %s", s.Ref)
+		}
+		s = next
+	}
+	return s, nil
+}
+
+func refName(ref, kind string) (string, error) {
+	prefix := "#/components/" + kind + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		return "", fmt.Errorf("unsupported reference %s (only local #/components/%s refs are resolved)", ref, kind)
+	}
+	name := strings.TrimPrefix(ref, prefix)
+	name = strings.ReplaceAll(name, "~1", "/")
+	name = strings.ReplaceAll(name, "~0", "~")
+	return name, nil
+}
+
+// flatten resolves refs and folds allOf/oneOf/anyOf compositions into a
+// single schema. allOf merges every member; for oneOf/anyOf a property is
+// only required when every variant requires it.
+func (im *Importer) flatten(s *Schema, hops int) (*Schema, error) {
+	s, err := im.resolveSchema(s)
+	if err != nil || s == nil {
+		return s, err
+	}
+	if len(s.AllOf) == 0 && len(s.OneOf) == 0 && len(s.AnyOf) == 0 {
+		return s, nil
+	}
+	if hops > maxRefDepth {
+		return nil, fmt.Errorf("schema composition cycle")
+	}
+
+	out := &Schema{
+		Type:       s.Type,
+		Format:     s.Format,
+		Nullable:   s.Nullable,
+		Items:      s.Items,
+		Enum:       s.Enum,
+		Pattern:    s.Pattern,
+		Minimum:    s.Minimum,
+		Maximum:    s.Maximum,
+		MinLength:  s.MinLength,
+		MaxLength:  s.MaxLength,
+		Properties: make(map[string]*Schema),
+	}
+	required := make(map[string]bool)
+	for name, prop := range s.Properties {
+		out.Properties[name] = prop
+	}
+	for _, name := range s.Required {
+		required[name] = true
+	}
+
+	for _, member := range s.AllOf {
+		m, err := im.flatten(member, hops+1)
+		if err != nil {
+			return nil, err
+		}
+		if m == nil {
+			continue
+		}
+		mergeInto(out, m)
+		for _, name := range m.Required {
+			required[name] = true
+		}
+	}
+
+	for _, variants := range [][]*Schema{s.OneOf, s.AnyOf} {
+		if len(variants) == 0 {
+			continue
+		}
+		counts := make(map[string]int)
+		for _, variant := range variants {
+			v, err := im.flatten(variant, hops+1)
+			if err != nil {
+				return nil, err
+			}
+			if v == nil {
+				continue
+			}
+			mergeInto(out, v)
+			for _, name := range v.Required {
+				counts[name]++
+			}
+		}
+		for name, n := range counts {
+			if n == len(variants) {
+				required[name] = true
+			}
+		}
+	}
+
+	for name := range required {
+		out.Required = append(out.Required, name)
+	}
+	sort.Strings(out.Required)
+	if len(out.Type) == 0 && len(out.Properties) > 0 {
+		out.Type = SchemaType{"object"}
+	}
+	return out, nil
+}
+
+func mergeInto(dst, src *Schema) {
+	if len(dst.Type) == 0 {
+		dst.Type = src.Type
+	}
+	if dst.Format == "" {
+		dst.Format = src.Format
+	}
+	if dst.Items == nil {
+		dst.Items = src.Items
+	}
+	if dst.Enum == nil {
+		dst.Enum = src.Enum
+	}
+	if dst.Pattern == "" {
+		dst.Pattern = src.Pattern
+	}
+	if dst.Minimum == nil {
+		dst.Minimum = src.Minimum
+	}
+	if dst.Maximum == nil {
+		dst.Maximum = src.Maximum
+	}
+	if dst.MinLength == nil {
+		dst.MinLength = src.MinLength
+	}
+	if dst.MaxLength == nil {
+		dst.MaxLength = src.MaxLength
+	}
+	for name, prop := range src.Properties {
+		if _, exists := dst.Properties[name]; !exists {
+			dst.Properties[name] = prop
+		}
+	}
+}
+
+func (im *Importer) convertObject(s *Schema, depth int) (*schemair.ObjectSchema, error) {
+	flat, err := im.flatten(s, 0)
+	if err != nil {
+		return nil, err
+	}
+	if flat == nil {
+		return &schemair.ObjectSchema{Type: "object"}, nil
+	}
+
+	typ := irType(flat)
+	obj := &schemair.ObjectSchema{Type: typ}
+	if depth > maxRefDepth {
+		return obj, nil
+	}
+
+	switch typ {
+	case "array":
+		if flat.Items != nil {
+			items, err := im.convertObject(flat.Items, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			obj.Items = items
+		}
+	case "object":
+		if len(flat.Properties) == 0 {
+			return obj, nil
+		}
+		required := make(map[string]bool, len(flat.Required))
+		for _, name := range flat.Required {
+			required[name] = true
+		}
+		obj.Fields = make(map[string]*schemair.Field, len(flat.Properties))
+		for name, prop := range flat.Properties {
+			field, err := im.convertField(prop, required[name], depth+1)
+			if err != nil {
+				return nil, fmt.Errorf("property %s: %w", name, err)
+			}
+			obj.Fields[name] = field
+		}
+	}
+	return obj, nil
+}
+
+func (im *Importer) convertField(s *Schema, required bool, depth int) (*schemair.Field, error) {
+	flat, err := im.flatten(s, 0)
+	if err != nil {
+		return nil, err
+	}
+	field := &schemair.Field{
+		Type:       "any",
+		Required:   required,
+		Confidence: 1.0,
+		SourceTag:  schemair.SourceOpenAPI,
+	}
+	if flat == nil {
+		return field, nil
+	}
+
+	field.Type = irType(flat)
+	applyConstraints(field, flat)
+	if depth > maxRefDepth {
+		return field, nil
+	}
+
+	switch field.Type {
+	case "object":
+		if len(flat.Properties) > 0 {
+			nested, err := im.convertObject(flat, depth)
+			if err != nil {
+				return nil, err
+			}
+			field.Nested = nested
+		}
+	case "array":
+		if flat.Items != nil {
+			items, err := im.convertObject(flat.Items, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			field.Nested = &schemair.ObjectSchema{Type: "array", Items: items}
+		}
+	}
+	return field, nil
+}
+
+// applyConstraints copies the value constraints of s onto field. Formats
+// already expressed by the IR type, such as uuid, are not repeated.
+func applyConstraints(field *schemair.Field, s *Schema) {
+	_, nullType := s.Type.Primary()
+	field.Nullable = s.Nullable || nullType
+	if s.Format != "" && field.Type != s.Format && field.Type != "time" && field.Type != "float" {
+		field.Format = s.Format
+	}
+	field.Enum = s.Enum
+	field.Pattern = s.Pattern
+	field.Minimum = s.Minimum
+	field.Maximum = s.Maximum
+	field.MinLength = s.MinLength
+	field.MaxLength = s.MaxLength
+}
+
+// irType maps an OpenAPI type/format pair onto the type vocabulary used by
+// the analyzers, so documented and extracted contracts compare cleanly.
+func irType(s *Schema) string {
+	typ, _ := s.Type.Primary()
+	if typ == "" {
+		switch {
+		case len(s.Properties) > 0:
+			typ = "object"
+		case s.Items != nil:
+			typ = "array"
+		default:
+			return "any"
+		}
+	}
+
+	switch typ {
+	case "string":
+		switch s.Format {
+		case "uuid":
+			return "uuid"
+		case "date-time", "date":
+			return "time"
+		}
+		return "string"
+	case "integer":
+		return "int"
+	case "number":
+		switch s.Format {
+		case "float", "double":
+			return "float"
+		}
+		return "number"
+	case "boolean":
+		return "bool"
+	case "object", "array":
+		return typ
+	default:
+		return "any"
+	}
+}
+
+// jsonMediaSchema picks the schema of the JSON media type, falling back to
+// any +json vendor type.
+func jsonMediaSchema(content map[string]*MediaType) *Schema {
+	if mt, ok := content["application/json"]; ok && mt != nil {
+		return mt.Schema
+	}
+	keys := make([]string, 0, len(content))
+	for k := range content {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		base := strings.TrimSpace(strings.SplitN(k, ";", 2)[0])
+		if (base == "application/json" || strings.HasSuffix(base, "+json")) && content[k] != nil {
+			return content[k].Schema
+		}
+	}
+	return nil
+}
+
+// requestMediaSchema prefers a JSON request body and falls back to form and
+// multipart bodies, returning their media type.
+func requestMediaSchema(content map[string]*MediaType) (*Schema, string) {
+	if schema := jsonMediaSchema(content); schema != nil {
+		return schema, ""
+	}
+	for _, mediaType := range []string{"application/x-www-form-urlencoded", "multipart/form-data"} {
+		if mt, ok := content[mediaType]; ok && mt != nil && mt.Schema != nil {
+			return mt.Schema, mediaType
+		}
+	}
+	return nil, ""
+}
+
+// parseStatusCode accepts explicit codes and range keys like "2XX" (mapped
+// to 200). "default" has no concrete status and is skipped.
+func parseStatusCode(code string) (int, bool) {
+	code = strings.TrimSpace(code)
+	if isStatusRange(code) {
+		return int(code[0]-'0') * 100, true
+	}
+	n, err := strconv.Atoi(code)
+	if err != nil || n < 100 || n > 599 {
+		return 0, false
+	}
+	return n, true
+}
+
+// isStatusRange reports whether code is a range such as "2XX".
+func isStatusRange(code string) bool {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	return len(code) == 3 && strings.HasSuffix(code, "XX") && code[0] >= '1' && code[0] <= '5'
+}
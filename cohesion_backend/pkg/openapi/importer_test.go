@@ -0,0 +1,340 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+const petstoreYAML = `
+openapi: 3.0.3
+info:
+  title: Pets
+  version: "1.0"
+servers:
+  - url: https://api.example.com/api
+paths:
+  /pets/{petId}:
+    parameters:
+      - $ref: '#/components/parameters/PetId'
+    get:
+      parameters:
+        - name: fields
+          in: query
+          schema: {type: array, items: {type: string}}
+        - name: X-Request-Id
+          in: header
+          required: true
+          schema: {type: string}
+        - name: Accept
+          in: header
+          schema: {type: string}
+      responses:
+        200:
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+        404:
+          $ref: '#/components/responses/NotFound'
+        default:
+          description: error
+    put:
+      requestBody:
+        $ref: '#/components/requestBodies/PetBody'
+      responses:
+        "204":
+          description: updated
+components:
+  parameters:
+    PetId:
+      name: petId
+      in: path
+      required: true
+      schema:
+        type: string
+        format: uuid
+  requestBodies:
+    PetBody:
+      content:
+        application/json:
+          schema:
+            $ref: '#/components/schemas/NewPet'
+  responses:
+    NotFound:
+      description: missing
+      content:
+        application/problem+json:
+          schema:
+            type: object
+            required: [message]
+            properties:
+              message:
+                type: string
+  schemas:
+    NewPet:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+          maxLength: 50
+        tag:
+          type: string
+          enum: [cat, dog]
+    Pet:
+      allOf:
+        - $ref: '#/components/schemas/NewPet'
+        - type: object
+          required: [id]
+          properties:
+            id:
+              type: string
+              format: uuid
+            born_at:
+              type: string
+              format: date-time
+            owner:
+              oneOf:
+                - type: object
+                  required: [kind, id]
+                  properties:
+                    kind: {type: string}
+                    id: {type: integer}
+                - type: object
+                  required: [kind]
+                  properties:
+                    kind: {type: string}
+                    email: {type: string}
+            toys:
+              type: array
+              items:
+                type: object
+                properties:
+                  price: {type: number, format: double}
+`
+
+func findSchema(t *testing.T, schemas []schemair.SchemaIR, method, path string) schemair.SchemaIR {
+	t.Helper()
+	for _, s := range schemas {
+		if s.Method == method && s.Endpoint == path {
+			return s
+		}
+	}
+	t.Fatalf("no schema for %s %s", method, path)
+	return schemair.SchemaIR{}
+}
+
+func TestImport(t *testing.T) {
+	schemas, err := Import([]byte(petstoreYAML))
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if len(schemas) != 2 {
+		t.Fatalf("Expected 2 operations, got %d", len(schemas))
+	}
+
+	t.Run("Server base path and source", func(t *testing.T) {
+		get := findSchema(t, schemas, "GET", "/api/pets/{petId}")
+		if get.Source != schemair.SourceOpenAPI {
+			t.Errorf("Expected source %s, got %s", schemair.SourceOpenAPI, get.Source)
+		}
+	})
+
+	t.Run("allOf merges properties and required", func(t *testing.T) {
+		get := findSchema(t, schemas, "GET", "/api/pets/{petId}")
+		resp := get.Response[200]
+		if resp == nil {
+			t.Fatalf("Expected 200 response")
+		}
+		for name, want := range map[string]struct {
+			typ      string
+			required bool
+		}{
+			"id":      {"uuid", true},
+			"name":    {"string", true},
+			"tag":     {"string", false},
+			"born_at": {"time", false},
+			"toys":    {"array", false},
+		} {
+			f := resp.Fields[name]
+			if f == nil {
+				t.Errorf("Missing field %s", name)
+				continue
+			}
+			if f.Type != want.typ || f.Required != want.required {
+				t.Errorf("Field %s: got (%s, %v), want (%s, %v)", name, f.Type, f.Required, want.typ, want.required)
+			}
+		}
+	})
+
+	t.Run("Constraints are carried over", func(t *testing.T) {
+		fields := findSchema(t, schemas, "GET", "/api/pets/{petId}").Response[200].Fields
+		if name := fields["name"]; name.MaxLength == nil || *name.MaxLength != 50 {
+			t.Errorf("Expected max_length 50 on name, got %+v", name.MaxLength)
+		}
+		if tag := fields["tag"]; len(tag.Enum) != 2 || tag.Enum[0] != "cat" {
+			t.Errorf("Expected enum [cat dog] on tag, got %v", tag.Enum)
+		}
+		if id := fields["id"]; id.Format != "" {
+			t.Errorf("Expected uuid format to be folded into the type, got %q", id.Format)
+		}
+	})
+
+	t.Run("oneOf requires only fields common to all variants", func(t *testing.T) {
+		owner := findSchema(t, schemas, "GET", "/api/pets/{petId}").Response[200].Fields["owner"]
+		if owner == nil || owner.Nested == nil {
+			t.Fatalf("Expected nested owner object")
+		}
+		if !owner.Nested.Fields["kind"].Required {
+			t.Errorf("Expected kind to be required")
+		}
+		if owner.Nested.Fields["id"].Required || owner.Nested.Fields["email"].Required {
+			t.Errorf("Expected variant-specific fields to be optional")
+		}
+	})
+
+	t.Run("Array items are converted", func(t *testing.T) {
+		toys := findSchema(t, schemas, "GET", "/api/pets/{petId}").Response[200].Fields["toys"]
+		if toys.Nested == nil || toys.Nested.Items == nil {
+			t.Fatalf("Expected array items schema")
+		}
+		if price := toys.Nested.Items.Fields["price"]; price == nil || price.Type != "float" {
+			t.Errorf("Expected float price in items, got %+v", price)
+		}
+	})
+
+	t.Run("Response refs and default", func(t *testing.T) {
+		get := findSchema(t, schemas, "GET", "/api/pets/{petId}")
+		if len(get.Response) != 2 {
+			t.Errorf("Expected 200 and 404 only, got %d responses", len(get.Response))
+		}
+		if msg := get.Response[404].Fields["message"]; msg == nil || !msg.Required {
+			t.Errorf("Expected required message in 404 response")
+		}
+	})
+
+	t.Run("Parameters", func(t *testing.T) {
+		get := findSchema(t, schemas, "GET", "/api/pets/{petId}")
+		if len(get.PathParams) != 1 || get.PathParams[0].Name != "petId" || get.PathParams[0].Type != "uuid" {
+			t.Errorf("Expected uuid petId path param, got %+v", get.PathParams)
+		}
+		if f := get.QueryParams["fields"]; f == nil || f.Type != "array" || f.Required {
+			t.Errorf("Expected optional array query param, got %+v", f)
+		}
+		if f := get.Headers["x-request-id"]; f == nil || !f.Required {
+			t.Errorf("Expected required lowercase header, got %+v", get.Headers)
+		}
+		if _, ok := get.Headers["accept"]; ok {
+			t.Errorf("Expected Accept header to be skipped")
+		}
+	})
+
+	t.Run("Request body refs", func(t *testing.T) {
+		put := findSchema(t, schemas, "PUT", "/api/pets/{petId}")
+		if put.Request == nil || put.Request.Fields["name"] == nil {
+			t.Fatalf("Expected request body with name field")
+		}
+		if resp, ok := put.Response[204]; !ok || len(resp.Fields) != 0 {
+			t.Errorf("Expected empty 204 response")
+		}
+	})
+}
+
+func TestImport_JSON31(t *testing.T) {
+	spec := `{
+  "openapi": "3.1.0",
+  "info": {"title": "t", "version": "1"},
+  "paths": {
+    "/users": {
+      "get": {
+        "responses": {
+          "2XX": {
+            "description": "ok",
+            "content": {"application/json": {"schema": {
+              "type": "array",
+              "items": {"type": "object", "required": ["nickname"], "properties": {
+                "nickname": {"type": ["string", "null"]},
+                "node": {"$ref": "#/components/schemas/Node"}
+              }}
+            }}}
+          }
+        }
+      }
+    }
+  },
+  "components": {"schemas": {"Node": {"type": "object", "properties": {
+    "child": {"$ref": "#/components/schemas/Node"}
+  }}}}
+}`
+
+	schemas, err := Import([]byte(spec))
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	resp := findSchema(t, schemas, "GET", "/users").Response[200]
+	if resp == nil || resp.Type != "array" || resp.Items == nil {
+		t.Fatalf("Expected array response for 2XX, got %+v", resp)
+	}
+	if nick := resp.Items.Fields["nickname"]; nick == nil || nick.Type != "string" || !nick.Nullable {
+		t.Errorf("Expected nullable string nickname, got %+v", nick)
+	}
+	if resp.Items.Fields["node"] == nil {
+		t.Errorf("Expected recursive node field to be converted")
+	}
+}
+
+func TestImport_ExplicitStatusBeatsRange(t *testing.T) {
+	spec := `
+openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /users:
+    get:
+      responses:
+        2XX:
+          description: any success
+          content:
+            application/json:
+              schema: {type: object, properties: {fallback: {type: string}}}
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {type: object, properties: {id: {type: string}}}
+        4xx:
+          description: client error
+          content:
+            application/json:
+              schema: {type: object, properties: {error: {type: string}}}
+`
+	for i := 0; i < 20; i++ {
+		schemas, err := Import([]byte(spec))
+		if err != nil {
+			t.Fatalf("Import failed: %v", err)
+		}
+		ir := findSchema(t, schemas, "GET", "/users")
+		if ok := ir.Response[200]; ok == nil || ok.Fields["id"] == nil || ok.Fields["fallback"] != nil {
+			t.Fatalf("Expected explicit 200 response to win over 2XX, got %+v", ok)
+		}
+		if clientErr := ir.Response[400]; clientErr == nil || clientErr.Fields["error"] == nil {
+			t.Fatalf("Expected 4xx range mapped to 400, got %+v", clientErr)
+		}
+	}
+}
+
+func TestImport_Errors(t *testing.T) {
+	cases := map[string]string{
+		"swagger 2":      "swagger: '2.0'\npaths: {}\n",
+		"no paths":       "openapi: 3.0.0\ninfo: {title: t, version: '1'}\n",
+		"unresolved ref": "openapi: 3.0.0\npaths:\n  /a:\n    get:\n      responses:\n        '200':\n          $ref: '#/components/responses/Missing'\n",
+	}
+	for name, spec := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Import([]byte(spec)); err == nil {
+				t.Errorf("Expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is the subset of an OpenAPI 3.0/3.1 document that Cohesion reads.
+// YAML and JSON specs are both decoded through yaml.v3, since JSON is valid YAML.
+type Document struct {
+	OpenAPI    string               `yaml:"openapi" json:"openapi"`
+	Info       Info                 `yaml:"info" json:"info"`
+	Servers    []Server             `yaml:"servers,omitempty" json:"servers,omitempty"`
+	Paths      map[string]*PathItem `yaml:"paths" json:"paths"`
+	Components *Components          `yaml:"components,omitempty" json:"components,omitempty"`
+
+	// Warnings lists endpoints left out of the document because their
+	// stored schemas could not be read.
+	Warnings []string `yaml:"x-cohesion-warnings,omitempty" json:"x-cohesion-warnings,omitempty"`
+}
+
+type Info struct {
+	Title       string `yaml:"title" json:"title"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Version     string `yaml:"version" json:"version"`
+}
+
+type Server struct {
+	URL string `yaml:"url" json:"url"`
+}
+
+type Components struct {
+	Schemas       map[string]*Schema      `yaml:"schemas,omitempty" json:"schemas,omitempty"`
+	Parameters    map[string]*Parameter   `yaml:"parameters,omitempty" json:"parameters,omitempty"`
+	RequestBodies map[string]*RequestBody `yaml:"requestBodies,omitempty" json:"requestBodies,omitempty"`
+	Responses     map[string]*Response    `yaml:"responses,omitempty" json:"responses,omitempty"`
+}
+
+type PathItem struct {
+	Ref        string       `yaml:"$ref,omitempty" json:"$ref,omitempty"`
+	Parameters []*Parameter `yaml:"parameters,omitempty" json:"parameters,omitempty"`
+	Get        *Operation   `yaml:"get,omitempty" json:"get,omitempty"`
+	Put        *Operation   `yaml:"put,omitempty" json:"put,omitempty"`
+	Post       *Operation   `yaml:"post,omitempty" json:"post,omitempty"`
+	Delete     *Operation   `yaml:"delete,omitempty" json:"delete,omitempty"`
+	Options    *Operation   `yaml:"options,omitempty" json:"options,omitempty"`
+	Head       *Operation   `yaml:"head,omitempty" json:"head,omitempty"`
+	Patch      *Operation   `yaml:"patch,omitempty" json:"patch,omitempty"`
+}
+
+// Operations returns the operations defined on the path item keyed by
+// uppercase HTTP method.
+func (p *PathItem) Operations() map[string]*Operation {
+	ops := make(map[string]*Operation)
+	for method, op := range map[string]*Operation{
+		"GET": p.Get, "PUT": p.Put, "POST": p.Post, "DELETE": p.Delete,
+		"OPTIONS": p.Options, "HEAD": p.Head, "PATCH": p.Patch,
+	} {
+		if op != nil {
+			ops[method] = op
+		}
+	}
+	return ops
+}
+
+type Operation struct {
+	OperationID string               `yaml:"operationId,omitempty" json:"operationId,omitempty"`
+	Summary     string               `yaml:"summary,omitempty" json:"summary,omitempty"`
+	Parameters  []*Parameter         `yaml:"parameters,omitempty" json:"parameters,omitempty"`
+	RequestBody *RequestBody         `yaml:"requestBody,omitempty" json:"requestBody,omitempty"`
+	Responses   map[string]*Response `yaml:"responses" json:"responses"`
+
+	// Source records which Cohesion source the operation was rendered from.
+	Source string `yaml:"x-cohesion-source,omitempty" json:"x-cohesion-source,omitempty"`
+}
+
+type Parameter struct {
+	Ref      string  `yaml:"$ref,omitempty" json:"$ref,omitempty"`
+	Name     string  `yaml:"name,omitempty" json:"name,omitempty"`
+	In       string  `yaml:"in,omitempty" json:"in,omitempty"`
+	Required bool    `yaml:"required,omitempty" json:"required,omitempty"`
+	Schema   *Schema `yaml:"schema,omitempty" json:"schema,omitempty"`
+}
+
+type RequestBody struct {
+	Ref      string                `yaml:"$ref,omitempty" json:"$ref,omitempty"`
+	Required bool                  `yaml:"required,omitempty" json:"required,omitempty"`
+	Content  map[string]*MediaType `yaml:"content,omitempty" json:"content,omitempty"`
+}
+
+type Response struct {
+	Ref         string                `yaml:"$ref,omitempty" json:"$ref,omitempty"`
+	Description string                `yaml:"description" json:"description"`
+	Content     map[string]*MediaType `yaml:"content,omitempty" json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `yaml:"schema,omitempty" json:"schema,omitempty"`
+}
+
+type Schema struct {
+	Ref                  string             `yaml:"$ref,omitempty" json:"$ref,omitempty"`
+	Type                 SchemaType         `yaml:"type,omitempty" json:"type,omitempty"`
+	Format               string             `yaml:"format,omitempty" json:"format,omitempty"`
+	Nullable             bool               `yaml:"nullable,omitempty" json:"nullable,omitempty"`
+	Properties           map[string]*Schema `yaml:"properties,omitempty" json:"properties,omitempty"`
+	Required             []string           `yaml:"required,omitempty" json:"required,omitempty"`
+	Items                *Schema            `yaml:"items,omitempty" json:"items,omitempty"`
+	AllOf                []*Schema          `yaml:"allOf,omitempty" json:"allOf,omitempty"`
+	OneOf                []*Schema          `yaml:"oneOf,omitempty" json:"oneOf,omitempty"`
+	AnyOf                []*Schema          `yaml:"anyOf,omitempty" json:"anyOf,omitempty"`
+	AdditionalProperties interface{}        `yaml:"additionalProperties,omitempty" json:"additionalProperties,omitempty"`
+	Enum                 []interface{}      `yaml:"enum,omitempty" json:"enum,omitempty"`
+	Pattern              string             `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+	Minimum              *float64           `yaml:"minimum,omitempty" json:"minimum,omitempty"`
+	Maximum              *float64           `yaml:"maximum,omitempty" json:"maximum,omitempty"`
+	MinLength            *int               `yaml:"minLength,omitempty" json:"minLength,omitempty"`
+	MaxLength            *int               `yaml:"maxLength,omitempty" json:"maxLength,omitempty"`
+
+	// Confidence carries schemair.Field.Confidence on exported properties.
+	Confidence *float64 `yaml:"x-cohesion-confidence,omitempty" json:"x-cohesion-confidence,omitempty"`
+}
+
+// SchemaType holds the "type" keyword, which is a single string in OpenAPI
+// 3.0 and may be a list such as ["string", "null"] in 3.1.
+type SchemaType []string
+
+func (t *SchemaType) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		*t = SchemaType{node.Value}
+		return nil
+	case yaml.SequenceNode:
+		var list []string
+		if err := node.Decode(&list); err != nil {
+			return err
+		}
+		*t = list
+		return nil
+	default:
+		return fmt.Errorf("line %d: schema type must be a string or list of strings", node.Line)
+	}
+}
+
+func (t SchemaType) MarshalYAML() (interface{}, error) {
+	if len(t) == 1 {
+		return t[0], nil
+	}
+	return []string(t), nil
+}
+
+func (t SchemaType) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// Primary returns the first non-null type and whether "null" was listed.
+func (t SchemaType) Primary() (string, bool) {
+	primary := ""
+	nullable := false
+	for _, typ := range t {
+		if typ == "null" {
+			nullable = true
+			continue
+		}
+		if primary == "" {
+			primary = typ
+		}
+	}
+	return primary, nullable
+}
@@ -0,0 +1,156 @@
+package runtime
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"strings"
+)
+
+// maxMultipartValue bounds how much of a non-file multipart value is kept.
+const maxMultipartValue = 4096
+
+// DecodeBody decodes a captured body according to its content type. JSON of
+// any shape is returned as decoded; NDJSON becomes an array of its records,
+// form and multipart bodies become an object keyed by field name, and plain
+// text a string. Bodies that cannot be decoded return nil.
+func DecodeBody(contentType string, body []byte) interface{} {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		return decodeForm(body)
+	case "multipart/form-data":
+		return decodeMultipart(body, params["boundary"])
+	case "application/x-ndjson", "application/jsonl", "application/jsonlines", "application/json-seq":
+		return decodeNDJSON(body)
+	case "text/plain":
+		return string(body)
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err == nil {
+		return v
+	}
+	if mediaType == "" {
+		return decodeNDJSON(body)
+	}
+	return nil
+}
+
+// NormalizeBody decodes bodies that were submitted as strings, as ingest
+// clients often forward the raw text, and leaves decoded values untouched.
+func NormalizeBody(contentType string, body interface{}) interface{} {
+	s, ok := body.(string)
+	if !ok {
+		return body
+	}
+	if decoded := DecodeBody(contentType, []byte(s)); decoded != nil {
+		return decoded
+	}
+	return s
+}
+
+// BodyContentType returns the media type of a body the IR should record,
+// or "" for JSON, which is the default.
+func BodyContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+		return ""
+	}
+	return mediaType
+}
+
+func decodeForm(body []byte) interface{} {
+	values, err := url.ParseQuery(string(body))
+	if err != nil || len(values) == 0 {
+		return nil
+	}
+	out := make(map[string]interface{}, len(values))
+	for name, vals := range values {
+		out[name] = formValue(vals)
+	}
+	return out
+}
+
+// decodeMultipart keeps every part's field name. File parts are recorded
+// by file name, matching OpenAPI's string/binary representation of uploads.
+func decodeMultipart(body []byte, boundary string) interface{} {
+	if boundary == "" {
+		return nil
+	}
+	values := make(map[string][]string)
+	var order []string
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+		name := part.FormName()
+		if name == "" {
+			part.Close()
+			continue
+		}
+		value := part.FileName()
+		if value == "" {
+			raw, _ := io.ReadAll(io.LimitReader(part, maxMultipartValue))
+			value = string(raw)
+		}
+		part.Close()
+		if _, ok := values[name]; !ok {
+			order = append(order, name)
+		}
+		values[name] = append(values[name], value)
+	}
+	if len(order) == 0 {
+		return nil
+	}
+	out := make(map[string]interface{}, len(order))
+	for _, name := range order {
+		out[name] = formValue(values[name])
+	}
+	return out
+}
+
+func formValue(vals []string) interface{} {
+	if len(vals) == 1 {
+		return vals[0]
+	}
+	items := make([]interface{}, len(vals))
+	for i, v := range vals {
+		items[i] = v
+	}
+	return items
+}
+
+// decodeNDJSON decodes one JSON value per line. Any undecodable line makes
+// the whole body undecodable, so arbitrary text is not mistaken for NDJSON.
+func decodeNDJSON(body []byte) interface{} {
+	var records []interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(body)+1)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		line = bytes.TrimPrefix(line, []byte{0x1e})
+		if len(line) == 0 {
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal(line, &v); err != nil {
+			return nil
+		}
+		records = append(records, v)
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	return records
+}
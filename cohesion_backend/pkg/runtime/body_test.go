@@ -0,0 +1,46 @@
+package runtime
+
+import (
+	"bytes"
+	"mime/multipart"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeBody(t *testing.T) {
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	mw.WriteField("title", "Report")
+	fw, _ := mw.CreateFormFile("attachment", "q3.pdf")
+	fw.Write([]byte("%PDF-1.7"))
+	mw.Close()
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        interface{}
+	}{
+		{"JSON array", "application/json", `[{"id":1},{"id":2}]`,
+			[]interface{}{map[string]interface{}{"id": 1.0}, map[string]interface{}{"id": 2.0}}},
+		{"JSON scalar", "application/json; charset=utf-8", `"ok"`, "ok"},
+		{"NDJSON", "application/x-ndjson", "{\"a\":1}\n{\"a\":2}\n",
+			[]interface{}{map[string]interface{}{"a": 1.0}, map[string]interface{}{"a": 2.0}}},
+		{"NDJSON without content type", "", "{\"a\":1}\n{\"a\":2}",
+			[]interface{}{map[string]interface{}{"a": 1.0}, map[string]interface{}{"a": 2.0}}},
+		{"Form", "application/x-www-form-urlencoded", "name=ada&tag=x&tag=y",
+			map[string]interface{}{"name": "ada", "tag": []interface{}{"x", "y"}}},
+		{"Multipart", mw.FormDataContentType(), form.String(),
+			map[string]interface{}{"title": "Report", "attachment": "q3.pdf"}},
+		{"Plain text", "text/plain", "pong", "pong"},
+		{"HTML", "text/html", "<p>hi</p>", nil},
+		{"Empty", "application/json", "  ", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DecodeBody(tt.contentType, []byte(tt.body)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
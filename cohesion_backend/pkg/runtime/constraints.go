@@ -0,0 +1,124 @@
+package runtime
+
+import (
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"time"
+)
+
+const (
+	// A string field is only treated as an enum once it has been seen at
+	// least minEnumSamples times with few, short, repeating values.
+	minEnumSamples     = 20
+	maxEnumValues      = 10
+	maxEnumValueLength = 32
+	minEnumRepeats     = 3
+)
+
+var uuidRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// valueStats tracks the string values observed for one field.
+type valueStats struct {
+	samples int
+	formats map[string]int
+	values  map[string]int
+	// enumable turns false once the values are too many or too long for
+	// the field to be an enum.
+	enumable bool
+}
+
+func newValueStats() *valueStats {
+	return &valueStats{
+		formats:  make(map[string]int),
+		values:   make(map[string]int),
+		enumable: true,
+	}
+}
+
+func (st *valueStats) add(s string, hits int) {
+	st.samples += hits
+	st.formats[detectFormat(s)] += hits
+
+	if !st.enumable {
+		return
+	}
+	// Masked values say nothing about the set of real values.
+	if s == MaskedValue {
+		st.enumable = false
+		st.values = nil
+		return
+	}
+	st.values[s] += hits
+	if len(st.values) > maxEnumValues || len(s) > maxEnumValueLength {
+		st.enumable = false
+		st.values = nil
+	}
+}
+
+// format returns the format every observed value matched, if any.
+func (st *valueStats) format() string {
+	if len(st.formats) != 1 {
+		return ""
+	}
+	for f := range st.formats {
+		return f
+	}
+	return ""
+}
+
+// enum returns the observed values, sorted, when there are few enough of
+// them and each repeats often enough to look like a closed set.
+func (st *valueStats) enum() []interface{} {
+	if !st.enumable || st.samples < minEnumSamples || st.samples < minEnumRepeats*len(st.values) {
+		return nil
+	}
+	values := make([]string, 0, len(st.values))
+	for v := range st.values {
+		if v == "" {
+			return nil
+		}
+		values = append(values, v)
+	}
+	sort.Strings(values)
+
+	out := make([]interface{}, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
+
+// detectFormat recognises the string formats the diff engine and OpenAPI
+// exporter understand. It returns "" for anything else.
+func detectFormat(s string) string {
+	switch {
+	case uuidRegex.MatchString(s):
+		return "uuid"
+	case isTime(time.RFC3339Nano, s):
+		return "date-time"
+	case len(s) == len("2006-01-02") && isTime("2006-01-02", s):
+		return "date"
+	case isEmail(s):
+		return "email"
+	case isURI(s):
+		return "uri"
+	}
+	return ""
+}
+
+func isTime(layout, s string) bool {
+	_, err := time.Parse(layout, s)
+	return err == nil
+}
+
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
+
+func isURI(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
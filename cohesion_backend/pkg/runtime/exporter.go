@@ -0,0 +1,408 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrExporterClosed is returned by Flush after Shutdown.
+var ErrExporterClosed = errors.New("exporter is shut down")
+
+// ExporterConfig configures an Exporter. Only Endpoint, ProjectID and Token
+// are required.
+type ExporterConfig struct {
+	// Endpoint is the base URL of the control plane, e.g.
+	// https://cohesion.example.com.
+	Endpoint  string
+	ProjectID string
+	// Token authenticates ingest requests as a bearer token.
+	Token string
+	// Source labels the captures, "sdk" by default.
+	Source string
+
+	// BatchSize is the most captures sent in one request (default 100).
+	BatchSize int
+	// FlushInterval is the longest a capture waits for a batch to fill
+	// (default 5s).
+	FlushInterval time.Duration
+	// QueueSize bounds the captures waiting to be sent (default 10000).
+	// Captures exported while the queue is full are dropped.
+	QueueSize int
+
+	// MaxRetries is how often a failed batch is retried before it is
+	// dropped (default 5; negative disables retries). Backoff doubles
+	// from InitialBackoff (default 500ms) up to MaxBackoff (default 30s),
+	// with jitter, or follows the server's Retry-After.
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	HTTPClient *http.Client
+	// OnError is called with every failed attempt. By default errors are
+	// logged.
+	OnError func(error)
+}
+
+// ExporterStats counts captures by outcome.
+type ExporterStats struct {
+	// Sent captures were accepted by the control plane.
+	Sent uint64 `json:"sent"`
+	// Dropped captures were exported while the queue was full or after
+	// shutdown.
+	Dropped uint64 `json:"dropped"`
+	// Failed captures were in batches rejected by the control plane or
+	// still failing after all retries.
+	Failed uint64 `json:"failed"`
+	// Retries counts retried batch requests.
+	Retries uint64 `json:"retries"`
+	// Queued captures are waiting to be sent.
+	Queued int `json:"queued"`
+}
+
+// ingestCapture is the wire format of one capture on /api/live/ingest.
+type ingestCapture struct {
+	ID           string              `json:"id"`
+	Timestamp    time.Time           `json:"timestamp"`
+	Path         string              `json:"path"`
+	Method       string              `json:"method"`
+	StatusCode   int                 `json:"status_code"`
+	DurationMs   float64             `json:"duration_ms"`
+	RequestBody  interface{}         `json:"request_body,omitempty"`
+	ResponseBody interface{}         `json:"response_body,omitempty"`
+	Source       string              `json:"source,omitempty"`
+	QueryParams  map[string][]string `json:"query_params,omitempty"`
+	Headers      map[string]string   `json:"headers,omitempty"`
+
+	RequestContentType  string `json:"request_content_type,omitempty"`
+	ResponseContentType string `json:"response_content_type,omitempty"`
+}
+
+type flushRequest struct {
+	done chan error
+}
+
+// Exporter ships captures to the control plane's ingest endpoint in
+// batches from a background goroutine. Every capture gets an ID when it is
+// exported, and the control plane skips IDs it already has, so a batch
+// retried after a lost response is neither stored nor streamed twice.
+type Exporter struct {
+	cfg ExporterConfig
+	url string
+
+	queue   chan ingestCapture
+	flushCh chan flushRequest
+	stop    chan struct{}
+	done    chan struct{}
+
+	// ctx is canceled when a shutdown deadline passes, aborting the
+	// request or backoff in progress.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	closeOnce sync.Once
+	closed    atomic.Bool
+
+	sent    atomic.Uint64
+	dropped atomic.Uint64
+	failed  atomic.Uint64
+	retries atomic.Uint64
+}
+
+// NewExporter validates the config and starts the exporter.
+func NewExporter(cfg ExporterConfig) (*Exporter, error) {
+	if cfg.Endpoint == "" || cfg.ProjectID == "" || cfg.Token == "" {
+		return nil, fmt.Errorf("endpoint, project ID and token are required")
+	}
+	if cfg.Source == "" {
+		cfg.Source = "sdk"
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 5 * time.Second
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 10000
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	} else if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 5
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = 500 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 30 * time.Second
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(err error) { log.Printf("[cohesion] export failed: %v", err) }
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &Exporter{
+		cfg:     cfg,
+		url:     strings.TrimRight(cfg.Endpoint, "/") + "/api/live/ingest",
+		queue:   make(chan ingestCapture, cfg.QueueSize),
+		flushCh: make(chan flushRequest),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	go e.run()
+	return e, nil
+}
+
+// Export queues a capture without blocking. It returns false, counting the
+// capture as dropped, when the queue is full or the exporter is shut down.
+func (e *Exporter) Export(req CapturedRequest) bool {
+	if e.closed.Load() {
+		e.dropped.Add(1)
+		return false
+	}
+	select {
+	case e.queue <- e.toIngest(req):
+		return true
+	default:
+		e.dropped.Add(1)
+		return false
+	}
+}
+
+// Flush sends every queued capture and waits until they are sent or ctx
+// is done. It returns the error of the last failed batch, if any.
+func (e *Exporter) Flush(ctx context.Context) error {
+	req := flushRequest{done: make(chan error, 1)}
+	select {
+	case e.flushCh <- req:
+	case <-e.done:
+		return ErrExporterClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-req.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown stops accepting captures, sends those still queued and stops the
+// exporter. If ctx is done first, the remaining captures are abandoned and
+// ctx's error is returned.
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	e.closeOnce.Do(func() {
+		e.closed.Store(true)
+		close(e.stop)
+	})
+	select {
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		e.cancel()
+		<-e.done
+		return ctx.Err()
+	}
+}
+
+// Stats returns the exporter's counters.
+func (e *Exporter) Stats() ExporterStats {
+	return ExporterStats{
+		Sent:    e.sent.Load(),
+		Dropped: e.dropped.Load(),
+		Failed:  e.failed.Load(),
+		Retries: e.retries.Load(),
+		Queued:  len(e.queue),
+	}
+}
+
+func (e *Exporter) run() {
+	defer close(e.done)
+	defer e.cancel()
+
+	ticker := time.NewTicker(e.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]ingestCapture, 0, e.cfg.BatchSize)
+	for {
+		select {
+		case c := <-e.queue:
+			batch = append(batch, c)
+			if len(batch) >= e.cfg.BatchSize {
+				e.send(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				e.send(batch)
+				batch = batch[:0]
+			}
+		case req := <-e.flushCh:
+			req.done <- e.drain(batch)
+			batch = batch[:0]
+		case <-e.stop:
+			e.drain(batch)
+			return
+		}
+	}
+}
+
+// drain sends the pending batch and everything queued.
+func (e *Exporter) drain(batch []ingestCapture) error {
+	var lastErr error
+	for {
+		for more := true; more && len(batch) < e.cfg.BatchSize; {
+			select {
+			case c := <-e.queue:
+				batch = append(batch, c)
+			default:
+				more = false
+			}
+		}
+		if len(batch) == 0 {
+			return lastErr
+		}
+		if err := e.send(batch); err != nil {
+			lastErr = err
+		}
+		batch = batch[:0]
+	}
+}
+
+// send posts a batch, retrying network errors, 429s and 5xx responses with
+// backoff. Other responses reject the batch for good.
+func (e *Exporter) send(batch []ingestCapture) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"project_id": e.cfg.ProjectID,
+		"requests":   batch,
+	})
+	if err != nil {
+		e.failed.Add(uint64(len(batch)))
+		return err
+	}
+
+	backoff := e.cfg.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := e.post(body)
+		if err == nil {
+			e.sent.Add(uint64(len(batch)))
+			return nil
+		}
+		e.cfg.OnError(err)
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) || attempt >= e.cfg.MaxRetries || e.ctx.Err() != nil {
+			e.failed.Add(uint64(len(batch)))
+			return err
+		}
+
+		wait := jitter(backoff)
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+		select {
+		case <-time.After(wait):
+		case <-e.ctx.Done():
+			e.failed.Add(uint64(len(batch)))
+			return e.ctx.Err()
+		}
+		e.retries.Add(1)
+		backoff = min(backoff*2, e.cfg.MaxBackoff)
+	}
+}
+
+type permanentError struct {
+	status int
+	body   string
+}
+
+func (e *permanentError) Error() string {
+	return fmt.Sprintf("ingest rejected with status %d: %s", e.status, e.body)
+}
+
+// post sends one request. It returns the server's Retry-After delay, if
+// any, alongside retryable errors.
+func (e *Exporter) post(body []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(e.ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, &permanentError{body: err.Error()}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+e.cfg.Token)
+
+	resp, err := e.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return 0, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		var retryAfter time.Duration
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			retryAfter = time.Duration(secs) * time.Second
+		}
+		return retryAfter, fmt.Errorf("ingest failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	default:
+		return 0, &permanentError{status: resp.StatusCode, body: strings.TrimSpace(string(msg))}
+	}
+}
+
+func (e *Exporter) toIngest(req CapturedRequest) ingestCapture {
+	return ingestCapture{
+		ID:                  newCaptureID(),
+		Timestamp:           time.Now(),
+		Path:                req.Path,
+		Method:              req.Method,
+		StatusCode:          req.StatusCode,
+		DurationMs:          req.DurationMs,
+		RequestBody:         req.RequestBody,
+		ResponseBody:        req.Response,
+		Source:              e.cfg.Source,
+		QueryParams:         req.QueryParams,
+		Headers:             req.Headers,
+		RequestContentType:  req.RequestContentType,
+		ResponseContentType: req.ResponseContentType,
+	}
+}
+
+func newCaptureID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// jitter returns a random duration between d/2 and d.
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(d/2)))
+	if err != nil {
+		return d
+	}
+	return d/2 + time.Duration(n.Int64())
+}
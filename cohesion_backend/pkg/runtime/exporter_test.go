@@ -0,0 +1,161 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type ingestServer struct {
+	mu       sync.Mutex
+	batches  [][]ingestCapture
+	failures int
+	auth     string
+}
+
+func (s *ingestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auth = r.Header.Get("Authorization")
+	if s.failures > 0 {
+		s.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	var req struct {
+		ProjectID string          `json:"project_id"`
+		Requests  []ingestCapture `json:"requests"`
+	}
+	if r.URL.Path != "/api/live/ingest" || json.NewDecoder(r.Body).Decode(&req) != nil || req.ProjectID != "proj" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.batches = append(s.batches, req.Requests)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *ingestServer) sizes() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var sizes []int
+	for _, b := range s.batches {
+		sizes = append(sizes, len(b))
+	}
+	return sizes
+}
+
+func newTestExporter(t *testing.T, url string, cfg ExporterConfig) *Exporter {
+	t.Helper()
+	cfg.Endpoint, cfg.ProjectID, cfg.Token = url, "proj", "secret"
+	cfg.InitialBackoff = time.Millisecond
+	cfg.OnError = func(error) {}
+	e, err := NewExporter(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestExporterBatchesAndShutsDown(t *testing.T) {
+	srv := &ingestServer{failures: 2}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	e := newTestExporter(t, ts.URL, ExporterConfig{BatchSize: 2, FlushInterval: time.Hour})
+	collector := NewCollector(10)
+	collector.ExportTo(e)
+	for i := 0; i < 5; i++ {
+		collector.Add(CapturedRequest{Path: "/users", Method: "GET", StatusCode: 200})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	total := 0
+	for _, n := range srv.sizes() {
+		if n > 2 {
+			t.Errorf("batch of %d exceeds the batch size", n)
+		}
+		total += n
+	}
+	if total != 5 {
+		t.Errorf("sent %d captures, want 5", total)
+	}
+	if srv.auth != "Bearer secret" {
+		t.Errorf("Authorization = %q", srv.auth)
+	}
+	stats := e.Stats()
+	if stats.Sent != 5 || stats.Retries != 2 || stats.Failed != 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if srv.batches[0][0].ID == "" || srv.batches[0][0].Source != "sdk" {
+		t.Errorf("capture not stamped: %+v", srv.batches[0][0])
+	}
+
+	if e.Export(CapturedRequest{Path: "/late"}) {
+		t.Error("Export succeeded after shutdown")
+	}
+	if err := e.Flush(ctx); err != ErrExporterClosed {
+		t.Errorf("Flush after shutdown = %v", err)
+	}
+}
+
+func TestExporterDropsWhenQueueFull(t *testing.T) {
+	block := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+	defer close(block)
+
+	e := newTestExporter(t, ts.URL, ExporterConfig{BatchSize: 1, QueueSize: 2, FlushInterval: time.Hour})
+
+	// The first capture is taken off the queue and blocks in flight; two
+	// more fill the queue and the rest are dropped.
+	e.Export(CapturedRequest{Path: "/a"})
+	deadline := time.Now().Add(5 * time.Second)
+	for e.Stats().Queued > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < 5; i++ {
+		e.Export(CapturedRequest{Path: "/b"})
+	}
+
+	if stats := e.Stats(); stats.Dropped != 3 || stats.Queued != 2 {
+		t.Errorf("stats = %+v, want 3 dropped and 2 queued", stats)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Shutdown = %v, want deadline exceeded", err)
+	}
+}
+
+func TestExporterRejectedBatch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	e := newTestExporter(t, ts.URL, ExporterConfig{})
+	e.Export(CapturedRequest{Path: "/a"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.Flush(ctx); err == nil {
+		t.Error("expected Flush to report the rejected batch")
+	}
+	if stats := e.Stats(); stats.Failed != 1 || stats.Retries != 0 {
+		t.Errorf("stats = %+v, want 1 failed without retries", stats)
+	}
+	e.Shutdown(ctx)
+}
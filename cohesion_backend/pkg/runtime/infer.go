@@ -5,6 +5,7 @@ import "github.com/cohesion-api/cohesion_backend/pkg/schemair"
 func InferSchema(requests []CapturedRequest) []*schemair.SchemaIR {
 	endpointMap := make(map[string]*schemair.SchemaIR)
 	endpointHits := make(map[string]int)
+	inf := newInferrer()
 
 	for _, req := range requests {
 		key := req.Method + ":" + req.Path
@@ -23,37 +24,124 @@ func InferSchema(requests []CapturedRequest) []*schemair.SchemaIR {
 
 		if req.RequestBody != nil {
 			if schema.Request == nil {
-				schema.Request = inferObjectSchema(req.RequestBody, req.ObservationCount)
+				schema.Request = inf.inferBody(req.RequestBody, req.RequestContentType, req.ObservationCount)
 			} else {
-				mergeObjectSchema(schema.Request, req.RequestBody, req.ObservationCount)
+				inf.mergeBody(schema.Request, req.RequestBody, req.ObservationCount)
 			}
 		}
 
 		if req.Response != nil {
 			if _, exists := schema.Response[req.StatusCode]; !exists {
-				schema.Response[req.StatusCode] = inferObjectSchema(req.Response, req.ObservationCount)
+				schema.Response[req.StatusCode] = inf.inferBody(req.Response, req.ResponseContentType, req.ObservationCount)
 			} else {
-				mergeObjectSchema(schema.Response[req.StatusCode], req.Response, req.ObservationCount)
+				inf.mergeBody(schema.Response[req.StatusCode], req.Response, req.ObservationCount)
 			}
 		}
+
+		inf.observeParams(schema, req)
 	}
 
 	result := make([]*schemair.SchemaIR, 0, len(endpointMap))
 	for key, schema := range endpointMap {
 		totalHits := endpointHits[key]
 		if schema.Request != nil {
-			normalizeConfidence(schema.Request, totalHits)
+			inf.normalizeConfidence(schema.Request, totalHits)
 		}
 		for _, res := range schema.Response {
-			normalizeConfidence(res, totalHits)
+			inf.normalizeConfidence(res, totalHits)
 		}
+		normalizeParamConfidence(schema.QueryParams, totalHits)
+		normalizeParamConfidence(schema.Headers, totalHits)
 		result = append(result, schema)
 	}
+	inf.applyConstraints()
 
 	return result
 }
 
-func inferObjectSchema(data map[string]interface{}, hits int) *schemair.ObjectSchema {
+// inferrer accumulates the values observed for every field so constraints
+// can be inferred once all requests have been merged, and counts the
+// elements merged into each array item schema so item fields are weighed
+// per element rather than per request.
+type inferrer struct {
+	stats    map[*schemair.Field]*valueStats
+	elements map[*schemair.ObjectSchema]int
+}
+
+func newInferrer() *inferrer {
+	return &inferrer{
+		stats:    make(map[*schemair.Field]*valueStats),
+		elements: make(map[*schemair.ObjectSchema]int),
+	}
+}
+
+// inferBody infers the root schema of a body of any JSON shape. Bodies
+// that were not sent as JSON record their media type.
+func (inf *inferrer) inferBody(body interface{}, contentType string, hits int) *schemair.ObjectSchema {
+	var schema *schemair.ObjectSchema
+	switch v := body.(type) {
+	case map[string]interface{}:
+		schema = inf.inferObjectSchema(v, hits)
+	case []interface{}:
+		schema = &schemair.ObjectSchema{Type: "array"}
+		inf.mergeItems(schema, v, hits)
+	default:
+		schema = &schemair.ObjectSchema{Type: inferType(body)}
+	}
+	schema.ContentType = BodyContentType(contentType)
+	return schema
+}
+
+// mergeBody merges a body into an existing root schema. Bodies whose shape
+// differs from the first one observed are ignored.
+func (inf *inferrer) mergeBody(schema *schemair.ObjectSchema, body interface{}, hits int) {
+	switch v := body.(type) {
+	case map[string]interface{}:
+		if schema.Type == "object" {
+			inf.mergeObjectSchema(schema, v, hits)
+		}
+	case []interface{}:
+		if schema.Type == "array" {
+			inf.mergeItems(schema, v, hits)
+		}
+	}
+}
+
+// mergeItems merges every element of an array into its Items schema.
+// Object elements merge their fields, array elements recurse into a nested
+// Items schema, and elements of differing types make the item type "any".
+// Null elements carry no shape and are skipped. Each element counts as one
+// observation of Items, so item fields are required only when every element
+// has them.
+func (inf *inferrer) mergeItems(schema *schemair.ObjectSchema, elems []interface{}, hits int) {
+	for _, elem := range elems {
+		if elem == nil {
+			continue
+		}
+		typ := inferType(elem)
+		items := schema.Items
+		if items == nil {
+			items = &schemair.ObjectSchema{Type: typ}
+			schema.Items = items
+		} else if items.Type != typ {
+			items.Type = "any"
+		}
+
+		switch v := elem.(type) {
+		case map[string]interface{}:
+			if items.Fields == nil {
+				items.Fields = inf.inferObjectSchema(v, hits).Fields
+			} else {
+				inf.mergeObjectSchema(items, v, hits)
+			}
+		case []interface{}:
+			inf.mergeItems(items, v, hits)
+		}
+		inf.elements[items] += hits
+	}
+}
+
+func (inf *inferrer) inferObjectSchema(data map[string]interface{}, hits int) *schemair.ObjectSchema {
 	schema := &schemair.ObjectSchema{
 		Type:   "object",
 		Fields: make(map[string]*schemair.Field),
@@ -66,10 +154,8 @@ func inferObjectSchema(data map[string]interface{}, hits int) *schemair.ObjectSc
 			Confidence: float64(hits),
 			SourceTag:  schemair.SourceRuntime,
 		}
-
-		if nested, ok := value.(map[string]interface{}); ok {
-			field.Nested = inferObjectSchema(nested, hits)
-		}
+		inf.observe(field, value, hits)
+		inf.observeNested(field, value, hits)
 
 		schema.Fields[key] = field
 	}
@@ -77,33 +163,88 @@ func inferObjectSchema(data map[string]interface{}, hits int) *schemair.ObjectSc
 	return schema
 }
 
-func mergeObjectSchema(schema *schemair.ObjectSchema, data map[string]interface{}, hits int) {
+func (inf *inferrer) mergeObjectSchema(schema *schemair.ObjectSchema, data map[string]interface{}, hits int) {
 	if schema == nil || schema.Fields == nil {
 		return
 	}
 
 	for key, value := range data {
-		if field, exists := schema.Fields[key]; exists {
+		field, exists := schema.Fields[key]
+		if exists {
 			field.Confidence += float64(hits)
-			if nested, ok := value.(map[string]interface{}); ok && field.Nested != nil {
-				mergeObjectSchema(field.Nested, nested, hits)
-			}
 		} else {
-			schema.Fields[key] = &schemair.Field{
+			field = &schemair.Field{
 				Type:       inferType(value),
 				Required:   false,
 				Confidence: float64(hits),
 				SourceTag:  schemair.SourceRuntime,
 			}
-			if nested, ok := value.(map[string]interface{}); ok {
-				schema.Fields[key].Nested = inferObjectSchema(nested, hits)
-			}
+			schema.Fields[key] = field
+		}
+		inf.observe(field, value, hits)
+		inf.observeNested(field, value, hits)
+	}
+}
+
+// observeNested merges an object or array value into the field's Nested
+// schema. Arrays nest as {"type": "array", "items": ...}, the shape the
+// OpenAPI importer and the diff engine use. Values whose shape differs from
+// the one first observed are ignored.
+func (inf *inferrer) observeNested(field *schemair.Field, value interface{}, hits int) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if field.Nested == nil {
+			field.Nested = inf.inferObjectSchema(v, hits)
+		} else if field.Nested.Type == "object" {
+			inf.mergeObjectSchema(field.Nested, v, hits)
+		}
+	case []interface{}:
+		if field.Nested == nil {
+			field.Nested = &schemair.ObjectSchema{Type: "array"}
+		}
+		if field.Nested.Type == "array" {
+			inf.mergeItems(field.Nested, v, hits)
+		}
+	}
+}
+
+// observe records one value of a field. A null marks the field nullable and
+// a field first seen as null takes the type of its first non-null value.
+func (inf *inferrer) observe(field *schemair.Field, value interface{}, hits int) {
+	if value == nil {
+		field.Nullable = true
+		return
+	}
+	if field.Type == "null" {
+		field.Type = inferType(value)
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return
+	}
+	st := inf.stats[field]
+	if st == nil {
+		st = newValueStats()
+		inf.stats[field] = st
+	}
+	st.add(s, hits)
+}
+
+func (inf *inferrer) applyConstraints() {
+	for field, st := range inf.stats {
+		if field.Type != "string" {
+			continue
+		}
+		field.Format = st.format()
+		if field.Format == "" {
+			field.Enum = st.enum()
 		}
 	}
 }
 
-func normalizeConfidence(schema *schemair.ObjectSchema, totalHits int) {
-	if schema == nil || schema.Fields == nil || totalHits == 0 {
+func (inf *inferrer) normalizeConfidence(schema *schemair.ObjectSchema, totalHits int) {
+	if schema == nil || totalHits == 0 {
 		return
 	}
 
@@ -113,9 +254,12 @@ func normalizeConfidence(schema *schemair.ObjectSchema, totalHits int) {
 			field.Required = false
 		}
 		if field.Nested != nil {
-			normalizeConfidence(field.Nested, totalHits)
+			inf.normalizeConfidence(field.Nested, totalHits)
 		}
 	}
+	if schema.Items != nil {
+		inf.normalizeConfidence(schema.Items, inf.elements[schema.Items])
+	}
 }
 
 func inferType(value interface{}) string {
@@ -0,0 +1,177 @@
+package runtime
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestInferSchemaConstraints(t *testing.T) {
+	var requests []CapturedRequest
+	statuses := []string{"active", "banned"}
+	for i := 0; i < 30; i++ {
+		resp := map[string]interface{}{
+			"id":      fmt.Sprintf("3f1c2a4e-8b7d-4c3a-9e2f-%012d", i),
+			"status":  statuses[i%2],
+			"name":    fmt.Sprintf("user-%d", i),
+			"email":   fmt.Sprintf("user%d@example.com", i),
+			"deleted": nil,
+		}
+		if i > 0 {
+			resp["deleted"] = "2024-01-02T15:04:05Z"
+		}
+		requests = append(requests, CapturedRequest{
+			Path: "/api/users", Method: "GET", StatusCode: 200, Response: resp, ObservationCount: 1,
+		})
+	}
+
+	schemas := InferSchema(requests)
+	if len(schemas) != 1 {
+		t.Fatalf("Expected one schema, got %d", len(schemas))
+	}
+	fields := schemas[0].Response[200].Fields
+
+	if f := fields["id"]; f.Format != "uuid" || f.Enum != nil {
+		t.Errorf("Expected uuid format and no enum on id, got %q %v", f.Format, f.Enum)
+	}
+	if f := fields["email"]; f.Format != "email" {
+		t.Errorf("Expected email format, got %q", f.Format)
+	}
+	if f := fields["status"]; len(f.Enum) != 2 || f.Enum[0] != "active" || f.Enum[1] != "banned" {
+		t.Errorf("Expected enum [active banned], got %v", f.Enum)
+	}
+	if f := fields["name"]; f.Enum != nil || f.Format != "" {
+		t.Errorf("Expected free-form name, got %q %v", f.Format, f.Enum)
+	}
+	if f := fields["deleted"]; f.Type != "string" || !f.Nullable || f.Format != "date-time" {
+		t.Errorf("Expected nullable date-time string, got %+v", f)
+	}
+}
+
+func TestInferSchemaNoEnumFromFewSamples(t *testing.T) {
+	schemas := InferSchema([]CapturedRequest{
+		{Path: "/a", Method: "GET", StatusCode: 200, Response: map[string]interface{}{"kind": "x"}, ObservationCount: 1},
+		{Path: "/a", Method: "GET", StatusCode: 200, Response: map[string]interface{}{"kind": "y"}, ObservationCount: 1},
+	})
+	if f := schemas[0].Response[200].Fields["kind"]; f.Enum != nil {
+		t.Errorf("Expected no enum from two samples, got %v", f.Enum)
+	}
+}
+
+func TestInferSchemaParams(t *testing.T) {
+	var requests []CapturedRequest
+	for i := 0; i < 4; i++ {
+		req := CapturedRequest{
+			Path: fmt.Sprintf("/api/users/%d", i+100), Method: "GET", StatusCode: 200, ObservationCount: 1,
+			QueryParams: map[string][]string{"limit": {fmt.Sprint(10 * (i + 1))}},
+			Headers:     CaptureHeaders(map[string][]string{"Authorization": {"Bearer secret"}, "User-Agent": {"curl"}, "X-Tenant-Id": {"acme"}}),
+		}
+		if i%2 == 0 {
+			req.QueryParams["tags"] = []string{"a", "b"}
+		}
+		requests = append(requests, req)
+	}
+
+	schemas := InferSchema(NewPathTemplater([]string{"/api/users/{}"}).Apply(requests))
+	if len(schemas) != 1 {
+		t.Fatalf("Expected one schema, got %d", len(schemas))
+	}
+	s := schemas[0]
+
+	if len(s.PathParams) != 1 || s.PathParams[0].Type != "integer" || !s.PathParams[0].Required {
+		t.Errorf("Expected one required integer path param, got %+v", s.PathParams)
+	}
+	if f := s.QueryParams["limit"]; f == nil || f.Type != "integer" || !f.Required {
+		t.Errorf("Expected required integer limit, got %+v", f)
+	}
+	if f := s.QueryParams["tags"]; f == nil || f.Type != "array" || f.Required {
+		t.Errorf("Expected optional array tags, got %+v", f)
+	}
+	if _, ok := s.Headers["user-agent"]; ok {
+		t.Errorf("Expected user-agent to be ignored")
+	}
+	if f := s.Headers["authorization"]; f == nil || f.Type != "string" {
+		t.Errorf("Expected masked authorization header to be kept, got %+v", f)
+	}
+	if f := s.Headers["x-tenant-id"]; f == nil || !f.Required {
+		t.Errorf("Expected required x-tenant-id header, got %+v", f)
+	}
+}
+
+func TestInferSchemaNonObjectBodies(t *testing.T) {
+	schemas := InferSchema([]CapturedRequest{
+		{Path: "/api/items", Method: "GET", StatusCode: 200, ObservationCount: 1, Response: []interface{}{
+			map[string]interface{}{"id": 1.0, "price": 9.5},
+			map[string]interface{}{"id": 2.0},
+		}},
+		{Path: "/api/items", Method: "POST", StatusCode: 204, ObservationCount: 1,
+			RequestContentType: "application/x-www-form-urlencoded",
+			RequestBody:        DecodeBody("application/x-www-form-urlencoded", []byte("name=lamp"))},
+	})
+	for _, s := range schemas {
+		switch s.Method {
+		case "GET":
+			list := s.Response[200]
+			if list.Type != "array" || list.Items == nil {
+				t.Fatalf("Expected array root with items, got %+v", list)
+			}
+			if id := list.Items.Fields["id"]; id == nil || !id.Required {
+				t.Errorf("Expected id required in every element, got %+v", id)
+			}
+			if price := list.Items.Fields["price"]; price == nil || price.Required || price.Confidence != 0.5 {
+				t.Errorf("Expected price optional with per-element confidence 0.5, got %+v", price)
+			}
+		case "POST":
+			if s.Request == nil || s.Request.ContentType != "application/x-www-form-urlencoded" || s.Request.Fields["name"] == nil {
+				t.Errorf("Expected form request schema, got %+v", s.Request)
+			}
+		}
+	}
+}
+
+func TestInferSchemaArrayItems(t *testing.T) {
+	resp := map[string]interface{}{
+		"orders": []interface{}{
+			map[string]interface{}{"id": 1.0, "lines": []interface{}{
+				map[string]interface{}{"sku": "a", "qty": 1.0},
+				map[string]interface{}{"sku": "b", "qty": 2.0},
+				map[string]interface{}{"sku": "c"},
+				map[string]interface{}{"sku": "d", "qty": 1.0},
+			}},
+			map[string]interface{}{"id": 2.0, "lines": []interface{}{}},
+		},
+		"tags":   []interface{}{"x", 1.0},
+		"matrix": []interface{}{[]interface{}{1.0, 2.0}, []interface{}{3.0}},
+	}
+	schemas := InferSchema([]CapturedRequest{
+		{Path: "/api/cart", Method: "GET", StatusCode: 200, ObservationCount: 1, Response: resp},
+	})
+	fields := schemas[0].Response[200].Fields
+
+	orders := fields["orders"]
+	if orders.Nested == nil || orders.Nested.Type != "array" || orders.Nested.Items == nil {
+		t.Fatalf("Expected orders to nest an array schema, got %+v", orders.Nested)
+	}
+	order := orders.Nested.Items
+	if id := order.Fields["id"]; id == nil || !id.Required || id.Confidence != 1 {
+		t.Errorf("Expected id in every order, got %+v", id)
+	}
+
+	lines := order.Fields["lines"]
+	if lines.Nested == nil || lines.Nested.Items == nil {
+		t.Fatalf("Expected nested line items, got %+v", lines.Nested)
+	}
+	line := lines.Nested.Items
+	if sku := line.Fields["sku"]; sku == nil || !sku.Required {
+		t.Errorf("Expected sku in every line, got %+v", sku)
+	}
+	if qty := line.Fields["qty"]; qty == nil || qty.Required || qty.Confidence != 0.75 {
+		t.Errorf("Expected qty optional with confidence 0.75, got %+v", qty)
+	}
+
+	if tags := fields["tags"].Nested; tags == nil || tags.Items == nil || tags.Items.Type != "any" {
+		t.Errorf("Expected mixed tag items to be any, got %+v", tags)
+	}
+	if m := fields["matrix"].Nested; m == nil || m.Items == nil || m.Items.Type != "array" || m.Items.Items == nil || m.Items.Items.Type != "number" {
+		t.Errorf("Expected array of number arrays, got %+v", m)
+	}
+}
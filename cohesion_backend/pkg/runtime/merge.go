@@ -0,0 +1,120 @@
+package runtime
+
+import (
+	"fmt"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+// MergeSchemas folds schemas that describe the same method and endpoint into
+// one, keeping the order in which endpoints first appear. A field missing
+// from any of the merged schemas becomes optional.
+func MergeSchemas(schemas []schemair.SchemaIR) []schemair.SchemaIR {
+	index := make(map[string]int)
+	out := make([]schemair.SchemaIR, 0, len(schemas))
+	for _, s := range schemas {
+		key := s.Method + ":" + s.Endpoint
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(out)
+			out = append(out, s)
+			continue
+		}
+
+		merged := &out[i]
+		merged.Request = mergeObjects(merged.Request, s.Request)
+		if len(merged.PathParams) == 0 {
+			merged.PathParams = s.PathParams
+		}
+		merged.QueryParams = mergeParams(merged.QueryParams, s.QueryParams)
+		merged.Headers = mergeParams(merged.Headers, s.Headers)
+		if len(s.Response) > 0 {
+			responses := make(map[int]*schemair.ObjectSchema, len(merged.Response)+len(s.Response))
+			for code, obj := range merged.Response {
+				responses[code] = obj
+			}
+			for code, obj := range s.Response {
+				responses[code] = mergeObjects(responses[code], obj)
+			}
+			merged.Response = responses
+		}
+	}
+	return out
+}
+
+func mergeObjects(a, b *schemair.ObjectSchema) *schemair.ObjectSchema {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	out := &schemair.ObjectSchema{Type: a.Type, Items: mergeObjects(a.Items, b.Items), ContentType: a.ContentType}
+	if len(a.Fields) == 0 && len(b.Fields) == 0 {
+		return out
+	}
+	out.Fields = make(map[string]*schemair.Field, len(a.Fields)+len(b.Fields))
+	for name, fa := range a.Fields {
+		fb, ok := b.Fields[name]
+		if !ok {
+			optional := *fa
+			optional.Required = false
+			out.Fields[name] = &optional
+			continue
+		}
+		out.Fields[name] = mergeFields(fa, fb)
+	}
+	for name, fb := range b.Fields {
+		if _, ok := a.Fields[name]; !ok {
+			optional := *fb
+			optional.Required = false
+			out.Fields[name] = &optional
+		}
+	}
+	return out
+}
+
+func mergeParams(a, b map[string]*schemair.Field) map[string]*schemair.Field {
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+	merged := mergeObjects(&schemair.ObjectSchema{Type: "object", Fields: a}, &schemair.ObjectSchema{Type: "object", Fields: b})
+	return merged.Fields
+}
+
+func mergeFields(a, b *schemair.Field) *schemair.Field {
+	f := *a
+	if f.Type == "null" {
+		f.Type = b.Type
+	}
+	f.Required = a.Required && b.Required
+	f.Nullable = a.Nullable || b.Nullable
+	if b.Confidence > f.Confidence {
+		f.Confidence = b.Confidence
+	}
+	if a.Format != b.Format {
+		f.Format = ""
+	}
+	if len(a.Enum) > 0 && len(b.Enum) > 0 {
+		f.Enum = append(append([]interface{}{}, a.Enum...), enumExtra(a.Enum, b.Enum)...)
+	} else {
+		f.Enum = nil
+	}
+	f.Nested = mergeObjects(a.Nested, b.Nested)
+	return &f
+}
+
+func enumExtra(have, values []interface{}) []interface{} {
+	seen := make(map[string]bool, len(have))
+	for _, v := range have {
+		seen[fmt.Sprint(v)] = true
+	}
+	var extra []interface{}
+	for _, v := range values {
+		if !seen[fmt.Sprint(v)] {
+			extra = append(extra, v)
+		}
+	}
+	return extra
+}
@@ -3,20 +3,31 @@ package runtime
 import (
 	"bytes"
 	"crypto/rand"
-	"encoding/json"
 	"io"
 	"math/big"
 	"net/http"
 	"sync"
+	"time"
 )
 
+// CapturedRequest is one observed exchange. Bodies hold any decoded JSON
+// value, see DecodeBody.
 type CapturedRequest struct {
-	Path             string                 `json:"path"`
-	Method           string                 `json:"method"`
-	RequestBody      map[string]interface{} `json:"request_body,omitempty"`
-	StatusCode       int                    `json:"status_code"`
-	Response         map[string]interface{} `json:"response,omitempty"`
-	ObservationCount int                    `json:"observation_count"`
+	Path             string      `json:"path"`
+	Method           string      `json:"method"`
+	RequestBody      interface{} `json:"request_body,omitempty"`
+	StatusCode       int         `json:"status_code"`
+	Response         interface{} `json:"response,omitempty"`
+	ObservationCount int         `json:"observation_count"`
+	DurationMs       float64     `json:"duration_ms,omitempty"`
+
+	RequestContentType  string `json:"request_content_type,omitempty"`
+	ResponseContentType string `json:"response_content_type,omitempty"`
+
+	QueryParams map[string][]string `json:"query_params,omitempty"`
+	Headers     map[string]string   `json:"headers,omitempty"`
+	// RawPath is the concrete path a templated request was observed on.
+	RawPath string `json:"raw_path,omitempty"`
 }
 
 type Collector struct {
@@ -25,6 +36,8 @@ type Collector struct {
 	maxSize      int
 	SamplingRate float64
 	MaxBodySize  int64
+
+	exporter *Exporter
 }
 
 func NewCollector(maxSize int) *Collector {
@@ -36,6 +49,14 @@ func NewCollector(maxSize int) *Collector {
 	}
 }
 
+// ExportTo also hands every capture added from now on to e, which ships
+// it to the control plane.
+func (c *Collector) ExportTo(e *Exporter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.exporter = e
+}
+
 func (c *Collector) Add(req CapturedRequest) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -44,6 +65,9 @@ func (c *Collector) Add(req CapturedRequest) {
 		c.captured = c.captured[1:]
 	}
 	c.captured = append(c.captured, req)
+	if c.exporter != nil {
+		c.exporter.Export(req)
+	}
 }
 
 func (c *Collector) GetAll() []CapturedRequest {
@@ -88,7 +112,7 @@ func CaptureMiddleware(collector *Collector) func(http.Handler) http.Handler {
 				}
 			}
 
-			var reqBody map[string]interface{}
+			var reqBody interface{}
 			if r.Body != nil {
 				if r.ContentLength > 0 && r.ContentLength > collector.MaxBodySize {
 					next.ServeHTTP(w, r)
@@ -97,9 +121,10 @@ func CaptureMiddleware(collector *Collector) func(http.Handler) http.Handler {
 
 				bodyBytes, _ := io.ReadAll(r.Body)
 				r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-				json.Unmarshal(bodyBytes, &reqBody)
+				reqBody = DecodeBody(r.Header.Get("Content-Type"), bodyBytes)
 			}
 
+			start := time.Now()
 			rw := &responseWriter{
 				ResponseWriter: w,
 				statusCode:     http.StatusOK,
@@ -112,16 +137,18 @@ func CaptureMiddleware(collector *Collector) func(http.Handler) http.Handler {
 				return
 			}
 
-			var respBody map[string]interface{}
-			json.Unmarshal(rw.body.Bytes(), &respBody)
-
 			captured := CapturedRequest{
-				Path:             r.URL.Path,
-				Method:           r.Method,
-				RequestBody:      reqBody,
-				StatusCode:       rw.statusCode,
-				Response:         respBody,
-				ObservationCount: 1,
+				Path:                r.URL.Path,
+				Method:              r.Method,
+				RequestBody:         reqBody,
+				StatusCode:          rw.statusCode,
+				Response:            DecodeBody(rw.Header().Get("Content-Type"), rw.body.Bytes()),
+				ObservationCount:    1,
+				DurationMs:          float64(time.Since(start).Microseconds()) / 1000,
+				QueryParams:         CaptureQuery(r.URL.Query()),
+				Headers:             CaptureHeaders(r.Header),
+				RequestContentType:  r.Header.Get("Content-Type"),
+				ResponseContentType: rw.Header().Get("Content-Type"),
 			}
 
 			collector.Add(captured)
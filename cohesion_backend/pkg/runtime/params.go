@@ -0,0 +1,221 @@
+package runtime
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cohesion-api/cohesion_backend/pkg/schemair"
+)
+
+// MaskedValue replaces the values of credential headers in captures.
+const MaskedValue = "[masked]"
+
+// ignoredHeaders are set by browsers, HTTP clients and proxies on nearly
+// every request. They describe the transport rather than the API contract.
+var ignoredHeaders = map[string]bool{
+	"accept":                    true,
+	"accept-encoding":           true,
+	"accept-language":           true,
+	"cache-control":             true,
+	"connection":                true,
+	"content-length":            true,
+	"content-type":              true,
+	"cookie":                    true,
+	"dnt":                       true,
+	"host":                      true,
+	"if-modified-since":         true,
+	"if-none-match":             true,
+	"keep-alive":                true,
+	"origin":                    true,
+	"pragma":                    true,
+	"priority":                  true,
+	"proxy-connection":          true,
+	"referer":                   true,
+	"te":                        true,
+	"trailer":                   true,
+	"transfer-encoding":         true,
+	"upgrade":                   true,
+	"upgrade-insecure-requests": true,
+	"user-agent":                true,
+	"via":                       true,
+	"x-forwarded-for":           true,
+	"x-forwarded-host":          true,
+	"x-forwarded-proto":         true,
+	"x-real-ip":                 true,
+}
+
+// credentialHeaders are kept so the contract records that they are sent,
+// but their values are masked.
+var credentialHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"x-api-key":           true,
+	"x-auth-token":        true,
+	"x-csrf-token":        true,
+	"x-xsrf-token":        true,
+}
+
+// CaptureQuery copies the query parameters of a request.
+func CaptureQuery(values url.Values) map[string][]string {
+	if len(values) == 0 {
+		return nil
+	}
+	out := make(map[string][]string, len(values))
+	for name, vals := range values {
+		out[name] = append([]string(nil), vals...)
+	}
+	return out
+}
+
+// CaptureHeaders returns the request headers that belong to the API
+// contract, keyed by lowercase name, with credential values masked.
+func CaptureHeaders(header http.Header) map[string]string {
+	out := make(map[string]string)
+	for name, vals := range header {
+		if len(vals) > 0 {
+			addHeader(out, name, vals[0])
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// SanitizeHeaders applies the CaptureHeaders rules to headers captured
+// elsewhere, such as by an SDK posting to the ingest endpoint.
+func SanitizeHeaders(headers map[string]string) map[string]string {
+	out := make(map[string]string)
+	for name, val := range headers {
+		addHeader(out, name, val)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func addHeader(out map[string]string, name, val string) {
+	name = strings.ToLower(name)
+	switch {
+	case ignoredHeaders[name], strings.HasPrefix(name, "sec-"):
+	case credentialHeaders[name]:
+		out[name] = MaskedValue
+	default:
+		out[name] = val
+	}
+}
+
+// observeParams records the path, query and header values of one request.
+// Path parameter values are read from the concrete path at the template's
+// parameter positions.
+func (inf *inferrer) observeParams(schema *schemair.SchemaIR, req CapturedRequest) {
+	if req.RawPath != "" {
+		tmpl, raw := splitPath(req.Path), splitPath(req.RawPath)
+		if len(tmpl) == len(raw) {
+			n := 0
+			for i, seg := range tmpl {
+				if seg != paramSegment && !templateParam.MatchString(seg) {
+					continue
+				}
+				if n == len(schema.PathParams) {
+					schema.PathParams = append(schema.PathParams, schemair.PathParam{
+						Field: schemair.Field{Required: true, SourceTag: schemair.SourceRuntime},
+					})
+				}
+				p := &schema.PathParams[n]
+				inf.observeParam(&p.Field, raw[i], req.ObservationCount)
+				n++
+			}
+		}
+	}
+
+	for name, vals := range req.QueryParams {
+		if schema.QueryParams == nil {
+			schema.QueryParams = make(map[string]*schemair.Field)
+		}
+		field := paramField(schema.QueryParams, name)
+		field.Confidence += float64(req.ObservationCount)
+		switch len(vals) {
+		case 0:
+		case 1:
+			inf.observeParam(field, vals[0], req.ObservationCount)
+		default:
+			field.Type = "array"
+		}
+	}
+
+	for name, val := range req.Headers {
+		if schema.Headers == nil {
+			schema.Headers = make(map[string]*schemair.Field)
+		}
+		field := paramField(schema.Headers, strings.ToLower(name))
+		field.Confidence += float64(req.ObservationCount)
+		if val == MaskedValue {
+			field.Type = "string"
+			continue
+		}
+		inf.observeParam(field, val, req.ObservationCount)
+	}
+}
+
+func paramField(fields map[string]*schemair.Field, name string) *schemair.Field {
+	field, ok := fields[name]
+	if !ok {
+		field = &schemair.Field{Required: true, SourceTag: schemair.SourceRuntime}
+		fields[name] = field
+	}
+	return field
+}
+
+// observeParam widens the field's type to fit a raw string value. Path,
+// query and header values carry no JSON types, so numbers and booleans are
+// recognised from their text.
+func (inf *inferrer) observeParam(field *schemair.Field, value string, hits int) {
+	if field.Type == "array" {
+		return
+	}
+	field.Type = widenType(field.Type, scalarType(value))
+	inf.observe(field, value, hits)
+}
+
+func scalarType(s string) string {
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return "integer"
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return "number"
+	}
+	if s == "true" || s == "false" {
+		return "boolean"
+	}
+	return "string"
+}
+
+func widenType(current, observed string) string {
+	switch {
+	case current == "" || current == observed:
+		return observed
+	case (current == "integer" || current == "number") && (observed == "integer" || observed == "number"):
+		return "number"
+	default:
+		return "string"
+	}
+}
+
+// normalizeParamConfidence turns query and header hit counts into the share
+// of observations that carried them. Parameters missing from any
+// observation are optional.
+func normalizeParamConfidence(fields map[string]*schemair.Field, totalHits int) {
+	if totalHits == 0 {
+		return
+	}
+	for _, field := range fields {
+		field.Confidence = field.Confidence / float64(totalHits)
+		if field.Confidence < 1.0 {
+			field.Required = false
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package runtime
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// DefaultRedactedFields are the field names whose values are always
+// masked. A field matches when its name ends with one of them as whole
+// words, split at separators and camelCase boundaries, so accessToken,
+// refresh_token and X-Session-Token match "token" and apiKey matches
+// "apikey", while token_count, max_tokens and className do not.
+var DefaultRedactedFields = []string{
+	"password", "passwd", "secret", "secretkey", "privatekey", "token", "apikey", "ssn", "cvv", "cvc",
+}
+
+// Detectors are the kinds of sensitive values masked wherever they appear.
+var Detectors = []string{"email", "jwt", "credit_card", "api_key"}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	jwtPattern   = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	cardPattern  = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+
+	// apiKeyPattern matches the prefixed key formats of common providers:
+	// Stripe, OpenAI and Anthropic, AWS access key IDs, GitHub, Slack and
+	// Google API keys.
+	apiKeyPattern = regexp.MustCompile(`\b(?:(?:sk|pk|rk)_(?:live|test)_[A-Za-z0-9]{10,}|sk-[A-Za-z0-9_-]{20,}|AKIA[0-9A-Z]{16}|(?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9_]{22,}|xox[abprs]-[A-Za-z0-9-]{10,}|AIza[0-9A-Za-z_-]{35})`)
+)
+
+// formatPlaceholders replace masked strings of a recognised format, so the
+// runtime-observed schema still records the format.
+var formatPlaceholders = map[string]string{
+	"uuid":      "00000000-0000-0000-0000-000000000000",
+	"date-time": "1970-01-01T00:00:00Z",
+	"date":      "1970-01-01",
+	"email":     "masked@example.com",
+	"uri":       "https://example.com/masked",
+}
+
+// RedactionRule masks values by field name, by value pattern, or by both.
+// A rule with only a Field masks every value of fields with that name,
+// compared lowercased and without separators. A rule with only a Pattern
+// masks the matching parts of any string. A rule with both masks the
+// matching parts of that field's strings.
+type RedactionRule struct {
+	Field   string `json:"field,omitempty"`
+	Pattern string `json:"pattern,omitempty"`
+}
+
+func (r *RedactionRule) Validate() error {
+	if r.Field == "" && r.Pattern == "" {
+		return fmt.Errorf("field or pattern is required")
+	}
+	if r.Pattern != "" {
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %v", err)
+		}
+	}
+	return nil
+}
+
+type compiledRule struct {
+	field   string
+	pattern *regexp.Regexp
+}
+
+// Redactor masks sensitive values in captured bodies, query parameters and
+// headers. Masked values keep their JSON type — strings stay strings and
+// numbers become 0 — so inference still sees the field's shape.
+type Redactor struct {
+	fields map[string]bool
+	rules  []compiledRule
+}
+
+// NewRedactor returns a redactor applying the default field names and
+// detectors plus the given custom rules.
+func NewRedactor(rules []RedactionRule) (*Redactor, error) {
+	r := &Redactor{fields: make(map[string]bool)}
+	for i, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+		field := normalizeName(rule.Field)
+		if rule.Pattern == "" {
+			r.fields[field] = true
+			continue
+		}
+		r.rules = append(r.rules, compiledRule{field: field, pattern: regexp.MustCompile(rule.Pattern)})
+	}
+	return r, nil
+}
+
+// Redact returns a copy of a decoded body with sensitive values masked.
+func (r *Redactor) Redact(body interface{}) interface{} {
+	return r.value("", body)
+}
+
+// RedactQuery returns a copy of captured query parameters with sensitive
+// values masked.
+func (r *Redactor) RedactQuery(query map[string][]string) map[string][]string {
+	if query == nil {
+		return nil
+	}
+	out := make(map[string][]string, len(query))
+	for name, vals := range query {
+		masked := make([]string, len(vals))
+		for i, v := range vals {
+			masked[i] = r.param(name, v)
+		}
+		out[name] = masked
+	}
+	return out
+}
+
+// RedactHeaders returns a copy of captured headers with sensitive values
+// masked.
+func (r *Redactor) RedactHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	out := make(map[string]string, len(headers))
+	for name, v := range headers {
+		out[name] = r.param(name, v)
+	}
+	return out
+}
+
+func (r *Redactor) param(name, v string) string {
+	if r.sensitive(name) {
+		return maskString(v)
+	}
+	return r.redactString(name, v)
+}
+
+func (r *Redactor) value(name string, v interface{}) interface{} {
+	if name != "" && r.sensitive(name) {
+		return maskAll(v)
+	}
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, fv := range val {
+			out[k] = r.value(k, fv)
+		}
+		return out
+	case []interface{}:
+		// Elements are values of the array's field, so field rules apply
+		// to them too.
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = r.value(name, item)
+		}
+		return out
+	case string:
+		return r.redactString(name, val)
+	case float64:
+		if isCardNumber(val) {
+			return float64(0)
+		}
+	}
+	return v
+}
+
+func (r *Redactor) sensitive(name string) bool {
+	n := normalizeName(name)
+	if n == "" {
+		return false
+	}
+	if r.fields[n] {
+		return true
+	}
+	words := nameWords(name)
+	for _, f := range DefaultRedactedFields {
+		suffix := ""
+		for i := len(words) - 1; i >= 0 && len(suffix) < len(f); i-- {
+			suffix = words[i] + suffix
+			if suffix == f {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nameWords splits a field name into lowercase words at separators and
+// camelCase boundaries: "X-Session-Token", "sessionToken" and "APIKey" give
+// [x session token], [session token] and [api key].
+func nameWords(name string) []string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = nil
+		}
+	}
+	runes := []rune(name)
+	for i, c := range runes {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(c) && len(word) > 0 {
+			prev := word[len(word)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextLower {
+				flush()
+			}
+		}
+		word = append(word, c)
+	}
+	flush()
+	return words
+}
+
+// redactString masks the sensitive parts of a string. A value that is an
+// email address as a whole is replaced by a placeholder address, so the
+// field keeps its email format.
+func (r *Redactor) redactString(name, s string) string {
+	if s == "" || s == MaskedValue {
+		return s
+	}
+	if isEmail(s) {
+		return formatPlaceholders["email"]
+	}
+
+	out := jwtPattern.ReplaceAllString(s, MaskedValue)
+	out = apiKeyPattern.ReplaceAllString(out, MaskedValue)
+	out = emailPattern.ReplaceAllString(out, MaskedValue)
+	out = cardPattern.ReplaceAllStringFunc(out, func(m string) string {
+		if luhn(m) {
+			return MaskedValue
+		}
+		return m
+	})
+
+	n := normalizeName(name)
+	for _, rule := range r.rules {
+		if rule.field == "" || rule.field == n {
+			out = rule.pattern.ReplaceAllString(out, MaskedValue)
+		}
+	}
+	return out
+}
+
+// maskAll masks every scalar in a value, keeping the shape of objects and
+// arrays.
+func maskAll(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, fv := range val {
+			out[k] = maskAll(fv)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = maskAll(item)
+		}
+		return out
+	case string:
+		return maskString(val)
+	case float64:
+		return float64(0)
+	}
+	return v
+}
+
+func maskString(s string) string {
+	if s == "" {
+		return s
+	}
+	if p, ok := formatPlaceholders[detectFormat(s)]; ok {
+		return p
+	}
+	return MaskedValue
+}
+
+func isCardNumber(v float64) bool {
+	if v < 1e12 || v != float64(int64(v)) {
+		return false
+	}
+	return luhn(strconv.FormatInt(int64(v), 10))
+}
+
+// luhn reports whether the digits of s, ignoring spaces and dashes, form a
+// 13 to 19 digit number with a valid Luhn check digit.
+func luhn(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		if c < '0' || c > '9' {
+			return false
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && n <= 19 && sum%10 == 0
+}
+
+func normalizeName(name string) string {
+	var b strings.Builder
+	for _, c := range strings.ToLower(name) {
+		if c != '_' && c != '-' && c != '.' && c != ' ' {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,244 @@
+package runtime
+
+import (
+	"regexp"
+	"strings"
+)
+
+const paramSegment = "{}"
+
+var (
+	numericSegment = regexp.MustCompile(`^[0-9]+$`)
+	hexSegment     = regexp.MustCompile(`^[0-9a-fA-F]{16,}$`)
+	ulidSegment    = regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{26}$`)
+	templateParam  = regexp.MustCompile(`^\{[^}]*\}$`)
+)
+
+// PathTemplater maps concrete request paths such as /users/42/orders onto
+// templates such as /users/{}/orders, so observations of one route are
+// inferred as one endpoint. Learned parameters use the same "{}" form the
+// control plane stores endpoint paths in.
+type PathTemplater struct {
+	// MinCardinality is how many distinct values a segment position needs,
+	// among paths that agree on every other segment, before it becomes a
+	// parameter.
+	MinCardinality int
+	// MinUniqueness is the smallest share of those values that may have
+	// been observed only once. Real routes are hit repeatedly, most
+	// identifiers once.
+	MinUniqueness float64
+
+	known    [][]string
+	literals map[string]bool
+}
+
+// NewPathTemplater returns a templater that snaps paths to the known
+// templates first and only learns templates for paths none of them match.
+// Literal segments of known templates are never turned into parameters.
+func NewPathTemplater(known []string) *PathTemplater {
+	t := &PathTemplater{
+		MinCardinality: 10,
+		MinUniqueness:  0.5,
+		literals:       make(map[string]bool),
+	}
+	for _, path := range known {
+		segs := splitPath(path)
+		for i, seg := range segs {
+			if templateParam.MatchString(seg) {
+				segs[i] = paramSegment
+			} else {
+				t.literals[seg] = true
+			}
+		}
+		t.known = append(t.known, segs)
+	}
+	return t
+}
+
+// Snap returns the most specific known template matching path.
+func (t *PathTemplater) Snap(path string) (string, bool) {
+	segs := splitPath(path)
+	best, bestLiterals := -1, -1
+	for i, tmpl := range t.known {
+		if len(tmpl) != len(segs) {
+			continue
+		}
+		literals := 0
+		matched := true
+		for j, seg := range tmpl {
+			if seg == paramSegment {
+				continue
+			}
+			if seg != segs[j] {
+				matched = false
+				break
+			}
+			literals++
+		}
+		if matched && literals > bestLiterals {
+			best, bestLiterals = i, literals
+		}
+	}
+	if best < 0 {
+		return "", false
+	}
+	return joinPath(t.known[best]), true
+}
+
+type observedPath struct {
+	path  string
+	shape []string
+	count int
+}
+
+// Learn maps every distinct path in paths to its template. Paths are
+// snapped to known templates where possible; the rest have identifier-like
+// segments (numbers, UUIDs, long hex, ULIDs, mixed tokens) and
+// high-cardinality positions replaced by parameters.
+func (t *PathTemplater) Learn(paths []string) map[string]string {
+	result := make(map[string]string, len(paths))
+	counts := make(map[string]int, len(paths))
+	var order []string
+	for _, p := range paths {
+		if counts[p] == 0 {
+			order = append(order, p)
+		}
+		counts[p]++
+	}
+
+	byLen := make(map[int][]*observedPath)
+	for _, p := range order {
+		if tmpl, ok := t.Snap(p); ok {
+			result[p] = tmpl
+			continue
+		}
+		segs := splitPath(p)
+		shape := make([]string, len(segs))
+		for i, seg := range segs {
+			if !t.literals[seg] && isIdentifier(seg) {
+				shape[i] = paramSegment
+			} else {
+				shape[i] = seg
+			}
+		}
+		byLen[len(segs)] = append(byLen[len(segs)], &observedPath{path: p, shape: shape, count: counts[p]})
+	}
+
+	for _, group := range byLen {
+		t.collapseHighCardinality(group)
+		for _, op := range group {
+			tmpl := joinPath(op.shape)
+			if snapped, ok := t.Snap(tmpl); ok {
+				tmpl = snapped
+			}
+			result[op.path] = tmpl
+		}
+	}
+	return result
+}
+
+// collapseHighCardinality turns a position into a parameter when paths that
+// agree everywhere else take many, mostly unique values there.
+func (t *PathTemplater) collapseHighCardinality(group []*observedPath) {
+	if len(group) == 0 {
+		return
+	}
+	width := len(group[0].shape)
+	for pos := 0; pos < width; pos++ {
+		siblings := make(map[string][]*observedPath)
+		for _, op := range group {
+			siblings[siblingKey(op.shape, pos)] = append(siblings[siblingKey(op.shape, pos)], op)
+		}
+
+		for _, members := range siblings {
+			hits := make(map[string]int)
+			protected := false
+			for _, op := range members {
+				if op.shape[pos] == paramSegment {
+					continue
+				}
+				hits[op.shape[pos]] += op.count
+				protected = protected || t.literals[op.shape[pos]]
+			}
+			once := 0
+			for _, n := range hits {
+				if n == 1 {
+					once++
+				}
+			}
+			if protected || len(hits) < t.MinCardinality ||
+				float64(once) < t.MinUniqueness*float64(len(hits)) {
+				continue
+			}
+			for _, op := range members {
+				op.shape[pos] = paramSegment
+			}
+		}
+	}
+}
+
+func siblingKey(shape []string, pos int) string {
+	parts := make([]string, len(shape))
+	copy(parts, shape)
+	parts[pos] = "*"
+	return strings.Join(parts, "/")
+}
+
+// Apply learns templates from the requests and returns copies with their
+// paths rewritten.
+func (t *PathTemplater) Apply(requests []CapturedRequest) []CapturedRequest {
+	paths := make([]string, len(requests))
+	for i, req := range requests {
+		paths[i] = req.Path
+	}
+	return ApplyTemplates(requests, t.Learn(paths))
+}
+
+// ApplyTemplates returns copies of requests with paths found in templates
+// rewritten. The concrete path is kept in RawPath for path parameter
+// inference.
+func ApplyTemplates(requests []CapturedRequest, templates map[string]string) []CapturedRequest {
+	out := make([]CapturedRequest, len(requests))
+	for i, req := range requests {
+		if tmpl, ok := templates[req.Path]; ok {
+			if req.RawPath == "" {
+				req.RawPath = req.Path
+			}
+			req.Path = tmpl
+		}
+		out[i] = req
+	}
+	return out
+}
+
+// isIdentifier reports whether a single segment looks like a generated
+// identifier rather than part of a route.
+func isIdentifier(seg string) bool {
+	switch {
+	case numericSegment.MatchString(seg), uuidRegex.MatchString(seg),
+		hexSegment.MatchString(seg), ulidSegment.MatchString(seg):
+		return true
+	}
+	if len(seg) < 8 {
+		return false
+	}
+	digits := 0
+	for _, r := range seg {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	return digits*4 >= len(seg)
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return []string{}
+	}
+	return strings.Split(path, "/")
+}
+
+func joinPath(segs []string) string {
+	return "/" + strings.Join(segs, "/")
+}
@@ -0,0 +1,105 @@
+package runtime
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPathTemplaterLearn(t *testing.T) {
+	t.Run("identifier segments", func(t *testing.T) {
+		templates := NewPathTemplater(nil).Learn([]string{
+			"/users/42/orders/7",
+			"/users/8f3a2c1e-9b7d-4c3a-9e2f-0123456789ab",
+			"/files/507f1f77bcf86cd799439011",
+			"/runs/01ARZ3NDEKTSV4RRFFQ69G5FAV",
+			"/invoices/inv_20240117",
+			"/api/v2/health",
+			"/oauth2callback",
+		})
+		for path, want := range map[string]string{
+			"/users/42/orders/7":                          "/users/{}/orders/{}",
+			"/users/8f3a2c1e-9b7d-4c3a-9e2f-0123456789ab": "/users/{}",
+			"/files/507f1f77bcf86cd799439011":             "/files/{}",
+			"/runs/01ARZ3NDEKTSV4RRFFQ69G5FAV":            "/runs/{}",
+			"/invoices/inv_20240117":                      "/invoices/{}",
+			"/api/v2/health":                              "/api/v2/health",
+			"/oauth2callback":                             "/oauth2callback",
+		} {
+			if got := templates[path]; got != want {
+				t.Errorf("%s: got %s, want %s", path, got, want)
+			}
+		}
+	})
+
+	t.Run("high-cardinality slugs", func(t *testing.T) {
+		var paths []string
+		for i := 0; i < 12; i++ {
+			paths = append(paths, fmt.Sprintf("/posts/post-title-%c/comments", 'a'+i))
+		}
+		for i := 0; i < 20; i++ {
+			paths = append(paths, "/posts/popular/comments")
+		}
+		templates := NewPathTemplater(nil).Learn(paths)
+		if got := templates["/posts/post-title-a/comments"]; got != "/posts/{}/comments" {
+			t.Errorf("Expected slug to become a parameter, got %s", got)
+		}
+	})
+
+	t.Run("few distinct routes stay literal", func(t *testing.T) {
+		templates := NewPathTemplater(nil).Learn([]string{
+			"/api/users", "/api/projects", "/api/orders", "/api/items", "/api/health",
+		})
+		if got := templates["/api/users"]; got != "/api/users" {
+			t.Errorf("Expected literal route, got %s", got)
+		}
+	})
+
+	t.Run("many routes hit repeatedly stay literal", func(t *testing.T) {
+		var paths []string
+		for i := 0; i < 12; i++ {
+			for n := 0; n < 3; n++ {
+				paths = append(paths, fmt.Sprintf("/api/resource%c", 'a'+i))
+			}
+		}
+		templates := NewPathTemplater(nil).Learn(paths)
+		if got := templates["/api/resourcea"]; got != "/api/resourcea" {
+			t.Errorf("Expected literal route, got %s", got)
+		}
+	})
+
+	t.Run("known templates win", func(t *testing.T) {
+		templater := NewPathTemplater([]string{"/users/{}", "/users/me", "/teams/{}/members/{}"})
+		templates := templater.Learn([]string{"/users/alice", "/users/me", "/teams/core/members/bob"})
+		for path, want := range map[string]string{
+			"/users/alice":            "/users/{}",
+			"/users/me":               "/users/me",
+			"/teams/core/members/bob": "/teams/{}/members/{}",
+		} {
+			if got := templates[path]; got != want {
+				t.Errorf("%s: got %s, want %s", path, got, want)
+			}
+		}
+	})
+}
+
+func TestInferSchemaAfterTemplating(t *testing.T) {
+	var requests []CapturedRequest
+	for i := 1; i <= 3; i++ {
+		resp := map[string]interface{}{"id": float64(i)}
+		if i == 1 {
+			resp["nickname"] = "first"
+		}
+		requests = append(requests, CapturedRequest{
+			Path: fmt.Sprintf("/users/%d", i), Method: "GET", StatusCode: 200, Response: resp, ObservationCount: 1,
+		})
+	}
+
+	schemas := InferSchema(NewPathTemplater(nil).Apply(requests))
+	if len(schemas) != 1 || schemas[0].Endpoint != "/users/{}" {
+		t.Fatalf("Expected a single /users/{} endpoint, got %d schemas", len(schemas))
+	}
+	fields := schemas[0].Response[200].Fields
+	if !fields["id"].Required || fields["nickname"].Required {
+		t.Errorf("Expected id required and nickname optional across observations")
+	}
+}
@@ -6,20 +6,45 @@ const (
 	SourceBackendStatic  SchemaSource = "backend-static"
 	SourceFrontendStatic SchemaSource = "frontend-static"
 	SourceRuntime        SchemaSource = "runtime-observed"
+	SourceOpenAPI        SchemaSource = "openapi-spec"
 )
 
+func (s SchemaSource) IsKnown() bool {
+	switch s {
+	case SourceBackendStatic, SourceFrontendStatic, SourceRuntime, SourceOpenAPI:
+		return true
+	}
+	return false
+}
+
 type SchemaIR struct {
 	Endpoint string                `json:"endpoint"`
 	Method   string                `json:"method"`
 	Source   SchemaSource          `json:"source"`
 	Request  *ObjectSchema         `json:"request,omitempty"`
 	Response map[int]*ObjectSchema `json:"response,omitempty"`
+
+	// PathParams are ordered by their position in the endpoint path. Stored
+	// endpoints have anonymous "{}" segments and codebases name the same
+	// parameter differently, so position is what identifies them.
+	PathParams  []PathParam       `json:"path_params,omitempty"`
+	QueryParams map[string]*Field `json:"query_params,omitempty"`
+	// Headers are keyed by lowercase header name.
+	Headers map[string]*Field `json:"headers,omitempty"`
+}
+
+type PathParam struct {
+	Name string `json:"name,omitempty"`
+	Field
 }
 
 type ObjectSchema struct {
 	Type   string            `json:"type"`
 	Fields map[string]*Field `json:"fields,omitempty"`
 	Items  *ObjectSchema     `json:"items,omitempty"`
+	// ContentType is the media type of a body that is not JSON, such as
+	// application/x-www-form-urlencoded or multipart/form-data.
+	ContentType string `json:"content_type,omitempty"`
 }
 
 type Field struct {
@@ -28,6 +53,17 @@ type Field struct {
 	Nested     *ObjectSchema `json:"nested,omitempty"`
 	Confidence float64       `json:"confidence,omitempty"`
 	SourceTag  SchemaSource  `json:"source_tag,omitempty"`
+
+	// Constraints narrow the values a field may hold. Zero values mean the
+	// source does not constrain the field.
+	Nullable  bool          `json:"nullable,omitempty"`
+	Enum      []interface{} `json:"enum,omitempty"`
+	Format    string        `json:"format,omitempty"`
+	Pattern   string        `json:"pattern,omitempty"`
+	Minimum   *float64      `json:"minimum,omitempty"`
+	Maximum   *float64      `json:"maximum,omitempty"`
+	MinLength *int          `json:"min_length,omitempty"`
+	MaxLength *int          `json:"max_length,omitempty"`
 }
 
 type MatchStatus string
@@ -55,6 +55,18 @@ var LanguageHints = map[string]string{
 	".swift": "Swift",
 }
 
+// ManifestFiles are the dependency manifests read to detect frameworks.
+var ManifestFiles = map[string]bool{
+	"go.mod":           true,
+	"package.json":     true,
+	"pyproject.toml":   true,
+	"requirements.txt": true,
+}
+
+func IsManifest(path string) bool {
+	return ManifestFiles[filepath.Base(path)]
+}
+
 func IsTestFile(path string) bool {
 	lower := strings.ToLower(path)
 	return strings.HasSuffix(lower, "_test.go") ||
@@ -77,3 +89,32 @@ func InSkippedDir(path string) bool {
 	}
 	return false
 }
+
+// Priority ranks a file by how likely its name says it declares endpoints:
+// 1 for routing, 2 for handlers, 3 for models and types, 4 for the rest.
+func Priority(name string) int {
+	lower := strings.ToLower(filepath.Base(name))
+
+	p1 := []string{"route", "router", "urls", "api", "endpoint", "path"}
+	for _, kw := range p1 {
+		if strings.Contains(lower, kw) {
+			return 1
+		}
+	}
+
+	p2 := []string{"handler", "controller", "view", "resource", "middleware"}
+	for _, kw := range p2 {
+		if strings.Contains(lower, kw) {
+			return 2
+		}
+	}
+
+	p3 := []string{"model", "schema", "dto", "type", "entity", "struct"}
+	for _, kw := range p3 {
+		if strings.Contains(lower, kw) {
+			return 3
+		}
+	}
+
+	return 4
+}
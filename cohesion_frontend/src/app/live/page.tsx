@@ -92,7 +92,7 @@ export default function LivePage() {
         api.live
             .getRequests(selectedProjectId)
             .then((data) => {
-                setRequests(data.reverse());
+                setRequests(data);
             })
             .catch(() => {
                 setRequests([]);
@@ -199,8 +199,10 @@ export default function LivePage() {
         return "text-red-400";
     };
 
-    const formatBody = (body?: Record<string, unknown>) => {
-        if (!body || Object.keys(body).length === 0) return "No body";
+    const formatBody = (body?: unknown) => {
+        if (body === undefined || body === null || body === "") return "No body";
+        if (typeof body === "string") return body;
+        if (!Array.isArray(body) && typeof body === "object" && Object.keys(body).length === 0) return "No body";
         return JSON.stringify(body, null, 2);
     };
 
@@ -10,6 +10,31 @@ import { Button } from "@/components/ui/button";
 import { Card } from "@/components/ui/card";
 import { Input } from "@/components/ui/input";
 import { api } from "@/lib/api";
+import type { LLMProvider } from "@/lib/types";
+
+const LLM_PROVIDERS: Record<LLMProvider, { label: string; keyPlaceholder: string; modelPlaceholder: string; keyUrl: string; keySite: string }> = {
+    gemini: {
+        label: "Google Gemini",
+        keyPlaceholder: "Enter your Gemini API key",
+        modelPlaceholder: "gemini-2.5-flash",
+        keyUrl: "https://aistudio.google.com/apikey",
+        keySite: "Google AI Studio",
+    },
+    openai: {
+        label: "OpenAI-compatible (OpenAI, Ollama, vLLM)",
+        keyPlaceholder: "Enter your API key (optional with a base URL)",
+        modelPlaceholder: "gpt-4o-mini",
+        keyUrl: "https://platform.openai.com/api-keys",
+        keySite: "the OpenAI dashboard",
+    },
+    anthropic: {
+        label: "Anthropic",
+        keyPlaceholder: "Enter your Anthropic API key",
+        modelPlaceholder: "claude-sonnet-4-5",
+        keyUrl: "https://console.anthropic.com/settings/keys",
+        keySite: "the Anthropic Console",
+    },
+};
 
 interface GitHubInstallation {
     id: string;
@@ -31,8 +56,10 @@ function SettingsContent() {
     const searchParams = useSearchParams();
     const [saving, setSaving] = useState(false);
     const [loading, setLoading] = useState(true);
+    const [llmProvider, setLlmProvider] = useState<LLMProvider>("gemini");
     const [llmApiKey, setLlmApiKey] = useState("");
     const [llmModel, setLlmModel] = useState("");
+    const [llmBaseUrl, setLlmBaseUrl] = useState("");
     const [githubToken, setGithubToken] = useState("");
     const [showApiKey, setShowApiKey] = useState(false);
     const [showGithubToken, setShowGithubToken] = useState(false);
@@ -54,8 +81,10 @@ function SettingsContent() {
     useEffect(() => {
         api.userSettings.get()
             .then((settings) => {
-                if (settings.gemini_api_key) setLlmApiKey(settings.gemini_api_key);
-                if (settings.gemini_model) setLlmModel(settings.gemini_model);
+                if (settings.llm_provider) setLlmProvider(settings.llm_provider);
+                if (settings.llm_api_key) setLlmApiKey(settings.llm_api_key);
+                if (settings.llm_model) setLlmModel(settings.llm_model);
+                if (settings.llm_base_url) setLlmBaseUrl(settings.llm_base_url);
                 if (settings.github_token) setGithubToken(settings.github_token);
             })
             .catch(() => {})
@@ -102,7 +131,14 @@ function SettingsContent() {
     const handleSave = async () => {
         setSaving(true);
         try {
-            await api.userSettings.save(llmApiKey, llmModel, githubToken);
+            const configured = llmApiKey !== "" || llmBaseUrl !== "";
+            await api.userSettings.save({
+                llm_provider: configured ? llmProvider : "",
+                llm_api_key: llmApiKey,
+                llm_model: llmModel,
+                llm_base_url: llmBaseUrl,
+                github_token: githubToken,
+            });
             toast.success("Settings saved");
         } catch (e) {
             toast.error("Failed to save settings", {
@@ -151,6 +187,21 @@ function SettingsContent() {
                             </div>
                         ) : (
                             <div className="space-y-4">
+                                <div>
+                                    <label className="block text-xs text-white/50 mb-2">Provider</label>
+                                    <select
+                                        value={llmProvider}
+                                        onChange={(e) => setLlmProvider(e.target.value as LLMProvider)}
+                                        className="w-full text-sm bg-white/5 border border-white/10 rounded-md px-3 py-2 text-white/80 focus:outline-none focus:border-white/30"
+                                    >
+                                        {(Object.keys(LLM_PROVIDERS) as LLMProvider[]).map((p) => (
+                                            <option key={p} value={p}>
+                                                {LLM_PROVIDERS[p].label}
+                                            </option>
+                                        ))}
+                                    </select>
+                                </div>
+
                                 <div>
                                     <label className="block text-xs text-white/50 mb-2">API Key</label>
                                     <div className="relative">
@@ -158,7 +209,7 @@ function SettingsContent() {
                                             type={showApiKey ? "text" : "password"}
                                             value={llmApiKey}
                                             onChange={(e) => setLlmApiKey(e.target.value)}
-                                            placeholder="Enter your Gemini API key"
+                                            placeholder={LLM_PROVIDERS[llmProvider].keyPlaceholder}
                                             className="pr-9"
                                         />
                                         <button
@@ -172,12 +223,12 @@ function SettingsContent() {
                                     <p className="text-[10px] text-white/30 mt-1.5">
                                         Get a key from{" "}
                                         <a
-                                            href="https://aistudio.google.com/apikey"
+                                            href={LLM_PROVIDERS[llmProvider].keyUrl}
                                             target="_blank"
                                             rel="noopener noreferrer"
                                             className="text-blue-400 hover:underline"
                                         >
-                                            Google AI Studio
+                                            {LLM_PROVIDERS[llmProvider].keySite}
                                         </a>
                                         . Encrypted and stored on our server.
                                     </p>
@@ -188,16 +239,30 @@ function SettingsContent() {
                                     <Input
                                         value={llmModel}
                                         onChange={(e) => setLlmModel(e.target.value)}
-                                        placeholder="gemini-2.5-flash"
+                                        placeholder={LLM_PROVIDERS[llmProvider].modelPlaceholder}
                                     />
                                     <p className="text-[10px] text-white/30 mt-1.5">
                                         Leave blank to use the default model.
                                     </p>
                                 </div>
 
+                                {llmProvider === "openai" && (
+                                    <div>
+                                        <label className="block text-xs text-white/50 mb-2">Base URL</label>
+                                        <Input
+                                            value={llmBaseUrl}
+                                            onChange={(e) => setLlmBaseUrl(e.target.value)}
+                                            placeholder="https://api.openai.com/v1"
+                                        />
+                                        <p className="text-[10px] text-white/30 mt-1.5">
+                                            Any OpenAI-compatible server reachable from the internet. Leave blank for OpenAI.
+                                        </p>
+                                    </div>
+                                )}
+
                                 <div className="flex items-center gap-2">
                                     <span className="text-xs text-white/50">Status:</span>
-                                    {llmApiKey ? (
+                                    {llmApiKey || llmBaseUrl ? (
                                         <span className="text-xs text-green-400 flex items-center gap-1">
                                             <Check className="w-3 h-3" />
                                             Configured
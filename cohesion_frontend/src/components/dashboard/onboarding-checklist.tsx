@@ -56,14 +56,14 @@ export function OnboardingChecklist({
         setDiffViewed(localStorage.getItem(DIFF_VIEWED_KEY) === "true");
 
         api.userSettings.get()
-            .then((s) => setHasApiKey(Boolean(s.gemini_api_key?.trim())))
+            .then((s) => setHasApiKey(Boolean(s.llm_api_key?.trim() || s.llm_base_url?.trim())))
             .catch(() => {});
     }, []);
 
     useEffect(() => {
         const syncApiKeyState = () => {
             api.userSettings.get()
-                .then((s) => setHasApiKey(Boolean(s.gemini_api_key?.trim())))
+                .then((s) => setHasApiKey(Boolean(s.llm_api_key?.trim() || s.llm_base_url?.trim())))
                 .catch(() => {});
         };
 
@@ -121,10 +121,10 @@ export function OnboardingChecklist({
             actionLabel: "Create",
         },
         {
-            id: "add-gemini-api-key",
-            title: "Add Gemini API key (required)",
+            id: "add-llm-api-key",
+            title: "Add LLM API key (required)",
             description:
-                "Required: add your Gemini API key in Settings. Analysis will not work without this step.",
+                "Required: choose an LLM provider (Gemini, OpenAI-compatible or Anthropic) and add its API key in Settings. Analysis will not work without this step.",
             icon: <Key className="w-3.5 h-3.5" />,
             completed: hasApiKey,
             action: () => router.push("/settings"),
@@ -99,8 +99,10 @@ function RequestDetail({
 }: {
     request: LiveCapturedRequest | null;
 }) {
-    const formatBody = (body?: Record<string, unknown>) => {
-        if (!body || Object.keys(body).length === 0) return "No body";
+    const formatBody = (body?: unknown) => {
+        if (body === undefined || body === null || body === "") return "No body";
+        if (typeof body === "string") return body;
+        if (!Array.isArray(body) && typeof body === "object" && Object.keys(body).length === 0) return "No body";
         return JSON.stringify(body, null, 2);
     };
 
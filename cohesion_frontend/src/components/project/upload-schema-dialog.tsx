@@ -14,7 +14,7 @@ import {
     DialogFooter,
 } from "@/components/ui/dialog";
 import { api } from "@/lib/api";
-import { SchemaIR, SchemaSource } from "@/lib/types";
+import { SchemaIR, SchemaSource, ScanResult, ScanJob, ScanJobEvent, ScanJobPhase } from "@/lib/types";
 
 interface UploadSchemaDialogProps {
     projectId: string;
@@ -35,6 +35,9 @@ const SOURCE_EXTENSIONS = new Set([
     ".java", ".rb", ".rs", ".php", ".cs", ".kt",
 ]);
 
+// Dependency manifests let the server pick a deterministic analyzer.
+const MANIFEST_FILES = new Set(["go.mod", "package.json", "pyproject.toml", "requirements.txt"]);
+
 const SKIP_DIRS = new Set([
     "vendor", ".git", "node_modules", "__pycache__",
     ".venv", "venv", "dist", "build", "target",
@@ -44,6 +47,58 @@ const SKIP_DIRS = new Set([
 const MAX_FILE_SIZE = 100 * 1024;
 const MAX_TOTAL_SIZE = 10 * 1024 * 1024;
 
+function describeScan(result: ScanResult): string {
+    const parts = [`${result.count} endpoints found`];
+    if (result.analyzers.length > 0) parts.push(`static analysis: ${result.analyzers.join(", ")}`);
+    if (result.fallback_files > 0) {
+        const chunks = result.chunks > 1 ? ` in ${result.chunks} chunks` : "";
+        parts.push(`${result.fallback_files} files analyzed by the LLM${chunks}`);
+        if (result.cache.hits > 0) parts.push(`${result.cache.cached_files} unchanged files reused from the cache`);
+    }
+    if (result.skipped_files > 0) parts.push(`${result.skipped_files} files skipped`);
+    const skippedTypes = result.skipped_types ?? [];
+    if (skippedTypes.length > 0) {
+        const names = skippedTypes.slice(0, 3).map((t) => t.type).join(", ");
+        const more = skippedTypes.length > 3 ? ", …" : "";
+        parts.push(`${skippedTypes.length} unresolved types (${names}${more})`);
+    }
+    return parts.join(" · ");
+}
+
+const PHASE_LABELS: Record<ScanJobPhase, string> = {
+    fetch: "Fetching files",
+    discover: "Detecting frameworks",
+    analyze: "Analyzing",
+    upload: "Saving schemas",
+};
+
+function describeProgress(job: ScanJob): string {
+    const label = job.phase ? PHASE_LABELS[job.phase] : "Queued";
+    return job.file_count > 0 ? `${label} · ${job.file_count} files` : label;
+}
+
+// Resolves with the job once it finishes. If the stream drops first,
+// EventSource reconnects and the server replays the job's current state.
+async function followScanJob(projectId: string, jobId: string, onProgress: (job: ScanJob) => void): Promise<ScanJob> {
+    const url = await api.scanJobs.streamUrl(projectId, jobId);
+    return new Promise((resolve) => {
+        const es = new EventSource(url);
+        es.onmessage = (event) => {
+            try {
+                const { job } = JSON.parse(event.data) as ScanJobEvent;
+                if (job.status === "completed" || job.status === "failed" || job.status === "cancelled") {
+                    es.close();
+                    resolve(job);
+                } else {
+                    onProgress(job);
+                }
+            } catch {
+                /* ignore parse errors */
+            }
+        };
+    });
+}
+
 type ScanTab = "scan-backend" | "scan-frontend" | "github" | "manual";
 
 const hasDirectoryPicker = typeof window !== "undefined" && "showDirectoryPicker" in window;
@@ -64,7 +119,7 @@ async function readDirectoryRecursive(
                 await walk(entry as FileSystemDirectoryHandle, currentPath ? `${currentPath}/${entry.name}` : entry.name);
             } else if (entry.kind === "file") {
                 const ext = entry.name.includes(".") ? "." + entry.name.split(".").pop()!.toLowerCase() : "";
-                if (!SOURCE_EXTENSIONS.has(ext)) continue;
+                if (!SOURCE_EXTENSIONS.has(ext) && !MANIFEST_FILES.has(entry.name)) continue;
 
                 const file = await (entry as FileSystemFileHandle).getFile();
                 if (file.size > MAX_FILE_SIZE) continue;
@@ -94,7 +149,7 @@ export function UploadSchemaDialog({ projectId, open, onOpenChange, onSuccess, o
     const [ghBranch, setGhBranch] = useState("");
     const [ghPath, setGhPath] = useState("");
     const [ghScanType, setGhScanType] = useState<"backend" | "frontend">("backend");
-    const [hasGeminiKey, setHasGeminiKey] = useState(true);
+    const [hasLlmProvider, setHasLlmProvider] = useState(true);
     const [ghRepos, setGhRepos] = useState<Array<{ full_name: string; owner: string; name: string; private: boolean; default_branch: string }>>([]);
     const [ghReposLoading, setGhReposLoading] = useState(false);
     const [ghRepoSearch, setGhRepoSearch] = useState("");
@@ -103,7 +158,7 @@ export function UploadSchemaDialog({ projectId, open, onOpenChange, onSuccess, o
     useEffect(() => {
         if (open) {
             api.userSettings.get().then((settings) => {
-                setHasGeminiKey(settings.gemini_api_key !== "");
+                setHasLlmProvider(settings.llm_api_key !== "" || settings.llm_base_url !== "");
             }).catch(() => {});
             api.github.status().then((s) => setGhHasApp(s.configured)).catch(() => {});
         }
@@ -153,20 +208,18 @@ export function UploadSchemaDialog({ projectId, open, onOpenChange, onSuccess, o
         onUploadStart?.();
 
         try {
-            if (filesToSend.length > 0) {
-                await api.schemas.scan(projectId, {
+            const result = filesToSend.length > 0
+                ? await api.schemas.scan(projectId, {
                     files: filesToSend,
                     scan_type: scanType,
-                });
-            } else {
-                await api.schemas.scan(projectId, {
+                })
+                : await api.schemas.scan(projectId, {
                     dir_path: pathToSend,
                     scan_type: scanType,
                 });
-            }
 
             toast.success("Analysis complete", {
-                description: `${scanType === "frontend" ? "Frontend" : "Backend"} scan finished successfully`,
+                description: describeScan(result),
             });
             onSuccess?.();
         } catch (e) {
@@ -193,17 +246,43 @@ export function UploadSchemaDialog({ projectId, open, onOpenChange, onSuccess, o
         onUploadStart?.();
 
         try {
-            await api.schemas.scanGitHub(projectId, {
+            const queued = await api.schemas.scanGitHub(projectId, {
                 repo_url: repoUrl,
                 branch,
                 path,
                 scan_type: scanType,
             });
 
-            toast.success("Analysis complete", {
-                description: `GitHub ${scanType} scan finished successfully`,
+            const cancel = {
+                label: "Cancel",
+                onClick: () => {
+                    api.scanJobs.cancel(projectId, queued.id).catch(() => {});
+                },
+            };
+            const toastId = toast.loading(`Scanning ${repoUrl}`, {
+                description: describeProgress(queued),
+                action: cancel,
             });
-            onSuccess?.();
+
+            const job = await followScanJob(projectId, queued.id, (job) => {
+                toast.loading(`Scanning ${repoUrl}`, {
+                    id: toastId,
+                    description: describeProgress(job),
+                    action: cancel,
+                });
+            });
+
+            if (job.status === "completed" && job.summary) {
+                toast.success("Analysis complete", {
+                    id: toastId,
+                    description: describeScan(job.summary),
+                });
+                onSuccess?.();
+            } else if (job.status === "cancelled") {
+                toast.info("GitHub scan cancelled", { id: toastId, description: repoUrl });
+            } else {
+                toast.error("GitHub scan failed", { id: toastId, description: job.error });
+            }
         } catch (e) {
             toast.error("GitHub scan failed", {
                 description: (e as Error).message,
@@ -368,13 +447,13 @@ export function UploadSchemaDialog({ projectId, open, onOpenChange, onSuccess, o
                                 </p>
                             </div>
 
-                            {!hasGeminiKey && (
+                            {!hasLlmProvider && (
                                 <div className="flex items-center gap-2 p-2.5 border border-amber-500/30 rounded bg-amber-500/10">
                                     <AlertTriangle className="w-4 h-4 text-amber-400 shrink-0" />
                                     <p className="text-xs text-amber-300">
-                                        No Gemini API key configured. Add one in{" "}
-                                        <a href="/settings" className="underline hover:text-amber-200">Settings</a>{" "}
-                                        before scanning.
+                                        No LLM provider configured. Go backends using net/http, chi, gin or echo are
+                                        analyzed without one; for other code, add one in{" "}
+                                        <a href="/settings" className="underline hover:text-amber-200">Settings</a>.
                                     </p>
                                 </div>
                             )}
@@ -435,13 +514,13 @@ export function UploadSchemaDialog({ projectId, open, onOpenChange, onSuccess, o
                     {/* GitHub tab content */}
                     {isGitHubTab && (
                         <div className="space-y-4 py-2">
-                            {!hasGeminiKey && (
+                            {!hasLlmProvider && (
                                 <div className="flex items-center gap-2 p-2.5 border border-amber-500/30 rounded bg-amber-500/10">
                                     <AlertTriangle className="w-4 h-4 text-amber-400 shrink-0" />
                                     <p className="text-xs text-amber-300">
-                                        No Gemini API key configured. Add one in{" "}
-                                        <a href="/settings" className="underline hover:text-amber-200">Settings</a>{" "}
-                                        before scanning.
+                                        No LLM provider configured. Go backends using net/http, chi, gin or echo are
+                                        analyzed without one; for other code, add one in{" "}
+                                        <a href="/settings" className="underline hover:text-amber-200">Settings</a>.
                                     </p>
                                 </div>
                             )}
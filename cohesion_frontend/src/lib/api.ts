@@ -1,4 +1,4 @@
-import { Project, Endpoint, DiffResult, SchemaIR, LiveCapturedRequest, LiveDiffResponse } from "./types";
+import { Project, Endpoint, DiffResult, SchemaIR, LiveCapturedRequest, LiveCaptureFilter, LiveDiffResponse, APIKey, APIKeyScope, CreatedAPIKey, Role, Organization, OrganizationDetail, Invitation, CreatedInvitation, UserSettings, ScanResult, ScanJob, AnalysisCacheStats } from "./types";
 import { getAuthToken } from "@/lib/auth";
 import { captureAround } from "@/lib/live-capture";
 
@@ -55,13 +55,61 @@ export const api = {
     projects: {
         list: () => fetchAPI<Project[]>("/api/projects"),
         get: (id: string) => fetchAPI<Project>(`/api/projects/${id}`),
-        create: (name: string, description: string) =>
+        create: (name: string, description: string, organizationId?: string) =>
             fetchAPI<Project>("/api/projects", {
                 method: "POST",
-                body: JSON.stringify({ name, description }),
+                body: JSON.stringify({ name, description, organization_id: organizationId }),
             }),
         delete: (id: string) =>
             fetchAPI<void>(`/api/projects/${id}`, { method: "DELETE" }),
+        move: (id: string, organizationId: string | null) =>
+            fetchAPI<Project>(`/api/projects/${id}/organization`, {
+                method: "PUT",
+                body: JSON.stringify({ organization_id: organizationId }),
+            }),
+    },
+
+    organizations: {
+        list: () => fetchAPI<Organization[]>("/api/organizations"),
+        get: (id: string) => fetchAPI<OrganizationDetail>(`/api/organizations/${id}`),
+        create: (name: string) =>
+            fetchAPI<Organization>("/api/organizations", {
+                method: "POST",
+                body: JSON.stringify({ name }),
+            }),
+        updateMember: (id: string, userId: string, role: Role) =>
+            fetchAPI<{ message: string }>(`/api/organizations/${id}/members/${userId}`, {
+                method: "PUT",
+                body: JSON.stringify({ role }),
+            }),
+        removeMember: (id: string, userId: string) =>
+            fetchAPI<void>(`/api/organizations/${id}/members/${userId}`, { method: "DELETE" }),
+        listInvitations: (id: string) =>
+            fetchAPI<Invitation[]>(`/api/organizations/${id}/invitations`),
+        invite: (id: string, email: string, role: Role) =>
+            fetchAPI<CreatedInvitation>(`/api/organizations/${id}/invitations`, {
+                method: "POST",
+                body: JSON.stringify({ email, role }),
+            }),
+        revokeInvitation: (id: string, invitationId: string) =>
+            fetchAPI<void>(`/api/organizations/${id}/invitations/${invitationId}`, { method: "DELETE" }),
+        acceptInvitation: (token: string) =>
+            fetchAPI<Organization>("/api/invitations/accept", {
+                method: "POST",
+                body: JSON.stringify({ token }),
+            }),
+    },
+
+    apiKeys: {
+        list: (projectId: string) =>
+            fetchAPI<APIKey[]>(`/api/projects/${projectId}/api-keys`),
+        create: (projectId: string, name: string, scopes: APIKeyScope[]) =>
+            fetchAPI<CreatedAPIKey>(`/api/projects/${projectId}/api-keys`, {
+                method: "POST",
+                body: JSON.stringify({ name, scopes }),
+            }),
+        revoke: (projectId: string, keyId: string) =>
+            fetchAPI<void>(`/api/projects/${projectId}/api-keys/${keyId}`, { method: "DELETE" }),
     },
 
     endpoints: {
@@ -91,7 +139,7 @@ export const api = {
             files?: Array<{ path: string; content: string }>;
             scan_type: "backend" | "frontend";
         }) =>
-            fetchAPI<{ message: string; count: number }>("/api/analyze/scan", {
+            fetchAPI<ScanResult>("/api/analyze/scan", {
                 method: "POST",
                 body: JSON.stringify({
                     project_id: projectId,
@@ -104,7 +152,7 @@ export const api = {
             path?: string;
             scan_type: "backend" | "frontend";
         }) =>
-            fetchAPI<{ message: string; count: number }>("/api/analyze/github", {
+            fetchAPI<ScanJob>("/api/analyze/github", {
                 method: "POST",
                 body: JSON.stringify({
                     project_id: projectId,
@@ -113,6 +161,37 @@ export const api = {
             }),
     },
 
+    analysisCache: {
+        get: (projectId: string) =>
+            fetchAPI<AnalysisCacheStats>(`/api/projects/${projectId}/analysis-cache`),
+        invalidate: (projectId: string, fingerprint?: string) =>
+            fetchAPI<{ message: string; deleted: number }>(
+                `/api/projects/${projectId}/analysis-cache${fingerprint ? `?fingerprint=${encodeURIComponent(fingerprint)}` : ""}`,
+                { method: "DELETE" },
+            ),
+    },
+
+    scanJobs: {
+        list: (projectId: string) =>
+            fetchAPI<ScanJob[]>(`/api/projects/${projectId}/scan-jobs`),
+        get: (projectId: string, jobId: string) =>
+            fetchAPI<ScanJob>(`/api/projects/${projectId}/scan-jobs/${jobId}`),
+        cancel: (projectId: string, jobId: string) =>
+            fetchAPI<{ message: string }>(`/api/projects/${projectId}/scan-jobs/${jobId}/cancel`, {
+                method: "POST",
+            }),
+        retry: (projectId: string, jobId: string) =>
+            fetchAPI<ScanJob>(`/api/projects/${projectId}/scan-jobs/${jobId}/retry`, {
+                method: "POST",
+            }),
+        streamUrl: async (projectId: string, jobId: string) => {
+            const token = await getAuthToken();
+            const params = new URLSearchParams();
+            if (token) params.set("token", token);
+            return `${API_BASE}/api/projects/${projectId}/scan-jobs/${jobId}/stream?${params}`;
+        },
+    },
+
     diff: {
         compute: (endpointId: string) =>
             fetchAPI<DiffResult>(`/api/diff/${endpointId}`, { method: "POST" }),
@@ -124,12 +203,11 @@ export const api = {
     },
 
     userSettings: {
-        get: () =>
-            fetchAPI<{ gemini_api_key: string; gemini_model: string; github_token: string }>("/api/user/settings"),
-        save: (geminiApiKey: string, geminiModel: string, githubToken: string) =>
+        get: () => fetchAPI<UserSettings>("/api/user/settings"),
+        save: (settings: UserSettings) =>
             fetchAPI<{ message: string }>("/api/user/settings", {
                 method: "PUT",
-                body: JSON.stringify({ gemini_api_key: geminiApiKey, gemini_model: geminiModel, github_token: githubToken }),
+                body: JSON.stringify(settings),
             }),
     },
 
@@ -170,8 +248,13 @@ export const api = {
                 method: "POST",
                 body: JSON.stringify({ project_id: projectId, requests }),
             }),
-        getRequests: (projectId: string) =>
-            fetchAPI<LiveCapturedRequest[]>(`/api/live/requests?project_id=${projectId}`),
+        getRequests: (projectId: string, filter: LiveCaptureFilter = {}) => {
+            const params = new URLSearchParams({ project_id: projectId });
+            for (const [key, value] of Object.entries(filter)) {
+                if (value !== undefined && value !== "") params.set(key, String(value));
+            }
+            return fetchAPI<LiveCapturedRequest[]>(`/api/live/requests?${params}`);
+        },
         infer: (projectId: string) =>
             fetchAPI<{ message: string; count: number }>("/api/live/infer", {
                 method: "POST",
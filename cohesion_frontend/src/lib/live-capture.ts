@@ -8,8 +8,10 @@ interface CapturedCall {
     method: string;
     status_code: number;
     duration_ms: number;
-    request_body?: Record<string, unknown>;
-    response_body?: Record<string, unknown>;
+    request_body?: unknown;
+    response_body?: unknown;
+    request_content_type?: string;
+    response_content_type?: string;
     source: string;
 }
 
@@ -119,25 +121,21 @@ export async function captureAround(
 
     // Clone the response so we can read the body without consuming it
     const cloned = response.clone();
-    let responseBody: Record<string, unknown> | undefined;
+    let responseBody: unknown;
     try {
         responseBody = await cloned.json();
     } catch {
         // Non-JSON response, skip body
     }
 
-    let reqBody: Record<string, unknown> | undefined;
-    if (requestBody && typeof requestBody === "object") {
-        reqBody = requestBody as Record<string, unknown>;
-    }
-
     enqueue({
         path,
         method: method.toUpperCase(),
         status_code: response.status,
         duration_ms: Math.round(durationMs),
-        request_body: reqBody,
+        request_body: requestBody,
         response_body: responseBody,
+        response_content_type: response.headers.get("content-type") ?? undefined,
         source: "frontend",
     });
 
@@ -1,7 +1,11 @@
+export type Role = "owner" | "editor" | "viewer";
+
 export interface Project {
   id: string;
   name: string;
   description: string;
+  organization_id?: string;
+  role?: Role;
   created_at: string;
   updated_at: string;
 }
@@ -34,10 +38,18 @@ export interface SchemaIR {
   source: SchemaSource;
   request?: ObjectSchema;
   response?: Record<number, ObjectSchema>;
+  path_params?: PathParam[];
+  query_params?: Record<string, Field>;
+  headers?: Record<string, Field>;
+}
+
+export interface PathParam extends Field {
+  name?: string;
 }
 
 export interface ObjectSchema {
   type: string;
+  content_type?: string;
   fields?: Record<string, Field>;
   items?: ObjectSchema;
 }
@@ -46,11 +58,26 @@ export interface Field {
   type: string;
   required: boolean;
   nested?: ObjectSchema;
+  nullable?: boolean;
+  enum?: (string | number | boolean)[];
+  format?: string;
+  pattern?: string;
+  minimum?: number;
+  maximum?: number;
+  min_length?: number;
+  max_length?: number;
 }
 
 export type MatchStatus = "match" | "partial" | "violation";
 
-export type MismatchType = "missing" | "type_mismatch" | "optionality_mismatch" | "extra_field";
+export type MismatchType =
+  | "missing"
+  | "type_mismatch"
+  | "optionality_mismatch"
+  | "extra_field"
+  | "nullability_mismatch"
+  | "enum_mismatch"
+  | "constraint_mismatch";
 
 export type Severity = "critical" | "warning" | "info";
 
@@ -80,11 +107,176 @@ export interface LiveCapturedRequest {
   method: string;
   status_code: number;
   duration_ms: number;
-  request_body?: Record<string, unknown>;
-  response_body?: Record<string, unknown>;
+  request_body?: unknown;
+  response_body?: unknown;
+  request_content_type?: string;
+  response_content_type?: string;
+  source?: string;
+  query_params?: Record<string, string[]>;
+  headers?: Record<string, string>;
+}
+
+export interface LiveCaptureFilter {
+  limit?: number;
+  offset?: number;
+  since?: string;
+  until?: string;
   source?: string;
 }
 
+export type APIKeyScope = "ingest" | "read" | "scan";
+
+export interface APIKey {
+  id: string;
+  project_id: string;
+  name: string;
+  prefix: string;
+  scopes: APIKeyScope[];
+  created_by: string;
+  created_at: string;
+  last_used_at?: string;
+  revoked_at?: string;
+}
+
+export interface CreatedAPIKey extends APIKey {
+  token: string;
+}
+
+export interface Organization {
+  id: string;
+  name: string;
+  created_by: string;
+  created_at: string;
+  role?: Role;
+}
+
+export interface OrganizationMember {
+  organization_id: string;
+  user_id: string;
+  role: Role;
+  created_at: string;
+}
+
+export interface OrganizationDetail extends Organization {
+  members: OrganizationMember[];
+}
+
+export interface Invitation {
+  id: string;
+  organization_id: string;
+  email?: string;
+  role: Role;
+  invited_by: string;
+  created_at: string;
+  expires_at: string;
+}
+
+export interface CreatedInvitation extends Invitation {
+  token: string;
+}
+
+export type LLMProvider = "gemini" | "openai" | "anthropic";
+
+export interface UserSettings {
+  /** Empty when the user relies on the server's provider. */
+  llm_provider: LLMProvider | "";
+  llm_api_key: string;
+  llm_model: string;
+  llm_base_url: string;
+  github_token: string;
+}
+
+export interface ScanDetection {
+  language: string;
+  languages: Record<string, number>;
+  frameworks?: Record<string, string[]>;
+}
+
+export interface ScanResult {
+  message: string;
+  count: number;
+  detection: ScanDetection;
+  /** Deterministic analyzers that produced schemas. */
+  analyzers: string[];
+  /** Files sent to the LLM provider because no analyzer covered them. */
+  fallback_files: number;
+  /** Prompts the LLM fallback was split into. */
+  chunks: number;
+  /** How many of those chunks were reused from earlier scans. */
+  cache: ScanCacheStats;
+  /** Files left unanalyzed, whatever the reason. */
+  skipped_files: number;
+  /** The skipped files with their reasons, at most 200. */
+  skipped: ScanSkippedFile[];
+  /**
+   * Types the syntax-only Go analyzer could not resolve, at most 200. Their
+   * fields are left out of the schemas. Missing from scans run before it
+   * was reported.
+   */
+  skipped_types?: ScanSkippedType[];
+}
+
+export interface ScanSkippedFile {
+  path: string;
+  reason: string;
+}
+
+export interface ScanSkippedType {
+  type: string;
+  /** The first file found using the type. */
+  path: string;
+}
+
+export interface ScanCacheStats {
+  hits: number;
+  misses: number;
+  cached_files: number;
+}
+
+export interface AnalysisCacheUsage {
+  /** Provider, model and prompt version, e.g. "gemini/gemini-2.5-flash@prompt-v2". */
+  fingerprint: string;
+  entries: number;
+  size_bytes: number;
+  last_used_at: string;
+}
+
+export interface AnalysisCacheStats {
+  entries: number;
+  size_bytes: number;
+  analyzers: AnalysisCacheUsage[];
+}
+
+export type ScanJobStatus = "queued" | "running" | "completed" | "failed" | "cancelled";
+
+export type ScanJobPhase = "fetch" | "discover" | "analyze" | "upload";
+
+export interface ScanJob {
+  id: string;
+  project_id: string;
+  created_by: string;
+  repo_url: string;
+  branch?: string;
+  path?: string;
+  scan_type: "backend" | "frontend";
+  status: ScanJobStatus;
+  phase?: ScanJobPhase;
+  error?: string;
+  file_count: number;
+  endpoint_count: number;
+  /** Set once the job completes; holds the detection while it runs. */
+  summary?: ScanResult;
+  attempts: number;
+  created_at: string;
+  started_at?: string;
+  finished_at?: string;
+}
+
+export interface ScanJobEvent {
+  type: "phase" | "status";
+  job: ScanJob;
+}
+
 export interface LiveDiffResponse {
   results: DiffResult[];
   source_a: string;